@@ -6,15 +6,24 @@
 // @in header
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key for integrations (issued via /auth/api-keys).
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"os"
+	"time"
 
+	"vector/internal/appsvc"
 	"vector/internal/config"
 	"vector/internal/db"
 	"vector/internal/handlers"
+	"vector/internal/models"
 	"vector/internal/repository"
 	"vector/internal/routes"
 	"vector/internal/service"
@@ -23,7 +32,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/joho/godotenv"
+	"github.com/robfig/cron/v3"
 	"gorm.io/gorm"
 )
 
@@ -48,14 +59,18 @@ func main() {
 	})
 
 	// Middleware
+	// X-Request-ID: берется из запроса или генерируется, попадает в журнал аудита
+	app.Use(requestid.New())
+
 	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${latency} ${method} ${path}\n",
+		Format: "[${time}] ${status} - ${latency} ${locals:requestid} ${method} ${path}\n",
 	}))
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*", // В продакшене указать конкретные домены
 		AllowMethods:     "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Request-ID,X-API-Key,If-Match",
+		ExposeHeaders:    "X-Request-ID,Retry-After,X-RateLimit-Limit,X-RateLimit-Remaining,ETag",
 		AllowCredentials: false,
 	}))
 
@@ -87,9 +102,18 @@ func main() {
 
 type dependencies struct {
 	appHandlers    *handlers.AppHandlers
+	exportHandlers *handlers.ExportHandlers
+	auditHandlers  *handlers.AuditHandlers
 	authHandlers   *handlers.AuthHandlers
 	healthHandlers *handlers.HealthHandlers
+	oidcHandlers   *handlers.OIDCHandlers
+	roleHandlers   *handlers.RoleHandlers
+	jobHandlers    *handlers.JobHandlers
+	policyHandlers *handlers.ReviewPolicyHandlers
+	schemaHandlers *handlers.QuestionnaireHandlers
 	authService    *service.AuthService
+	roleService    *service.RoleService
+	auditService   *service.AuditService
 }
 
 func initDependencies(gdb *gorm.DB) *dependencies {
@@ -99,33 +123,158 @@ func initDependencies(gdb *gorm.DB) *dependencies {
 	checkRepo := repository.NewCheckRepository(gdb)
 	recalcRepo := repository.NewRecalcRepository(gdb)
 	syncContractRepo := repository.NewSyncContractRepository(gdb)
+	exportLogRepo := repository.NewExportLogRepository(gdb)
+	piiRevealRepo := repository.NewPiiRevealRepository(gdb)
+	reviewPolicyRepo := repository.NewReviewPolicyRepository(gdb)
+	questionnaireRepo := repository.NewQuestionnaireSchemaRepository(gdb)
+	auditRepo := repository.NewAuditRepository(gdb)
+	sessionRepo := repository.NewSessionRepository(gdb)
+	throttleRepo := repository.NewAuthThrottleRepository(gdb)
+	mfaRepo := repository.NewMFARepository(gdb)
+	oidcStateRepo := repository.NewOIDCStateRepository(gdb)
+	roleRepo := repository.NewRoleRepository(gdb)
+	apiKeyRepo := repository.NewAPIKeyRepository(gdb)
 
 	// JWT Configuration
-	jwtConfig := config.GetJWTConfig()
+	jwtConfig, err := config.GetJWTConfig()
+	if err != nil {
+		log.Fatal("Invalid JWT configuration: ", err)
+	}
+	mfaConfig, err := config.GetMFAConfig()
+	if err != nil {
+		log.Fatal("Invalid MFA configuration: ", err)
+	}
+	passwordPolicy := config.GetPasswordPolicy()
+	apiKeyConfig := config.GetAPIKeyConfig()
+	oidcConfig, err := config.GetOIDCConfig()
+	if err != nil {
+		log.Fatal("Invalid OIDC configuration: ", err)
+	}
+	riskRules, err := config.GetRiskScoringRules()
+	if err != nil {
+		log.Fatal("Invalid risk scoring rules: ", err)
+	}
 
 	// Services
-	appService := service.NewAppService(clientRepo, userRepo, checkRepo, recalcRepo, syncContractRepo)
-	authService := service.NewAuthService(userRepo, jwtConfig)
+	auditService := service.NewAuditService(auditRepo)
+	riskScoringService := service.NewRiskScoringService(riskRules, clientRepo, checkRepo, syncContractRepo)
+	questionnaireService := service.NewQuestionnaireService(questionnaireRepo, auditService)
+	appService := service.NewAppService(clientRepo, userRepo, checkRepo, syncContractRepo, piiRevealRepo, auditService, riskScoringService, questionnaireService)
+	roleService := service.NewRoleService(roleRepo, auditService)
+	jobRunner := service.NewJobRunner(repository.NewJobLocker(gdb), repository.NewJobRunRepository(gdb), config.GetInstanceID())
+	recalcService := service.NewRecalcService(recalcRepo, jobRunner, config.GetRecalcTimeout())
+	authService := service.NewAuthService(userRepo, sessionRepo, throttleRepo, mfaRepo, apiKeyRepo, jwtConfig, mfaConfig, apiKeyConfig, passwordPolicy, roleService, auditService)
+	exportService := service.NewExportService(clientRepo, syncContractRepo, exportLogRepo, auditRepo, auditService)
 
 	// Handlers
 	appHandlers := handlers.NewAppHandlers(appService)
+	exportHandlers := handlers.NewExportHandlers(exportService)
+	auditHandlers := handlers.NewAuditHandlers(auditService, exportService)
 	authHandlers := handlers.NewAuthHandlers(authService)
+	roleHandlers := handlers.NewRoleHandlers(roleService)
 	healthHandlers := handlers.NewHealthHandlers()
+	jobHandlers := handlers.NewJobHandlers(recalcService, jobRunner)
+	policyHandlers := handlers.NewReviewPolicyHandlers(service.NewReviewPolicyService(reviewPolicyRepo, auditService))
+	schemaHandlers := handlers.NewQuestionnaireHandlers(questionnaireService)
 
-	if err := userRepo.Seed(); err != nil {
-		log.Printf("Warning: Failed to seed admin user: %v", err)
-	} else {
-		log.Println("✅ Default admin user seeded (admin@vector.com / admin123)")
+	// Вход через корпоративный SSO
+	var oidcHandlers *handlers.OIDCHandlers
+	if oidcConfig.Enabled() {
+		oidcService := service.NewOIDCService(authService, userRepo, oidcStateRepo, oidcConfig, auditService)
+		if err := oidcService.CheckRoleMapping(); err != nil {
+			log.Fatal("Invalid OIDC configuration: ", err)
+		}
+		oidcHandlers = handlers.NewOIDCHandlers(oidcService)
+		startOIDCReconcile(oidcService, jobRunner)
+		log.Printf("🔑 OIDC login enabled (issuer %s)", oidcConfig.IssuerURL)
+	}
+
+	seedAdmin(userRepo)
+
+	// Пересчет признаков второй части по расписанию
+	if _, err := appsvc.StartCron(recalcService); err != nil {
+		log.Fatal("Invalid APP_CRON: ", err)
 	}
 
 	return &dependencies{
 		appHandlers:    appHandlers,
+		exportHandlers: exportHandlers,
+		auditHandlers:  auditHandlers,
 		authHandlers:   authHandlers,
 		healthHandlers: healthHandlers,
+		oidcHandlers:   oidcHandlers,
+		roleHandlers:   roleHandlers,
+		jobHandlers:    jobHandlers,
+		policyHandlers: policyHandlers,
+		schemaHandlers: schemaHandlers,
 		authService:    authService,
+		roleService:    roleService,
+		auditService:   auditService,
 	}
 }
 
+// seedAdmin создает начального администратора, если в системе нет ни одного активного
+func seedAdmin(userRepo repository.UserRepository) {
+	seed, err := config.GetAdminSeedConfig()
+	if err != nil {
+		log.Printf("Warning: Failed to prepare admin seed: %v", err)
+		return
+	}
+	created, err := userRepo.Seed(seed.Email, seed.Password)
+	switch {
+	case errors.Is(err, models.ErrAdminSeedEmailTaken):
+		log.Fatalf("No active administrator and the initial admin cannot be seeded: %v; restore that account manually or set ADMIN_EMAIL to an unused address", err)
+	case err != nil:
+		log.Printf("Warning: Failed to seed admin user: %v", err)
+	case created && seed.Generated:
+		where, err := seed.RevealGeneratedPassword()
+		if err != nil {
+			log.Printf("Warning: Initial admin user %s seeded, but the generated password could not be delivered: %v; reset it with ADMIN_INITIAL_PASSWORD", seed.Email, err)
+			return
+		}
+		log.Printf("✅ Initial admin user seeded: %s (generated password written to %s, change required on first login)", seed.Email, where)
+	case created:
+		log.Printf("✅ Initial admin user seeded: %s (password from ADMIN_INITIAL_PASSWORD, change required on first login)", seed.Email)
+	}
+}
+
+// startOIDCReconcile периодически сверяет пользователей SSO с провайдером
+func startOIDCReconcile(oidcService *service.OIDCService, jobRunner *service.JobRunner) {
+	spec := os.Getenv("OIDC_RECONCILE_CRON")
+	if spec == "" {
+		spec = "*/30 * * * *"
+	}
+	if spec == "off" {
+		return
+	}
+
+	c := cron.New()
+	_, err := c.AddFunc(spec, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+		_, err := jobRunner.RunScheduled(ctx, models.JobOIDCReconcile, service.CronSlot(time.Now()), func(ctx context.Context) (any, error) {
+			result, err := oidcService.Reconcile(ctx, models.SystemActor("oidc-reconcile"))
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("[cron] oidc reconcile: checked=%d deactivated=%d role_changed=%d skipped=%d failed=%d",
+				result.Checked, result.Deactivated, result.RoleChanged, result.Skipped, result.Failed)
+			return result, nil
+		})
+		if errors.Is(err, service.ErrJobSkipped) {
+			log.Printf("[cron] oidc reconcile skipped: already running on another instance")
+		} else if errors.Is(err, service.ErrJobSlotDone) {
+			log.Printf("[cron] oidc reconcile skipped: slot already done by another instance")
+		} else if err != nil {
+			log.Printf("[cron] oidc reconcile error: %v", err)
+		}
+	})
+	if err != nil {
+		log.Fatal("Invalid OIDC_RECONCILE_CRON: ", err)
+	}
+	c.Start()
+}
+
 func setupRoutes(app *fiber.App, deps *dependencies) {
 	// Основная информация об API
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -133,21 +282,50 @@ func setupRoutes(app *fiber.App, deps *dependencies) {
 			"name":        "Vector API",
 			"version":     "1.0.0",
 			"description": "API для работы с клиентами и контрактами",
-			"auth":        "JWT Bearer Token",
+			"auth":        "JWT Bearer Token or X-API-Key",
 			"docs":        "/swagger",
 			"endpoints": fiber.Map{
 				"auth": fiber.Map{
-					"login":   "POST /auth/login",
-					"profile": "GET /auth/profile",
-					"users":   "GET /auth/users (admin only)",
+					"login":    "POST /auth/login, POST /auth/login/mfa",
+					"refresh":  "POST /auth/refresh",
+					"logout":   "POST /auth/logout",
+					"profile":  "GET /auth/profile",
+					"password": "POST /auth/password",
+					"mfa":      "GET /auth/mfa, POST /auth/mfa/{enroll,verify,recovery-codes,disable}",
+					"jwks":     "GET /.well-known/jwks.json",
+					"oidc":     "GET /auth/oidc/{login,callback}, POST /auth/oidc/exchange, POST /auth/oidc/reconcile (users.manage; only if OIDC is configured)",
+					"users":    "GET|POST /auth/users, GET|DELETE /auth/users/:id, PATCH /auth/users/:id/role, POST /auth/users/:id/{deactivate,activate,reset-password,logout-all,unlock,mfa/reset} (users.manage)",
+					"roles":    "GET /auth/roles, GET /auth/permissions, POST /auth/roles, GET|PATCH|DELETE /auth/roles/:name (roles.manage)",
+					"api_keys": "GET|POST /auth/api-keys, POST /auth/api-keys/:id/revoke (api_keys.manage); keys are sent as X-API-Key",
 				},
 				"clients": fiber.Map{
-					"list": "GET /clients",
-					"get":  "GET /clients/:id",
+					"list":   "GET /clients",
+					"search": "GET /clients/search?q=",
+					"export": "GET /clients/export?format=csv|xlsx",
+					"get":    "GET /clients/:id",
 				},
 				"contracts": fiber.Map{
-					"list": "GET /contracts",
-					"get":  "GET /contracts/:id",
+					"list":   "GET /contracts",
+					"export": "GET /contracts/export?format=csv|xlsx",
+					"get":    "GET /contracts/:id",
+				},
+				"audit": fiber.Map{
+					"search": "GET /audit (audit.read)",
+					"export": "GET /audit/export?format=csv|xlsx (audit.read)",
+				},
+				"admin": fiber.Map{
+					"recalc":   "POST /admin/recalc (jobs.manage)",
+					"job_runs": "GET /admin/job-runs?job=&status= (jobs.manage)",
+				},
+				"review_policies": fiber.Map{
+					"list":   "GET /review-policies",
+					"create": "POST /review-policies (review_policies.manage)",
+				},
+				"questionnaire_schemas": fiber.Map{
+					"current": "GET /questionnaire-schemas/current",
+					"get":     "GET /questionnaire-schemas/:version",
+					"list":    "GET /questionnaire-schemas",
+					"create":  "POST /questionnaire-schemas (questionnaire_schemas.manage)",
 				},
 			},
 		})
@@ -156,11 +334,16 @@ func setupRoutes(app *fiber.App, deps *dependencies) {
 	// Health check роуты (публичные)
 	routes.SetupAppRoutes(app, deps.healthHandlers)
 
+	// Вход через SSO - до SetupAuthRoutes: защищенные группы /auth применяют JWT ко всем роутам, объявленным после них
+	if deps.oidcHandlers != nil {
+		routes.SetupOIDCRoutes(app, deps.oidcHandlers, deps.authService, deps.roleService, deps.auditService)
+	}
+
 	// JWT Authentication роуты
-	routes.SetupAuthRoutes(app, deps.authHandlers, deps.authService)
+	routes.SetupAuthRoutes(app, deps.authHandlers, deps.roleHandlers, deps.authService, deps.roleService, deps.auditService)
 
 	// Защищенные роуты с проверкой ролей
-	routes.SetupProtectedRoutes(app, deps.appHandlers, deps.authService)
+	routes.SetupProtectedRoutes(app, deps.appHandlers, deps.exportHandlers, deps.auditHandlers, deps.jobHandlers, deps.policyHandlers, deps.schemaHandlers, deps.authService, deps.roleService, deps.auditService)
 
 	// 404 handler
 	app.Use(func(c *fiber.Ctx) error {
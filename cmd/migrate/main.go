@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 	"vector/internal/migrations"
 
 	"github.com/joho/godotenv"
@@ -12,8 +13,15 @@ import (
 
 func main() {
 	var (
-		action = flag.String("action", "", "Action to perform: up, seed, migrate-users")
+		action = flag.String("action", "", "Action to perform: up, seed, migrate-users, reparse, audit-verify, recompute-due-at")
 		help   = flag.Bool("help", false, "Show help")
+
+		entity      = flag.String("entity", migrations.ReparseEntityAll, "reparse: entity to process (clients, contracts, all)")
+		fromVersion = flag.Int("from-version", 0, "reparse: process client versions starting from this number")
+		toVersion   = flag.Int("to-version", 0, "reparse: process client versions up to this number")
+		batchSize   = flag.Int("batch-size", 500, "reparse, recompute-due-at: rows per batch")
+		dryRun      = flag.Bool("dry-run", false, "reparse, recompute-due-at: report changes without writing them")
+		asOf        = flag.String("as-of", "", "recompute-due-at: apply review periods in force on this date (YYYY-MM-DD, default today)")
 	)
 	flag.Parse()
 
@@ -44,6 +52,43 @@ func main() {
 			log.Fatalf("User migration failed: %v", err)
 		}
 		log.Println("✅ User migration to JWT completed successfully")
+	case "reparse":
+		stats, err := migrator.Reparse(migrations.ReparseOptions{
+			Entity:      *entity,
+			FromVersion: *fromVersion,
+			ToVersion:   *toVersion,
+			BatchSize:   *batchSize,
+			DryRun:      *dryRun,
+		})
+		if err != nil {
+			log.Fatalf("Reparse failed: %v", err)
+		}
+		printReparseStats(stats, *dryRun)
+	case "recompute-due-at":
+		var at time.Time
+		if *asOf != "" {
+			if at, err = time.Parse("2006-01-02", *asOf); err != nil {
+				log.Fatalf("Invalid -as-of: expected YYYY-MM-DD")
+			}
+		}
+		report, err := migrator.RecomputeDueAt(migrations.DueAtOptions{
+			AsOf:      at,
+			BatchSize: *batchSize,
+			DryRun:    *dryRun,
+		})
+		if err != nil {
+			log.Fatalf("Due date recomputation failed: %v", err)
+		}
+		printDueAtReport(report)
+	case "audit-verify":
+		checked, broken, err := migrator.VerifyAudit()
+		if err != nil {
+			log.Fatalf("Audit verification failed: %v", err)
+		}
+		if broken != nil {
+			log.Fatalf("❌ Audit chain broken at event %d: %s (%d events verified before it)", broken.EventID, broken.Reason, checked)
+		}
+		log.Printf("✅ Audit chain intact: %d events verified", checked)
 	default:
 		fmt.Printf("Unknown action: %s\n", *action)
 		printHelp()
@@ -59,14 +104,71 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Actions:")
 	fmt.Println("  up             - Run all database migrations")
-	fmt.Println("  seed           - Seed default admin user")
+	fmt.Println("  seed           - Seed initial admin user (only if no active administrator exists)")
 	fmt.Println("  migrate-users  - Migrate existing users to JWT structure")
+	fmt.Println("  reparse        - Recompute typed columns from stored Raw (no new versions)")
+	fmt.Println("  audit-verify   - Verify the hash chain of the audit log")
+	fmt.Println("  recompute-due-at - Recompute due_at of approved second parts from the review periods table")
+	fmt.Println()
+	fmt.Println("Reparse flags:")
+	fmt.Println("  -entity=clients|contracts|all  (default all)")
+	fmt.Println("  -from-version=N -to-version=N  (client versions only)")
+	fmt.Println("  -batch-size=N                  (default 500)")
+	fmt.Println("  -dry-run                       (report only)")
+	fmt.Println()
+	fmt.Println("Recompute-due-at flags:")
+	fmt.Println("  -as-of=YYYY-MM-DD              (review periods in force on this date, default today)")
+	fmt.Println("  -dry-run                       (report only)")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run cmd/migrate/main.go -action=up")
 	fmt.Println("  go run cmd/migrate/main.go -action=seed")
 	fmt.Println("  go run cmd/migrate/main.go -action=migrate-users")
+	fmt.Println("  go run cmd/migrate/main.go -action=reparse -entity=clients -dry-run")
+	fmt.Println("  go run cmd/migrate/main.go -action=recompute-due-at -as-of=2027-01-01 -dry-run")
 	fmt.Println()
 	fmt.Println("Environment variables:")
 	fmt.Println("  DB_HOST, DB_PORT, POSTGRES_USER, POSTGRES_PASSWORD, POSTGRES_DB")
+	fmt.Println("  ADMIN_EMAIL, ADMIN_INITIAL_PASSWORD, ADMIN_INITIAL_PASSWORD_FILE (seed; a generated password goes to the terminal or the file)")
+}
+
+func printReparseStats(stats []migrations.ReparseStats, dryRun bool) {
+	mode := "applied"
+	if dryRun {
+		mode = "dry-run, nothing written"
+	}
+	for _, st := range stats {
+		log.Printf("✅ Reparse %s (%s): scanned=%d changed=%d unparsable=%d",
+			st.Entity, mode, st.Scanned, st.Changed, st.Unparsable)
+		for _, field := range st.SortedFields() {
+			log.Printf("    %-32s %d", field, st.ChangedFields[field])
+		}
+	}
+}
+
+func printDueAtReport(r migrations.DueAtReport) {
+	mode := "applied"
+	if r.DryRun {
+		mode = "dry-run, nothing written"
+	}
+	log.Printf("✅ Recompute due_at as of %s (%s)", r.AsOf.Format("2006-01-02"), mode)
+	for _, st := range r.Levels {
+		level, interval := st.RiskLevel, "no policy"
+		if level == "" {
+			level = "(none)"
+		}
+		if st.IntervalMonths != nil {
+			interval = fmt.Sprintf("%d months", *st.IntervalMonths)
+		}
+		log.Printf("    %-12s %-10s approved=%d changed=%d earlier=%d later=%d became_overdue=%d no_policy=%d",
+			level, interval, st.Approved, st.Changed, st.Earlier, st.Later, st.BecameOverdue, st.NoPolicy)
+	}
+	for _, c := range r.Samples {
+		old := "-"
+		if c.OldDueAt != nil {
+			old = c.OldDueAt.Format("2006-01-02")
+		}
+		log.Printf("    client %d v%d %s approved %s: %s -> %s",
+			c.ClientID, c.Version, c.RiskLevel, c.ApprovedAt.Format("2006-01-02"), old, c.NewDueAt.Format("2006-01-02"))
+	}
 }
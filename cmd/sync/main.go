@@ -7,6 +7,7 @@ package main
 import (
 	"log"
 
+	"vector/internal/config"
 	"vector/internal/cron"
 	"vector/internal/db"
 	"vector/internal/external"
@@ -25,13 +26,15 @@ import (
 func main() {
 	_ = godotenv.Load()
 
-	_, _ = cron.StartCron()
-
 	gdb, err := db.Connect()
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	if _, err := cron.StartCron(gdb); err != nil {
+		log.Fatal("Invalid SYNC_CRON: ", err)
+	}
+
 	deps := initDependencies(gdb)
 
 	app := fiber.New(fiber.Config{
@@ -54,7 +57,7 @@ func main() {
 
 	app.Use(swagger.New(cfg))
 
-	routes.SetupSyncRoutes(app, deps.syncHandlers, deps.healthHandlers)
+	routes.SetupSyncRoutes(app, deps.syncHandlers, deps.healthHandlers, deps.authService, deps.roleService, deps.auditService)
 
 	if err := app.Listen(":8080"); err != nil {
 		log.Fatal("Failed to start server:", err)
@@ -64,6 +67,9 @@ func main() {
 type dependencies struct {
 	syncHandlers   *handlers.SyncHandlers
 	healthHandlers *handlers.HealthHandlers
+	authService    *service.AuthService
+	roleService    *service.RoleService
+	auditService   *service.AuditService
 }
 
 func initDependencies(gdb *gorm.DB) *dependencies {
@@ -83,7 +89,30 @@ func initDependencies(gdb *gorm.DB) *dependencies {
 
 	contractService := service.NewContractService(contractStagingRepo, contractRepo, externalAPI)
 
-	fullSyncService := service.NewFullSyncService(applyService, contractService, externalAPI)
+	jobRunner := service.NewJobRunner(repository.NewJobLocker(gdb), repository.NewJobRunRepository(gdb), config.GetInstanceID())
+	fullSyncService := service.NewFullSyncService(applyService, contractService, externalAPI, jobRunner)
+
+	// Аутентификация общая с сервисом приложения: публичные ключи его JWT (JWT_PUBLIC_KEYS_DIR), сессии, роли и API-ключи
+	jwtConfig, err := config.GetSharedJWTConfig()
+	if err != nil {
+		log.Fatal("Invalid JWT configuration: ", err)
+	}
+	mfaConfig, err := config.GetMFAConfig()
+	if err != nil {
+		log.Fatal("Invalid MFA configuration: ", err)
+	}
+
+	auditService := service.NewAuditService(repository.NewAuditRepository(gdb))
+	roleService := service.NewRoleService(repository.NewRoleRepository(gdb), auditService)
+	authService := service.NewAuthService(
+		repository.NewUserRepository(gdb),
+		repository.NewSessionRepository(gdb),
+		repository.NewAuthThrottleRepository(gdb),
+		repository.NewMFARepository(gdb),
+		repository.NewAPIKeyRepository(gdb),
+		jwtConfig, mfaConfig, config.GetAPIKeyConfig(), config.GetPasswordPolicy(),
+		roleService, auditService,
+	)
 
 	syncHandlers := handlers.NewSyncHandlers(stagingService, applyService, fullSyncService)
 	healthHandlers := handlers.NewHealthHandlers()
@@ -91,5 +120,8 @@ func initDependencies(gdb *gorm.DB) *dependencies {
 	return &dependencies{
 		syncHandlers:   syncHandlers,
 		healthHandlers: healthHandlers,
+		authService:    authService,
+		roleService:    roleService,
+		auditService:   auditService,
 	}
 }
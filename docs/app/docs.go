@@ -15,150 +15,128 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Вход в систему с email и паролем, возвращает JWT токен",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Ключи в формате RFC 7517 для проверки подписи access-токенов другими сервисами. Ключ выбирается по kid из заголовка токена; при ротации старые ключи остаются в наборе, пока выданные ими токены не истекут. Сервисы должны проверять iss=vector-app и aud=vector-api.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "auth"
                 ],
-                "summary": "Аутентификация пользователя",
-                "parameters": [
-                    {
-                        "description": "Данные для входа",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginRequest"
-                        }
-                    }
-                ],
+                "summary": "Публичные ключи проверки токенов (JWKS)",
                 "responses": {
                     "200": {
-                        "description": "Успешная аутентификация",
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверные данные",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "Неверный email или пароль",
+                        "description": "Набор ключей",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/signing.JWKSet"
                         }
                     }
                 }
             }
         },
-        "/auth/profile": {
+        "/admin/job-runs": {
             "get": {
-                "description": "Получить информацию о текущем аутентифицированном пользователе",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Runs of background jobs (sync.full, app.recalc, oidc.reconcile), newest first: instance, trigger, status and result.\nSkipped runs were started while another instance held the job.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Получить профиль текущего пользователя",
-                "responses": {
-                    "200": {
-                        "description": "Профиль пользователя",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                "summary": "Background job log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset pagination, ignored when cursor is set)",
+                        "name": "page",
+                        "in": "query"
                     },
-                    "401": {
-                        "description": "Пользователь не аутентифицирован",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor/prev_cursor of a previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Total count mode: true, false or estimate",
+                        "name": "with_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by job (sync.full, app.recalc, oidc.reconcile)",
+                        "name": "job",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (running, succeeded, failed, skipped)",
+                        "name": "status",
+                        "in": "query"
                     }
-                }
-            }
-        },
-        "/auth/roles": {
-            "get": {
-                "description": "Получить список всех доступных ролей в системе",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
                 ],
-                "summary": "Получить список доступных ролей",
                 "responses": {
                     "200": {
-                        "description": "Список ролей",
+                        "description": "Job runs",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.ListJobRunsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/auth/users": {
+        "/admin/recalc": {
             "post": {
-                "description": "Создать нового пользователя в системе. Доступно только администраторам.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Runs all recalculation rules now (the same job as APP_CRON) and returns how many requirements each rule opened and resolved.\nThe run is recorded in the job log. Only one recalculation runs at a time across all instances.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Создание нового пользователя (только для администраторов)",
-                "parameters": [
-                    {
-                        "description": "Данные пользователя",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateUserRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Run needs_second_part recalculation",
                 "responses": {
-                    "201": {
-                        "description": "Пользователь создан",
+                    "200": {
+                        "description": "Requirements opened and resolved per reason (questionnaire_expired, data_changed, passport_expired)",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.RecalcResult"
                         }
                     },
-                    "400": {
-                        "description": "Неверные данные",
+                    "409": {
+                        "description": "Recalculation is already running",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "403": {
-                        "description": "Недостаточно прав",
+                    "500": {
+                        "description": "Some rules failed; data contains the rules that succeeded",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -167,57 +145,111 @@ const docTemplate = `{
                 }
             }
         },
-        "/clients": {
+        "/audit": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get list of clients with optional filtering",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Search the append-only audit log of user actions, newest first. Administrators only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "clients"
+                    "audit"
                 ],
-                "summary": "List clients",
+                "summary": "Search audit log",
                 "parameters": [
                     {
                         "type": "integer",
                         "default": 1,
-                        "description": "Page number",
+                        "description": "Page number (offset pagination, ignored when cursor is set)",
                         "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "default": 10,
+                        "default": 50,
                         "description": "Items per page",
                         "name": "per_page",
                         "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "description": "Filter by needs second part",
-                        "name": "needs_second_part",
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor/prev_cursor of a previous response",
+                        "name": "cursor",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter by second part status (draft, completed)",
-                        "name": "sp_status",
+                        "description": "Total count mode: true, false or estimate (default: true without cursor, false with cursor)",
+                        "name": "with_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by user ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user email",
+                        "name": "actor_email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action, comma-separated (http.request, auth.login_succeeded, auth.login_failed, user.created, second_part.draft_created, client.pii_revealed, export.started)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by entity type (client, contract, user, export)",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by entity ID",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by request ID (X-Request-ID)",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by HTTP method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Occurred at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Occurred at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of clients",
+                        "description": "Audit events",
                         "schema": {
-                            "$ref": "#/definitions/models.ListClientsResponse"
+                            "$ref": "#/definitions/models.ListAuditEventsResponse"
                         }
                     },
                     "400": {
@@ -235,180 +267,301 @@ const docTemplate = `{
                 }
             }
         },
-        "/clients/{id}": {
+        "/audit/export": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get complete client information including all available fields and second part if available",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stream audit events matching the filters of GET /audit as CSV or XLSX. Administrators only.",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "clients"
+                    "audit"
                 ],
-                "summary": "Get client information",
+                "summary": "Export audit log",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "File format: csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Columns to export, comma-separated (default: all). Allowed: id, occurred_at, actor_id, actor_email, actor_role, action, entity_type, entity_id, request_id, ip, method, path, status, before, after, metadata, prev_hash, hash",
+                        "name": "columns",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Client ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by user ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user email",
+                        "name": "actor_email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action, comma-separated",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by entity type",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by entity ID",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by request ID",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by HTTP method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Occurred at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Occurred at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Complete client information",
+                        "description": "Export file",
                         "schema": {
-                            "$ref": "#/definitions/models.GetClientResponse"
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Invalid client ID",
+                        "description": "Invalid parameters",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Client not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "/clients/{id}/history": {
+        "/auth/api-keys": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all versions of a specific client ordered by version (newest first)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Секреты ключей не возвращаются; ключ определяется по префиксу",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "clients"
+                    "api-keys"
                 ],
-                "summary": "Get client history (all versions)",
+                "summary": "Список API-ключей (только для управляющих ключами)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Client ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Включить отозванные ключи",
+                        "name": "include_revoked",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Client history",
+                        "description": "Ключи",
                         "schema": {
-                            "$ref": "#/definitions/models.ClientHistoryResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
                         }
-                    },
-                    "400": {
-                        "description": "Invalid client ID",
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ключ возвращается один раз. Передается в заголовке X-API-Key или Authorization: ApiKey \u003cключ\u003e.\nДопустимые права: clients.read, clients.export, pii.view_masked, pii.view_full, contracts.read, contracts.export, audit.read, sync.run — и только те, что есть у автора.\nПрава ключа проверяются при каждом запросе: ключ действует, пока автор активен, и только в пределах прав, которые сейчас есть у его роли.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Выпустить API-ключ (только для управляющих ключами)",
+                "parameters": [
+                    {
+                        "description": "Название, права, лимит и срок действия",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
                         }
-                    },
-                    "404": {
-                        "description": "Client not found",
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Ключ выпущен",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "400": {
+                        "description": "Неверные параметры",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/clients/{id}/history/{version}": {
-            "get": {
+        "/auth/api-keys/{id}/revoke": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get full data of a specific version of a client",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Ключ перестает действовать сразу",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "clients"
+                    "api-keys"
                 ],
-                "summary": "Get specific client version",
+                "summary": "Отозвать API-ключ (только для управляющих ключами)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Client ID",
+                        "description": "ID ключа",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ключ отозван",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIKey"
+                        }
                     },
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Вход в систему с email и паролем, возвращает JWT токен.\nЕсли у пользователя подключена 2FA, токены не выдаются: ответ содержит mfa_required=true и mfa_token для POST /auth/login/mfa.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Аутентификация пользователя",
+                "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Version number",
-                        "name": "version",
-                        "in": "path",
-                        "required": true
+                        "description": "Данные для входа",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Client version data",
+                        "description": "Успешная аутентификация",
                         "schema": {
-                            "$ref": "#/definitions/models.GetClientVersionResponse"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid client ID or version",
+                        "description": "Неверные данные",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Client version not found",
+                    "401": {
+                        "description": "Неверный email или пароль",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "429": {
+                        "description": "Слишком много неудачных попыток (заголовок Retry-After)",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/clients/{id}/second-part/current": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get the current active second part information for a specific client",
+        "/auth/login/mfa": {
+            "post": {
+                "description": "Обменивает mfa_token из ответа /auth/login и код из приложения-аутентификатора (или код восстановления) на пару токенов. mfa_token одноразовый: после успешного входа повторно не принимается",
                 "consumes": [
                     "application/json"
                 ],
@@ -416,99 +569,108 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "clients"
+                    "auth"
                 ],
-                "summary": "Get current second part for client",
+                "summary": "Второй шаг входа (2FA)",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Client ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Токен второго шага и код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginMFARequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Current second part information",
+                        "description": "Успешная аутентификация",
                         "schema": {
-                            "$ref": "#/definitions/models.GetSecondPartResponse"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid client ID",
+                        "description": "Неверные данные",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Second part not found",
+                    "401": {
+                        "description": "Неверный код или просроченный или уже использованный mfa_token",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "429": {
+                        "description": "Слишком много неудачных попыток (заголовок Retry-After)",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/clients/{id}/second-part/draft": {
+        "/auth/logout": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new second part draft for a client",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Завершает текущую сессию: access- и refresh-токены этой сессии перестают действовать",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "clients"
+                    "auth"
                 ],
-                "summary": "Create second part draft",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Client ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                "summary": "Выход из системы",
+                "responses": {
+                    "200": {
+                        "description": "Сессия завершена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "description": "Draft data",
-                        "name": "draft",
-                        "in": "body",
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
                         "schema": {
                             "type": "object",
-                            "properties": {
-                                "data_override": {
-                                    "type": "object"
-                                },
-                                "risk_level": {
-                                    "type": "string"
-                                }
-                            }
+                            "additionalProperties": true
                         }
                     }
+                }
+            }
+        },
+        "/auth/mfa": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Подключена ли двухфакторная аутентификация, обязательна ли она для роли и сколько осталось кодов восстановления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
                 ],
+                "summary": "Состояние 2FA",
                 "responses": {
                     "200": {
-                        "description": "Created second part draft",
+                        "description": "Состояние 2FA",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.MFAStatusResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid input or creation failed",
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -517,14 +679,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/clients/{id}/second-part/history": {
-            "get": {
+        "/auth/mfa/disable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get history of second part information for specific client",
+                "description": "Отключает двухфакторную аутентификацию по коду из приложения или коду восстановления. Недоступно для ролей, где 2FA обязательна.",
                 "consumes": [
                     "application/json"
                 ],
@@ -532,35 +694,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "clients"
+                    "auth"
                 ],
-                "summary": "Get second part history for client",
+                "summary": "Отключить 2FA",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Client ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Код из приложения или код восстановления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Second part history",
+                        "description": "2FA отключена",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid client ID",
+                        "description": "Неверный код",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "409": {
+                        "description": "2FA не подключена или обязательна для роли",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -569,82 +733,99 @@ const docTemplate = `{
                 }
             }
         },
-        "/contracts": {
-            "get": {
+        "/auth/mfa/enroll": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get list of contracts with optional filtering",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Создает секрет TOTP и возвращает его вместе с otpauth:// URI для QR-кода. 2FA включается после подтверждения кодом (POST /auth/mfa/verify).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "contracts"
+                    "auth"
                 ],
-                "summary": "List contracts",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
-                        "name": "per_page",
-                        "in": "query"
+                "summary": "Начать подключение 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Секрет и URI для приложения",
+                        "schema": {
+                            "$ref": "#/definitions/models.MFAEnrollResponse"
+                        }
                     },
+                    "409": {
+                        "description": "2FA уже подключена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/mfa/recovery-codes": {
+            "post": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Filter by user ID",
-                        "name": "user_id",
-                        "in": "query"
-                    },
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выдает новый набор кодов восстановления по коду из приложения; прежние коды перестают действовать",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Новые коды восстановления",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Filter by status (active, closed)",
-                        "name": "status",
-                        "in": "query"
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of contracts",
+                        "description": "Коды восстановления",
                         "schema": {
-                            "$ref": "#/definitions/models.ListContractsResponse"
+                            "$ref": "#/definitions/models.MFARecoveryCodesResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid parameters",
+                        "description": "Неверный код",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "409": {
+                        "description": "2FA не подключена",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/contracts/{id}": {
-            "get": {
+        "/auth/mfa/verify": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get complete contract information by contract ID",
+                "description": "Проверяет первый код из приложения, включает 2FA и возвращает коды восстановления (показываются один раз). Остальные сессии пользователя завершаются.",
                 "consumes": [
                     "application/json"
                 ],
@@ -652,878 +833,4916 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "contracts"
+                    "auth"
                 ],
-                "summary": "Get contract information",
+                "summary": "Подтвердить подключение 2FA",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Contract ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MFACodeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Complete contract information",
+                        "description": "Коды восстановления",
                         "schema": {
-                            "$ref": "#/definitions/models.GetContractResponse"
+                            "$ref": "#/definitions/models.MFARecoveryCodesResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid contract ID",
+                        "description": "Неверный код",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Contract not found",
+                    "409": {
+                        "description": "2FA уже подключена или подключение не начато",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/dbping": {
+        "/auth/oidc/callback": {
             "get": {
+                "description": "Проверяет state, обменивает код на токены провайдера, проверяет ID-токен и создает или обновляет пользователя.\nЕсли настроен OIDC_FRONTEND_REDIRECT_URL, браузер перенаправляется туда с одноразовым login_code для POST /auth/oidc/exchange; иначе ответ содержит токены.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "health"
+                    "auth"
+                ],
+                "summary": "Возврат от провайдера SSO",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Database ping",
                 "responses": {
                     "200": {
-                        "description": "db ok",
+                        "description": "Успешная аутентификация",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "302": {
+                        "description": "Переход на frontend с login_code"
+                    },
+                    "400": {
+                        "description": "Недействительный запрос входа",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Вход отклонен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Нет роли в корпоративном каталоге",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/healthz": {
-            "get": {
+        "/auth/oidc/exchange": {
+            "post": {
+                "description": "Одноразовый login_code (действует минуту) из перенаправления callback обменивается на пару токенов или на mfa_token, если у пользователя подключена 2FA",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "health"
+                    "auth"
+                ],
+                "summary": "Обмен кода входа SSO на токены",
+                "parameters": [
+                    {
+                        "description": "Код входа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCExchangeRequest"
+                        }
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
-                        "description": "ok",
+                        "description": "Успешная аутентификация",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Недействительный или просроченный код",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AppUser": {
-            "type": "object",
-            "properties": {
-                "createdAt": {
-                    "type": "string"
-                },
-                "email": {
-                    "type": "string"
-                },
-                "firstName": {
-                    "description": "Имя",
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "isActive": {
-                    "description": "Активен ли пользователь",
-                    "type": "boolean"
-                },
-                "lastName": {
-                    "description": "Фамилия",
-                    "type": "string"
-                },
-                "middleName": {
-                    "description": "Отчество (может быть пустым)",
-                    "type": "string"
-                },
-                "passwordHash": {
-                    "type": "string"
-                },
-                "role": {
-                    "type": "string"
-                },
-                "updatedAt": {
-                    "type": "string"
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Перенаправляет браузер на страницу входа провайдера (authorization code + PKCE)",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Вход через корпоративный SSO (OIDC)",
+                "responses": {
+                    "302": {
+                        "description": "Переход к провайдеру"
+                    },
+                    "502": {
+                        "description": "Провайдер недоступен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
                 }
             }
         },
-        "models.ClientDetailResponse": {
-            "type": "object",
-            "properties": {
-                "birth_place": {
-                    "type": "string"
-                },
-                "birthday": {
-                    "type": "string"
-                },
-                "contact_email": {
-                    "type": "string"
-                },
-                "created_lk_at": {
-                    "type": "string"
-                },
-                "external_risk_level": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "inn": {
-                    "type": "string"
-                },
-                "main_phone": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "needs_second_part": {
-                    "type": "boolean"
-                },
-                "pass_issue_date": {
-                    "type": "string"
-                },
-                "pass_issuer": {
-                    "type": "string"
-                },
-                "pass_issuer_code": {
-                    "type": "string"
-                },
-                "pass_number": {
+        "/auth/oidc/reconcile": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет роли пользователей SSO по группам провайдера и деактивирует тех, чей доступ у провайдера отозван. Выполняется также по расписанию (OIDC_RECONCILE_CRON).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Сверка пользователей SSO с провайдером (только для администраторов)",
+                "responses": {
+                    "200": {
+                        "description": "Итог сверки",
+                        "schema": {
+                            "$ref": "#/definitions/models.OIDCReconcileResult"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сверки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Смена пароля текущим пользователем; требуется текущий пароль. Остальные сессии пользователя завершаются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Сменить свой пароль",
+                "parameters": [
+                    {
+                        "description": "Текущий и новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пароль изменен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный текущий пароль или новый пароль не подходит",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Справочник прав и права ролей (только для управляющих ролями)",
+                "responses": {
+                    "200": {
+                        "description": "Права и роли",
+                        "schema": {
+                            "$ref": "#/definitions/models.PermissionMatrix"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/profile": {
+            "get": {
+                "description": "Получить информацию о текущем аутентифицированном пользователе и права его роли (permissions)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Получить профиль текущего пользователя",
+                "responses": {
+                    "200": {
+                        "description": "Профиль пользователя",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Пользователь не аутентифицирован",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Обменивает refresh-токен на новую пару access/refresh. Каждый refresh-токен одноразовый: повторное использование завершает все сессии этой цепочки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Обновление токенов",
+                "parameters": [
+                    {
+                        "description": "Refresh-токен",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новая пара токенов",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Недействительный refresh-токен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/roles": {
+            "get": {
+                "description": "Получить список всех доступных ролей в системе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Получить список доступных ролей",
+                "responses": {
+                    "200": {
+                        "description": "Список ролей",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Создать роль (только для управляющих ролями)",
+                "parameters": [
+                    {
+                        "description": "Роль и ее права",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Роль создана",
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные или роль уже существует",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/roles/{name}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Роль с правами (только для управляющих ролями)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя роли",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль",
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Роль не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Системные роли и роли, назначенные пользователям, не удаляются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Удалить роль (только для управляющих ролями)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя роли",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль удалена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Роль не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Системная роль или роль назначена пользователям",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "permissions заменяет набор прав целиком; права роли Administrator не меняются (у нее все права)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Изменить роль и ее права (только для управляющих ролями)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя роли",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль изменена",
+                        "schema": {
+                            "$ref": "#/definitions/models.RoleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Роль не найдена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Права администратора не редактируются",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получить пользователей с фильтрами по роли, активности и строке поиска (email, имя, фамилия)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Список пользователей (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Роли через запятую (Administrator, Podft, ClientManagement)",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Фильтр по активности",
+                        "name": "is_active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока email, имени или фамилии",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Источник учетной записи (local, oidc)",
+                        "name": "auth_provider",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список пользователей",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создать нового пользователя в системе. Доступно только администраторам.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Создание нового пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "description": "Данные пользователя",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Пользователь создан",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Получить пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Мягкое удаление: пользователь деактивируется и скрывается из списков, записи журналов сохраняются. Нельзя удалить себя и последнего активного администратора.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Удалить пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь удален",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Последний администратор или собственная учетная запись",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/activate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Повторно активировать пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь активирован",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/deactivate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Деактивированный пользователь не может войти, выданные токены перестают действовать. Нельзя деактивировать себя и последнего активного администратора.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Деактивировать пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пользователь деактивирован",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Последний администратор или собственная учетная запись",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отзывает все refresh-токены пользователя; выданные access-токены перестают приниматься",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Завершить все сессии пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сессии завершены",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/mfa/reset": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет секрет и коды восстановления пользователя (например, при потере устройства) и завершает все его сессии. При следующем входе 2FA подключается заново.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Сбросить 2FA пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "2FA сброшена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Нельзя сбросить собственную 2FA",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/reset-password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Задает новый пароль. Если пароль не передан, генерируется временный и возвращается в ответе один раз.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Сбросить пароль пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый пароль (необязательно)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Пароль сброшен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/role": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Нельзя менять собственную роль и понижать последнего активного администратора",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Изменить роль пользователя (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая роль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Роль изменена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные данные",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Последний администратор или собственная учетная запись",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/users/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Сбрасывает счетчик неудачных входов и блокировку аккаунта; с параметром ip — также блокировку адреса",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Снять блокировку входа (только для администраторов)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IP-адрес, блокировку которого нужно снять",
+                        "name": "ip",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Блокировка снята",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/clients": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get list of clients with optional filtering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "List clients",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset pagination, ignored when cursor is set)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor/prev_cursor of a previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Total count mode: true, false or estimate (default: true without cursor, false with cursor)",
+                        "name": "with_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by needs second part",
+                        "name": "needs_second_part",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by open needs_second_part reason, comma-separated (new_client, data_changed, questionnaire_expired, passport_expired)",
+                        "name": "needs_second_part_reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by second part status, comma-separated (draft, submitted, approved, rejected, doc_requested)",
+                        "name": "sp_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Second part due at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "sp_due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Second part due at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "sp_due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by risk level, comma-separated",
+                        "name": "risk_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by external risk level, comma-separated",
+                        "name": "external_risk_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by blocked flag",
+                        "name": "blocked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by RF residency",
+                        "name": "is_rf_resident",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by qualified investor flag",
+                        "name": "qualified_investor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created in LK at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_lk_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created in LK at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_lk_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by fill stage, comma-separated",
+                        "name": "fill_stage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by having at least one active contract",
+                        "name": "has_active_contract",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort fields, comma-separated; prefix with - or suffix :desc for descending (id, surname, name, birthday, version, created_lk_at, updated_lk_at, risk_level, external_risk_level, fill_stage, sp_status, sp_due_at); fields masked for the caller are rejected",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of clients",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListClientsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream clients matching the list filters as CSV or XLSX. Accepts the same filters and sort as GET /clients. Every export is recorded in the export log.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Export clients register",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "File format: csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Columns to export, comma-separated (default: all). Allowed: id, surname, name, patronymic, birthday, birth_place, contact_email, main_phone, inn, snils, pass_series, pass_number, pass_issue_date, pass_issuer, pass_issuer_code, created_lk_at, updated_lk_at, risk_level, external_risk_level, needs_second_part, needs_second_part_reasons, second_part_created, version, sp_status, sp_due_at",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by needs second part",
+                        "name": "needs_second_part",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by open needs_second_part reason, comma-separated (new_client, data_changed, questionnaire_expired, passport_expired)",
+                        "name": "needs_second_part_reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by second part status, comma-separated",
+                        "name": "sp_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Second part due at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "sp_due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Second part due at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "sp_due_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by risk level, comma-separated",
+                        "name": "risk_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by external risk level, comma-separated",
+                        "name": "external_risk_level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by blocked flag",
+                        "name": "blocked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by RF residency",
+                        "name": "is_rf_resident",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by qualified investor flag",
+                        "name": "qualified_investor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created in LK at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_lk_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created in LK at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_lk_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by fill stage, comma-separated",
+                        "name": "fill_stage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Filter by having at least one active contract",
+                        "name": "has_active_contract",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort fields, same as GET /clients",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Full-text and fuzzy search over current client versions by name, INN, SNILS, passport, phone, email or login. The query type is detected automatically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Search clients",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Max results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ranked search results",
+                        "schema": {
+                            "$ref": "#/definitions/models.ClientSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Empty query",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get complete client information including all available fields and second part if available. second_part_requirements lists the open reasons why a new second part is needed. Personal data (passport, INN, SNILS, phone, email, address, Raw) is masked or hidden according to the caller's role; see masked_fields.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Get client information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Complete client information",
+                        "schema": {
+                            "$ref": "#/definitions/models.GetClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get versions of a specific client ordered by version (newest first). Without limit/cursor all versions are returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Get client history (all versions)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Versions per page (enables cursor pagination)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor/prev_cursor of a previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Client history",
+                        "schema": {
+                            "$ref": "#/definitions/models.ClientHistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/history/{version}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get full data of a specific version of a client. Personal data is masked according to the caller's role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Get specific client version",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Client version data",
+                        "schema": {
+                            "$ref": "#/definitions/models.GetClientVersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID or version",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Client version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/reveal": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return full values of fields masked for the caller's role in the current client version. A reason is required and every reveal is written to the PII reveal log. Fields hidden for the role cannot be revealed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Reveal masked personal data",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to reveal (pass_series, pass_number, pass_issuer, pass_issuer_code, pass_issue_date, inn, snils, main_phone, contact_email, birthday, birth_place, address) and reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RevealClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Revealed values",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevealClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Field is hidden for the role",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Field-level changes made by editing drafts in place (PATCH /clients/{id}/second-part/current), newest first. Paths are JSON Pointers into the questionnaire data; old_value is absent for added fields and new_value for removed ones.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Second part questionnaire change log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only changes of this second part version",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset pagination, ignored when cursor is set)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor/prev_cursor of a previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Total count mode: true, false or estimate",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changes",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListSecondPartChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/current": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the current active second part information for a specific client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Get current second part for client",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Current second part information",
+                        "schema": {
+                            "$ref": "#/definitions/models.GetSecondPartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Second part not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Apply a JSON Merge Patch (RFC 7396) to the questionnaire data of the current draft (status draft or doc_requested) in place, without creating a new version: keys set to null are removed, objects are merged, other values replace the existing ones.\nIf-Match must carry the ETag from GET /clients/{id}/second-part/current (or a previous PATCH); if the draft was changed since, 409 is returned and the client must reload. Each changed field is recorded in the change log (GET /clients/{id}/second-part/changes).\nThe result is validated against the current questionnaire schema like a draft save: violations are returned in validation_errors and do not block saving.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Edit the current second part draft",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the draft being edited",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "JSON Merge Patch for the questionnaire data",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated second part draft; new ETag in the ETag header",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patch or If-Match",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Second part not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Draft was changed concurrently or is not editable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/draft": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new second part draft for a client. The risk-scoring engine proposes a risk level (risk_proposed_level, risk_assessment with contributing factors) which is stored on the draft.\nrisk_level accepts the proposal when it matches and overrides it otherwise; an override requires risk_override_reason, and lowering a proposed high level requires second_part.approve_high_risk.\nWithout risk_level the decision is left for POST /clients/{id}/second-part/risk. risk_level must have a review period (GET /review-policies); due_at follows it.\nThe questionnaire data (data_override, or the previous version's data) is validated against the current schema (GET /questionnaire-schemas/current). An incomplete draft is still saved: violations are returned in validation_errors and schema_version records the schema used; submitting requires a valid questionnaire.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Create second part draft",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Draft data",
+                        "name": "draft",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "data_override": {
+                                    "type": "object"
+                                },
+                                "risk_level": {
+                                    "type": "string"
+                                },
+                                "risk_override_reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created second part draft",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or creation failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Lowering a proposed high risk level is not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get history of second part information for specific client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Get second part history for client",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Second part history",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/requirements": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reasons why the client needs a new second part (new_client, data_changed, questionnaire_expired, passport_expired), newest first. Resolved reasons carry resolved_at and resolution.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Get second part requirement reasons",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Include resolved reasons",
+                        "name": "include_resolved",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Requirement reasons",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/risk": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Record the officer's decision on the risk level proposed for the current draft (status draft or doc_requested) and recalculate due_at from the review period of the level (GET /review-policies).\nWithout risk_level the proposal is accepted. A different level is an override and requires risk_override_reason; lowering a proposed high level requires second_part.approve_high_risk.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Accept or override the proposed risk level",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Decision",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.SecondPartRiskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated second part draft",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Lowering a proposed high risk level is not allowed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Second part not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Second part is not a draft",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/risk-assessment": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Score the client's current data with the risk-scoring rules without storing the result: proposed level, score and contributing factors.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Preview second part risk assessment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Proposed risk level",
+                        "schema": {
+                            "$ref": "#/definitions/models.RiskAssessment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/clients/{id}/second-part/submit": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Submit the current second part draft (status draft or doc_requested). The questionnaire must match the current schema (GET /questionnaire-schemas/current); otherwise 422 with validation_errors. The schema version is recorded on the submitted version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clients"
+                ],
+                "summary": "Submit second part draft",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Submitted second part",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid client ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Second part not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Second part is not a draft or was changed concurrently",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Questionnaire does not match the schema",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/contracts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get list of contracts with optional filtering",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contracts"
+                ],
+                "summary": "List contracts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number (offset pagination, ignored when cursor is set)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor/prev_cursor of a previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Total count mode: true, false or estimate (default: true without cursor, false with cursor)",
+                        "name": "with_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status, comma-separated (active, closed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by kind, comma-separated (broking, depo)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signed at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "signed_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signed at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "signed_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Closed at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "closed_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Closed at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "closed_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tariff ID or name",
+                        "name": "tariff",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by strategy ID or name",
+                        "name": "strategy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort fields, comma-separated; prefix with - or suffix :desc for descending (id, user_id, inner_code, kind, status, created_at, updated_at, signed_at, closed_at, tariff_name, strategy_name)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of contracts",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListContractsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/contracts/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream contracts matching the list filters as CSV or XLSX. Accepts the same filters and sort as GET /contracts. Every export is recorded in the export log.",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "contracts"
+                ],
+                "summary": "Export contracts register",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "csv",
+                        "description": "File format: csv or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Columns to export, comma-separated (default: all). Allowed: id, user_id, user_login, inner_code, kind, status, rialto_code, is_personal_invest_account, signed_at, closed_at, created_at, updated_at, tariff_id, tariff_name, strategy_id, strategy_name, comment",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status, comma-separated (active, closed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by kind, comma-separated (broking, depo)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signed at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "signed_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signed at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "signed_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Closed at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "closed_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Closed at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "closed_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by tariff ID or name",
+                        "name": "tariff",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by strategy ID or name",
+                        "name": "strategy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort fields, same as GET /contracts",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/contracts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get complete contract information by contract ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contracts"
+                ],
+                "summary": "Get contract information",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Contract ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Complete contract information",
+                        "schema": {
+                            "$ref": "#/definitions/models.GetContractResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid contract ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Contract not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbping": {
+            "get": {
+                "tags": [
+                    "health"
+                ],
+                "summary": "Database ping",
+                "responses": {
+                    "200": {
+                        "description": "db ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/questionnaire-schemas": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Published schema versions without their content, newest (current) first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "questionnaire-schemas"
+                ],
+                "summary": "List questionnaire schema versions",
+                "responses": {
+                    "200": {
+                        "description": "Schema versions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.QuestionnaireSchemaInfo"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Publish a new schema version; it becomes current immediately. Supported keywords: type, properties, required, additionalProperties, enum, const, minLength, maxLength, pattern, format (date, date-time, email), minimum, maximum, exclusiveMinimum, exclusiveMaximum, items, minItems, maxItems, uniqueItems; annotations and x- keys are allowed.\nExisting second part versions keep the schema_version they were validated against.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "questionnaire-schemas"
+                ],
+                "summary": "Publish a questionnaire schema version",
+                "parameters": [
+                    {
+                        "description": "Schema",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateQuestionnaireSchemaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Published schema",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuestionnaireSchema"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schema",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/questionnaire-schemas/current": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "JSON Schema of the second part questionnaire that drafts are validated against and submissions must match. Keys prefixed with x- are UI hints and do not affect validation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "questionnaire-schemas"
+                ],
+                "summary": "Get the current questionnaire schema",
+                "responses": {
+                    "200": {
+                        "description": "Current schema",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuestionnaireSchema"
+                        }
+                    },
+                    "404": {
+                        "description": "No schema published",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/questionnaire-schemas/{version}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Schema version referenced by schema_version of a second part version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "questionnaire-schemas"
+                ],
+                "summary": "Get a questionnaire schema version",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schema version",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Schema",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuestionnaireSchema"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid version",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Schema version not found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/review-policies": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Review period (months until the next second part review) per risk level with effective-from dates. effective contains the periods in force today; the levels listed there are the ones a risk decision may use.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review-policies"
+                ],
+                "summary": "List second part review periods",
+                "responses": {
+                    "200": {
+                        "description": "Review periods",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListReviewPoliciesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the review period for a risk level (existing or new) from effective_from (today or later). Second parts approved after that date get due_at from the new period; to recompute already approved second parts run migrate -action=recompute-due-at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "review-policies"
+                ],
+                "summary": "Add a review period for a risk level",
+                "parameters": [
+                    {
+                        "description": "Review period",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateReviewPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created review period",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Period for the level and date already exists",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "description": "запросов в минуту",
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.AppUser": {
+            "type": "object",
+            "properties": {
+                "authProvider": {
+                    "description": "Учетные записи OIDC создаются при первом входе и не имеют локального пароля.\nExternalSubject — sub провайдера, ExternalRefreshToken (зашифрован) нужен для проверки,\nчто пользователь не удален у провайдера",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "externalCheckedAt": {
+                    "type": "string"
+                },
+                "externalSubject": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "description": "Имя",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isActive": {
+                    "description": "Активен ли пользователь",
+                    "type": "boolean"
+                },
+                "lastName": {
+                    "description": "Фамилия",
+                    "type": "string"
+                },
+                "mfaenabled": {
+                    "description": "Двухфакторная аутентификация (TOTP). Секрет хранится зашифрованным,\nMFALastStep — последний принятый шаг времени (защита от повтора кода)",
+                    "type": "boolean"
+                },
+                "mfaenabledAt": {
+                    "type": "string"
+                },
+                "middleName": {
+                    "description": "Отчество (может быть пустым)",
+                    "type": "string"
+                },
+                "mustChangePassword": {
+                    "description": "Пароль задан при начальной настройке или сброшен администратором и должен быть сменен",
+                    "type": "boolean"
+                },
+                "passwordChangedAt": {
+                    "type": "string"
+                },
+                "passwordHash": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_email": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "actor_role": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "entity_type": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "prev_hash": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ClientDetailResponse": {
+            "type": "object",
+            "properties": {
+                "birth_place": {
+                    "type": "string"
+                },
+                "birthday": {
+                    "type": "string"
+                },
+                "contact_email": {
+                    "type": "string"
+                },
+                "created_lk_at": {
+                    "type": "string"
+                },
+                "external_risk_level": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inn": {
+                    "type": "string"
+                },
+                "main_phone": {
+                    "type": "string"
+                },
+                "masked_fields": {
+                    "description": "MaskedFields поля, замаскированные или скрытые политикой роли",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "inn",
+                        "snils"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "needs_second_part": {
+                    "type": "boolean"
+                },
+                "needs_second_part_reasons": {
+                    "description": "NeedsSecondPartReasons коды открытых причин needs_second_part",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "data_changed",
+                        "passport_expired"
+                    ]
+                },
+                "pass_issue_date": {
+                    "type": "string"
+                },
+                "pass_issuer": {
+                    "type": "string"
+                },
+                "pass_issuer_code": {
+                    "type": "string"
+                },
+                "pass_number": {
+                    "type": "string"
+                },
+                "pass_series": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "second_part": {
+                    "$ref": "#/definitions/models.SecondPartResponse"
+                },
+                "second_part_created": {
+                    "type": "boolean"
+                },
+                "snils": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                },
+                "updated_lk_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ClientHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "integer",
+                    "example": 123
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GetClientResponse"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "models.ClientSearchResponse": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string",
+                    "example": "Иванов Иван"
+                },
+                "query_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "text"
+                    ]
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ClientSearchResult"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.ClientSearchResult": {
+            "type": "object",
+            "properties": {
+                "birth_place": {
+                    "type": "string"
+                },
+                "birthday": {
+                    "type": "string"
+                },
+                "contact_email": {
+                    "type": "string"
+                },
+                "created_lk_at": {
+                    "type": "string"
+                },
+                "external_risk_level": {
+                    "type": "string"
+                },
+                "highlights": {
+                    "description": "Highlights совпавшие фрагменты в \u003cem\u003e, остальной текст экранирован как HTML",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "inn": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string",
+                    "example": "user_login"
+                },
+                "main_phone": {
+                    "type": "string"
+                },
+                "masked_fields": {
+                    "description": "MaskedFields поля, замаскированные или скрытые политикой роли",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "inn",
+                        "snils"
+                    ]
+                },
+                "matched_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "surname",
+                        "name"
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "needs_second_part": {
+                    "type": "boolean"
+                },
+                "pass_issue_date": {
+                    "type": "string"
+                },
+                "pass_issuer": {
+                    "type": "string"
+                },
+                "pass_issuer_code": {
+                    "type": "string"
+                },
+                "pass_number": {
+                    "type": "string"
+                },
+                "pass_series": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number",
+                    "example": 0.87
+                },
+                "second_part_created": {
+                    "type": "boolean"
+                },
+                "snils": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                },
+                "updated_lk_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "0 — срок по умолчанию",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "description": "запросов в минуту; 0 — по умолчанию",
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateQuestionnaireSchemaRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Добавлен источник происхождения средств"
+                },
+                "schema": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Анкета второй части"
+                }
+            }
+        },
+        "models.CreateReviewPolicyRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "Решение правления от 01.12.2026"
+                },
+                "effective_from": {
+                    "description": "EffectiveFrom дата YYYY-MM-DD; пусто — с сегодняшнего дня",
+                    "type": "string",
+                    "example": "2027-01-01"
+                },
+                "interval_months": {
+                    "type": "integer",
+                    "example": 24
+                },
+                "risk_level": {
+                    "type": "string",
+                    "example": "medium"
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "middle_name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6
+                },
+                "role": {
                     "type": "string"
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "client not found"
+                }
+            }
+        },
+        "models.GetClientResponse": {
+            "type": "object",
+            "properties": {
+                "ad_source": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "agent_id": {
+                    "type": "integer",
+                    "example": 456
+                },
+                "agent_point_id": {
+                    "type": "integer",
+                    "example": 789
+                },
+                "birth_place": {
+                    "type": "string",
+                    "example": "Test City"
+                },
+                "birthday": {
+                    "type": "string",
+                    "example": "1990-01-01"
+                },
+                "block_type": {
+                    "type": "string",
+                    "example": ""
+                },
+                "blocked": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "blocked_reason": {
+                    "type": "string",
+                    "example": ""
+                },
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "city": {
+                    "type": "string",
+                    "example": "Москва"
+                },
+                "client_id": {
+                    "type": "integer",
+                    "example": 12345
+                },
+                "contact_email": {
+                    "type": "string",
+                    "example": "test@example.com"
+                },
+                "corps": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "country": {
+                    "type": "string",
+                    "example": "Россия"
+                },
+                "created_lk_at": {
+                    "type": "string",
+                    "example": "2024-01-01T10:00:00.000+03:00"
+                },
+                "current_sign_in_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "data_received_digital_profile": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "district": {
+                    "type": "string",
+                    "example": "Тверской"
+                },
+                "document_country": {
+                    "type": "string",
+                    "example": "RU"
+                },
+                "document_type": {
+                    "type": "string",
+                    "example": "passport"
+                },
+                "esia_id": {
+                    "type": "integer",
+                    "example": 98765
+                },
+                "external_id": {
+                    "type": "integer",
+                    "example": 54321
+                },
+                "external_id_str": {
+                    "type": "string",
+                    "example": "EXT_12345"
+                },
+                "external_risk_level": {
+                    "type": "string",
+                    "example": "low"
+                },
+                "fill_stage": {
+                    "type": "string",
+                    "example": "completed"
+                },
+                "flat": {
+                    "type": "integer",
+                    "example": 25
+                },
+                "from_company_settings": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123def456"
+                },
+                "house": {
+                    "type": "string",
+                    "example": "10"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12345
+                },
+                "identification_type": {
+                    "type": "string",
+                    "example": "passport"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 123456
+                },
+                "inn": {
+                    "type": "string",
+                    "example": "123456789012"
+                },
+                "is_american_national": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_current": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_filled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_rf_resident": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_rf_taxpayer": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_valid_info": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "legal_capacity": {
+                    "type": "string",
+                    "example": "full"
+                },
+                "locked_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "login": {
+                    "type": "string",
+                    "example": "user_login"
+                },
+                "main_phone": {
+                    "type": "string",
+                    "example": "1234567890"
+                },
+                "male": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "manager": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "masked_fields": {
+                    "description": "MaskedFields поля, замаскированные или скрытые политикой роли (см. POST /clients/{id}/reveal)",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "inn",
+                        "snils"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Иван"
+                },
+                "need_to_set_password": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "needs_second_part": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "note": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "pass_issue_date": {
+                    "type": "string",
+                    "example": "01.01.2020"
+                },
+                "pass_issuer": {
+                    "type": "string",
+                    "example": "MVD Test District"
+                },
+                "pass_issuer_code": {
+                    "type": "string",
+                    "example": "100-001"
+                },
+                "pass_number": {
+                    "type": "string",
+                    "example": "123456"
                 },
                 "pass_series": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "1234"
                 },
                 "patronymic": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "Иванович"
+                },
+                "person_info": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "pifs_portfolio_code": {
+                    "type": "integer",
+                    "example": 123
+                },
+                "qualified_investor": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "raw": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "region": {
+                    "type": "string",
+                    "example": "Московская область"
+                },
+                "risk_level": {
+                    "type": "string",
+                    "example": "low"
                 },
                 "second_part": {
-                    "$ref": "#/definitions/models.SecondPartResponse"
+                    "type": "object",
+                    "properties": {
+                        "client_version": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "due_at": {
+                            "type": "string",
+                            "format": "date-time"
+                        },
+                        "is_current": {
+                            "type": "boolean",
+                            "example": true
+                        },
+                        "risk_level": {
+                            "type": "string",
+                            "example": "low"
+                        },
+                        "status": {
+                            "type": "string",
+                            "example": "draft"
+                        },
+                        "version": {
+                            "type": "integer",
+                            "example": 1
+                        }
+                    }
                 },
                 "second_part_created": {
-                    "type": "boolean"
+                    "type": "boolean",
+                    "example": true
+                },
+                "second_part_requirements": {
+                    "description": "SecondPartRequirements открытые причины needs_second_part",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SecondPartRequirement"
+                    }
+                },
+                "second_part_trigger_hash": {
+                    "type": "string",
+                    "example": "xyz789abc123"
+                },
+                "settings": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "sign_in_count": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "signature_allowed_numbers": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "signature_type": {
+                    "type": "string",
+                    "example": "electronic"
                 },
                 "snils": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "12345678901"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "unchanged"
+                },
+                "street": {
+                    "type": "string",
+                    "example": "ул. Тверская"
                 },
                 "surname": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "Иванов"
+                },
+                "synced_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "tax_status": {
+                    "type": "string",
+                    "example": "resident"
                 },
                 "updated_lk_at": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "2024-01-01T12:00:00.000+03:00"
+                },
+                "valid_from": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "valid_to": {
+                    "type": "string",
+                    "format": "date-time"
                 },
                 "version": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "models.ClientHistoryResponse": {
+        "models.GetClientVersionResponse": {
             "type": "object",
             "properties": {
                 "client_id": {
                     "type": "integer",
                     "example": 123
                 },
-                "history": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.GetClientResponse"
-                    }
-                },
                 "success": {
                     "type": "boolean",
                     "example": true
                 },
-                "total": {
-                    "type": "integer",
-                    "example": 5
-                }
-            }
-        },
-        "models.CreateUserRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "first_name",
-                "last_name",
-                "password",
-                "role"
-            ],
-            "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "first_name": {
-                    "type": "string"
-                },
-                "last_name": {
-                    "type": "string"
-                },
-                "middle_name": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
-                },
-                "role": {
-                    "type": "string"
+                "version": {
+                    "$ref": "#/definitions/models.GetClientResponse"
                 }
             }
         },
-        "models.ErrorResponse": {
+        "models.GetContractResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "anketa": {
                     "type": "string",
-                    "example": "client not found"
-                }
-            }
-        },
-        "models.GetClientResponse": {
-            "type": "object",
-            "properties": {
-                "ad_source": {
-                    "type": "object",
-                    "additionalProperties": true
+                    "example": "ANK456"
                 },
-                "agent_id": {
+                "calculated_profile_id": {
                     "type": "integer",
-                    "example": 456
+                    "example": 222
                 },
-                "agent_point_id": {
+                "closed_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "comment": {
+                    "type": "string",
+                    "example": "Основной договор"
+                },
+                "contract_owner_id": {
                     "type": "integer",
                     "example": 789
                 },
-                "birth_place": {
+                "contract_owner_type": {
                     "type": "string",
-                    "example": "Test City"
+                    "example": "individual"
                 },
-                "birthday": {
+                "created_at": {
                     "type": "string",
-                    "example": "1990-01-01"
+                    "format": "date-time"
                 },
-                "block_type": {
+                "depo_accounts_type": {
                     "type": "string",
-                    "example": ""
+                    "example": "standard"
                 },
-                "blocked": {
+                "external_id": {
+                    "type": "integer",
+                    "example": 54321
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "abc123def456"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 123
+                },
+                "inner_code": {
+                    "type": "string",
+                    "example": "BRK-001"
+                },
+                "is_personal_invest_account": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_personal_invest_account_new": {
                     "type": "boolean",
                     "example": false
                 },
-                "blocked_reason": {
+                "kind": {
                     "type": "string",
-                    "example": ""
+                    "example": "broking"
                 },
-                "checks": {
+                "owner_id": {
+                    "type": "integer",
+                    "example": 111
+                },
+                "raw": {
                     "type": "object",
                     "additionalProperties": true
                 },
-                "city": {
+                "rialto_code": {
                     "type": "string",
-                    "example": "Москва"
+                    "example": "RLT123"
                 },
-                "client_id": {
-                    "type": "integer",
-                    "example": 12345
+                "signed_at": {
+                    "type": "string",
+                    "format": "date-time"
                 },
-                "contact_email": {
+                "status": {
                     "type": "string",
-                    "example": "test@example.com"
+                    "example": "active"
                 },
-                "corps": {
+                "strategy_id": {
                     "type": "integer",
-                    "example": 1
+                    "example": 333
                 },
-                "country": {
+                "strategy_name": {
                     "type": "string",
-                    "example": "Россия"
+                    "example": "Conservative"
                 },
-                "created_lk_at": {
+                "synced_at": {
                     "type": "string",
-                    "example": "2024-01-01T10:00:00.000+03:00"
+                    "format": "date-time"
                 },
-                "current_sign_in_at": {
+                "tariff_id": {
+                    "type": "integer",
+                    "example": 444
+                },
+                "tariff_name": {
+                    "type": "string",
+                    "example": "Basic"
+                },
+                "updated_at": {
                     "type": "string",
                     "format": "date-time"
                 },
-                "data_received_digital_profile": {
-                    "type": "boolean",
-                    "example": true
+                "user_id": {
+                    "type": "integer",
+                    "example": 12345
                 },
-                "district": {
+                "user_login": {
                     "type": "string",
-                    "example": "Тверской"
+                    "example": "user123"
+                }
+            }
+        },
+        "models.GetSecondPartResponse": {
+            "type": "object",
+            "properties": {
+                "approved_by_user_id": {
+                    "type": "integer",
+                    "example": 101
                 },
-                "document_country": {
+                "client_id": {
+                    "type": "integer",
+                    "example": 123
+                },
+                "client_version": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_by_user_id": {
+                    "type": "integer",
+                    "example": 456
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "due_at": {
                     "type": "string",
-                    "example": "RU"
+                    "format": "date-time"
                 },
-                "document_type": {
+                "is_current": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "reason": {
                     "type": "string",
-                    "example": "passport"
+                    "example": "Additional documents required"
                 },
-                "esia_id": {
+                "revision": {
                     "type": "integer",
-                    "example": 98765
+                    "example": 0
                 },
-                "external_id": {
+                "risk_assessment": {
+                    "$ref": "#/definitions/models.RiskAssessment"
+                },
+                "risk_decided_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "risk_decided_by_user_id": {
                     "type": "integer",
-                    "example": 54321
+                    "example": 456
                 },
-                "external_id_str": {
+                "risk_decision": {
                     "type": "string",
-                    "example": "EXT_12345"
+                    "example": "overridden"
                 },
-                "external_risk_level": {
+                "risk_level": {
                     "type": "string",
                     "example": "low"
                 },
-                "fill_stage": {
+                "risk_override_reason": {
                     "type": "string",
-                    "example": "completed"
+                    "example": "Подтвержден источник средств"
                 },
-                "flat": {
+                "risk_proposed_level": {
+                    "description": "Предложение скоринга и решение сотрудника по нему",
+                    "type": "string",
+                    "example": "medium"
+                },
+                "schema_version": {
                     "type": "integer",
-                    "example": 25
+                    "example": 1
                 },
-                "from_company_settings": {
-                    "type": "object",
-                    "additionalProperties": true
+                "status": {
+                    "type": "string",
+                    "example": "draft"
                 },
-                "hash": {
+                "updated_by_user_id": {
+                    "type": "integer",
+                    "example": 789
+                },
+                "valid_from": {
                     "type": "string",
-                    "example": "abc123def456"
+                    "format": "date-time"
                 },
-                "house": {
+                "valid_to": {
                     "type": "string",
-                    "example": "10"
+                    "format": "date-time"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.JobRun": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "result": {
+                    "type": "object"
+                },
+                "scheduled_at": {
+                    "description": "ScheduledAt слот расписания запуска по cron; у запусков вручную не заполнен",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "triggered_by": {
+                    "description": "cron или email/ключ инициатора",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ListAuditEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "eyJ2IjpbIjEwMjQiXSwicyI6IjFhMmIzYzRkNWU2ZiJ9"
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "per_page": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 150
                 },
-                "id": {
+                "total_estimated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "total_pages": {
                     "type": "integer",
-                    "example": 12345
+                    "example": 15
+                }
+            }
+        },
+        "models.ListClientsResponse": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ClientDetailResponse"
+                    }
                 },
-                "identification_type": {
+                "has_more": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "next_cursor": {
                     "type": "string",
-                    "example": "passport"
+                    "example": "eyJ2IjpbIjEwMjQiXSwicyI6IjFhMmIzYzRkNWU2ZiJ9"
                 },
-                "index": {
+                "page": {
                     "type": "integer",
-                    "example": 123456
+                    "example": 1
                 },
-                "inn": {
-                    "type": "string",
-                    "example": "123456789012"
+                "per_page": {
+                    "type": "integer",
+                    "example": 10
                 },
-                "is_american_national": {
-                    "type": "boolean",
-                    "example": false
+                "prev_cursor": {
+                    "type": "string"
                 },
-                "is_current": {
+                "success": {
                     "type": "boolean",
                     "example": true
                 },
-                "is_filled": {
-                    "type": "boolean",
-                    "example": true
+                "total": {
+                    "type": "integer",
+                    "example": 150
                 },
-                "is_rf_resident": {
+                "total_estimated": {
                     "type": "boolean",
-                    "example": true
+                    "example": false
                 },
-                "is_rf_taxpayer": {
-                    "type": "boolean",
-                    "example": true
+                "total_pages": {
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "models.ListContractsResponse": {
+            "type": "object",
+            "properties": {
+                "contracts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GetContractResponse"
+                    }
                 },
-                "is_valid_info": {
+                "has_more": {
                     "type": "boolean",
                     "example": true
                 },
-                "legal_capacity": {
+                "next_cursor": {
                     "type": "string",
-                    "example": "full"
+                    "example": "eyJ2IjpbIjEwMjQiXSwicyI6IjFhMmIzYzRkNWU2ZiJ9"
                 },
-                "locked_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "page": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "login": {
-                    "type": "string",
-                    "example": "user_login"
+                "per_page": {
+                    "type": "integer",
+                    "example": 10
                 },
-                "main_phone": {
-                    "type": "string",
-                    "example": "1234567890"
+                "prev_cursor": {
+                    "type": "string"
                 },
-                "male": {
+                "success": {
                     "type": "boolean",
                     "example": true
                 },
-                "manager": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "name": {
-                    "type": "string",
-                    "example": "Иван"
+                "total": {
+                    "type": "integer",
+                    "example": 150
                 },
-                "need_to_set_password": {
+                "total_estimated": {
                     "type": "boolean",
                     "example": false
                 },
-                "needs_second_part": {
+                "total_pages": {
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "models.ListJobRunsResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
                     "type": "boolean",
                     "example": true
                 },
-                "note": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "pass_issue_date": {
-                    "type": "string",
-                    "example": "01.01.2020"
-                },
-                "pass_issuer": {
+                "next_cursor": {
                     "type": "string",
-                    "example": "MVD Test District"
+                    "example": "eyJ2IjpbIjEwMjQiXSwicyI6IjFhMmIzYzRkNWU2ZiJ9"
                 },
-                "pass_issuer_code": {
-                    "type": "string",
-                    "example": "100-001"
+                "page": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "pass_number": {
-                    "type": "string",
-                    "example": "123456"
+                "per_page": {
+                    "type": "integer",
+                    "example": 10
                 },
-                "pass_series": {
-                    "type": "string",
-                    "example": "1234"
+                "prev_cursor": {
+                    "type": "string"
                 },
-                "patronymic": {
-                    "type": "string",
-                    "example": "Иванович"
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.JobRun"
+                    }
                 },
-                "person_info": {
-                    "type": "object",
-                    "additionalProperties": true
+                "success": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "pifs_portfolio_code": {
+                "total": {
                     "type": "integer",
-                    "example": 123
+                    "example": 150
                 },
-                "qualified_investor": {
+                "total_estimated": {
                     "type": "boolean",
                     "example": false
                 },
-                "raw": {
+                "total_pages": {
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "models.ListReviewPoliciesResponse": {
+            "type": "object",
+            "properties": {
+                "effective": {
                     "type": "object",
-                    "additionalProperties": true
-                },
-                "region": {
-                    "type": "string",
-                    "example": "Московская область"
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ReviewPolicy"
+                    }
                 },
-                "risk_level": {
-                    "type": "string",
-                    "example": "low"
+                "policies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReviewPolicy"
+                    }
                 },
-                "second_part": {
-                    "type": "object",
-                    "properties": {
-                        "client_version": {
-                            "type": "integer",
-                            "example": 1
-                        },
-                        "due_at": {
-                            "type": "string",
-                            "format": "date-time"
-                        },
-                        "is_current": {
-                            "type": "boolean",
-                            "example": true
-                        },
-                        "risk_level": {
-                            "type": "string",
-                            "example": "low"
-                        },
-                        "status": {
-                            "type": "string",
-                            "example": "draft"
-                        },
-                        "version": {
-                            "type": "integer",
-                            "example": 1
-                        }
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ListSecondPartChangesResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SecondPartChange"
                     }
                 },
-                "second_part_created": {
+                "has_more": {
                     "type": "boolean",
                     "example": true
                 },
-                "second_part_trigger_hash": {
+                "next_cursor": {
                     "type": "string",
-                    "example": "xyz789abc123"
+                    "example": "eyJ2IjpbIjEwMjQiXSwicyI6IjFhMmIzYzRkNWU2ZiJ9"
                 },
-                "settings": {
-                    "type": "object",
-                    "additionalProperties": true
+                "page": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "sign_in_count": {
+                "per_page": {
                     "type": "integer",
-                    "example": 5
+                    "example": 10
                 },
-                "signature_allowed_numbers": {
-                    "type": "object",
-                    "additionalProperties": true
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 150
+                },
+                "total_estimated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "models.LoginMFARequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Код из приложения (6 цифр) или код восстановления",
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
                 },
-                "signature_type": {
+                "password": {
                     "type": "string",
-                    "example": "electronic"
+                    "minLength": 6
+                }
+            }
+        },
+        "models.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "integer"
                 },
-                "snils": {
-                    "type": "string",
-                    "example": "12345678901"
+                "mfa_enrollment_required": {
+                    "description": "Для роли пользователя 2FA обязательна, но еще не подключена",
+                    "type": "boolean"
                 },
-                "status": {
-                    "type": "string",
-                    "example": "unchanged"
+                "mfa_required": {
+                    "description": "Пароль верный, но нужен код 2FA: токены не выдаются, вместо них mfa_token\nдля POST /auth/login/mfa",
+                    "type": "boolean"
                 },
-                "street": {
-                    "type": "string",
-                    "example": "ул. Тверская"
+                "mfa_token": {
+                    "type": "string"
                 },
-                "surname": {
-                    "type": "string",
-                    "example": "Иванов"
+                "mfa_token_expires_at": {
+                    "type": "integer"
                 },
-                "synced_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "refresh_expires_at": {
+                    "type": "integer"
                 },
-                "tax_status": {
-                    "type": "string",
-                    "example": "resident"
+                "refresh_token": {
+                    "type": "string"
                 },
-                "updated_lk_at": {
-                    "type": "string",
-                    "example": "2024-01-01T12:00:00.000+03:00"
+                "token": {
+                    "type": "string"
                 },
-                "valid_from": {
+                "user": {
+                    "$ref": "#/definitions/models.AppUser"
+                }
+            }
+        },
+        "models.MFACodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MFAEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
                     "type": "string",
-                    "format": "date-time"
+                    "example": "otpauth://totp/Vector:user@vector.com?algorithm=SHA1\u0026digits=6\u0026issuer=Vector\u0026period=30\u0026secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
                 },
-                "valid_to": {
+                "secret": {
                     "type": "string",
-                    "format": "date-time"
-                },
-                "version": {
-                    "type": "integer",
-                    "example": 1
+                    "example": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
                 }
             }
         },
-        "models.GetClientVersionResponse": {
+        "models.MFARecoveryCodesResponse": {
             "type": "object",
             "properties": {
-                "client_id": {
-                    "type": "integer",
-                    "example": 123
-                },
-                "success": {
-                    "type": "boolean",
-                    "example": true
-                },
-                "version": {
-                    "$ref": "#/definitions/models.GetClientResponse"
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "models.GetContractResponse": {
+        "models.MFAStatusResponse": {
             "type": "object",
             "properties": {
-                "anketa": {
-                    "type": "string",
-                    "example": "ANK456"
-                },
-                "calculated_profile_id": {
-                    "type": "integer",
-                    "example": 222
+                "enabled": {
+                    "type": "boolean"
                 },
-                "closed_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "enabled_at": {
+                    "type": "string"
                 },
-                "comment": {
-                    "type": "string",
-                    "example": "Основной договор"
+                "recovery_codes_left": {
+                    "type": "integer"
                 },
-                "contract_owner_id": {
-                    "type": "integer",
-                    "example": 789
+                "required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.OIDCExchangeRequest": {
+            "type": "object",
+            "required": [
+                "login_code"
+            ],
+            "properties": {
+                "login_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OIDCReconcileResult": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "integer"
                 },
-                "contract_owner_type": {
-                    "type": "string",
-                    "example": "individual"
+                "deactivated": {
+                    "type": "integer"
                 },
-                "created_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "failed": {
+                    "type": "integer"
                 },
-                "depo_accounts_type": {
-                    "type": "string",
-                    "example": "standard"
+                "role_changed": {
+                    "type": "integer"
                 },
-                "external_id": {
-                    "type": "integer",
-                    "example": 54321
+                "skipped": {
+                    "description": "нет refresh-токена провайдера или провайдер недоступен",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PermissionInfo": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
                 },
-                "hash": {
-                    "type": "string",
-                    "example": "abc123def456"
+                "description": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PermissionMatrix": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PermissionInfo"
+                    }
                 },
-                "id": {
-                    "type": "integer",
-                    "example": 123
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RoleResponse"
+                    }
+                }
+            }
+        },
+        "models.QuestionnaireSchema": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
                 },
-                "inner_code": {
-                    "type": "string",
-                    "example": "BRK-001"
+                "created_at": {
+                    "type": "string"
                 },
-                "is_personal_invest_account": {
-                    "type": "boolean",
-                    "example": true
+                "created_by_user_id": {
+                    "type": "integer"
                 },
-                "is_personal_invest_account_new": {
-                    "type": "boolean",
-                    "example": false
+                "schema": {
+                    "type": "object"
                 },
-                "kind": {
+                "title": {
                     "type": "string",
-                    "example": "broking"
+                    "example": "Анкета второй части"
                 },
-                "owner_id": {
+                "version": {
                     "type": "integer",
-                    "example": 111
+                    "example": 2
+                }
+            }
+        },
+        "models.QuestionnaireSchemaInfo": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
                 },
-                "raw": {
-                    "type": "object",
-                    "additionalProperties": true
+                "created_at": {
+                    "type": "string"
                 },
-                "rialto_code": {
-                    "type": "string",
-                    "example": "RLT123"
+                "created_by_user_id": {
+                    "type": "integer"
                 },
-                "signed_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "is_current": {
+                    "type": "boolean"
                 },
-                "status": {
+                "title": {
                     "type": "string",
-                    "example": "active"
+                    "example": "Анкета второй части"
                 },
-                "strategy_id": {
+                "version": {
                     "type": "integer",
-                    "example": 333
+                    "example": 2
+                }
+            }
+        },
+        "models.RecalcResult": {
+            "type": "object",
+            "properties": {
+                "finished_at": {
+                    "type": "string"
                 },
-                "strategy_name": {
-                    "type": "string",
-                    "example": "Conservative"
+                "flagged": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
                 },
-                "synced_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "instance": {
+                    "type": "string"
                 },
-                "tariff_id": {
-                    "type": "integer",
-                    "example": 444
+                "resolved": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
                 },
-                "tariff_name": {
-                    "type": "string",
-                    "example": "Basic"
+                "run_id": {
+                    "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string",
-                    "format": "date-time"
+                "started_at": {
+                    "type": "string"
                 },
-                "user_id": {
-                    "type": "integer",
-                    "example": 12345
+                "total_flagged": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResetPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Пустой пароль — сгенерировать временный",
+                    "type": "string"
+                }
+            }
+        },
+        "models.RevealClientRequest": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "pass_series",
+                        "pass_number"
+                    ]
                 },
-                "user_login": {
+                "reason": {
                     "type": "string",
-                    "example": "user123"
+                    "example": "Запрос клиента по телефону, сверка паспорта"
                 }
             }
         },
-        "models.GetSecondPartResponse": {
+        "models.RevealClientResponse": {
             "type": "object",
             "properties": {
-                "approved_by_user_id": {
-                    "type": "integer",
-                    "example": 101
-                },
                 "client_id": {
                     "type": "integer",
                     "example": 123
                 },
-                "client_version": {
-                    "type": "integer",
-                    "example": 1
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "created_by_user_id": {
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "version": {
                     "type": "integer",
-                    "example": 456
+                    "example": 3
+                }
+            }
+        },
+        "models.ReviewPolicy": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
                 },
-                "data": {
-                    "type": "object",
-                    "additionalProperties": true
+                "created_at": {
+                    "type": "string"
                 },
-                "due_at": {
+                "created_by_user_id": {
+                    "type": "integer"
+                },
+                "effective_from": {
                     "type": "string",
-                    "format": "date-time"
+                    "example": "2026-01-01T00:00:00Z"
                 },
-                "is_current": {
-                    "type": "boolean",
-                    "example": true
+                "id": {
+                    "type": "integer"
                 },
-                "reason": {
-                    "type": "string",
-                    "example": "Additional documents required"
+                "interval_months": {
+                    "type": "integer",
+                    "example": 36
                 },
                 "risk_level": {
                     "type": "string",
                     "example": "low"
+                }
+            }
+        },
+        "models.RiskAssessment": {
+            "type": "object",
+            "properties": {
+                "assessed_at": {
+                    "type": "string"
                 },
-                "status": {
+                "factors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RiskFactor"
+                    }
+                },
+                "level": {
                     "type": "string",
-                    "example": "draft"
+                    "example": "medium"
                 },
-                "updated_by_user_id": {
+                "rules_version": {
+                    "type": "string",
+                    "example": "2026-10"
+                },
+                "score": {
                     "type": "integer",
-                    "example": 789
+                    "example": 40
+                }
+            }
+        },
+        "models.RiskFactor": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "non_resident"
                 },
-                "valid_from": {
+                "description": {
                     "type": "string",
-                    "format": "date-time"
+                    "example": "Не является резидентом РФ"
                 },
-                "valid_to": {
+                "fact": {
                     "type": "string",
-                    "format": "date-time"
+                    "example": "is_rf_resident"
                 },
-                "version": {
+                "min_level": {
+                    "type": "string",
+                    "example": "high"
+                },
+                "points": {
                     "type": "integer",
-                    "example": 2
+                    "example": 25
+                },
+                "value": {
+                    "type": "string",
+                    "example": "false"
                 }
             }
         },
-        "models.ListClientsResponse": {
+        "models.RoleCreateRequest": {
             "type": "object",
+            "required": [
+                "label",
+                "name"
+            ],
             "properties": {
-                "clients": {
+                "description": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.ClientDetailResponse"
+                        "type": "string"
                     }
+                }
+            }
+        },
+        "models.RoleResponse": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
                 },
-                "page": {
-                    "type": "integer",
-                    "example": 1
+                "is_system": {
+                    "type": "boolean"
                 },
-                "per_page": {
-                    "type": "integer",
-                    "example": 10
+                "label": {
+                    "type": "string"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "name": {
+                    "type": "string"
                 },
-                "total": {
-                    "type": "integer",
-                    "example": 150
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "total_pages": {
-                    "type": "integer",
-                    "example": 15
+                "updated_at": {
+                    "type": "string"
+                },
+                "users_count": {
+                    "type": "integer"
                 }
             }
         },
-        "models.ListContractsResponse": {
+        "models.RoleUpdateRequest": {
             "type": "object",
             "properties": {
-                "contracts": {
+                "description": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "permissions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.GetContractResponse"
+                        "type": "string"
                     }
+                }
+            }
+        },
+        "models.SecondPartChange": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
                 },
-                "page": {
+                "changed_by_user_id": {
                     "type": "integer",
-                    "example": 1
+                    "example": 456
                 },
-                "per_page": {
+                "client_id": {
                     "type": "integer",
-                    "example": 10
+                    "example": 123
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "id": {
+                    "type": "integer"
                 },
-                "total": {
+                "new_value": {
+                    "type": "object"
+                },
+                "old_value": {
+                    "type": "object"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/source_of_funds"
+                },
+                "revision": {
                     "type": "integer",
-                    "example": 150
+                    "example": 7
                 },
-                "total_pages": {
+                "version": {
                     "type": "integer",
-                    "example": 15
+                    "example": 4
                 }
             }
         },
-        "models.LoginRequest": {
+        "models.SecondPartRequirement": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "client_id": {
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6
-                }
-            }
-        },
-        "models.LoginResponse": {
-            "type": "object",
-            "properties": {
-                "expires_at": {
+                "client_version": {
+                    "description": "версия анкеты, на которой выявлена причина",
                     "type": "integer"
                 },
-                "token": {
+                "details": {
+                    "type": "object"
+                },
+                "detected_at": {
                     "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.AppUser"
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "passport_expired"
+                },
+                "resolution": {
+                    "type": "string",
+                    "example": "second_part_approved"
+                },
+                "resolved_at": {
+                    "type": "string"
                 }
             }
         },
@@ -1557,9 +5776,86 @@ const docTemplate = `{
                     "example": 3
                 }
             }
+        },
+        "models.SecondPartRiskRequest": {
+            "type": "object",
+            "properties": {
+                "risk_level": {
+                    "description": "RiskLevel уровень, для которого задан срок пересмотра (low, medium, high, ...); пусто — принять предложенный",
+                    "type": "string",
+                    "example": "low"
+                },
+                "risk_override_reason": {
+                    "description": "RiskOverrideReason обязательна, если уровень отличается от предложенного",
+                    "type": "string",
+                    "example": "Подтвержден источник средств"
+                }
+            }
+        },
+        "models.UpdateRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "signing.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "EC и Ed25519 (OKP)",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "signing.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/signing.JWK"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key for integrations (issued via /auth/api-keys).",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "BearerAuth": {
             "description": "Type \"Bearer\" followed by a space and JWT token.",
             "type": "apiKey",
@@ -2,48 +2,41 @@ package appsvc
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"time"
 
-	"vector/internal/db"
-	"vector/internal/repository"
+	"vector/internal/service"
 
 	"github.com/robfig/cron/v3"
 )
 
-func StartCron() (*cron.Cron, error) {
+// StartCron запускает пересчет признаков второй части по расписанию APP_CRON ("off" — отключить).
+// При нескольких репликах пересчет выполняет одна, остальные записывают пропуск.
+func StartCron(recalcService *service.RecalcService) (*cron.Cron, error) {
 	spec := os.Getenv("APP_CRON")
 	if spec == "" {
 		spec = "0 4 * * *"
 	}
+	if spec == "off" {
+		log.Printf("[app-cron] disabled")
+		return nil, nil
+	}
 	c := cron.New()
 	_, err := c.AddFunc(spec, func() {
-		log.Printf("[app-cron] recalc start")
-		gdb, err := db.Connect()
-		if err != nil {
-			log.Printf("[app-cron] db connect error: %v", err)
-			return
-		}
-
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-		defer cancel()
-		_ = ctx
-
-		recalcRepo := repository.NewRecalcRepository(gdb)
-
-		n, err := recalcRepo.RecalcNeedsSecondPart()
-		if err != nil {
-			log.Printf("[app-cron] recalc error: %v", err)
-		} else {
-			log.Printf("[app-cron] recalc done, updated=%d", n)
-		}
-
-		n2, err2 := recalcRepo.RecalcPassportExpiry()
-		if err2 != nil {
-			log.Printf("[app-cron] passport recalc error: %v", err2)
-		} else {
-			log.Printf("[app-cron] passport recalc updated=%d", n2)
+		slot := service.CronSlot(time.Now())
+		log.Printf("[app-cron] recalc start (slot %s)", slot.Format(time.RFC3339))
+		result, err := recalcService.RunScheduled(context.Background(), slot)
+		switch {
+		case errors.Is(err, service.ErrJobSkipped):
+			log.Printf("[app-cron] recalc skipped: already running on another instance")
+		case errors.Is(err, service.ErrJobSlotDone):
+			log.Printf("[app-cron] recalc skipped: slot already done by another instance")
+		case err != nil:
+			log.Printf("[app-cron] recalc error (run %d): %v", result.RunID, err)
+		default:
+			log.Printf("[app-cron] recalc done (run %d), flagged=%d", result.RunID, result.TotalFlagged)
 		}
 	})
 	if err != nil {
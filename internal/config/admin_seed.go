@@ -0,0 +1,75 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// AdminSeedConfig учетные данные начального администратора
+type AdminSeedConfig struct {
+	Email     string
+	Password  string
+	Generated bool // пароль сгенерирован и должен быть передан администратору один раз
+	// PasswordFile файл для сгенерированного пароля при неинтерактивном запуске
+	PasswordFile string
+}
+
+// GetAdminSeedConfig читает ADMIN_EMAIL, ADMIN_INITIAL_PASSWORD и ADMIN_INITIAL_PASSWORD_FILE;
+// без пароля генерирует случайный
+func GetAdminSeedConfig() (AdminSeedConfig, error) {
+	cfg := AdminSeedConfig{
+		Email:        os.Getenv("ADMIN_EMAIL"),
+		Password:     os.Getenv("ADMIN_INITIAL_PASSWORD"),
+		PasswordFile: os.Getenv("ADMIN_INITIAL_PASSWORD_FILE"),
+	}
+	if cfg.Email == "" {
+		cfg.Email = "admin@vector.com"
+	}
+	if cfg.PasswordFile == "" {
+		cfg.PasswordFile = "admin-initial-password"
+	}
+	if cfg.Password == "" {
+		b := make([]byte, 12)
+		if _, err := rand.Read(b); err != nil {
+			return cfg, err
+		}
+		cfg.Password = base64.RawURLEncoding.EncodeToString(b)
+		cfg.Generated = true
+	}
+	return cfg, nil
+}
+
+// RevealGeneratedPassword передает сгенерированный пароль администратору в обход логов:
+// в терминал (stderr), если процесс запущен интерактивно, иначе в файл PasswordFile с правами 0600.
+// Возвращает, куда записан пароль, для сообщения в лог.
+func (c AdminSeedConfig) RevealGeneratedPassword() (string, error) {
+	if isTerminal(os.Stderr) {
+		fmt.Fprintf(os.Stderr, "\nInitial admin password for %s: %s\nIt is shown only once; change it on first login.\n\n", c.Email, c.Password)
+		return "terminal", nil
+	}
+
+	f, err := os.OpenFile(c.PasswordFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	// файл мог существовать с более широкими правами
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return "", err
+	}
+	if _, err := fmt.Fprintf(f, "%s\n%s\n", c.Email, c.Password); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return c.PasswordFile, nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRevealGeneratedPasswordWritesPrivateFile(t *testing.T) {
+	if isTerminal(os.Stderr) {
+		t.Skip("stderr is a terminal: the password would be printed instead of written")
+	}
+	path := filepath.Join(t.TempDir(), "admin-initial-password")
+	// существующий файл с широкими правами должен стать 0600
+	if err := os.WriteFile(path, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	seed := AdminSeedConfig{Email: "admin@vector.com", Password: "s3cret", Generated: true, PasswordFile: path}
+	where, err := seed.RevealGeneratedPassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if where != path {
+		t.Fatalf("written to %q, want %q", where, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("file mode = %o, want 600", perm)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "admin@vector.com\ns3cret\n" {
+		t.Fatalf("file content = %q", data)
+	}
+}
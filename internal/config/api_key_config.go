@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+	"vector/internal/models"
+)
+
+// GetAPIKeyConfig возвращает настройки API-ключей из переменных окружения
+func GetAPIKeyConfig() models.APIKeyConfig {
+	cfg := models.APIKeyConfig{
+		DefaultRateLimit: 600,
+		DefaultTTL:       90 * 24 * time.Hour,
+		MaxTTL:           365 * 24 * time.Hour,
+	}
+	if v := os.Getenv("API_KEY_DEFAULT_RATE_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.DefaultRateLimit = n
+		}
+	}
+	if v := os.Getenv("API_KEY_DEFAULT_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.DefaultTTL = d
+		}
+	}
+	if v := os.Getenv("API_KEY_MAX_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.MaxTTL = d
+		}
+	}
+	if cfg.DefaultTTL > cfg.MaxTTL {
+		cfg.DefaultTTL = cfg.MaxTTL
+	}
+	return cfg
+}
@@ -0,0 +1,12 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// IsProduction APP_ENV=production: небезопасные значения по умолчанию для разработки
+// (временные ключи подписи и шифрования) запрещены, приложение не стартует без настоящих ключей
+func IsProduction() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("APP_ENV")), "production")
+}
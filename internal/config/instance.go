@@ -0,0 +1,20 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GetInstanceID имя экземпляра сервиса для журнала фоновых задач:
+// INSTANCE_ID, по умолчанию hostname-pid (в Kubernetes hostname — имя пода)
+func GetInstanceID() string {
+	if id := strings.TrimSpace(os.Getenv("INSTANCE_ID")); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -1,30 +1,79 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"os"
 	"time"
 	"vector/internal/models"
+	"vector/internal/pkg/signing"
 )
 
-// GetJWTConfig возвращает конфигурацию JWT из переменных окружения
-func GetJWTConfig() models.JWTConfig {
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	if secretKey == "" {
-		// Временный ключ для разработки
-		// В продакшене ОБЯЗАТЕЛЬНО должен быть установлен через переменную окружения
-		secretKey = "your-very-secret-jwt-key-change-this-in-production"
+// GetJWTConfig возвращает конфигурацию JWT сервиса приложения из переменных окружения.
+// Ключи подписи читаются из JWT_KEYS_DIR (*.pem, kid — имя файла), подписывает JWT_ACTIVE_KID.
+// Без каталога ключей в разработке создается временный ключ, в production — ошибка.
+func GetJWTConfig() (models.JWTConfig, error) {
+	var cfg models.JWTConfig
+
+	dir := os.Getenv("JWT_KEYS_DIR")
+	switch {
+	case dir != "":
+		keys, err := signing.LoadDir(dir, os.Getenv("JWT_ACTIVE_KID"))
+		if err != nil {
+			return cfg, fmt.Errorf("failed to load JWT signing keys: %w", err)
+		}
+		cfg.Keys = keys
+	case IsProduction():
+		return cfg, errors.New("JWT_KEYS_DIR is required in production")
+	default:
+		keys, err := signing.Ephemeral()
+		if err != nil {
+			return cfg, err
+		}
+		log.Printf("Warning: JWT_KEYS_DIR is not set, using an ephemeral signing key (tokens will not survive restart and are not accepted by the sync service)")
+		cfg.Keys = keys
+	}
+
+	return withTokenDurations(cfg), nil
+}
+
+// GetSharedJWTConfig конфигурация JWT сервиса синхронизации. Он только проверяет токены, выданные
+// сервисом приложения, поэтому читает публичные ключи из JWT_PUBLIC_KEYS_DIR (*.pem, kid — имя файла,
+// как у приватного ключа приложения) и подписывать не может. Каталог обязателен и в разработке:
+// без ключей приложения ни один токен не прошел бы проверку.
+func GetSharedJWTConfig() (models.JWTConfig, error) {
+	var cfg models.JWTConfig
+
+	dir := os.Getenv("JWT_PUBLIC_KEYS_DIR")
+	if dir == "" {
+		return cfg, errors.New("JWT_PUBLIC_KEYS_DIR is required: tokens are issued by the app service, export its public keys (openssl pkey -pubout) into this directory")
+	}
+	keys, err := signing.LoadPublicDir(dir)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load JWT public keys: %w", err)
 	}
+	cfg.Keys = keys
+
+	return withTokenDurations(cfg), nil
+}
 
-	// Время жизни токена (по умолчанию 24 часа)
-	tokenDuration := 24 * time.Hour
+func withTokenDurations(cfg models.JWTConfig) models.JWTConfig {
+	// Время жизни access-токена (по умолчанию 15 минут)
+	cfg.TokenDuration = 15 * time.Minute
 	if durationStr := os.Getenv("JWT_TOKEN_DURATION"); durationStr != "" {
 		if d, err := time.ParseDuration(durationStr); err == nil {
-			tokenDuration = d
+			cfg.TokenDuration = d
 		}
 	}
 
-	return models.JWTConfig{
-		SecretKey:     secretKey,
-		TokenDuration: tokenDuration,
+	// Время жизни refresh-токена (по умолчанию 30 дней, продлевается при обновлении)
+	cfg.RefreshTokenDuration = 30 * 24 * time.Hour
+	if durationStr := os.Getenv("JWT_REFRESH_TOKEN_DURATION"); durationStr != "" {
+		if d, err := time.ParseDuration(durationStr); err == nil {
+			cfg.RefreshTokenDuration = d
+		}
 	}
+
+	return cfg
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"vector/internal/pkg/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeTestKey пишет приватный ключ kid в dir и его публичную часть в pubDir
+func writeTestKey(t *testing.T, dir, pubDir, kid string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	der, err = x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(pubDir, kid+".pem"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSharedJWTConfigRequiresPublicKeysDir(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_PUBLIC_KEYS_DIR", "")
+
+	if _, err := GetSharedJWTConfig(); err == nil {
+		t.Fatal("sync service started without JWT_PUBLIC_KEYS_DIR")
+	}
+	// сервис приложения в разработке работает на временном ключе
+	if cfg, err := GetJWTConfig(); err != nil || cfg.Keys == nil {
+		t.Fatalf("app without JWT_KEYS_DIR in development: %v", err)
+	}
+}
+
+func TestSharedJWTConfigRejectsPrivateKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, t.TempDir(), "2026-01")
+	t.Setenv("JWT_PUBLIC_KEYS_DIR", dir)
+
+	if _, err := GetSharedJWTConfig(); err == nil {
+		t.Fatal("sync service loaded private signing keys")
+	}
+}
+
+func TestSharedJWTConfigValidatesAppTokens(t *testing.T) {
+	dir, pubDir := t.TempDir(), t.TempDir()
+	writeTestKey(t, dir, pubDir, "2026-01")
+	writeTestKey(t, dir, pubDir, "2026-07")
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", "")
+	t.Setenv("JWT_PUBLIC_KEYS_DIR", pubDir)
+
+	app, err := GetJWTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sync, err := GetSharedJWTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := app.Keys.Sign(jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Parse(raw, sync.Keys.Keyfunc, jwt.WithValidMethods(sync.Keys.Algorithms()))
+	if err != nil || !token.Valid {
+		t.Fatalf("sync rejected an app token: %v", err)
+	}
+	if kid := token.Header["kid"]; kid != "2026-07" {
+		t.Fatalf("signed with %v, want the last key by name", kid)
+	}
+
+	// токен, подписанный предыдущим ключом, тоже принимается
+	t.Setenv("JWT_ACTIVE_KID", "2026-01")
+	previous, err := GetJWTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = previous.Keys.Sign(jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()})
+	if _, err := jwt.Parse(raw, sync.Keys.Keyfunc, jwt.WithValidMethods(sync.Keys.Algorithms())); err != nil {
+		t.Fatalf("sync rejected a token of the previous key: %v", err)
+	}
+
+	// сервис синхронизации подписывать не может
+	if _, err := sync.Keys.Sign(jwt.MapClaims{"sub": "1"}); !errors.Is(err, signing.ErrVerifyOnly) {
+		t.Fatalf("verify-only key set signed a token: %v", err)
+	}
+
+	// временный ключ приложения сервис синхронизации не принимает
+	t.Setenv("JWT_KEYS_DIR", "")
+	ephemeral, err := GetJWTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ = ephemeral.Keys.Sign(jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()})
+	if _, err := jwt.Parse(raw, sync.Keys.Keyfunc, jwt.WithValidMethods(sync.Keys.Algorithms())); err == nil {
+		t.Fatal("sync accepted a token signed with an ephemeral key")
+	}
+}
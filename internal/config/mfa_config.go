@@ -0,0 +1,62 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"vector/internal/models"
+	"vector/internal/pkg/secretbox"
+)
+
+// devMFAKeySeed ключ шифрования секретов 2FA для разработки; в production запрещен
+const devMFAKeySeed = "vector-development-mfa-key"
+
+// GetMFAConfig возвращает настройки 2FA из переменных окружения.
+// MFA_ENCRYPTION_KEY — 32 байта в base64, в production обязателен.
+func GetMFAConfig() (models.MFAConfig, error) {
+	cfg := models.MFAConfig{
+		Issuer:        os.Getenv("MFA_ISSUER"),
+		RequiredRoles: []string{models.RoleAdministrator, models.RolePodft},
+		TokenDuration: 5 * time.Minute,
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "Vector"
+	}
+
+	if v, ok := os.LookupEnv("MFA_REQUIRED_ROLES"); ok {
+		cfg.RequiredRoles = nil
+		for _, role := range strings.Split(v, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				cfg.RequiredRoles = append(cfg.RequiredRoles, role)
+			}
+		}
+	}
+
+	if v := os.Getenv("MFA_TOKEN_DURATION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.TokenDuration = d
+		}
+	}
+
+	if v := os.Getenv("MFA_ENCRYPTION_KEY"); v != "" {
+		key, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(key) != secretbox.KeySize {
+			return cfg, fmt.Errorf("MFA_ENCRYPTION_KEY must be %d bytes in base64", secretbox.KeySize)
+		}
+		cfg.EncryptionKey = key
+		return cfg, nil
+	}
+
+	if IsProduction() {
+		return cfg, errors.New("MFA_ENCRYPTION_KEY is required in production")
+	}
+	log.Printf("Warning: MFA_ENCRYPTION_KEY is not set, using a development key")
+	sum := sha256.Sum256([]byte(devMFAKeySeed))
+	cfg.EncryptionKey = sum[:]
+	return cfg, nil
+}
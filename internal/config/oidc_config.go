@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"vector/internal/models"
+)
+
+func envList(key, def string) []string {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		v = def
+	}
+	return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// oidcRoleGroups сопоставление групп провайдера ролям. OIDC_ROLE_GROUPS — список role:group через запятую
+// (роль можно повторять); порядок первого упоминания роли задает приоритет при нескольких совпадениях.
+// OIDC_ADMIN_GROUPS, OIDC_PODFT_GROUPS и OIDC_CLIENT_MANAGEMENT_GROUPS по-прежнему поддерживаются
+// и идут первыми, от старшей встроенной роли к младшей.
+func oidcRoleGroups() ([]models.OIDCRoleGroups, error) {
+	var out []models.OIDCRoleGroups
+	add := func(role, group string) {
+		for i := range out {
+			if out[i].Role == role {
+				out[i].Groups = append(out[i].Groups, group)
+				return
+			}
+		}
+		out = append(out, models.OIDCRoleGroups{Role: role, Groups: []string{group}})
+	}
+
+	for _, legacy := range []struct{ role, env string }{
+		{models.RoleAdministrator, "OIDC_ADMIN_GROUPS"},
+		{models.RolePodft, "OIDC_PODFT_GROUPS"},
+		{models.RoleClientManagement, "OIDC_CLIENT_MANAGEMENT_GROUPS"},
+	} {
+		for _, g := range envList(legacy.env, "") {
+			add(legacy.role, g)
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("OIDC_ROLE_GROUPS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		role, group, ok := strings.Cut(entry, ":")
+		role, group = strings.TrimSpace(role), strings.TrimSpace(group)
+		if !ok || role == "" || group == "" {
+			return nil, fmt.Errorf("OIDC_ROLE_GROUPS: expected role:group, got %q", entry)
+		}
+		add(role, group)
+	}
+	return out, nil
+}
+
+// GetOIDCConfig возвращает настройки входа через OIDC. Вход выключен, если OIDC_ISSUER_URL не задан.
+func GetOIDCConfig() (models.OIDCConfig, error) {
+	cfg := models.OIDCConfig{
+		IssuerURL:           strings.TrimSpace(os.Getenv("OIDC_ISSUER_URL")),
+		ClientID:            os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret:        os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:         os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:              envList("OIDC_SCOPES", "openid email profile offline_access"),
+		GroupsClaim:         os.Getenv("OIDC_GROUPS_CLAIM"),
+		FrontendRedirectURL: os.Getenv("OIDC_FRONTEND_REDIRECT_URL"),
+		StateTTL:            10 * time.Minute,
+	}
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	roleGroups, err := oidcRoleGroups()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.RoleGroups = roleGroups
+	if v := os.Getenv("OIDC_STATE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.StateTTL = d
+		}
+	}
+
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return cfg, errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER_URL is set")
+	}
+	if IsProduction() && (!strings.HasPrefix(cfg.IssuerURL, "https://") || !strings.HasPrefix(cfg.RedirectURL, "https://")) {
+		return cfg, errors.New("OIDC_ISSUER_URL and OIDC_REDIRECT_URL must use https in production")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"vector/internal/models"
+)
+
+func TestOIDCRoleGroups(t *testing.T) {
+	t.Setenv("OIDC_ADMIN_GROUPS", "vector-admins")
+	t.Setenv("OIDC_PODFT_GROUPS", "")
+	t.Setenv("OIDC_CLIENT_MANAGEMENT_GROUPS", "vector-clients")
+	t.Setenv("OIDC_ROLE_GROUPS", " Auditor:vector-audit , ClientManagement:vector-sales,Auditor:vector-audit-ext,")
+
+	got, err := oidcRoleGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.OIDCRoleGroups{
+		{Role: models.RoleAdministrator, Groups: []string{"vector-admins"}},
+		{Role: models.RoleClientManagement, Groups: []string{"vector-clients", "vector-sales"}},
+		{Role: "Auditor", Groups: []string{"vector-audit", "vector-audit-ext"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("oidcRoleGroups() = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"Auditor", "Auditor:", ":group"} {
+		t.Setenv("OIDC_ROLE_GROUPS", bad)
+		if _, err := oidcRoleGroups(); err == nil {
+			t.Errorf("OIDC_ROLE_GROUPS=%q accepted", bad)
+		}
+	}
+}
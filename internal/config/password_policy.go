@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+	"vector/internal/pkg/password"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// GetPasswordPolicy возвращает политику паролей из переменных окружения
+func GetPasswordPolicy() password.Policy {
+	p := password.DefaultPolicy()
+	p.MinLength = envInt("PASSWORD_MIN_LENGTH", p.MinLength)
+	p.MinCharClasses = envInt("PASSWORD_MIN_CHAR_CLASSES", p.MinCharClasses)
+	p.HistorySize = envInt("PASSWORD_HISTORY_SIZE", p.HistorySize)
+	p.BcryptCost = envInt("PASSWORD_BCRYPT_COST", p.BcryptCost)
+	if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		p.BcryptCost = bcrypt.DefaultCost
+	}
+
+	// Срок действия пароля; 0 отключает истечение
+	if v := os.Getenv("PASSWORD_MAX_AGE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			p.MaxAge = d
+		}
+	}
+	return p
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// GetRecalcTimeout ограничение времени пересчета признаков второй части (APP_RECALC_TIMEOUT, по умолчанию 5m)
+func GetRecalcTimeout() time.Duration {
+	if v := os.Getenv("APP_RECALC_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return 5 * time.Minute
+}
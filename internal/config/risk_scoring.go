@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"vector/internal/pkg/riskscore"
+)
+
+// GetRiskScoringRules правила скоринга риска второй части: JSON-файл из RISK_SCORING_RULES
+// (формат internal/pkg/riskscore/default_rules.json) или встроенные правила по умолчанию
+func GetRiskScoringRules() (riskscore.Rules, error) {
+	path := os.Getenv("RISK_SCORING_RULES")
+	if path == "" {
+		return riskscore.Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return riskscore.Rules{}, fmt.Errorf("RISK_SCORING_RULES: %w", err)
+	}
+	rules, err := riskscore.Parse(data)
+	if err != nil {
+		return rules, fmt.Errorf("RISK_SCORING_RULES %s: %w", path, err)
+	}
+	return rules, nil
+}
@@ -2,20 +2,24 @@ package cron
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
 
-	"vector/internal/db"
+	"vector/internal/config"
 	"vector/internal/external"
+	"vector/internal/models"
 	"vector/internal/repository"
 	"vector/internal/service"
 )
 
-func StartCron() (*cron.Cron, error) {
+// StartCron запускает полную синхронизацию по расписанию SYNC_CRON на общем пуле соединений процесса gdb
+func StartCron(gdb *gorm.DB) (*cron.Cron, error) {
 	spec := os.Getenv("SYNC_CRON")
 	if spec == "" {
 		spec = "0 3 * * *" // ежедневно в 03:00
@@ -28,41 +32,47 @@ func StartCron() (*cron.Cron, error) {
 		}
 	}
 
-	c := cron.New()
-
-	_, err := c.AddFunc(spec, func() {
-		start := time.Now()
-		log.Printf("[cron] full sync start (per_page=%d)", perPage)
+	stagingRepo := repository.NewSyncStagingRepository(gdb)
+	clientRepo := repository.NewSyncClientRepository(gdb)
 
-		gdb, err := db.Connect()
-		if err != nil {
-			log.Printf("[cron] db connect error: %v", err)
-			return
-		}
+	contractStagingRepo := repository.NewContractStagingRepository(gdb)
+	contractRepo := repository.NewSyncContractRepository(gdb)
 
-		stagingRepo := repository.NewSyncStagingRepository(gdb)
-		clientRepo := repository.NewSyncClientRepository(gdb)
+	externalClient := external.NewClient()
+	externalAPI := repository.NewSyncExternalAPIClient(externalClient)
 
-		contractStagingRepo := repository.NewContractStagingRepository(gdb)
-		contractRepo := repository.NewSyncContractRepository(gdb)
+	triggerService := service.NewTriggerService()
+	applyService := service.NewApplyService(stagingRepo, clientRepo, externalAPI, triggerService)
 
-		externalClient := external.NewClient()
-		externalAPI := repository.NewSyncExternalAPIClient(externalClient)
+	contractService := service.NewContractService(contractStagingRepo, contractRepo, externalAPI)
 
-		triggerService := service.NewTriggerService()
-		applyService := service.NewApplyService(stagingRepo, clientRepo, externalAPI, triggerService)
+	jobRunner := service.NewJobRunner(repository.NewJobLocker(gdb), repository.NewJobRunRepository(gdb), config.GetInstanceID())
+	fullSyncService := service.NewFullSyncService(applyService, contractService, externalAPI, jobRunner)
 
-		contractService := service.NewContractService(contractStagingRepo, contractRepo, externalAPI)
+	c := cron.New()
 
-		fullSyncService := service.NewFullSyncService(applyService, contractService, externalAPI)
+	_, err := c.AddFunc(spec, func() {
+		start := time.Now()
+		log.Printf("[cron] full sync start (per_page=%d)", perPage)
 
 		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
 		defer cancel()
 
+		slot := service.CronSlot(start)
 		resp, err := fullSyncService.SyncFull(ctx, service.FullSyncRequest{
 			PerPage:       perPage,
 			SyncContracts: true,
+			TriggeredBy:   models.JobTriggerCron,
+			ScheduledAt:   &slot,
 		})
+		if errors.Is(err, service.ErrSyncInProgress) {
+			log.Printf("[cron] full sync skipped: already running on another instance")
+			return
+		}
+		if errors.Is(err, service.ErrJobSlotDone) {
+			log.Printf("[cron] full sync skipped: slot %s already done by another instance", slot.Format(time.RFC3339))
+			return
+		}
 		if err != nil {
 			log.Printf("[cron] full sync error: %v", err)
 			return
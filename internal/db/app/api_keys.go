@@ -0,0 +1,84 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateAPIKey(gdb *gorm.DB, key models.APIKey) (models.APIKey, error) {
+	err := gdb.Create(&key).Error
+	return key, err
+}
+
+func GetAPIKeyByPrefix(gdb *gorm.DB, prefix string) (models.APIKey, error) {
+	var key models.APIKey
+	err := gdb.Where("prefix = ?", prefix).Take(&key).Error
+	return key, err
+}
+
+func GetAPIKey(gdb *gorm.DB, id uint) (models.APIKey, error) {
+	var key models.APIKey
+	err := gdb.Where("id = ?", id).Take(&key).Error
+	return key, err
+}
+
+// ListAPIKeys ключи, новые первыми; отозванные — только при includeRevoked
+func ListAPIKeys(gdb *gorm.DB, includeRevoked bool) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	q := gdb.Order("id DESC")
+	if !includeRevoked {
+		q = q.Where("revoked_at IS NULL")
+	}
+	err := q.Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey отзывает ключ; повторный отзыв возвращает ErrRecordNotFound
+func RevokeAPIKey(gdb *gorm.DB, id uint, revokedBy *uint) (models.APIKey, error) {
+	var key models.APIKey
+	res := gdb.Raw(`
+		UPDATE core.api_keys
+		SET revoked_at = now(), revoked_by = ?
+		WHERE id = ? AND revoked_at IS NULL
+		RETURNING *`, revokedBy, id).Scan(&key)
+	if res.Error != nil {
+		return key, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return key, gorm.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+// HitAPIKeyRateWindow атомарно учитывает запрос ключа; окно начинается заново,
+// если с его начала прошло не меньше period
+func HitAPIKeyRateWindow(gdb *gorm.DB, id uint, period time.Duration) (models.APIKeyRateWindow, error) {
+	now := time.Now().UTC()
+	var w models.APIKeyRateWindow
+	err := gdb.Raw(`
+		INSERT INTO core.api_key_rate_windows (api_key_id, window_start, requests, updated_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (api_key_id) DO UPDATE SET
+			window_start = CASE
+				WHEN core.api_key_rate_windows.window_start <= ? THEN EXCLUDED.window_start
+				ELSE core.api_key_rate_windows.window_start
+			END,
+			requests = CASE
+				WHEN core.api_key_rate_windows.window_start <= ? THEN 1
+				ELSE core.api_key_rate_windows.requests + 1
+			END,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`,
+		id, now, now, now.Add(-period), now.Add(-period),
+	).Scan(&w).Error
+	return w, err
+}
+
+func TouchAPIKey(gdb *gorm.DB, id uint, ip string, at time.Time) error {
+	return gdb.Model(&models.APIKey{}).Where("id = ?", id).Updates(map[string]any{
+		"last_used_at": at,
+		"last_used_ip": ip,
+	}).Error
+}
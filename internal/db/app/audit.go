@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"time"
+	"vector/internal/db/pagination"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey ключ advisory-блокировки: записи цепочки добавляются строго по одной,
+// иначе две транзакции прочитают один и тот же prev_hash
+const auditChainLockKey = "core.audit_events"
+
+// AppendAuditEvent добавляет событие в конец цепочки хешей
+func AppendAuditEvent(gdb *gorm.DB, ev models.AuditEvent) (models.AuditEvent, error) {
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now()
+	}
+	// timestamptz хранит микросекунды; хеш считается от значения, которое вернется из БД
+	ev.OccurredAt = ev.OccurredAt.UTC().Truncate(time.Microsecond)
+
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		var prev []string
+		if err := tx.Model(&models.AuditEvent{}).
+			Order("id DESC").
+			Limit(1).
+			Pluck("hash", &prev).Error; err != nil {
+			return err
+		}
+		ev.PrevHash = ""
+		if len(prev) > 0 {
+			ev.PrevHash = prev[0]
+		}
+		ev.Hash = ev.ComputeHash()
+
+		return tx.Create(&ev).Error
+	})
+	return ev, err
+}
+
+type auditEventRow struct {
+	models.AuditEvent
+	SortKey []byte `gorm:"column:sort_key"`
+}
+
+// auditKeyset журнал читается от новых событий к старым
+var auditKeyset = pagination.Keyset{Keys: []pagination.Key{{Expr: "id", Cast: "bigint", Desc: true}}}
+
+func ListAuditEvents(gdb *gorm.DB, page models.PageRequest, filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	base := gdb.Table("core.audit_events").Select("core.audit_events.*, " + auditKeyset.SelectExpr())
+	base = applyAuditFilter(base, filter)
+
+	rows, info, err := pagination.Paginate(base, auditKeyset, page, func(r *auditEventRow) []byte { return r.SortKey })
+	if err != nil {
+		return nil, info, err
+	}
+
+	events := make([]models.AuditEvent, len(rows))
+	for i := range rows {
+		events[i] = rows[i].AuditEvent
+	}
+	return events, info, nil
+}
+
+func applyAuditFilter(q *gorm.DB, f models.AuditFilter) *gorm.DB {
+	if f.ActorID != nil {
+		q = q.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.ActorEmail != nil {
+		q = q.Where("lower(actor_email) = lower(?)", *f.ActorEmail)
+	}
+	if len(f.Action) > 0 {
+		q = q.Where("action IN ?", f.Action)
+	}
+	if f.EntityType != nil {
+		q = q.Where("entity_type = ?", *f.EntityType)
+	}
+	if f.EntityID != nil {
+		q = q.Where("entity_id = ?", *f.EntityID)
+	}
+	if f.RequestID != nil {
+		q = q.Where("request_id = ?", *f.RequestID)
+	}
+	if f.Method != nil {
+		q = q.Where("method = upper(?)", *f.Method)
+	}
+	if f.From != nil {
+		q = q.Where("occurred_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("occurred_at <= ?", *f.To)
+	}
+	return q
+}
+
+// AuditChainBreak место, где цепочка хешей нарушена
+type AuditChainBreak struct {
+	EventID uint64
+	Reason  string
+}
+
+// VerifyAuditChain проходит журнал от начала и пересчитывает хеши.
+// Возвращает количество проверенных записей и первое нарушение (nil, если цепочка цела).
+func VerifyAuditChain(gdb *gorm.DB, batchSize int) (int64, *AuditChainBreak, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var (
+		checked  int64
+		lastID   uint64
+		prevHash string
+	)
+	for {
+		var batch []models.AuditEvent
+		if err := gdb.Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&batch).Error; err != nil {
+			return checked, nil, err
+		}
+		if len(batch) == 0 {
+			return checked, nil, nil
+		}
+
+		for _, ev := range batch {
+			if ev.PrevHash != prevHash {
+				return checked, &AuditChainBreak{EventID: ev.ID, Reason: "prev_hash does not match previous event"}, nil
+			}
+			if got := ev.ComputeHash(); got != ev.Hash {
+				return checked, &AuditChainBreak{EventID: ev.ID, Reason: fmt.Sprintf("hash mismatch: stored %s, computed %s", ev.Hash, got)}, nil
+			}
+			prevHash = ev.Hash
+			lastID = ev.ID
+			checked++
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func GetThrottle(gdb *gorm.DB, key string) (*models.AuthThrottle, error) {
+	var t models.AuthThrottle
+	err := gdb.Where("key = ?", key).Take(&t).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &t, err
+}
+
+// RegisterThrottleFailure атомарно увеличивает счетчик неудач; счетчик начинается заново,
+// если с прошлой неудачи прошло больше window
+func RegisterThrottleFailure(gdb *gorm.DB, key string, window time.Duration) (models.AuthThrottle, error) {
+	now := time.Now().UTC()
+	var t models.AuthThrottle
+	err := gdb.Raw(`
+		INSERT INTO core.auth_throttle (key, failures, last_failure_at, updated_at)
+		VALUES (?, 1, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN core.auth_throttle.last_failure_at < ? THEN 1
+				ELSE core.auth_throttle.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`,
+		key, now, now, now.Add(-window),
+	).Scan(&t).Error
+	return t, err
+}
+
+func LockThrottle(gdb *gorm.DB, key string, until time.Time) error {
+	return gdb.Model(&models.AuthThrottle{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func ClearThrottle(gdb *gorm.DB, key string) (int64, error) {
+	res := gdb.Where("key = ?", key).Delete(&models.AuthThrottle{})
+	return res.RowsAffected, res.Error
+}
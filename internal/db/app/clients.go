@@ -1,13 +1,15 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
+	"vector/internal/db/pagination"
 	"vector/internal/models"
 
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetClientCurrent(gdb *gorm.DB, id int) (models.ClientVersion, error) {
@@ -28,12 +30,39 @@ func ListSecondPartHistory(gdb *gorm.DB, id int) ([]models.SecondPartVersion, er
 	return vs, err
 }
 
+// applySecondPartRisk записывает в версию предложение скоринга и решение сотрудника (если принято);
+// срок пересмотра — по действующему сроку для уровня (ErrNoReviewPolicy для уровня без срока)
+func applySecondPartRisk(tx *gorm.DB, sp *models.SecondPartVersion, risk models.SecondPartRisk, now time.Time) error {
+	if risk.Assessment.Level != "" {
+		raw, err := json.Marshal(risk.Assessment)
+		if err != nil {
+			return err
+		}
+		sp.RiskProposedLevel = risk.Assessment.Level
+		sp.RiskAssessment = raw
+	}
+	if risk.Level != "" {
+		dueAt, err := secondPartDueAt(tx, now, risk.Level)
+		if err != nil {
+			return err
+		}
+		sp.RiskLevel = risk.Level
+		sp.DueAt = dueAt
+		sp.RiskDecision = risk.Decision
+		sp.RiskOverrideReason = risk.OverrideReason
+		sp.RiskDecidedByUserID = risk.DecidedBy
+		sp.RiskDecidedAt = &now
+	}
+	return nil
+}
+
 func CreateSecondPartDraft(
 	gdb *gorm.DB,
 	clientID int,
-	riskLevel *string,
+	risk models.SecondPartRisk,
 	createdBy *int,
 	dataOverride *datatypes.JSON,
+	schemaVersion *int,
 ) (models.SecondPartVersion, error) {
 	now := time.Now().UTC()
 	var out models.SecondPartVersion
@@ -71,20 +100,6 @@ func CreateSecondPartDraft(
 			data = *dataOverride
 		}
 
-		var dueAt *time.Time
-		var risk string
-		if riskLevel != nil && *riskLevel != "" {
-			risk = *riskLevel
-			years := 0
-			if risk == "low" {
-				years = 3
-			} else {
-				years = 1
-			}
-			t := now.AddDate(years, 0, 0)
-			dueAt = &t
-		}
-
 		sp := models.SecondPartVersion{
 			ClientID:      clientID,
 			ClientVersion: curClient.Version,
@@ -93,8 +108,10 @@ func CreateSecondPartDraft(
 			ValidFrom:     now,
 			Status:        "draft",
 			Data:          data,
-			RiskLevel:     risk,
-			DueAt:         dueAt,
+			SchemaVersion: schemaVersion,
+		}
+		if err := applySecondPartRisk(tx, &sp, risk, now); err != nil {
+			return err
 		}
 		if createdBy != nil {
 			sp.CreatedByUserID = createdBy
@@ -122,6 +139,19 @@ func TransitionSecondPartStatus(
 	newStatus string, // submitted|approved|rejected|doc_requested
 	actorID *int,
 	reason *string,
+) (models.SecondPartVersion, error) {
+	return transitionSecondPart(gdb, clientID, newStatus, actorID, reason, nil)
+}
+
+// transitionSecondPart создает версию с новым статусом; check вызывается под блокировкой текущей версии
+// и может отклонить переход или дополнить новую версию
+func transitionSecondPart(
+	gdb *gorm.DB,
+	clientID int,
+	newStatus string,
+	actorID *int,
+	reason *string,
+	check func(cur models.SecondPartVersion, next *models.SecondPartVersion) error,
 ) (models.SecondPartVersion, error) {
 	now := time.Now().UTC()
 	var out models.SecondPartVersion
@@ -134,9 +164,11 @@ func TransitionSecondPartStatus(
 		}
 
 		var curSP models.SecondPartVersion
-		err = tx.Where("client_id = ? AND is_current = true", clientID).Take(&curSP).Error
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("client_id = ? AND is_current = true", clientID).
+			Take(&curSP).Error
 		if err == gorm.ErrRecordNotFound {
-			spDraft, err := CreateSecondPartDraft(tx, clientID, nil, actorID, nil)
+			spDraft, err := CreateSecondPartDraft(tx, clientID, models.SecondPartRisk{}, actorID, nil, nil)
 			if err != nil {
 				return err
 			}
@@ -162,22 +194,37 @@ func TransitionSecondPartStatus(
 			ValidFrom:     now,
 			Status:        newStatus,
 			Data:          curSP.Data,
+			SchemaVersion: curSP.SchemaVersion,
 			RiskLevel:     curSP.RiskLevel,
 			DueAt:         curSP.DueAt,
 			Reason:        "",
+
+			RiskProposedLevel:   curSP.RiskProposedLevel,
+			RiskAssessment:      curSP.RiskAssessment,
+			RiskDecision:        curSP.RiskDecision,
+			RiskOverrideReason:  curSP.RiskOverrideReason,
+			RiskDecidedByUserID: curSP.RiskDecidedByUserID,
+			RiskDecidedAt:       curSP.RiskDecidedAt,
+		}
+
+		if check != nil {
+			if err := check(curSP, &next); err != nil {
+				return err
+			}
 		}
 
 		if newStatus == "approved" {
-			years := 1
-			if strings.ToLower(curSP.RiskLevel) == "low" {
-				years = 3
+			if next.DueAt, err = secondPartDueAt(tx, now, curSP.RiskLevel); err != nil {
+				return err
 			}
-			t := now.AddDate(years, 0, 0)
-			next.DueAt = &t
 
-			if err := tx.Model(&models.ClientVersion{}).
-				Where("client_id = ? AND is_current = true", clientID).
-				Update("needs_second_part", false).Error; err != nil {
+			// утвержденная вторая часть снимает причины, связанные с анкетой; passport_expired
+			// остается до получения нового паспорта
+			if _, err := ResolveSecondPartRequirements(tx, clientID, []string{
+				models.RequirementNewClient,
+				models.RequirementDataChanged,
+				models.RequirementQuestionnaireExpired,
+			}, models.ResolutionSecondPartApproved); err != nil {
 				return err
 			}
 		}
@@ -204,8 +251,57 @@ func TransitionSecondPartStatus(
 	return out, err
 }
 
-func SubmitSecondPart(gdb *gorm.DB, clientID int, userID *int) (models.SecondPartVersion, error) {
-	return TransitionSecondPartStatus(gdb, clientID, "submitted", userID, nil)
+// DecideSecondPartRisk записывает решение сотрудника по уровню риска в текущий черновик
+// (draft или doc_requested) и пересчитывает срок пересмотра
+func DecideSecondPartRisk(gdb *gorm.DB, clientID int, risk models.SecondPartRisk) (models.SecondPartVersion, error) {
+	now := time.Now().UTC()
+	var sp models.SecondPartVersion
+
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("client_id = ? AND is_current = true", clientID).
+			Take(&sp).Error; err != nil {
+			return err
+		}
+		if sp.Status != "draft" && sp.Status != "doc_requested" {
+			return models.ErrSecondPartNotEditable
+		}
+		if err := applySecondPartRisk(tx, &sp, risk, now); err != nil {
+			return err
+		}
+		return tx.Model(&models.SecondPartVersion{}).
+			Where("client_id = ? AND version = ?", clientID, sp.Version).
+			Updates(map[string]any{
+				"risk_level":              sp.RiskLevel,
+				"due_at":                  sp.DueAt,
+				"risk_proposed_level":     sp.RiskProposedLevel,
+				"risk_assessment":         sp.RiskAssessment,
+				"risk_decision":           sp.RiskDecision,
+				"risk_override_reason":    sp.RiskOverrideReason,
+				"risk_decided_by_user_id": sp.RiskDecidedByUserID,
+				"risk_decided_at":         sp.RiskDecidedAt,
+			}).Error
+	})
+	return sp, err
+}
+
+// SubmitSecondPart отправляет черновик версии spVersion, проверенный по схеме анкеты schemaVersion.
+// Если вторая часть изменилась после проверки — ErrSecondPartVersionConflict.
+// SubmitSecondPart отправляет черновик, проверенный по схеме в версии spVersion и правке revision.
+// Правка черновика (PATCH) меняет только Revision, поэтому сверяются обе: иначе данные, измененные
+// между проверкой и отправкой, ушли бы непроверенными.
+func SubmitSecondPart(gdb *gorm.DB, clientID int, userID *int, spVersion, revision, schemaVersion int) (models.SecondPartVersion, error) {
+	return transitionSecondPart(gdb, clientID, "submitted", userID, nil,
+		func(cur models.SecondPartVersion, next *models.SecondPartVersion) error {
+			if cur.Version != spVersion || cur.Revision != revision {
+				return models.ErrSecondPartVersionConflict
+			}
+			if cur.Status != "draft" && cur.Status != "doc_requested" {
+				return models.ErrSecondPartNotEditable
+			}
+			next.SchemaVersion = &schemaVersion
+			return nil
+		})
 }
 
 func ApproveSecondPart(gdb *gorm.DB, clientID int, approvedBy *int) (models.SecondPartVersion, error) {
@@ -238,6 +334,7 @@ type ClientWithSP struct {
 	PassIssueDate     string `gorm:"column:pass_issue_date" json:"pass_issue_date"`
 	PassIssuer        string `gorm:"column:pass_issuer" json:"pass_issuer"`
 	MainPhone         string `gorm:"column:main_phone" json:"main_phone"`
+	RiskLevel         string `gorm:"column:risk_level" json:"risk_level"`
 	ExternalRiskLevel string `gorm:"column:external_risk_level" json:"external_risk_level"`
 	NeedsSecondPart   bool   `gorm:"column:needs_second_part" json:"needs_second_part"`
 	SecondPartCreated bool   `gorm:"column:second_part_created" json:"second_part_created"`
@@ -246,25 +343,32 @@ type ClientWithSP struct {
 	SpStatus        *string    `gorm:"column:sp_status" json:"sp_status,omitempty"`
 	SpDueAt         *time.Time `gorm:"column:sp_due_at" json:"sp_due_at,omitempty"`
 	SpClientVersion *int       `gorm:"column:sp_client_version" json:"sp_client_version,omitempty"`
+
+	NeedsSecondPartReasons datatypes.JSONSlice[string] `gorm:"column:needs_second_part_reasons" json:"needs_second_part_reasons"`
+
+	SortKey []byte `gorm:"column:sort_key" json:"-"`
 }
 
-func ListClientsWithSP(
-	gdb *gorm.DB,
-	page, perPage int,
-	needsSecondPart *bool,
-	spStatus *string,
-	dueBefore *time.Time,
-) (items []ClientWithSP, total int64, err error) {
-	if page <= 0 {
-		page = 1
-	}
-	if perPage <= 0 || perPage > 500 {
-		perPage = 100
-	}
-	offset := (page - 1) * perPage
+// clientCreatedLKExpr created_lk_at хранится строкой из внешней системы; приводим только ISO-даты
+const clientCreatedLKExpr = `(CASE WHEN c.created_lk_at ~ '^\d{4}-\d{2}-\d{2}' THEN c.created_lk_at::timestamptz END)`
+
+// clientSortColumns сопоставляет поля sort= с выражениями SQL (whitelist)
+var clientSortColumns = map[string]pagination.Column{
+	"id":                  {Expr: "c.client_id", Cast: "bigint"},
+	"surname":             {Expr: "c.surname", Cast: "text", Zero: "''", Nullable: true},
+	"name":                {Expr: "c.name", Cast: "text", Zero: "''", Nullable: true},
+	"birthday":            {Expr: "c.birthday", Cast: "text", Zero: "''", Nullable: true},
+	"version":             {Expr: "c.version", Cast: "bigint"},
+	"created_lk_at":       {Expr: clientCreatedLKExpr, Cast: "timestamptz", Zero: "'epoch'::timestamptz", Nullable: true},
+	"updated_lk_at":       {Expr: "c.updated_lk_at", Cast: "text", Zero: "''", Nullable: true},
+	"risk_level":          {Expr: "c.risk_level", Cast: "text", Zero: "''", Nullable: true},
+	"external_risk_level": {Expr: "c.external_risk_level", Cast: "text", Zero: "''", Nullable: true},
+	"fill_stage":          {Expr: "c.fill_stage", Cast: "text", Zero: "''", Nullable: true},
+	"sp_status":           {Expr: "sp.status", Cast: "text", Zero: "''", Nullable: true},
+	"sp_due_at":           {Expr: "sp.due_at", Cast: "timestamptz", Zero: "'epoch'::timestamptz", Nullable: true},
+}
 
-	base := gdb.Table("core.clients_versions AS c").
-		Select(`
+const clientListSelect = `
 		c.client_id,
 		c.surname, c.name, c.patronymic,
 		c.birthday, c.birth_place,
@@ -272,14 +376,39 @@ func ListClientsWithSP(
 		c.created_lk_at, c.updated_lk_at,
 		c.pass_issuer_code, c.pass_series, c.pass_number,
 		c.pass_issue_date, c.pass_issuer, c.main_phone,
+		COALESCE(c.risk_level, '') AS risk_level,
 		c.external_risk_level,
 		c.needs_second_part,
 		c.second_part_created,
 		c.version AS client_version,
 		sp.status AS sp_status,
 		sp.due_at AS sp_due_at,
-		sp.client_version AS sp_client_version
-	`).
+		sp.client_version AS sp_client_version,
+		COALESCE((
+		  SELECT jsonb_agg(r.reason ORDER BY r.reason)
+		  FROM core.second_part_requirements r
+		  WHERE r.client_id = c.client_id AND r.resolved_at IS NULL
+		), '[]'::jsonb) AS needs_second_part_reasons`
+
+func ListClientsWithSP(
+	gdb *gorm.DB,
+	page models.PageRequest,
+	filter models.ClientListFilter,
+) ([]ClientWithSP, models.PageInfo, error) {
+	if page.Page <= 0 {
+		page.Page = 1
+	}
+	if page.PerPage <= 0 || page.PerPage > 500 {
+		page.PerPage = 100
+	}
+
+	ks, err := clientKeyset(filter.Sort)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	base := gdb.Table("core.clients_versions AS c").
+		Select(clientListSelect + ", " + ks.SelectExpr()).
 		Joins(`
 		LEFT JOIN core.second_part_versions AS sp
 			ON sp.client_id = c.client_id
@@ -287,27 +416,77 @@ func ListClientsWithSP(
 	`).
 		Where("c.is_current = true")
 
-	if needsSecondPart != nil {
-		base = base.Where("c.needs_second_part = ?", *needsSecondPart)
+	base = applyClientFilter(base, filter)
+
+	return pagination.Paginate(base, ks, page, func(r *ClientWithSP) []byte { return r.SortKey })
+}
+
+func applyClientFilter(q *gorm.DB, f models.ClientListFilter) *gorm.DB {
+	if f.NeedsSecondPart != nil {
+		q = q.Where("c.needs_second_part = ?", *f.NeedsSecondPart)
+	}
+	if len(f.NeedsSecondPartReason) > 0 {
+		q = q.Where(`EXISTS (
+			SELECT 1 FROM core.second_part_requirements r
+			WHERE r.client_id = c.client_id AND r.resolved_at IS NULL AND r.reason IN ?
+		)`, f.NeedsSecondPartReason)
+	}
+	if len(f.SpStatus) > 0 {
+		q = q.Where("sp.status IN ?", f.SpStatus)
+	}
+	if f.SpDueBefore != nil {
+		q = q.Where("sp.due_at IS NOT NULL AND sp.due_at <= ?", *f.SpDueBefore)
+	}
+	if f.SpDueAfter != nil {
+		q = q.Where("sp.due_at IS NOT NULL AND sp.due_at >= ?", *f.SpDueAfter)
+	}
+	if len(f.RiskLevel) > 0 {
+		q = q.Where("c.risk_level IN ?", f.RiskLevel)
 	}
-	if spStatus != nil && *spStatus != "" {
-		base = base.Where("sp.status = ?", *spStatus)
+	if len(f.ExternalRiskLevel) > 0 {
+		q = q.Where("c.external_risk_level IN ?", f.ExternalRiskLevel)
 	}
-	if dueBefore != nil {
-		base = base.Where("sp.due_at IS NOT NULL AND sp.due_at <= ?", *dueBefore)
+	if f.Blocked != nil {
+		q = q.Where("COALESCE(c.blocked, false) = ?", *f.Blocked)
 	}
+	if f.IsRfResident != nil {
+		q = q.Where("c.is_rf_resident = ?", *f.IsRfResident)
+	}
+	if f.QualifiedInvestor != nil {
+		q = q.Where("COALESCE(c.qualified_investor, false) = ?", *f.QualifiedInvestor)
+	}
+	if f.CreatedLKFrom != nil {
+		q = q.Where(clientCreatedLKExpr+" >= ?", *f.CreatedLKFrom)
+	}
+	if f.CreatedLKTo != nil {
+		q = q.Where(clientCreatedLKExpr+" <= ?", *f.CreatedLKTo)
+	}
+	if len(f.FillStage) > 0 {
+		q = q.Where("c.fill_stage IN ?", f.FillStage)
+	}
+	if f.HasActiveContract != nil {
+		cond := "EXISTS (SELECT 1 FROM core.contracts AS ct WHERE ct.user_id = c.client_id AND ct.status = 'active')"
+		if !*f.HasActiveContract {
+			cond = "NOT " + cond
+		}
+		q = q.Where(cond)
+	}
+	return q
+}
 
-	if err = base.Count(&total).Error; err != nil {
-		return
+// clientKeyset порядок списка клиентов; client_id уникален среди текущих версий
+// и делает порядок детерминированным
+func clientKeyset(sort []models.SortField) (pagination.Keyset, error) {
+	var ks pagination.Keyset
+	for _, sf := range sort {
+		col, ok := clientSortColumns[sf.Field]
+		if !ok {
+			return ks, fmt.Errorf("unsupported sort field %q", sf.Field)
+		}
+		ks.Keys = append(ks.Keys, col.Keys(sf.Desc)...)
 	}
-	fmt.Printf("DEBUG: Total found: %d\n", total)
-	err = base.
-		Order("c.client_id ASC").
-		Limit(perPage).
-		Offset(offset).
-		Scan(&items).Error
-
-	return
+	ks.Keys = append(ks.Keys, pagination.Key{Expr: "c.client_id", Cast: "bigint"})
+	return ks, nil
 }
 
 func GetClientHistory(gdb *gorm.DB, clientID int) ([]models.ClientVersion, error) {
@@ -318,9 +497,123 @@ func GetClientHistory(gdb *gorm.DB, clientID int) ([]models.ClientVersion, error
 	return versions, err
 }
 
+// clientHistoryKeyset версии клиента от новых к старым
+var clientHistoryKeyset = pagination.Keyset{Keys: []pagination.Key{{Expr: "version", Cast: "bigint", Desc: true}}}
+
+type clientVersionRow struct {
+	models.ClientVersion
+	SortKey []byte `gorm:"column:sort_key"`
+}
+
+// ListClientHistory постраничная история версий клиента
+func ListClientHistory(gdb *gorm.DB, clientID int, page models.PageRequest) ([]models.ClientVersion, models.PageInfo, error) {
+	base := gdb.Table("core.clients_versions").
+		Select("*, "+clientHistoryKeyset.SelectExpr()).
+		Where("client_id = ?", clientID)
+
+	rows, info, err := pagination.Paginate(base, clientHistoryKeyset, page, func(r *clientVersionRow) []byte { return r.SortKey })
+	if err != nil {
+		return nil, info, err
+	}
+
+	versions := make([]models.ClientVersion, len(rows))
+	for i := range rows {
+		versions[i] = rows[i].ClientVersion
+	}
+	return versions, info, nil
+}
+
 func GetClientVersion(gdb *gorm.DB, clientID int, version int) (models.ClientVersion, error) {
 	var clientVersion models.ClientVersion
 	err := gdb.Where("client_id = ? AND version = ?", clientID, version).
 		Take(&clientVersion).Error
 	return clientVersion, err
 }
+
+// UpdateSecondPartDraftData заменяет анкету текущего черновика без создания новой версии и записывает
+// изменения полей. Черновик должен быть в версии version и правке revision (If-Match), иначе
+// ErrSecondPartVersionConflict; правка увеличивается на единицу.
+func UpdateSecondPartDraftData(
+	gdb *gorm.DB,
+	clientID, version, revision int,
+	data datatypes.JSON,
+	schemaVersion *int,
+	changes []models.SecondPartChange,
+	actorID *int,
+) (models.SecondPartVersion, error) {
+	now := time.Now().UTC()
+	var sp models.SecondPartVersion
+
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("client_id = ? AND is_current = true", clientID).
+			Take(&sp).Error; err != nil {
+			return err
+		}
+		if sp.Version != version || sp.Revision != revision {
+			return models.ErrSecondPartVersionConflict
+		}
+		if sp.Status != "draft" && sp.Status != "doc_requested" {
+			return models.ErrSecondPartNotEditable
+		}
+
+		sp.Data = data
+		sp.SchemaVersion = schemaVersion
+		sp.Revision++
+		if actorID != nil {
+			sp.UpdatedByUserID = actorID
+		}
+		if err := tx.Model(&models.SecondPartVersion{}).
+			Where("client_id = ? AND version = ?", clientID, sp.Version).
+			Updates(map[string]any{
+				"data":               sp.Data,
+				"schema_version":     sp.SchemaVersion,
+				"revision":           sp.Revision,
+				"updated_by_user_id": sp.UpdatedByUserID,
+			}).Error; err != nil {
+			return err
+		}
+
+		for i := range changes {
+			changes[i].ClientID = clientID
+			changes[i].Version = sp.Version
+			changes[i].Revision = sp.Revision
+			changes[i].ChangedByUserID = actorID
+			changes[i].ChangedAt = now
+		}
+		if len(changes) > 0 {
+			return tx.Create(&changes).Error
+		}
+		return nil
+	})
+	return sp, err
+}
+
+// secondPartChangesKeyset изменения анкеты от новых к старым
+var secondPartChangesKeyset = pagination.Keyset{Keys: []pagination.Key{{Expr: "id", Cast: "bigint", Desc: true}}}
+
+type secondPartChangeRow struct {
+	models.SecondPartChange
+	SortKey []byte `gorm:"column:sort_key"`
+}
+
+// ListSecondPartChanges постраничный журнал изменений анкеты клиента; version > 0 — только для этой версии
+func ListSecondPartChanges(gdb *gorm.DB, clientID, version int, page models.PageRequest) ([]models.SecondPartChange, models.PageInfo, error) {
+	base := gdb.Table("core.second_part_changes").
+		Select("*, "+secondPartChangesKeyset.SelectExpr()).
+		Where("client_id = ?", clientID)
+	if version > 0 {
+		base = base.Where("version = ?", version)
+	}
+
+	rows, info, err := pagination.Paginate(base, secondPartChangesKeyset, page, func(r *secondPartChangeRow) []byte { return r.SortKey })
+	if err != nil {
+		return nil, info, err
+	}
+
+	changes := make([]models.SecondPartChange, len(rows))
+	for i := range rows {
+		changes[i] = rows[i].SecondPartChange
+	}
+	return changes, info, nil
+}
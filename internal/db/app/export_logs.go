@@ -0,0 +1,29 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateExportLog(gdb *gorm.DB, log models.ExportLog) (models.ExportLog, error) {
+	if log.StartedAt.IsZero() {
+		log.StartedAt = time.Now().UTC()
+	}
+	if log.Status == "" {
+		log.Status = models.ExportStatusStarted
+	}
+	return log, gdb.Create(&log).Error
+}
+
+func FinishExportLog(gdb *gorm.DB, id uint, status string, rows int64, errMsg string) error {
+	return gdb.Model(&models.ExportLog{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":      status,
+			"row_count":   rows,
+			"error":       errMsg,
+			"finished_at": time.Now().UTC(),
+		}).Error
+}
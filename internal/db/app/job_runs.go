@@ -0,0 +1,95 @@
+package app
+
+import (
+	"time"
+	"vector/internal/db/pagination"
+	"vector/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateJobRun записывает запуск. Запуск по расписанию, слот которого уже занят выполняемым
+// или успешным запуском (уникальный индекс idx_job_runs_slot), не создается — ErrJobSlotTaken.
+func CreateJobRun(gdb *gorm.DB, run models.JobRun) (models.JobRun, error) {
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	if run.Status == "" {
+		run.Status = models.JobStatusRunning
+	}
+	if run.ScheduledAt == nil {
+		return run, gdb.Create(&run).Error
+	}
+	res := gdb.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+	if res.Error != nil {
+		return run, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return run, models.ErrJobSlotTaken
+	}
+	return run, nil
+}
+
+func FinishJobRun(gdb *gorm.DB, id uint, status string, result datatypes.JSON, errMsg string) error {
+	return gdb.Model(&models.JobRun{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":      status,
+			"result":      result,
+			"error":       errMsg,
+			"finished_at": time.Now().UTC(),
+		}).Error
+}
+
+// LatestRunningJobRun последний незавершенный запуск задачи
+func LatestRunningJobRun(gdb *gorm.DB, job string) (models.JobRun, error) {
+	var run models.JobRun
+	err := gdb.Where("job = ? AND status = ?", job, models.JobStatusRunning).
+		Order("started_at DESC").
+		Take(&run).Error
+	return run, err
+}
+
+// AbandonJobRuns помечает незавершенные запуски задачи как прерванные.
+// Вызывается под блокировкой задачи: раз она свободна, эти запуски уже не выполняются.
+func AbandonJobRuns(gdb *gorm.DB, job string) (int64, error) {
+	res := gdb.Model(&models.JobRun{}).
+		Where("job = ? AND status = ?", job, models.JobStatusRunning).
+		Updates(map[string]any{
+			"status":      models.JobStatusFailed,
+			"error":       "прервано: экземпляр завершился до окончания задачи",
+			"finished_at": time.Now().UTC(),
+		})
+	return res.RowsAffected, res.Error
+}
+
+type jobRunRow struct {
+	models.JobRun
+	SortKey []byte `gorm:"column:sort_key"`
+}
+
+// jobRunKeyset запуски читаются от новых к старым
+var jobRunKeyset = pagination.Keyset{Keys: []pagination.Key{{Expr: "id", Cast: "bigint", Desc: true}}}
+
+func ListJobRuns(gdb *gorm.DB, page models.PageRequest, filter models.JobRunFilter) ([]models.JobRun, models.PageInfo, error) {
+	base := gdb.Table("core.job_runs").Select("core.job_runs.*, " + jobRunKeyset.SelectExpr())
+	if filter.Job != nil {
+		base = base.Where("job = ?", *filter.Job)
+	}
+	if filter.Status != nil {
+		base = base.Where("status = ?", *filter.Status)
+	}
+
+	rows, info, err := pagination.Paginate(base, jobRunKeyset, page, func(r *jobRunRow) []byte { return r.SortKey })
+	if err != nil {
+		return nil, info, err
+	}
+
+	runs := make([]models.JobRun, len(rows))
+	for i := range rows {
+		runs[i] = rows[i].JobRun
+	}
+	return runs, info, nil
+}
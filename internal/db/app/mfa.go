@@ -0,0 +1,123 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SetPendingMFASecret сохраняет секрет начатого подключения 2FA; до подтверждения кодом
+// 2FA остается выключенной
+func SetPendingMFASecret(gdb *gorm.DB, userID uint, sealed string) error {
+	res := gdb.Model(&models.AppUser{}).
+		Where("id = ? AND mfa_enabled = false", userID).
+		Updates(map[string]any{"mfa_secret": sealed, "mfa_last_step": 0})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EnableMFA включает 2FA после проверки первого кода и заменяет коды восстановления
+func EnableMFA(gdb *gorm.DB, userID uint, step int64, codeHashes []string) error {
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.AppUser{}).
+			Where("id = ? AND mfa_enabled = false AND mfa_secret <> ''", userID).
+			Updates(map[string]any{
+				"mfa_enabled":    true,
+				"mfa_enabled_at": time.Now().UTC(),
+				"mfa_last_step":  step,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// DisableMFA выключает 2FA, удаляет секрет и коды восстановления
+func DisableMFA(gdb *gorm.DB, userID uint) error {
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.AppUser{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{
+				"mfa_enabled":    false,
+				"mfa_secret":     "",
+				"mfa_last_step":  0,
+				"mfa_enabled_at": nil,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// AcceptMFAStep фиксирует использованный шаг TOTP. false — код этого или более
+// позднего шага уже принимался (повтор перехваченного кода)
+func AcceptMFAStep(gdb *gorm.DB, userID uint, step int64) (bool, error) {
+	res := gdb.Model(&models.AppUser{}).
+		Where("id = ? AND mfa_last_step < ?", userID, step).
+		Update("mfa_last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+// UseMFAToken фиксирует использование токена второго шага. false — токен с этим jti
+// уже обменивался на сессию. Истекшие записи удаляются: такие токены и так не проходят проверку
+func UseMFAToken(gdb *gorm.DB, jti string, userID uint, expiresAt time.Time) (bool, error) {
+	now := time.Now().UTC()
+	if err := gdb.Where("expires_at < ?", now).Delete(&models.MFAUsedToken{}).Error; err != nil {
+		return false, err
+	}
+	res := gdb.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.MFAUsedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt.UTC(),
+		UsedAt:    now,
+	})
+	return res.RowsAffected == 1, res.Error
+}
+
+func ReplaceRecoveryCodes(gdb *gorm.DB, userID uint, codeHashes []string) error {
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.MFARecoveryCode, len(codeHashes))
+	for i, h := range codeHashes {
+		codes[i] = models.MFARecoveryCode{UserID: userID, CodeHash: h}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode погашает код восстановления; false — кода нет или он уже использован
+func UseRecoveryCode(gdb *gorm.DB, userID uint, codeHash string) (bool, error) {
+	res := gdb.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now().UTC())
+	return res.RowsAffected == 1, res.Error
+}
+
+func CountUnusedRecoveryCodes(gdb *gorm.DB, userID uint) (int64, error) {
+	var n int64
+	err := gdb.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateOIDCState сохраняет новую попытку входа и удаляет давно истекшие
+func CreateOIDCState(gdb *gorm.DB, st models.OIDCLoginState) (models.OIDCLoginState, error) {
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at < ?", time.Now().UTC().Add(-24*time.Hour)).
+			Delete(&models.OIDCLoginState{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&st).Error
+	})
+	return st, err
+}
+
+// ConsumeOIDCState погашает state: повторный или просроченный callback получает ErrRecordNotFound
+func ConsumeOIDCState(gdb *gorm.DB, stateHash string) (models.OIDCLoginState, error) {
+	var st models.OIDCLoginState
+	res := gdb.Raw(`
+		UPDATE core.oidc_login_states
+		SET consumed_at = now()
+		WHERE state_hash = ? AND consumed_at IS NULL AND expires_at > now()
+		RETURNING *`, stateHash).Scan(&st)
+	if res.Error != nil {
+		return st, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return st, gorm.ErrRecordNotFound
+	}
+	return st, nil
+}
+
+func SetOIDCLoginCode(gdb *gorm.DB, id uint64, userID uint, codeHash string, expiresAt time.Time) error {
+	return gdb.Model(&models.OIDCLoginState{}).Where("id = ?", id).Updates(map[string]any{
+		"user_id":         userID,
+		"login_code_hash": codeHash,
+		"expires_at":      expiresAt,
+	}).Error
+}
+
+// RedeemOIDCLoginCode погашает одноразовый код входа и возвращает пользователя
+func RedeemOIDCLoginCode(gdb *gorm.DB, codeHash string) (uint, error) {
+	var st models.OIDCLoginState
+	res := gdb.Raw(`
+		UPDATE core.oidc_login_states
+		SET login_code_used = now()
+		WHERE login_code_hash = ? AND login_code_used IS NULL AND expires_at > now()
+		RETURNING *`, codeHash).Scan(&st)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 || st.UserID == nil {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return *st.UserID, nil
+}
@@ -0,0 +1,15 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreatePiiRevealLog(gdb *gorm.DB, log models.PiiRevealLog) (models.PiiRevealLog, error) {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now().UTC()
+	}
+	return log, gdb.Create(&log).Error
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"errors"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CurrentQuestionnaireSchema последняя версия схемы анкеты
+func CurrentQuestionnaireSchema(gdb *gorm.DB) (models.QuestionnaireSchema, error) {
+	var s models.QuestionnaireSchema
+	err := gdb.Order("version DESC").Take(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s, models.ErrNoQuestionnaireSchema
+	}
+	return s, err
+}
+
+func GetQuestionnaireSchema(gdb *gorm.DB, version int) (models.QuestionnaireSchema, error) {
+	var s models.QuestionnaireSchema
+	err := gdb.Where("version = ?", version).Take(&s).Error
+	return s, err
+}
+
+// ListQuestionnaireSchemas версии схемы без содержимого, новые первыми
+func ListQuestionnaireSchemas(gdb *gorm.DB) ([]models.QuestionnaireSchema, error) {
+	out := []models.QuestionnaireSchema{}
+	err := gdb.Omit("schema").Order("version DESC").Find(&out).Error
+	return out, err
+}
+
+// CreateQuestionnaireSchema сохраняет схему следующей версией. Таблица блокируется на время
+// вставки, чтобы параллельные публикации не получили один номер.
+func CreateQuestionnaireSchema(gdb *gorm.DB, s models.QuestionnaireSchema) (models.QuestionnaireSchema, error) {
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE core.second_part_schemas IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		var last int
+		if err := tx.Model(&models.QuestionnaireSchema{}).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&last).Error; err != nil {
+			return err
+		}
+		s.Version = last + 1
+		return tx.Clauses(clause.Returning{}).Create(&s).Error
+	})
+	return s, err
+}
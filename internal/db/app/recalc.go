@@ -2,83 +2,147 @@ package app
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"vector/internal/models"
 
 	"gorm.io/gorm"
 )
 
-func RecalcNeedsSecondPart(gdb *gorm.DB) (int64, error) {
-	query := `
-		UPDATE core.clients_versions AS c
-		SET needs_second_part = true
-		FROM core.second_part_versions AS sp
-		WHERE c.is_current = true
-		  AND sp.is_current = true
-		  AND sp.client_id = c.client_id
-		  AND (
-			 (sp.due_at IS NOT NULL AND sp.due_at <= NOW())
-		   OR (sp.client_version <> c.version)
-		  )
-		  AND c.needs_second_part = false
-	`
+// Каждое правило пересчета в одной транзакции открывает свою причину клиентам, для которых условие
+// выполняется, и снимает ее у тех, для кого перестало; needs_second_part следует за открытыми причинами.
 
-	result := gdb.Exec(query)
-	if result.Error != nil {
-		return 0, fmt.Errorf("failed to recalc needs_second_part: %w", result.Error)
-	}
+// RecalcQuestionnaireExpired причина questionnaire_expired: наступил срок пересмотра текущей второй части.
+// Снимается, когда текущая вторая часть утверждена с новым сроком.
+func RecalcQuestionnaireExpired(gdb *gorm.DB) (models.RecalcRuleResult, error) {
+	var out models.RecalcRuleResult
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		var err error
+		out.Flagged, err = openRequirements(tx, models.RequirementQuestionnaireExpired, `
+			SELECT c.client_id, c.version
+			FROM core.clients_versions c
+			JOIN core.second_part_versions sp
+			  ON sp.client_id = c.client_id AND sp.is_current = true
+			WHERE c.is_current = true
+			  AND sp.due_at IS NOT NULL
+			  AND sp.due_at <= NOW()`)
+		if err != nil {
+			return err
+		}
 
-	return result.RowsAffected, nil
+		out.Resolved, err = resolveRequirements(tx, models.RequirementQuestionnaireExpired, models.ResolutionConditionCleared, `
+			SELECT sp.client_id
+			FROM core.second_part_versions sp
+			WHERE sp.is_current = true
+			  AND sp.status = 'approved'
+			  AND sp.due_at > NOW()`)
+		return err
+	})
+	if err != nil {
+		return out, fmt.Errorf("failed to recalc questionnaire expiry: %w", err)
+	}
+	return out, nil
 }
 
-func RecalcPassportExpiry(gdb *gorm.DB) (int64, error) {
-	query := `
-		WITH birthdays AS (
-		  SELECT
-		    c.client_id,
-		    c.is_current,
-		    c.needs_second_part,
-		    COALESCE(
-		      NULLIF(c.raw->>'birthday',''),
-		      NULLIF(c.raw->'person_info'->>'birthday','')
-		    ) AS bday_str
-		  FROM core.clients_versions c
-		  WHERE c.is_current = true
-		)
-		UPDATE core.clients_versions AS c
-		SET needs_second_part = true
-		FROM birthdays b
-		WHERE c.client_id = b.client_id
-		  AND c.is_current = true
-		  AND c.needs_second_part = false
-		  AND b.bday_str IS NOT NULL
-		  AND (
-		    NOW() >= (to_date(b.bday_str, 'DD.MM.YYYY') + INTERVAL '20 years')
-		    OR
-		    NOW() >= (to_date(b.bday_str, 'DD.MM.YYYY') + INTERVAL '45 years')
-		  )
-	`
+// RecalcDataChanged причина data_changed: текущая вторая часть составлена по другой версии анкеты.
+// Снимается, когда утверждена вторая часть по текущей версии.
+func RecalcDataChanged(gdb *gorm.DB) (models.RecalcRuleResult, error) {
+	var out models.RecalcRuleResult
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		var err error
+		out.Flagged, err = openRequirements(tx, models.RequirementDataChanged, `
+			SELECT c.client_id, c.version
+			FROM core.clients_versions c
+			JOIN core.second_part_versions sp
+			  ON sp.client_id = c.client_id AND sp.is_current = true
+			WHERE c.is_current = true
+			  AND sp.client_version <> c.version`)
+		if err != nil {
+			return err
+		}
 
-	result := gdb.Exec(query)
-	if result.Error != nil {
-		return 0, fmt.Errorf("failed to recalc passport expiry: %w", result.Error)
+		out.Resolved, err = resolveRequirements(tx, models.RequirementDataChanged, models.ResolutionConditionCleared, `
+			SELECT c.client_id
+			FROM core.clients_versions c
+			JOIN core.second_part_versions sp
+			  ON sp.client_id = c.client_id AND sp.is_current = true
+			WHERE c.is_current = true
+			  AND sp.status = 'approved'
+			  AND sp.client_version = c.version`)
+		return err
+	})
+	if err != nil {
+		return out, fmt.Errorf("failed to recalc data changes: %w", err)
 	}
-
-	return result.RowsAffected, nil
+	return out, nil
 }
 
-func RecalcAll(gdb *gorm.DB) error {
-	n1, err := RecalcNeedsSecondPart(gdb)
-	if err != nil {
-		return fmt.Errorf("RecalcNeedsSecondPart failed: %w", err)
-	}
+// clientPassportsSQL даты рождения и выдачи паспорта текущих версий. Даты в Raw бывают
+// в форматах DD.MM.YYYY и YYYY-MM-DD; нераспознанные (core.parse_client_date -> NULL) пропускаются.
+const clientPassportsSQL = `
+	SELECT
+	  c.client_id,
+	  c.version,
+	  core.parse_client_date(COALESCE(
+	    NULLIF(c.raw->>'birthday',''),
+	    NULLIF(c.raw->'person_info'->>'birthday','')
+	  )) AS birthday,
+	  core.parse_client_date(COALESCE(
+	    NULLIF(c.raw->>'pass_issue_date',''),
+	    NULLIF(c.raw->'person_info'->>'pass_issue_date','')
+	  )) AS issued_at
+	FROM core.clients_versions c
+	WHERE c.is_current = true`
+
+// passportExpiredCond паспорт выдан до 20-го (45-го) дня рождения, и с этого дня рождения
+// прошло больше PassportGraceDays дней. Параметры: возрасты ('{20,45}') и число дней.
+const passportExpiredCond = `
+	EXISTS (
+	  SELECT 1
+	  FROM unnest(?::int[]) AS age
+	  WHERE p.issued_at < (p.birthday + make_interval(years => age))::date
+	    AND CURRENT_DATE >= (p.birthday + make_interval(years => age))::date + ?::int
+	)`
+
+// RecalcPassportExpiry причина passport_expired. Снимается, когда из внешней системы пришла
+// дата выдачи нового паспорта (условие перестало выполняться).
+func RecalcPassportExpiry(gdb *gorm.DB) (models.RecalcRuleResult, error) {
+	ages := intArrayLiteral(models.PassportReplacementAges)
 
-	n2, err := RecalcPassportExpiry(gdb)
+	var out models.RecalcRuleResult
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		var err error
+		out.Flagged, err = openRequirements(tx, models.RequirementPassportExpired, `
+			SELECT p.client_id, p.version
+			FROM (`+clientPassportsSQL+`) p
+			WHERE p.birthday IS NOT NULL
+			  AND p.issued_at IS NOT NULL
+			  AND `+passportExpiredCond,
+			ages, models.PassportGraceDays)
+		if err != nil {
+			return err
+		}
+
+		out.Resolved, err = resolveRequirements(tx, models.RequirementPassportExpired, models.ResolutionPassportReplaced, `
+			SELECT p.client_id
+			FROM (`+clientPassportsSQL+`) p
+			WHERE p.birthday IS NOT NULL
+			  AND p.issued_at IS NOT NULL
+			  AND NOT `+passportExpiredCond,
+			ages, models.PassportGraceDays)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("RecalcPassportExpiry failed: %w", err)
+		return out, fmt.Errorf("failed to recalc passport expiry: %w", err)
 	}
+	return out, nil
+}
 
-	if n1 > 0 || n2 > 0 {
-		fmt.Printf("Recalc completed: needs_second_part=%d, passport_expiry=%d\n", n1, n2)
+// intArrayLiteral литерал массива Postgres ('{20,45}'): срез в параметрах GORM раскрывает в список (a,b)
+func intArrayLiteral(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
 	}
-
-	return nil
+	return "{" + strings.Join(parts, ",") + "}"
 }
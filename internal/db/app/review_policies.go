@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ReviewPolicyFor срок пересмотра уровня риска, действующий на дату at
+func ReviewPolicyFor(gdb *gorm.DB, riskLevel string, at time.Time) (models.ReviewPolicy, error) {
+	var p models.ReviewPolicy
+	err := gdb.Where("risk_level = ? AND effective_from <= ?::date", riskLevel, at).
+		Order("effective_from DESC").
+		Take(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return p, fmt.Errorf("%w %q", models.ErrNoReviewPolicy, riskLevel)
+	}
+	return p, err
+}
+
+// ListReviewPolicies все записи: по уровню, новые сроки первыми
+func ListReviewPolicies(gdb *gorm.DB) ([]models.ReviewPolicy, error) {
+	out := []models.ReviewPolicy{}
+	err := gdb.Order("risk_level ASC, effective_from DESC").Find(&out).Error
+	return out, err
+}
+
+// EffectiveReviewPolicies действующие на дату at сроки по каждому уровню
+func EffectiveReviewPolicies(gdb *gorm.DB, at time.Time) ([]models.ReviewPolicy, error) {
+	var out []models.ReviewPolicy
+	err := gdb.Raw(`
+		SELECT DISTINCT ON (risk_level) *
+		FROM core.second_part_review_policies
+		WHERE effective_from <= ?::date
+		ORDER BY risk_level, effective_from DESC`, at).
+		Scan(&out).Error
+	return out, err
+}
+
+func CreateReviewPolicy(gdb *gorm.DB, p models.ReviewPolicy) (models.ReviewPolicy, error) {
+	return p, gdb.Create(&p).Error
+}
+
+// secondPartDueAt срок пересмотра второй части, утвержденной (или оцененной) в момент now
+func secondPartDueAt(gdb *gorm.DB, now time.Time, riskLevel string) (*time.Time, error) {
+	level := strings.ToLower(strings.TrimSpace(riskLevel))
+	if level == "" {
+		level = models.ReviewPolicyDefaultLevel
+	}
+	p, err := ReviewPolicyFor(gdb, level, now)
+	if err != nil {
+		return nil, err
+	}
+	t := AddMonths(now, p.IntervalMonths)
+	return &t, nil
+}
+
+// AddMonths прибавляет месяцы, как интервал Postgres: 31 января + 1 месяц = 28 (29) февраля,
+// а не 3 марта, как у time.AddDate
+func AddMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// DueAtChange пересчитанный срок текущей утвержденной второй части
+type DueAtChange struct {
+	ClientID       int
+	Version        int
+	RiskLevel      string
+	ApprovedAt     time.Time
+	OldDueAt       *time.Time
+	NewDueAt       *time.Time // nil — для уровня нет срока
+	PolicyID       *uint
+	IntervalMonths *int
+}
+
+// Changed срок изменится (для уровней без срока — нет)
+func (c DueAtChange) Changed() bool {
+	if c.NewDueAt == nil {
+		return false
+	}
+	return c.OldDueAt == nil || !c.OldDueAt.Equal(*c.NewDueAt)
+}
+
+// PlanSecondPartDueAt считает сроки текущих утвержденных вторых частей по срокам, действующим на asOf,
+// от даты утверждения (valid_from утвержденной версии) и передает их в fn партиями
+func PlanSecondPartDueAt(gdb *gorm.DB, asOf time.Time, batchSize int, fn func([]DueAtChange) error) error {
+	rows, err := gdb.Raw(`
+		SELECT sp.client_id, sp.version, sp.risk_level, sp.valid_from AS approved_at, sp.due_at AS old_due_at,
+		  p.id AS policy_id, p.interval_months
+		FROM core.second_part_versions sp
+		LEFT JOIN LATERAL (
+		  SELECT id, interval_months
+		  FROM core.second_part_review_policies p
+		  WHERE p.risk_level = COALESCE(NULLIF(lower(sp.risk_level), ''), ?)
+		    AND p.effective_from <= ?::date
+		  ORDER BY p.effective_from DESC
+		  LIMIT 1
+		) p ON true
+		WHERE sp.is_current = true AND sp.status = 'approved'
+		ORDER BY sp.client_id`, models.ReviewPolicyDefaultLevel, asOf).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]DueAtChange, 0, batchSize)
+	for rows.Next() {
+		var c DueAtChange
+		if err := gdb.ScanRows(rows, &c); err != nil {
+			return err
+		}
+		if c.IntervalMonths != nil {
+			t := AddMonths(c.ApprovedAt.UTC(), *c.IntervalMonths)
+			c.NewDueAt = &t
+		}
+		batch = append(batch, c)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// SetSecondPartDueAt записывает пересчитанный срок в утвержденную версию
+func SetSecondPartDueAt(gdb *gorm.DB, c DueAtChange) error {
+	return gdb.Model(&models.SecondPartVersion{}).
+		Where("client_id = ? AND version = ? AND is_current = true", c.ClientID, c.Version).
+		Update("due_at", c.NewDueAt).Error
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddMonths(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 15, 4, 5, 6, msk)
+	}
+
+	cases := []struct {
+		name   string
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{"jan31 plus 1", date(2025, time.January, 31), 1, date(2025, time.February, 28)},
+		{"jan31 plus 1 leap year", date(2024, time.January, 31), 1, date(2024, time.February, 29)},
+		{"feb29 plus 12", date(2024, time.February, 29), 12, date(2025, time.February, 28)},
+		{"feb29 plus 48", date(2024, time.February, 29), 48, date(2028, time.February, 29)},
+		{"aug31 plus 36", date(2023, time.August, 31), 36, date(2026, time.August, 31)},
+		{"jan31 plus 36", date(2024, time.January, 31), 36, date(2027, time.January, 31)},
+		{"mar31 plus 35", date(2023, time.March, 31), 35, date(2026, time.February, 28)},
+		{"across year end", date(2025, time.November, 30), 3, date(2026, time.February, 28)},
+		{"into 30-day month", date(2025, time.March, 31), 1, date(2025, time.April, 30)},
+		{"mid month", date(2025, time.May, 15), 6, date(2025, time.November, 15)},
+		{"zero", date(2025, time.January, 31), 0, date(2025, time.January, 31)},
+		{"negative", date(2024, time.March, 31), -1, date(2024, time.February, 29)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := AddMonths(tc.from, tc.months)
+			// время суток и часовой пояс сохраняются
+			if !got.Equal(tc.want) || got.Location() != msk {
+				t.Errorf("AddMonths(%s, %d) = %s, want %s", tc.from, tc.months, got, tc.want)
+			}
+		})
+	}
+}
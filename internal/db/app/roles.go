@@ -0,0 +1,132 @@
+package app
+
+import (
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func ListRoles(gdb *gorm.DB) ([]models.Role, error) {
+	var roles []models.Role
+	err := gdb.Order("is_system DESC, name").Find(&roles).Error
+	return roles, err
+}
+
+func GetRole(gdb *gorm.DB, name string) (models.Role, error) {
+	var role models.Role
+	err := gdb.Where("name = ?", name).Take(&role).Error
+	return role, err
+}
+
+// ListRolePermissions права всех ролей: имя роли -> коды прав
+func ListRolePermissions(gdb *gorm.DB) (map[string][]string, error) {
+	var rows []models.RolePermission
+	if err := gdb.Order("role_name, permission").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string][]string)
+	for _, r := range rows {
+		out[r.RoleName] = append(out[r.RoleName], r.Permission)
+	}
+	return out, nil
+}
+
+// CountUsersByRole число пользователей (включая неактивных) в каждой роли
+func CountUsersByRole(gdb *gorm.DB) (map[string]int64, error) {
+	var rows []struct {
+		Role  string
+		Count int64
+	}
+	if err := gdb.Model(&models.AppUser{}).Select("role, count(*) AS count").Group("role").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		out[r.Role] = r.Count
+	}
+	return out, nil
+}
+
+func replaceRolePermissions(tx *gorm.DB, name string, permissions []string) error {
+	if err := tx.Where("role_name = ?", name).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+	rows := make([]models.RolePermission, len(permissions))
+	for i, p := range permissions {
+		rows[i] = models.RolePermission{RoleName: name, Permission: p}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func CreateRole(gdb *gorm.DB, role models.Role, permissions []string) (models.Role, error) {
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&role).Error; err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role.Name, permissions)
+	})
+	return role, err
+}
+
+// UpdateRole сохраняет описание роли; permissions == nil оставляет права без изменений
+func UpdateRole(gdb *gorm.DB, role models.Role, permissions []string) (models.Role, error) {
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&role).Select("label", "description", "updated_at").Updates(&role).Error; err != nil {
+			return err
+		}
+		if permissions == nil {
+			return nil
+		}
+		return replaceRolePermissions(tx, role.Name, permissions)
+	})
+	return role, err
+}
+
+// DeleteRole удаляет роль без пользователей; роль с пользователями не удаляется (ErrRoleInUse)
+func DeleteRole(gdb *gorm.DB, name string) error {
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		var users int64
+		if err := tx.Model(&models.AppUser{}).Where("role = ?", name).Count(&users).Error; err != nil {
+			return err
+		}
+		if users > 0 {
+			return models.ErrRoleInUse
+		}
+		if err := tx.Where("role_name = ?", name).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("name = ? AND NOT is_system", name).Delete(&models.Role{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// SeedRoles создает недостающие исходные роли с их правами; существующие роли не меняются,
+// чтобы не затереть права, настроенные администратором
+func SeedRoles(gdb *gorm.DB, defaults []models.RoleDefault) error {
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		for _, d := range defaults {
+			role := d.Role
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			if err := replaceRolePermissions(tx, role.Name, d.Permissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
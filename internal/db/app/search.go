@@ -0,0 +1,183 @@
+package app
+
+import (
+	"strings"
+	"vector/internal/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// Выражения, по которым построены индексы поиска (см. migrations.MigrateClientSearch).
+// Запросы должны использовать их дословно, иначе Postgres не применит индекс.
+const (
+	ClientFullNameExpr = `(coalesce(surname, '') || ' ' || coalesce(name, '') || ' ' || coalesce(patronymic, ''))`
+	ClientNameTSVExpr  = `to_tsvector('simple', ` + ClientFullNameExpr + `)`
+	ClientPhoneExpr    = `right(regexp_replace(coalesce(main_phone, ''), '\D', '', 'g'), 10)`
+	ClientSnilsExpr    = `regexp_replace(coalesce(snils, ''), '\D', '', 'g')`
+	ClientPassportExpr = `regexp_replace(coalesce(pass_series, '') || coalesce(pass_number, ''), '\D', '', 'g')`
+)
+
+type ClientSearchRow struct {
+	ClientID          int     `gorm:"column:client_id"`
+	Version           int     `gorm:"column:version"`
+	Surname           string  `gorm:"column:surname"`
+	Name              string  `gorm:"column:name"`
+	Patronymic        string  `gorm:"column:patronymic"`
+	Birthday          string  `gorm:"column:birthday"`
+	BirthPlace        string  `gorm:"column:birth_place"`
+	ContactEmail      string  `gorm:"column:contact_email"`
+	Inn               string  `gorm:"column:inn"`
+	Snils             string  `gorm:"column:snils"`
+	CreatedLKAt       string  `gorm:"column:created_lk_at"`
+	UpdatedLKAt       string  `gorm:"column:updated_lk_at"`
+	PassIssuerCode    string  `gorm:"column:pass_issuer_code"`
+	PassSeries        string  `gorm:"column:pass_series"`
+	PassNumber        string  `gorm:"column:pass_number"`
+	PassIssueDate     string  `gorm:"column:pass_issue_date"`
+	PassIssuer        string  `gorm:"column:pass_issuer"`
+	MainPhone         string  `gorm:"column:main_phone"`
+	Login             string  `gorm:"column:login"`
+	ExternalRiskLevel string  `gorm:"column:external_risk_level"`
+	NeedsSecondPart   bool    `gorm:"column:needs_second_part"`
+	SecondPartCreated bool    `gorm:"column:second_part_created"`
+	Rank              float64 `gorm:"column:rank"`
+}
+
+type searchPart struct {
+	cond     string
+	condArgs []any
+	rank     string
+	rankArgs []any
+}
+
+// SearchClients ищет по текущим версиям клиентов. Идентификаторы (ИНН, СНИЛС, паспорт,
+// телефон) сравниваются точно, ФИО/логин/email — через tsvector и pg_trgm.
+func SearchClients(gdb *gorm.DB, q utils.SearchQuery, limit int) ([]ClientSearchRow, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	var parts []searchPart
+	for _, t := range q.Types {
+		parts = append(parts, searchPartsFor(t, q)...)
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	conds := make([]string, 0, len(parts))
+	ranks := make([]string, 0, len(parts))
+	var rankArgs, condArgs []any
+	for _, p := range parts {
+		conds = append(conds, "("+p.cond+")")
+		ranks = append(ranks, p.rank)
+		rankArgs = append(rankArgs, p.rankArgs...)
+		condArgs = append(condArgs, p.condArgs...)
+	}
+
+	rankExpr := ranks[0]
+	if len(ranks) > 1 {
+		rankExpr = "GREATEST(" + strings.Join(ranks, ", ") + ")"
+	}
+
+	sql := `
+		SELECT client_id, version,
+			surname, name, patronymic, birthday, birth_place,
+			contact_email, inn, snils, created_lk_at, updated_lk_at,
+			pass_issuer_code, pass_series, pass_number, pass_issue_date, pass_issuer,
+			main_phone, login, external_risk_level, needs_second_part, second_part_created,
+			` + rankExpr + ` AS rank
+		FROM core.clients_versions
+		WHERE is_current = true
+		  AND (` + strings.Join(conds, " OR ") + `)
+		ORDER BY rank DESC, client_id ASC
+		LIMIT ?`
+
+	args := append(append(rankArgs, condArgs...), limit)
+
+	var rows []ClientSearchRow
+	err := gdb.Raw(sql, args...).Scan(&rows).Error
+	return rows, err
+}
+
+func searchPartsFor(t string, q utils.SearchQuery) []searchPart {
+	switch t {
+	case utils.SearchTypeINN:
+		return []searchPart{exactPart("inn", q.Digits, 1.0)}
+	case utils.SearchTypeSNILS:
+		return []searchPart{exactPart(ClientSnilsExpr, q.Digits, 1.0)}
+	case utils.SearchTypePassport:
+		if len(q.Digits) == 6 {
+			return []searchPart{exactPart("pass_number", q.Digits, 0.8)}
+		}
+		return []searchPart{exactPart(ClientPassportExpr, q.Digits, 1.0)}
+	case utils.SearchTypePhone:
+		phone := utils.NormalizePhone(q.Digits)
+		if len(phone) >= 10 {
+			return []searchPart{exactPart(ClientPhoneExpr, phone[len(phone)-10:], 0.9)}
+		}
+		return []searchPart{{
+			cond:     ClientPhoneExpr + " LIKE ?",
+			condArgs: []any{"%" + phone + "%"},
+			rank:     "CASE WHEN " + ClientPhoneExpr + " LIKE ? THEN 0.5 ELSE 0 END",
+			rankArgs: []any{"%" + phone + "%"},
+		}}
+	case utils.SearchTypeEmail:
+		email := strings.ToLower(q.Raw)
+		return []searchPart{{
+			cond:     "lower(contact_email) = ? OR lower(contact_email) % ?",
+			condArgs: []any{email, email},
+			rank:     "CASE WHEN lower(contact_email) = ? THEN 1.0 ELSE similarity(lower(contact_email), ?) END",
+			rankArgs: []any{email, email},
+		}}
+	case utils.SearchTypeText:
+		return textParts(q)
+	}
+	return nil
+}
+
+func exactPart(expr, value string, rank float64) searchPart {
+	return searchPart{
+		cond:     expr + " = ?",
+		condArgs: []any{value},
+		rank:     "CASE WHEN " + expr + " = ? THEN ? ELSE 0 END",
+		rankArgs: []any{value, rank},
+	}
+}
+
+func textParts(q utils.SearchQuery) []searchPart {
+	if len(q.Words) == 0 {
+		return nil
+	}
+	text := strings.Join(q.Words, " ")
+
+	// Префиксный tsquery собирается только из букв/цифр, поэтому синтаксис tsquery сломать нельзя
+	prefixes := make([]string, len(q.Words))
+	for i, w := range q.Words {
+		prefixes[i] = w + ":*"
+	}
+	tsq := strings.Join(prefixes, " & ")
+
+	login := strings.ToLower(q.Raw)
+	fullName := "lower(" + ClientFullNameExpr + ")"
+	return []searchPart{
+		{
+			cond:     ClientNameTSVExpr + " @@ to_tsquery('simple', ?)",
+			condArgs: []any{tsq},
+			rank:     "ts_rank(" + ClientNameTSVExpr + ", to_tsquery('simple', ?)) + 0.5",
+			rankArgs: []any{tsq},
+		},
+		{
+			cond:     ClientFullNameExpr + " % ? OR ? <% " + ClientFullNameExpr,
+			condArgs: []any{text, text},
+			rank:     "GREATEST(similarity(" + fullName + ", ?), word_similarity(?, " + fullName + ") * 0.9)",
+			rankArgs: []any{text, text},
+		},
+		{
+			cond:     "lower(login) % ? OR lower(login) = ?",
+			condArgs: []any{login, login},
+			rank:     "CASE WHEN lower(login) = ? THEN 1.0 ELSE similarity(lower(login), ?) END",
+			rankArgs: []any{login, login},
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"encoding/json"
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// OpenSecondPartRequirement открывает причину для клиента (если такая же еще не открыта)
+// и выставляет needs_second_part текущей версии
+func OpenSecondPartRequirement(gdb *gorm.DB, clientID, clientVersion int, reason string, details map[string]any) error {
+	var raw datatypes.JSON
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		raw = b
+	}
+
+	if err := gdb.Exec(`
+		INSERT INTO core.second_part_requirements (client_id, reason, client_version, details, detected_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (client_id, reason) WHERE resolved_at IS NULL DO NOTHING`,
+		clientID, reason, clientVersion, raw, time.Now().UTC()).Error; err != nil {
+		return err
+	}
+
+	return gdb.Model(&models.ClientVersion{}).
+		Where("client_id = ? AND is_current = true", clientID).
+		Update("needs_second_part", true).Error
+}
+
+// ResolveSecondPartRequirements снимает открытые причины клиента и пересчитывает needs_second_part
+func ResolveSecondPartRequirements(gdb *gorm.DB, clientID int, reasons []string, resolution string) (int64, error) {
+	res := gdb.Model(&models.SecondPartRequirement{}).
+		Where("client_id = ? AND reason IN ? AND resolved_at IS NULL", clientID, reasons).
+		Updates(map[string]any{
+			"resolved_at": time.Now().UTC(),
+			"resolution":  resolution,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, refreshNeedsSecondPart(gdb, []int{clientID})
+}
+
+// ListSecondPartRequirements причины клиента: открытые, а при includeResolved — и снятые; новые первыми
+func ListSecondPartRequirements(gdb *gorm.DB, clientID int, includeResolved bool) ([]models.SecondPartRequirement, error) {
+	out := []models.SecondPartRequirement{}
+	q := gdb.Where("client_id = ?", clientID)
+	if !includeResolved {
+		q = q.Where("resolved_at IS NULL")
+	}
+	err := q.Order("detected_at DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+// refreshNeedsSecondPart приводит needs_second_part текущих версий клиентов к наличию открытых причин
+func refreshNeedsSecondPart(gdb *gorm.DB, clientIDs []int) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	return gdb.Exec(`
+		UPDATE core.clients_versions AS c
+		SET needs_second_part = o.open
+		FROM (
+		  SELECT cv.client_id, EXISTS (
+		    SELECT 1 FROM core.second_part_requirements r
+		    WHERE r.client_id = cv.client_id AND r.resolved_at IS NULL
+		  ) AS open
+		  FROM core.clients_versions cv
+		  WHERE cv.client_id = ANY(?::int[]) AND cv.is_current = true
+		) AS o
+		WHERE c.client_id = o.client_id
+		  AND c.is_current = true
+		  AND c.needs_second_part <> o.open`, intArrayLiteral(clientIDs)).Error
+}
+
+// openRequirements открывает причину reason клиентам из candidates — запроса, возвращающего
+// client_id и version текущей версии. Возвращает число новых причин.
+func openRequirements(tx *gorm.DB, reason, candidates string, args ...any) (int64, error) {
+	query := `
+		WITH candidates AS (` + candidates + `),
+		opened AS (
+		  INSERT INTO core.second_part_requirements (client_id, reason, client_version, detected_at)
+		  SELECT client_id, ?, version, now() FROM candidates
+		  ON CONFLICT (client_id, reason) WHERE resolved_at IS NULL DO NOTHING
+		  RETURNING client_id
+		)
+		UPDATE core.clients_versions AS c
+		SET needs_second_part = true
+		FROM opened o
+		WHERE c.client_id = o.client_id
+		  AND c.is_current = true`
+
+	res := tx.Exec(query, append(args, reason)...)
+	return res.RowsAffected, res.Error
+}
+
+// resolveRequirements снимает открытые причины reason у клиентов из clients — запроса, возвращающего client_id
+func resolveRequirements(tx *gorm.DB, reason, resolution, clients string, args ...any) (int64, error) {
+	var ids []int
+	query := `
+		UPDATE core.second_part_requirements
+		SET resolved_at = now(), resolution = ?
+		WHERE reason = ?
+		  AND resolved_at IS NULL
+		  AND client_id IN (` + clients + `)
+		RETURNING client_id`
+	if err := tx.Raw(query, append([]any{resolution, reason}, args...)...).Scan(&ids).Error; err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), refreshNeedsSecondPart(tx, ids)
+}
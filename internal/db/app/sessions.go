@@ -0,0 +1,68 @@
+package app
+
+import (
+	"time"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateSession(gdb *gorm.DB, s models.AuthSession) (models.AuthSession, error) {
+	return s, gdb.Create(&s).Error
+}
+
+func GetSessionByTokenHash(gdb *gorm.DB, tokenHash string) (models.AuthSession, error) {
+	var s models.AuthSession
+	err := gdb.Where("token_hash = ?", tokenHash).Take(&s).Error
+	return s, err
+}
+
+// RotateSession помечает текущий refresh-токен использованным и создает следующий.
+// Если токен уже обменян параллельным запросом, возвращает ErrSessionReused.
+func RotateSession(gdb *gorm.DB, current models.AuthSession, next models.AuthSession) (models.AuthSession, error) {
+	err := gdb.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.AuthSession{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", current.ID).
+			Update("rotated_at", time.Now().UTC())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return models.ErrSessionReused
+		}
+		return tx.Create(&next).Error
+	})
+	return next, err
+}
+
+func revokeSessions(q *gorm.DB, reason string) (int64, error) {
+	res := q.Model(&models.AuthSession{}).
+		Where("revoked_at IS NULL").
+		Updates(map[string]any{
+			"revoked_at":    time.Now().UTC(),
+			"revoke_reason": reason,
+		})
+	return res.RowsAffected, res.Error
+}
+
+func RevokeSessionFamily(gdb *gorm.DB, familyID, reason string) (int64, error) {
+	return revokeSessions(gdb.Where("family_id = ?", familyID), reason)
+}
+
+// RevokeUserSessions отзывает все сессии пользователя, кроме exceptFamilyID (если задан)
+func RevokeUserSessions(gdb *gorm.DB, userID uint, exceptFamilyID, reason string) (int64, error) {
+	q := gdb.Where("user_id = ?", userID)
+	if exceptFamilyID != "" {
+		q = q.Where("family_id <> ?", exceptFamilyID)
+	}
+	return revokeSessions(q, reason)
+}
+
+// IsSessionActive есть ли в семействе действующий (не обменянный и не отозванный) refresh-токен
+func IsSessionActive(gdb *gorm.DB, familyID string) (bool, error) {
+	var n int64
+	err := gdb.Model(&models.AuthSession{}).
+		Where("family_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now().UTC()).
+		Count(&n).Error
+	return n > 0, err
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// TryAdvisoryLock берет сессионную advisory-блокировку Postgres с именем name, не дожидаясь ее.
+// Блокировка держится на выделенном соединении до вызова unlock; если процесс упадет,
+// Postgres снимет ее вместе с соединением. ok=false — блокировку держит другой процесс.
+func TryAdvisoryLock(ctx context.Context, gdb *gorm.DB, name string) (unlock func(), ok bool, err error) {
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&ok); err != nil || !ok {
+		_ = conn.Close()
+		return nil, false, err
+	}
+
+	return func() {
+		// контекст задачи к этому моменту может быть отменен
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", name); err != nil {
+			log.Printf("advisory lock %q: unlock failed: %v", name, err)
+			// соединение с неснятой блокировкой нельзя возвращать в пул
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}, true, nil
+}
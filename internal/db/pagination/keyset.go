@@ -0,0 +1,294 @@
+package pagination
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Key выражение сортировки. Expr не должен возвращать NULL (используйте COALESCE),
+// иначе сравнение с курсором потеряет строки. Cast — тип Postgres для значения курсора.
+type Key struct {
+	Expr string
+	Cast string
+	Desc bool
+}
+
+// NullsLast ключи для nullable-выражения: сначала признак NULL, затем значение.
+// Сохраняет порядок NULLS LAST в обоих направлениях и не дает NULL попасть в сравнение.
+func NullsLast(expr, cast, zero string, desc bool) []Key {
+	return []Key{
+		{Expr: "(" + expr + " IS NULL)", Cast: "boolean"},
+		{Expr: "COALESCE(" + expr + ", " + zero + ")", Cast: cast, Desc: desc},
+	}
+}
+
+// Column колонка, доступная для сортировки списка (элемент whitelist)
+type Column struct {
+	Expr     string
+	Cast     string
+	Zero     string // значение вместо NULL, только для Nullable
+	Nullable bool
+}
+
+// Keys ключи keyset для сортировки по колонке
+func (c Column) Keys(desc bool) []Key {
+	if c.Nullable {
+		return NullsLast(c.Expr, c.Cast, c.Zero, desc)
+	}
+	return []Key{{Expr: c.Expr, Cast: c.Cast, Desc: desc}}
+}
+
+type cursor struct {
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+	Sig      string   `json:"s"`
+}
+
+// Keyset описывает порядок списка и строит условия для курсоров
+type Keyset struct {
+	Keys []Key
+}
+
+// SelectExpr колонка с значениями ключей строки; ее нужно сканировать в поле sort_key
+func (k Keyset) SelectExpr() string {
+	exprs := make([]string, len(k.Keys))
+	for i, key := range k.Keys {
+		exprs[i] = key.Expr
+	}
+	return "json_build_array(" + strings.Join(exprs, ", ") + ") AS sort_key"
+}
+
+// OrderBy порядок выборки; при backward направление инвертируется
+func (k Keyset) OrderBy(backward bool) string {
+	parts := make([]string, len(k.Keys))
+	for i, key := range k.Keys {
+		desc := key.Desc != backward
+		if desc {
+			parts[i] = key.Expr + " DESC"
+		} else {
+			parts[i] = key.Expr + " ASC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (k Keyset) signature() string {
+	h := sha256.New()
+	for _, key := range k.Keys {
+		fmt.Fprintf(h, "%s|%s|%v;", key.Expr, key.Cast, key.Desc)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// Apply добавляет к запросу условие "после курсора" и порядок.
+// Возвращает направление курсора (true — предыдущая страница).
+func (k Keyset) Apply(q *gorm.DB, token string) (*gorm.DB, bool, error) {
+	c, err := k.decode(token)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... с учетом направления каждого ключа
+	var (
+		ors  []string
+		args []any
+	)
+	for i := range k.Keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = CAST(CAST(? AS text) AS %s)", k.Keys[j].Expr, k.Keys[j].Cast))
+			args = append(args, c.Values[j])
+		}
+		op := ">"
+		if k.Keys[i].Desc != c.Backward {
+			op = "<"
+		}
+		ands = append(ands, fmt.Sprintf("%s %s CAST(CAST(? AS text) AS %s)", k.Keys[i].Expr, op, k.Keys[i].Cast))
+		args = append(args, c.Values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+
+	q = q.Where("("+strings.Join(ors, " OR ")+")", args...).Order(k.OrderBy(c.Backward))
+	return q, c.Backward, nil
+}
+
+// Cursor строит токен по значениям ключей строки (содержимое sort_key)
+func (k Keyset) Cursor(sortKey []byte, backward bool) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(sortKey))
+	dec.UseNumber()
+	var raw []any
+	if err := dec.Decode(&raw); err != nil {
+		return "", err
+	}
+	if len(raw) != len(k.Keys) {
+		return "", fmt.Errorf("sort key has %d values, expected %d", len(raw), len(k.Keys))
+	}
+
+	values := make([]string, len(raw))
+	for i, v := range raw {
+		switch t := v.(type) {
+		case string:
+			values[i] = t
+		case json.Number:
+			values[i] = t.String()
+		case bool:
+			values[i] = fmt.Sprintf("%v", t)
+		case nil:
+			return "", fmt.Errorf("sort key %d is null", i)
+		default:
+			return "", fmt.Errorf("unsupported sort key value %T", v)
+		}
+	}
+
+	data, err := json.Marshal(cursor{Values: values, Backward: backward, Sig: k.signature()})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func (k Keyset) decode(token string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, models.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, models.ErrInvalidCursor
+	}
+	if c.Sig != k.signature() || len(c.Values) != len(k.Keys) {
+		// курсор выдан для другой сортировки
+		return c, models.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Paginate выбирает страницу по курсору (keyset) или по номеру страницы (OFFSET).
+// base должен содержать Select с ks.SelectExpr() и фильтры, но не Order/Limit.
+// Строк запрашивается на одну больше, чтобы узнать, есть ли следующая страница.
+func Paginate[T any](base *gorm.DB, ks Keyset, req models.PageRequest, sortKey func(*T) []byte) ([]T, models.PageInfo, error) {
+	var info models.PageInfo
+
+	// отдельная сессия: Count и выборка не должны делить один Statement
+	base = base.Session(&gorm.Session{})
+
+	mode := req.TotalMode
+	if mode == "" {
+		// с курсором общее количество по умолчанию не считаем: клиент получил его на первой странице
+		mode = models.TotalModeExact
+		if req.Cursor != "" {
+			mode = models.TotalModeNone
+		}
+	}
+
+	total, estimated, err := Total(base, mode)
+	if err != nil {
+		return nil, info, err
+	}
+	info.Total = total
+	info.TotalEstimated = estimated
+
+	q := base
+	backward := false
+	switch {
+	case req.Cursor != "":
+		if q, backward, err = ks.Apply(base, req.Cursor); err != nil {
+			return nil, info, err
+		}
+	case req.Page > 1:
+		q = base.Order(ks.OrderBy(false)).Offset((req.Page - 1) * req.PerPage)
+	default:
+		q = base.Order(ks.OrderBy(false))
+	}
+
+	var rows []T
+	if err := q.Limit(req.PerPage + 1).Scan(&rows).Error; err != nil {
+		return nil, info, err
+	}
+
+	more := len(rows) > req.PerPage
+	if more {
+		rows = rows[:req.PerPage]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, info, nil
+	}
+
+	// при движении назад следующая страница всегда есть — с нее пришли
+	info.HasMore = more || backward
+	if info.HasMore {
+		if info.NextCursor, err = ks.Cursor(sortKey(&rows[len(rows)-1]), false); err != nil {
+			return nil, info, err
+		}
+	}
+
+	hasPrev := (backward && more) || (!backward && (req.Cursor != "" || req.Page > 1))
+	if hasPrev {
+		if info.PrevCursor, err = ks.Cursor(sortKey(&rows[0]), true); err != nil {
+			return nil, info, err
+		}
+	}
+
+	return rows, info, nil
+}
+
+// Total считает общее количество строк в соответствии с режимом
+func Total(q *gorm.DB, mode string) (*int64, bool, error) {
+	switch mode {
+	case models.TotalModeNone:
+		return nil, false, nil
+	case models.TotalModeEstimate:
+		n, err := EstimateCount(q)
+		if err != nil {
+			return nil, false, err
+		}
+		return &n, true, nil
+	default:
+		var n int64
+		if err := q.Count(&n).Error; err != nil {
+			return nil, false, err
+		}
+		return &n, false, nil
+	}
+}
+
+// EstimateCount возвращает оценку количества строк по плану запроса (без выполнения)
+func EstimateCount(q *gorm.DB) (int64, error) {
+	var probe []map[string]any
+	stmt := q.Session(&gorm.Session{DryRun: true}).Find(&probe).Statement
+
+	sqlDB, err := q.DB()
+	if err != nil {
+		return 0, err
+	}
+
+	var plan string
+	row := sqlDB.QueryRowContext(q.Statement.Context, "EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...)
+	if err := row.Scan(&plan); err != nil {
+		return 0, err
+	}
+
+	var parsed []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &parsed); err != nil || len(parsed) == 0 {
+		return 0, fmt.Errorf("failed to parse query plan: %v", err)
+	}
+
+	return int64(parsed[0].Plan.PlanRows), nil
+}
@@ -0,0 +1,433 @@
+package pagination
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"vector/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// surnameKeyset порядок как у списка клиентов: nullable-фамилия по убыванию
+// (NULL в конце), затем id по возрастанию
+var surnameKeyset = Keyset{Keys: append(
+	Column{Expr: "surname", Cast: "text", Zero: "''", Nullable: true}.Keys(true),
+	Key{Expr: "id", Cast: "bigint"},
+)}
+
+func mustCursor(t *testing.T, ks Keyset, sortKey string, backward bool) string {
+	t.Helper()
+	token, err := ks.Cursor([]byte(sortKey), backward)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// dryRunDB gorm с диалектом Postgres без соединения: только построение SQL
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fakeConnector{})}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gdb
+}
+
+func applySQL(t *testing.T, ks Keyset, token string) (string, bool) {
+	t.Helper()
+	var backward bool
+	gdb := dryRunDB(t)
+	query := gdb.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		q, b, err := ks.Apply(tx.Table("clients"), token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backward = b
+		var rows []map[string]any
+		return q.Find(&rows)
+	})
+	return query, backward
+}
+
+func TestApplyMixedDirections(t *testing.T) {
+	ks := Keyset{Keys: []Key{
+		{Expr: "risk_level", Cast: "text", Desc: true},
+		{Expr: "id", Cast: "bigint"},
+	}}
+
+	query, backward := applySQL(t, ks, mustCursor(t, ks, `["high", 7]`, false))
+	if backward {
+		t.Fatal("forward cursor reported as backward")
+	}
+	// по убывающему ключу "после" значит меньше, по возрастающему — больше
+	for _, want := range []string{
+		"(risk_level < CAST(CAST('high' AS text) AS text))",
+		"(risk_level = CAST(CAST('high' AS text) AS text) AND id > CAST(CAST('7' AS text) AS bigint))",
+		"ORDER BY risk_level DESC, id ASC",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("forward query lacks %q:\n%s", want, query)
+		}
+	}
+
+	query, backward = applySQL(t, ks, mustCursor(t, ks, `["high", 7]`, true))
+	if !backward {
+		t.Fatal("backward cursor reported as forward")
+	}
+	// назад: операторы и порядок инвертированы
+	for _, want := range []string{
+		"(risk_level > CAST(CAST('high' AS text) AS text))",
+		"(risk_level = CAST(CAST('high' AS text) AS text) AND id < CAST(CAST('7' AS text) AS bigint))",
+		"ORDER BY risk_level ASC, id DESC",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("backward query lacks %q:\n%s", want, query)
+		}
+	}
+}
+
+func TestApplyNullableKeys(t *testing.T) {
+	// строка с NULL в фамилии: в sort_key признак true и подставленное нулевое значение
+	query, _ := applySQL(t, surnameKeyset, mustCursor(t, surnameKeyset, `[true, "", 5]`, false))
+	for _, want := range []string{
+		"((surname IS NULL) > CAST(CAST('true' AS text) AS boolean))",
+		"(surname IS NULL) = CAST(CAST('true' AS text) AS boolean) AND COALESCE(surname, '') < CAST(CAST('' AS text) AS text)",
+		"COALESCE(surname, '') = CAST(CAST('' AS text) AS text) AND id > CAST(CAST('5' AS text) AS bigint)",
+		"ORDER BY (surname IS NULL) ASC, COALESCE(surname, '') DESC, id ASC",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query lacks %q:\n%s", want, query)
+		}
+	}
+	// сам NULL в сравнение не попадает
+	if strings.Contains(query, "surname <") || strings.Contains(query, "surname >") {
+		t.Errorf("bare nullable column compared:\n%s", query)
+	}
+
+	// NULL вместо нулевого значения — ошибка выражения, а не пустой курсор
+	if _, err := surnameKeyset.Cursor([]byte(`[true, null, 5]`), false); err == nil {
+		t.Error("cursor built from a null sort key")
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	token := mustCursor(t, surnameKeyset, `[false, "Иванов", 12345678901]`, true)
+	c, err := surnameKeyset.decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// большие числа не теряют точность, значения хранятся строками
+	want := []string{"false", "Иванов", "12345678901"}
+	if !c.Backward || strings.Join(c.Values, "|") != strings.Join(want, "|") {
+		t.Errorf("decoded %+v, want values %v backward", c, want)
+	}
+
+	if _, err := surnameKeyset.Cursor([]byte(`[false, "Иванов"]`), false); err == nil {
+		t.Error("cursor built from a short sort key")
+	}
+}
+
+func TestApplyRejectsForeignCursor(t *testing.T) {
+	byID := Keyset{Keys: []Key{{Expr: "id", Cast: "bigint"}}}
+	byIDDesc := Keyset{Keys: []Key{{Expr: "id", Cast: "bigint", Desc: true}}}
+	bySurname := Keyset{Keys: append(
+		Column{Expr: "surname", Cast: "text", Zero: "''", Nullable: true}.Keys(false),
+		Key{Expr: "id", Cast: "bigint"},
+	)}
+
+	tampered, err := json.Marshal(cursor{Values: []string{"1", "2"}, Sig: byID.signature()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"other direction":     mustCursor(t, byIDDesc, `[7]`, false),
+		"other column":        mustCursor(t, bySurname, `[false, "a", 7]`, false),
+		"other nullable sort": mustCursor(t, surnameKeyset, `[false, "a", 7]`, false),
+		"wrong value count":   base64.RawURLEncoding.EncodeToString(tampered),
+		"not base64":          "%%%",
+		"not json":            base64.RawURLEncoding.EncodeToString([]byte("[1")),
+	}
+	gdb := dryRunDB(t)
+	for name, token := range cases {
+		target := byID
+		if name == "other nullable sort" {
+			target = bySurname
+		}
+		if _, _, err := target.Apply(gdb.Table("clients"), token); !errors.Is(err, models.ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", name, err)
+		}
+	}
+
+	if _, _, err := byID.Apply(gdb.Table("clients"), mustCursor(t, byID, `[7]`, false)); err != nil {
+		t.Errorf("own cursor rejected: %v", err)
+	}
+}
+
+// --- Paginate на таблице в памяти ---
+
+type person struct {
+	ID      int64
+	Surname *string
+}
+
+type personRow struct {
+	ID      int64
+	Surname *string
+	SortKey []byte `gorm:"column:sort_key"`
+}
+
+// tuple значения ключей surnameKeyset в виде, в котором они лежат в курсоре
+func (p person) tuple() []string {
+	surname := ""
+	if p.Surname != nil {
+		surname = *p.Surname
+	}
+	return []string{strconv.FormatBool(p.Surname == nil), surname, strconv.FormatInt(p.ID, 10)}
+}
+
+// comparePeople эталонный порядок surnameKeyset: NULL в конце, фамилия по убыванию, id по возрастанию
+func comparePeople(a, b []string) int {
+	if a[0] != b[0] {
+		if a[0] == "false" {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(a[1], b[1]); c != 0 {
+		return -c
+	}
+	ia, _ := strconv.ParseInt(a[2], 10, 64)
+	ib, _ := strconv.ParseInt(b[2], 10, 64)
+	switch {
+	case ia < ib:
+		return -1
+	case ia > ib:
+		return 1
+	}
+	return 0
+}
+
+var limitRe = regexp.MustCompile(`LIMIT \$(\d+)`)
+
+// peopleTable отвечает на запросы Paginate по surnameKeyset так, как ответил бы Postgres.
+// Значения курсора берутся из аргументов последнего слагаемого условия Apply
+func peopleTable(people []person) queryFunc {
+	return func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "count(") {
+			return []string{"count"}, [][]driver.Value{{int64(len(people))}}, nil
+		}
+
+		m := limitRe.FindStringSubmatch(query)
+		if m == nil {
+			return nil, nil, fmt.Errorf("no LIMIT in %q", query)
+		}
+		n, _ := strconv.Atoi(m[1])
+		limit := int(args[n-1].Value.(int64))
+
+		backward := strings.Contains(query, "ORDER BY (surname IS NULL) DESC")
+		var after []string
+		if strings.Contains(query, "WHERE") {
+			keys := len(surnameKeyset.Keys)
+			start := keys*(keys+1)/2 - keys
+			for _, a := range args[start : start+keys] {
+				after = append(after, a.Value.(string))
+			}
+		}
+
+		var rows []person
+		for _, p := range people {
+			c := 0
+			if after != nil {
+				c = comparePeople(p.tuple(), after)
+			}
+			if after == nil || (!backward && c > 0) || (backward && c < 0) {
+				rows = append(rows, p)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			c := comparePeople(rows[i].tuple(), rows[j].tuple())
+			if backward {
+				return c > 0
+			}
+			return c < 0
+		})
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+
+		values := make([][]driver.Value, len(rows))
+		for i, p := range rows {
+			t := p.tuple()
+			key, _ := json.Marshal([]any{p.Surname == nil, t[1], p.ID})
+			var surname driver.Value
+			if p.Surname != nil {
+				surname = *p.Surname
+			}
+			values[i] = []driver.Value{p.ID, surname, key}
+		}
+		return []string{"id", "surname", "sort_key"}, values, nil
+	}
+}
+
+func TestPaginateForwardAndBackward(t *testing.T) {
+	name := func(s string) *string { return &s }
+	people := []person{
+		{1, name("Петров")}, {2, nil}, {3, name("Иванов")}, {4, name("Петров")},
+		{5, nil}, {6, name("Сидоров")}, {7, name("")}, {8, name("Иванов")},
+	}
+	// пустая фамилия совпадает с нулевым значением COALESCE, но идет раньше NULL
+	wantOrder := []int64{6, 1, 4, 3, 8, 7, 2, 5}
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fakeConnector{query: peopleTable(people)})}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := gdb.Table("people").Select("id, surname, " + surnameKeyset.SelectExpr())
+	sortKey := func(r *personRow) []byte { return r.SortKey }
+
+	ids := func(rows []personRow) []int64 {
+		out := make([]int64, len(rows))
+		for i, r := range rows {
+			out[i] = r.ID
+		}
+		return out
+	}
+
+	// вперед до конца
+	var (
+		forward []int64
+		pages   [][]int64
+		last    models.PageInfo
+	)
+	req := models.PageRequest{Page: 1, PerPage: 3}
+	for i := 0; ; i++ {
+		rows, info, err := Paginate(base, surnameKeyset, req, sortKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if info.Total == nil || *info.Total != int64(len(people)) || info.PrevCursor != "" {
+				t.Fatalf("first page info %+v", info)
+			}
+		} else if info.Total != nil || info.PrevCursor == "" {
+			// с курсором total не считается по умолчанию, назад всегда можно вернуться
+			t.Fatalf("page %d info %+v", i, info)
+		}
+		forward = append(forward, ids(rows)...)
+		pages = append(pages, ids(rows))
+		last = info
+		if !info.HasMore {
+			break
+		}
+		if i > len(people) {
+			t.Fatal("pagination does not terminate")
+		}
+		req.Cursor = info.NextCursor
+	}
+	if fmt.Sprint(forward) != fmt.Sprint(wantOrder) {
+		t.Fatalf("forward order %v, want %v", forward, wantOrder)
+	}
+
+	// назад с последней страницы: те же страницы в обратном порядке, строки внутри — в прямом
+	req.Cursor = last.PrevCursor
+	for p := len(pages) - 2; p >= 0; p-- {
+		rows, info, err := Paginate(base, surnameKeyset, req, sortKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(ids(rows)) != fmt.Sprint(pages[p]) {
+			t.Fatalf("backward page %d = %v, want %v", p, ids(rows), pages[p])
+		}
+		if !info.HasMore || info.NextCursor == "" {
+			t.Fatalf("backward page %d lost the way forward: %+v", p, info)
+		}
+		if (p > 0) != (info.PrevCursor != "") {
+			t.Fatalf("backward page %d prev cursor %q", p, info.PrevCursor)
+		}
+		req.Cursor = info.PrevCursor
+	}
+
+	// курсор другой сортировки не выполняется
+	other := Keyset{Keys: []Key{{Expr: "id", Cast: "bigint"}}}
+	if _, _, err := Paginate(base, other, models.PageRequest{PerPage: 3, Cursor: last.PrevCursor}, sortKey); !errors.Is(err, models.ErrInvalidCursor) {
+		t.Fatalf("foreign cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+// --- драйвер database/sql для тестов ---
+
+// queryFunc отвечает на запрос: колонки и строки результата
+type queryFunc func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error)
+
+type fakeConnector struct {
+	query queryFunc
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{query: c.query}, nil
+}
+func (c fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("use fakeConnector") }
+
+type fakeConn struct {
+	query queryFunc
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("tx not supported") }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.query == nil {
+		return nil, errors.New("no queries expected")
+	}
+	cols, values, err := c.query(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{cols: cols, values: values}, nil
+}
+
+type fakeRows struct {
+	cols   []string
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"vector/internal/db/pagination"
 	"vector/internal/models"
 
 	"gorm.io/gorm"
@@ -27,29 +28,21 @@ type ClientListItem struct {
 	ExternalRiskLevel string `gorm:"column:external_risk_level" json:"external_risk_level"`
 	NeedsSecondPart   bool   `gorm:"column:needs_second_part" json:"needs_second_part"`
 	SecondPartCreated bool   `gorm:"column:second_part_created" json:"second_part_created"`
+
+	SortKey []byte `gorm:"column:sort_key" json:"-"`
 }
 
-func ListCurrentClients(gdb *gorm.DB, page, perPage int, needsSecondPart *bool) (items []ClientListItem, total int64, err error) {
-	q := gdb.Model(&models.ClientVersion{}).
-		Where("is_current = ?", true)
+var currentClientsKeyset = pagination.Keyset{Keys: []pagination.Key{{Expr: "client_id", Cast: "bigint"}}}
 
-	if needsSecondPart != nil {
-		q = q.Where("needs_second_part = ?", *needsSecondPart)
+func ListCurrentClients(gdb *gorm.DB, page models.PageRequest, needsSecondPart *bool) ([]ClientListItem, models.PageInfo, error) {
+	if page.Page <= 0 {
+		page.Page = 1
 	}
-
-	if err = q.Count(&total).Error; err != nil {
-		return
+	if page.PerPage <= 0 || page.PerPage > 500 {
+		page.PerPage = 100
 	}
 
-	if page <= 0 {
-		page = 1
-	}
-	if perPage <= 0 || perPage > 500 {
-		perPage = 100
-	}
-	offset := (page - 1) * perPage
-
-	err = q.
+	q := gdb.Model(&models.ClientVersion{}).
 		Select([]string{
 			"client_id",
 			"surname",
@@ -71,11 +64,13 @@ func ListCurrentClients(gdb *gorm.DB, page, perPage int, needsSecondPart *bool)
 			"pass_issue_date",
 			"pass_issuer",
 			"main_phone",
+			currentClientsKeyset.SelectExpr(),
 		}).
-		Order("client_id ASC").
-		Limit(perPage).
-		Offset(offset).
-		Scan(&items).Error
+		Where("is_current = ?", true)
+
+	if needsSecondPart != nil {
+		q = q.Where("needs_second_part = ?", *needsSecondPart)
+	}
 
-	return
+	return pagination.Paginate(q, currentClientsKeyset, page, func(r *ClientListItem) []byte { return r.SortKey })
 }
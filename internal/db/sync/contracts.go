@@ -2,7 +2,9 @@ package sync
 
 import (
 	"context"
+	"fmt"
 	"time"
+	"vector/internal/db/pagination"
 	"vector/internal/models"
 	"vector/internal/pkg/utils"
 
@@ -43,31 +45,96 @@ func GetCurrentContract(gdb *gorm.DB, contractID int) (*models.Contract, error)
 	return &contract, err
 }
 
-func ListContracts(gdb *gorm.DB, page, perPage int, userID *int, status *string) ([]models.Contract, int64, error) {
-	var contracts []models.Contract
-	var total int64
+// contractSortColumns сопоставляет поля sort= с колонками core.contracts (whitelist)
+var contractSortColumns = map[string]pagination.Column{
+	"id":            {Expr: "external_id", Cast: "bigint"},
+	"user_id":       {Expr: "user_id", Cast: "bigint"},
+	"inner_code":    {Expr: "inner_code", Cast: "text"},
+	"kind":          {Expr: "kind", Cast: "text"},
+	"status":        {Expr: "status", Cast: "text"},
+	"created_at":    {Expr: "created_at", Cast: "timestamptz"},
+	"updated_at":    {Expr: "updated_at", Cast: "timestamptz"},
+	"signed_at":     {Expr: "signed_at", Cast: "timestamptz", Zero: "'epoch'::timestamptz", Nullable: true},
+	"closed_at":     {Expr: "closed_at", Cast: "timestamptz", Zero: "'epoch'::timestamptz", Nullable: true},
+	"tariff_name":   {Expr: "tariff_name", Cast: "text", Zero: "''", Nullable: true},
+	"strategy_name": {Expr: "strategy_name", Cast: "text", Zero: "''", Nullable: true},
+}
 
-	query := gdb.Table("core.contracts")
+type contractRow struct {
+	models.Contract
+	SortKey []byte `gorm:"column:sort_key"`
+}
 
-	if userID != nil {
-		query = query.Where("user_id = ?", *userID)
+func ListContracts(gdb *gorm.DB, page models.PageRequest, filter models.ContractListFilter) ([]models.Contract, models.PageInfo, error) {
+	ks, err := contractKeyset(filter.Sort)
+	if err != nil {
+		return nil, models.PageInfo{}, err
 	}
-	if status != nil {
-		query = query.Where("status = ?", *status)
+
+	base := gdb.Table("core.contracts").Select("core.contracts.*, " + ks.SelectExpr())
+	base = applyContractFilter(base, filter)
+
+	rows, info, err := pagination.Paginate(base, ks, page, func(r *contractRow) []byte { return r.SortKey })
+	if err != nil {
+		return nil, info, err
 	}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	contracts := make([]models.Contract, len(rows))
+	for i := range rows {
+		contracts[i] = rows[i].Contract
 	}
+	return contracts, info, nil
+}
 
-	offset := (page - 1) * perPage
-	err := query.
-		Order("external_id DESC").
-		Offset(offset).
-		Limit(perPage).
-		Find(&contracts).Error
+func applyContractFilter(q *gorm.DB, f models.ContractListFilter) *gorm.DB {
+	if f.UserID != nil {
+		q = q.Where("user_id = ?", *f.UserID)
+	}
+	if len(f.Status) > 0 {
+		q = q.Where("status IN ?", f.Status)
+	}
+	if len(f.Kind) > 0 {
+		q = q.Where("kind IN ?", f.Kind)
+	}
+	if f.SignedFrom != nil {
+		q = q.Where("signed_at >= ?", *f.SignedFrom)
+	}
+	if f.SignedTo != nil {
+		q = q.Where("signed_at <= ?", *f.SignedTo)
+	}
+	if f.ClosedFrom != nil {
+		q = q.Where("closed_at >= ?", *f.ClosedFrom)
+	}
+	if f.ClosedTo != nil {
+		q = q.Where("closed_at <= ?", *f.ClosedTo)
+	}
+	if f.TariffID != nil {
+		q = q.Where("tariff_id = ?", *f.TariffID)
+	}
+	if f.TariffName != nil {
+		q = q.Where("lower(tariff_name) = lower(?)", *f.TariffName)
+	}
+	if f.StrategyID != nil {
+		q = q.Where("strategy_id = ?", *f.StrategyID)
+	}
+	if f.StrategyName != nil {
+		q = q.Where("lower(strategy_name) = lower(?)", *f.StrategyName)
+	}
+	return q
+}
 
-	return contracts, total, err
+// contractKeyset порядок списка контрактов; по умолчанию новые (по external_id) первыми
+func contractKeyset(sort []models.SortField) (pagination.Keyset, error) {
+	var ks pagination.Keyset
+	for _, sf := range sort {
+		col, ok := contractSortColumns[sf.Field]
+		if !ok {
+			return ks, fmt.Errorf("unsupported sort field %q", sf.Field)
+		}
+		ks.Keys = append(ks.Keys, col.Keys(sf.Desc)...)
+	}
+	ks.Keys = append(ks.Keys, pagination.Key{Expr: "external_id", Cast: "bigint", Desc: true})
+	return ks, nil
 }
 
 func ApplyContractsBatch(gdb *gorm.DB, ctx context.Context, contractsData []ApplyContractData) (ApplyStats, error) {
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ListAPIKeys godoc
+// @Summary Список API-ключей (только для управляющих ключами)
+// @Description Секреты ключей не возвращаются; ключ определяется по префиксу
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param include_revoked query bool false "Включить отозванные ключи"
+// @Success 200 {array} models.APIKey "Ключи"
+// @Router /auth/api-keys [get]
+func (h *AuthHandlers) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.authService.ListAPIKeys(c.QueryBool("include_revoked"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка получения API-ключей",
+			"success": false,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary Выпустить API-ключ (только для управляющих ключами)
+// @Description Ключ возвращается один раз. Передается в заголовке X-API-Key или Authorization: ApiKey <ключ>.
+// @Description Допустимые права: clients.read, clients.export, pii.view_masked, pii.view_full, contracts.read, contracts.export, audit.read, sync.run — и только те, что есть у автора.
+// @Description Права ключа проверяются при каждом запросе: ключ действует, пока автор активен, и только в пределах прав, которые сейчас есть у его роли.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateAPIKeyRequest true "Название, права, лимит и срок действия"
+// @Success 201 {object} models.CreateAPIKeyResponse "Ключ выпущен"
+// @Failure 400 {object} map[string]interface{} "Неверные параметры"
+// @Router /auth/api-keys [post]
+func (h *AuthHandlers) CreateAPIKey(c *fiber.Ctx) error {
+	var req models.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат данных", "success": false})
+	}
+
+	resp, err := h.authService.CreateAPIKey(middleware.GetAuditActor(c), req)
+	if errors.Is(err, service.ErrAPIKeyRequest) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Ошибка выпуска API-ключа", "success": false})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Отозвать API-ключ (только для управляющих ключами)
+// @Description Ключ перестает действовать сразу
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID ключа"
+// @Success 200 {object} models.APIKey "Ключ отозван"
+// @Failure 404 {object} map[string]interface{} "Ключ не найден или уже отозван"
+// @Router /auth/api-keys/{id}/revoke [post]
+func (h *AuthHandlers) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil || id == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный ID ключа", "success": false})
+	}
+
+	key, err := h.authService.RevokeAPIKey(middleware.GetAuditActor(c), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Ключ не найден или уже отозван", "success": false})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Ошибка отзыва API-ключа", "success": false})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    key,
+		"message": "API-ключ отозван",
+	})
+}
@@ -2,14 +2,21 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"vector/internal/middleware"
 	"vector/internal/models"
+	"vector/internal/pkg/jsonschema"
+	"vector/internal/pkg/masking"
 	"vector/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type AppHandlers struct {
@@ -24,11 +31,12 @@ func NewAppHandlers(appService *service.AppService) *AppHandlers {
 
 // GetClient godoc
 // @Summary Get client information
-// @Description Get complete client information including all available fields and second part if available
+// @Description Get complete client information including all available fields and second part if available. second_part_requirements lists the open reasons why a new second part is needed. Personal data (passport, INN, SNILS, phone, email, address, Raw) is masked or hidden according to the caller's role; see masked_fields.
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Client ID"
 // @Success 200 {object} models.GetClientResponse "Complete client information"
 // @Failure 400 {object} models.ErrorResponse "Invalid client ID"
@@ -45,98 +53,14 @@ func (h *AppHandlers) GetClient(c *fiber.Ctx) error {
 		return c.Status(404).JSON(models.ErrorResponse{Error: "client not found"})
 	}
 
-	response := models.GetClientResponse{
-		ID:            cur.ID,
-		ClientID:      cur.ClientID,
-		Version:       cur.Version,
-		ExternalID:    cur.ID,
-		ExternalIDStr: cur.ExternalIDStr,
-
-		Surname:    cur.Surname,
-		Name:       cur.Name,
-		Patronymic: cur.Patronymic,
-		Birthday:   cur.Birthday,
-		BirthPlace: cur.BirthPlace,
-
-		Inn:             cur.Inn,
-		Snils:           cur.Snils,
-		PassSeries:      cur.PassSeries,
-		PassNumber:      cur.PassNumber,
-		PassIssueDate:   cur.PassIssueDate,
-		PassIssuer:      cur.PassIssuer,
-		PassIssuerCode:  cur.PassIssuerCode,
-		DocumentType:    cur.DocumentType,
-		DocumentCountry: cur.DocumentCountry,
-
-		ContactEmail: cur.ContactEmail,
-		MainPhone:    cur.MainPhone,
-		Login:        cur.Login,
-
-		Blocked:            cur.Blocked,
-		BlockedReason:      cur.BlockedReason,
-		BlockType:          cur.BlockType,
-		Male:               cur.Male,
-		IsRfResident:       cur.IsRfResident,
-		IsRfTaxpayer:       cur.IsRfTaxpayer,
-		IsValidInfo:        cur.IsValidInfo,
-		QualifiedInvestor:  cur.QualifiedInvestor,
-		IsFilled:           cur.IsFilled,
-		IsAmericanNational: cur.IsAmericanNational,
-		NeedToSetPassword:  cur.NeedToSetPassword,
-
-		LegalCapacity:      cur.LegalCapacity,
-		PifsPortfolioCode:  cur.PifsPortfolioCode,
-		RiskLevel:          cur.RiskLevel,
-		ExternalRiskLevel:  cur.ExternalRiskLevel,
-		FillStage:          cur.FillStage,
-		IdentificationType: cur.IdentificationType,
-		TaxStatus:          cur.TaxStatus,
+	response := convertClientVersionToResponse(cur, currentPolicy(c))
 
-		EsiaID:       cur.EsiaID,
-		AgentID:      cur.AgentID,
-		AgentPointID: cur.AgentPointID,
-
-		Country:  cur.Country,
-		Region:   cur.Region,
-		Index:    cur.Index,
-		City:     cur.City,
-		Street:   cur.Street,
-		House:    cur.House,
-		Corps:    cur.Corps,
-		Flat:     cur.Flat,
-		District: cur.District,
-
-		SignatureType:              cur.SignatureType,
-		DataReceivedDigitalProfile: cur.DataReceivedDigitalProfile,
-		LockedAt:                   cur.LockedAt,
-		CurrentSignInAt:            cur.CurrentSignInAt,
-		SignInCount:                cur.SignInCount,
-
-		CreatedLKAt: cur.CreatedLKAt,
-		UpdatedLKAt: cur.UpdatedLKAt,
-		SyncedAt:    cur.SyncedAt,
-		ValidFrom:   cur.ValidFrom,
-		ValidTo:     cur.ValidTo,
-		IsCurrent:   cur.IsCurrent,
-
-		Hash:                  cur.Hash,
-		Status:                cur.Status,
-		SecondPartTriggerHash: cur.SecondPartTriggerHash,
-
-		NeedsSecondPart:   cur.NeedsSecondPart,
-		SecondPartCreated: cur.SecondPartCreated,
+	if cur.NeedsSecondPart {
+		if reqs, err := h.appService.ListSecondPartRequirements(id, false); err == nil {
+			response.SecondPartRequirements = reqs
+		}
 	}
 
-	response.FromCompanySettings = convertJSONToMap(cur.FromCompanySettings)
-	response.Settings = convertJSONToMap(cur.Settings)
-	response.PersonInfo = convertJSONToMap(cur.PersonInfo)
-	response.Manager = convertJSONToMap(cur.Manager)
-	response.Checks = convertJSONToMap(cur.Checks)
-	response.Note = convertJSONToMap(cur.Note)
-	response.AdSource = convertJSONToMap(cur.AdSource)
-	response.SignatureAllowedNumbers = convertJSONToMap(cur.SignatureAllowedNumbers)
-	response.Raw = convertJSONToMap(cur.Raw)
-
 	if curSP, err := h.appService.GetSecondPartCurrent(id); err == nil {
 		response.SecondPart = &struct {
 			ClientVersion int        `json:"client_version" example:"1"`
@@ -158,6 +82,57 @@ func (h *AppHandlers) GetClient(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+// RevealClient godoc
+// @Summary Reveal masked personal data
+// @Description Return full values of fields masked for the caller's role in the current client version. A reason is required and every reveal is written to the PII reveal log. Fields hidden for the role cannot be revealed.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Param request body models.RevealClientRequest true "Fields to reveal (pass_series, pass_number, pass_issuer, pass_issuer_code, pass_issue_date, inn, snils, main_phone, contact_email, birthday, birth_place, address) and reason"
+// @Success 200 {object} models.RevealClientResponse "Revealed values"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 403 {object} models.ErrorResponse "Field is hidden for the role"
+// @Failure 404 {object} models.ErrorResponse "Client not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /clients/{id}/reveal [post]
+func (h *AppHandlers) RevealClient(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid client id"})
+	}
+
+	var req models.RevealClientRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid request body"})
+	}
+
+	if _, err := middleware.GetCurrentUser(c); err != nil {
+		return c.Status(401).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	cv, values, err := h.appService.RevealClientFields(middleware.GetAuditActor(c), currentPolicy(c), id, req.Fields, req.Reason)
+	switch {
+	case errors.Is(err, service.ErrRevealInvalid):
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrRevealForbidden):
+		return c.Status(403).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(404).JSON(models.ErrorResponse{Error: "client not found"})
+	case err != nil:
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.JSON(models.RevealClientResponse{
+		Success:  true,
+		ClientID: id,
+		Version:  cv.Version,
+		Fields:   values,
+	})
+}
+
 func convertJSONToMap(jsonData datatypes.JSON) *map[string]interface{} {
 	if len(jsonData) == 0 {
 		return nil
@@ -177,6 +152,7 @@ func convertJSONToMap(jsonData datatypes.JSON) *map[string]interface{} {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Client ID"
 // @Success 200 {object} map[string]interface{} "Second part history"
 // @Failure 400 {object} map[string]interface{} "Invalid client ID"
@@ -210,17 +186,53 @@ func (h *AppHandlers) GetSecondPartHistory(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"success": true, "versions": out})
 }
 
+// GetSecondPartRequirements godoc
+// @Summary Get second part requirement reasons
+// @Description Reasons why the client needs a new second part (new_client, data_changed, questionnaire_expired, passport_expired), newest first. Resolved reasons carry resolved_at and resolution.
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Param include_resolved query bool false "Include resolved reasons" default(false)
+// @Success 200 {object} map[string]interface{} "Requirement reasons"
+// @Failure 400 {object} map[string]interface{} "Invalid parameters"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /clients/{id}/second-part/requirements [get]
+func (h *AppHandlers) GetSecondPartRequirements(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid client id"})
+	}
+	includeResolved, err := queryBool(c, "include_resolved")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	reqs, err := h.appService.ListSecondPartRequirements(id, includeResolved != nil && *includeResolved)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "requirements: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "requirements": reqs})
+}
+
 // CreateSecondPartDraft godoc
 // @Summary Create second part draft
-// @Description Create a new second part draft for a client
+// @Description Create a new second part draft for a client. The risk-scoring engine proposes a risk level (risk_proposed_level, risk_assessment with contributing factors) which is stored on the draft.
+// @Description risk_level accepts the proposal when it matches and overrides it otherwise; an override requires risk_override_reason, and lowering a proposed high level requires second_part.approve_high_risk.
+// @Description Without risk_level the decision is left for POST /clients/{id}/second-part/risk. risk_level must have a review period (GET /review-policies); due_at follows it.
+// @Description The questionnaire data (data_override, or the previous version's data) is validated against the current schema (GET /questionnaire-schemas/current). An incomplete draft is still saved: violations are returned in validation_errors and schema_version records the schema used; submitting requires a valid questionnaire.
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Client ID"
-// @Param draft body object{risk_level=string,data_override=object} false "Draft data"
+// @Param draft body object{risk_level=string,risk_override_reason=string,data_override=object} false "Draft data"
 // @Success 200 {object} map[string]interface{} "Created second part draft"
 // @Failure 400 {object} map[string]interface{} "Invalid input or creation failed"
+// @Failure 403 {object} map[string]interface{} "Lowering a proposed high risk level is not allowed"
 // @Router /clients/{id}/second-part/draft [post]
 func (h *AppHandlers) CreateSecondPartDraft(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
@@ -229,17 +241,15 @@ func (h *AppHandlers) CreateSecondPartDraft(c *fiber.Ctx) error {
 	}
 
 	var in struct {
-		RiskLevel    *string                 `json:"risk_level,omitempty"`
-		DataOverride *map[string]interface{} `json:"data_override,omitempty"`
+		RiskLevel          *string                 `json:"risk_level,omitempty"`
+		RiskOverrideReason string                  `json:"risk_override_reason,omitempty"`
+		DataOverride       *map[string]interface{} `json:"data_override,omitempty"`
 	}
 
 	if err := c.BodyParser(&in); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
 	}
 
-	// TODO: Get user from auth middleware
-	var createdBy *int = nil // This will be set from authenticated user later
-
 	var dataOverrideJSON *datatypes.JSON
 	if in.DataOverride != nil {
 		jsonBytes, err := json.Marshal(in.DataOverride)
@@ -249,22 +259,307 @@ func (h *AppHandlers) CreateSecondPartDraft(c *fiber.Ctx) error {
 		dataOverrideJSON = (*datatypes.JSON)(&jsonBytes)
 	}
 
-	sp, err := h.appService.CreateSecondPartDraft(id, in.RiskLevel, createdBy, dataOverrideJSON)
+	decision := riskDecisionInput(c, in.RiskOverrideReason)
+	if in.RiskLevel != nil {
+		decision.Level = strings.ToLower(strings.TrimSpace(*in.RiskLevel))
+	}
+
+	sp, violations, err := h.appService.CreateSecondPartDraft(middleware.GetAuditActor(c), id, decision, dataOverrideJSON)
+	if errors.Is(err, models.ErrRiskDowngradeForbidden) {
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{
-		"success":        true,
-		"client_version": sp.ClientVersion,
-		"version":        sp.Version,
-		"status":         sp.Status,
-		"risk_level":     sp.RiskLevel,
-		"due_at":         sp.DueAt,
-		"is_current":     sp.IsCurrent,
+	resp := secondPartRiskResponse(sp)
+	resp["validation_errors"] = violationsOrEmpty(violations)
+	c.Set(fiber.HeaderETag, secondPartETag(sp))
+	return c.JSON(resp)
+}
+
+// PatchSecondPartCurrent godoc
+// @Summary Edit the current second part draft
+// @Description Apply a JSON Merge Patch (RFC 7396) to the questionnaire data of the current draft (status draft or doc_requested) in place, without creating a new version: keys set to null are removed, objects are merged, other values replace the existing ones.
+// @Description If-Match must carry the ETag from GET /clients/{id}/second-part/current (or a previous PATCH); if the draft was changed since, 409 is returned and the client must reload. Each changed field is recorded in the change log (GET /clients/{id}/second-part/changes).
+// @Description The result is validated against the current questionnaire schema like a draft save: violations are returned in validation_errors and do not block saving.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Param If-Match header string true "ETag of the draft being edited"
+// @Param patch body object true "JSON Merge Patch for the questionnaire data"
+// @Success 200 {object} map[string]interface{} "Updated second part draft; new ETag in the ETag header"
+// @Failure 400 {object} map[string]interface{} "Invalid patch or If-Match"
+// @Failure 404 {object} map[string]interface{} "Second part not found"
+// @Failure 409 {object} map[string]interface{} "Draft was changed concurrently or is not editable"
+// @Failure 428 {object} map[string]interface{} "If-Match header is missing"
+// @Router /clients/{id}/second-part/current [patch]
+func (h *AppHandlers) PatchSecondPartCurrent(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid client id"})
+	}
+
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if ifMatch == "" {
+		return c.Status(428).JSON(fiber.Map{"error": "If-Match header is required"})
+	}
+	version, revision, ok := parseSecondPartETag(ifMatch)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid If-Match: expected an ETag from GET /clients/{id}/second-part/current"})
+	}
+
+	sp, violations, err := h.appService.PatchSecondPartDraft(middleware.GetAuditActor(c), id, version, revision, c.Body())
+	switch {
+	case errors.Is(err, models.ErrInvalidMergePatch):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "second part not found"})
+	case errors.Is(err, models.ErrSecondPartVersionConflict):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error(), "etag": secondPartETag(sp)})
+	case errors.Is(err, models.ErrSecondPartNotEditable):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	resp := secondPartRiskResponse(sp)
+	resp["validation_errors"] = violationsOrEmpty(violations)
+	c.Set(fiber.HeaderETag, secondPartETag(sp))
+	return c.JSON(resp)
+}
+
+// GetSecondPartChanges godoc
+// @Summary Second part questionnaire change log
+// @Description Field-level changes made by editing drafts in place (PATCH /clients/{id}/second-part/current), newest first. Paths are JSON Pointers into the questionnaire data; old_value is absent for added fields and new_value for removed ones.
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Param version query int false "Only changes of this second part version"
+// @Param page query int false "Page number (offset pagination, ignored when cursor is set)" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Param cursor query string false "Opaque cursor from next_cursor/prev_cursor of a previous response"
+// @Param with_total query string false "Total count mode: true, false or estimate"
+// @Success 200 {object} models.ListSecondPartChangesResponse "Changes"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Router /clients/{id}/second-part/changes [get]
+func (h *AppHandlers) GetSecondPartChanges(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid client id"})
+	}
+	version := c.QueryInt("version", 0)
+	if version < 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid version"})
+	}
+	page, err := parsePageRequest(c, 50, 500)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	changes, info, err := h.appService.ListSecondPartChanges(id, version, page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to get second part changes: " + err.Error()})
+	}
+
+	return c.JSON(models.ListSecondPartChangesResponse{
+		Success:  true,
+		Changes:  changes,
+		PageMeta: models.NewPageMeta(page, info),
 	})
 }
 
+// secondPartETag версия и правка черновика: меняется при каждом изменении анкеты
+func secondPartETag(sp models.SecondPartVersion) string {
+	return `"` + strconv.Itoa(sp.Version) + "." + strconv.Itoa(sp.Revision) + `"`
+}
+
+func parseSecondPartETag(tag string) (version, revision int, ok bool) {
+	tag = strings.TrimPrefix(tag, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, 0, false
+	}
+	v, r, found := strings.Cut(tag[1:len(tag)-1], ".")
+	if !found {
+		return 0, 0, false
+	}
+	version, err1 := strconv.Atoi(v)
+	revision, err2 := strconv.Atoi(r)
+	if err1 != nil || err2 != nil || version < 1 || revision < 0 {
+		return 0, 0, false
+	}
+	return version, revision, true
+}
+
+// SubmitSecondPart godoc
+// @Summary Submit second part draft
+// @Description Submit the current second part draft (status draft or doc_requested). The questionnaire must match the current schema (GET /questionnaire-schemas/current); otherwise 422 with validation_errors. The schema version is recorded on the submitted version.
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Success 200 {object} map[string]interface{} "Submitted second part"
+// @Failure 400 {object} map[string]interface{} "Invalid client ID"
+// @Failure 404 {object} map[string]interface{} "Second part not found"
+// @Failure 409 {object} map[string]interface{} "Second part is not a draft or was changed concurrently"
+// @Failure 422 {object} map[string]interface{} "Questionnaire does not match the schema"
+// @Router /clients/{id}/second-part/submit [post]
+func (h *AppHandlers) SubmitSecondPart(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid client id"})
+	}
+
+	sp, err := h.appService.SubmitSecondPart(middleware.GetAuditActor(c), id)
+	var invalid *service.QuestionnaireInvalidError
+	switch {
+	case errors.As(err, &invalid):
+		return c.Status(422).JSON(fiber.Map{
+			"error":             "questionnaire does not match the schema",
+			"schema_version":    invalid.SchemaVersion,
+			"validation_errors": invalid.Errors,
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "second part not found"})
+	case errors.Is(err, models.ErrSecondPartNotEditable), errors.Is(err, models.ErrSecondPartVersionConflict):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(secondPartRiskResponse(sp))
+}
+
+func violationsOrEmpty(v []jsonschema.ValidationError) []jsonschema.ValidationError {
+	if v == nil {
+		return []jsonschema.ValidationError{}
+	}
+	return v
+}
+
+// GetSecondPartRiskAssessment godoc
+// @Summary Preview second part risk assessment
+// @Description Score the client's current data with the risk-scoring rules without storing the result: proposed level, score and contributing factors.
+// @Tags clients
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Success 200 {object} models.RiskAssessment "Proposed risk level"
+// @Failure 400 {object} models.ErrorResponse "Invalid client ID"
+// @Failure 404 {object} models.ErrorResponse "Client not found"
+// @Router /clients/{id}/second-part/risk-assessment [get]
+func (h *AppHandlers) GetSecondPartRiskAssessment(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid client id"})
+	}
+
+	assessment, err := h.appService.AssessSecondPartRisk(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "client not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(assessment)
+}
+
+// DecideSecondPartRisk godoc
+// @Summary Accept or override the proposed risk level
+// @Description Record the officer's decision on the risk level proposed for the current draft (status draft or doc_requested) and recalculate due_at from the review period of the level (GET /review-policies).
+// @Description Without risk_level the proposal is accepted. A different level is an override and requires risk_override_reason; lowering a proposed high level requires second_part.approve_high_risk.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Client ID"
+// @Param request body models.SecondPartRiskRequest false "Decision"
+// @Success 200 {object} map[string]interface{} "Updated second part draft"
+// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 403 {object} map[string]interface{} "Lowering a proposed high risk level is not allowed"
+// @Failure 404 {object} map[string]interface{} "Second part not found"
+// @Failure 409 {object} map[string]interface{} "Second part is not a draft"
+// @Router /clients/{id}/second-part/risk [post]
+func (h *AppHandlers) DecideSecondPartRisk(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid client id"})
+	}
+
+	var req models.SecondPartRiskRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid json"})
+		}
+	}
+
+	decision := riskDecisionInput(c, req.RiskOverrideReason)
+	decision.Level = strings.ToLower(strings.TrimSpace(req.RiskLevel))
+
+	sp, err := h.appService.DecideSecondPartRisk(middleware.GetAuditActor(c), id, decision)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "second part not found"})
+	case errors.Is(err, models.ErrSecondPartNotEditable):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, models.ErrRiskDowngradeForbidden):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(secondPartRiskResponse(sp))
+}
+
+func riskDecisionInput(c *fiber.Ctx, overrideReason string) service.RiskDecisionInput {
+	return service.RiskDecisionInput{
+		OverrideReason: strings.TrimSpace(overrideReason),
+		CanLowerHigh:   middleware.GetPermissions(c).Has(models.PermSecondPartApprove),
+	}
+}
+
+// secondPartRiskResponse ответ на создание черновика и решение по риску
+func secondPartRiskResponse(sp models.SecondPartVersion) fiber.Map {
+	return fiber.Map{
+		"success":              true,
+		"client_version":       sp.ClientVersion,
+		"version":              sp.Version,
+		"revision":             sp.Revision,
+		"status":               sp.Status,
+		"risk_level":           sp.RiskLevel,
+		"risk_proposed_level":  sp.RiskProposedLevel,
+		"risk_assessment":      riskAssessment(sp),
+		"risk_decision":        sp.RiskDecision,
+		"risk_override_reason": sp.RiskOverrideReason,
+		"due_at":               sp.DueAt,
+		"schema_version":       sp.SchemaVersion,
+		"is_current":           sp.IsCurrent,
+	}
+}
+
+// riskAssessment предложение скоринга, сохраненное в версии второй части
+func riskAssessment(sp models.SecondPartVersion) *models.RiskAssessment {
+	if len(sp.RiskAssessment) == 0 {
+		return nil
+	}
+	var a models.RiskAssessment
+	if err := json.Unmarshal(sp.RiskAssessment, &a); err != nil {
+		return nil
+	}
+	return &a
+}
+
 // GetContract godoc
 // @Summary Get contract information
 // @Description Get complete contract information by contract ID
@@ -272,6 +567,7 @@ func (h *AppHandlers) CreateSecondPartDraft(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Contract ID"
 // @Success 200 {object} models.GetContractResponse "Complete contract information"
 // @Failure 400 {object} models.ErrorResponse "Invalid contract ID"
@@ -335,38 +631,40 @@ func (h *AppHandlers) GetContract(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param page query int false "Page number" default(1)
+// @Security ApiKeyAuth
+// @Param page query int false "Page number (offset pagination, ignored when cursor is set)" default(1)
 // @Param per_page query int false "Items per page" default(10)
+// @Param cursor query string false "Opaque cursor from next_cursor/prev_cursor of a previous response"
+// @Param with_total query string false "Total count mode: true, false or estimate (default: true without cursor, false with cursor)"
 // @Param user_id query int false "Filter by user ID"
-// @Param status query string false "Filter by status (active, closed)"
+// @Param status query string false "Filter by status, comma-separated (active, closed)"
+// @Param kind query string false "Filter by kind, comma-separated (broking, depo)"
+// @Param signed_from query string false "Signed at or after (RFC3339 or YYYY-MM-DD)"
+// @Param signed_to query string false "Signed at or before (RFC3339 or YYYY-MM-DD)"
+// @Param closed_from query string false "Closed at or after (RFC3339 or YYYY-MM-DD)"
+// @Param closed_to query string false "Closed at or before (RFC3339 or YYYY-MM-DD)"
+// @Param tariff query string false "Filter by tariff ID or name"
+// @Param strategy query string false "Filter by strategy ID or name"
+// @Param sort query string false "Sort fields, comma-separated; prefix with - or suffix :desc for descending (id, user_id, inner_code, kind, status, created_at, updated_at, signed_at, closed_at, tariff_name, strategy_name)"
 // @Success 200 {object} models.ListContractsResponse "List of contracts"
 // @Failure 400 {object} models.ErrorResponse "Invalid parameters"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /contracts [get]
 func (h *AppHandlers) ListContracts(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	perPage := c.QueryInt("per_page", 10)
-
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 10
+	page, err := parsePageRequest(c, 10, 100)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
-	var userID *int
-	if userIDStr := c.Query("user_id"); userIDStr != "" {
-		if uid, err := strconv.Atoi(userIDStr); err == nil {
-			userID = &uid
-		}
+	filter, err := parseContractListFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
-	var status *string
-	if statusStr := c.Query("status"); statusStr != "" {
-		status = &statusStr
+	contracts, info, err := h.appService.ListContracts(page, filter)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
 	}
-
-	contracts, total, err := h.appService.ListContracts(page, perPage, userID, status)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to get contracts: " + err.Error()})
 	}
@@ -411,15 +709,10 @@ func (h *AppHandlers) ListContracts(c *fiber.Ctx) error {
 		}
 	}
 
-	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
-
 	response := models.ListContractsResponse{
-		Success:    true,
-		Contracts:  contractResponses,
-		Page:       page,
-		PerPage:    perPage,
-		Total:      total,
-		TotalPages: totalPages,
+		Success:   true,
+		Contracts: contractResponses,
+		PageMeta:  models.NewPageMeta(page, info),
 	}
 
 	return c.JSON(response)
@@ -432,42 +725,50 @@ func (h *AppHandlers) ListContracts(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param page query int false "Page number" default(1)
+// @Security ApiKeyAuth
+// @Param page query int false "Page number (offset pagination, ignored when cursor is set)" default(1)
 // @Param per_page query int false "Items per page" default(10)
+// @Param cursor query string false "Opaque cursor from next_cursor/prev_cursor of a previous response"
+// @Param with_total query string false "Total count mode: true, false or estimate (default: true without cursor, false with cursor)"
 // @Param needs_second_part query bool false "Filter by needs second part"
-// @Param sp_status query string false "Filter by second part status (draft, completed)"
+// @Param needs_second_part_reason query string false "Filter by open needs_second_part reason, comma-separated (new_client, data_changed, questionnaire_expired, passport_expired)"
+// @Param sp_status query string false "Filter by second part status, comma-separated (draft, submitted, approved, rejected, doc_requested)"
+// @Param sp_due_before query string false "Second part due at or before (RFC3339 or YYYY-MM-DD)"
+// @Param sp_due_after query string false "Second part due at or after (RFC3339 or YYYY-MM-DD)"
+// @Param risk_level query string false "Filter by risk level, comma-separated"
+// @Param external_risk_level query string false "Filter by external risk level, comma-separated"
+// @Param blocked query bool false "Filter by blocked flag"
+// @Param is_rf_resident query bool false "Filter by RF residency"
+// @Param qualified_investor query bool false "Filter by qualified investor flag"
+// @Param created_lk_from query string false "Created in LK at or after (RFC3339 or YYYY-MM-DD)"
+// @Param created_lk_to query string false "Created in LK at or before (RFC3339 or YYYY-MM-DD)"
+// @Param fill_stage query string false "Filter by fill stage, comma-separated"
+// @Param has_active_contract query bool false "Filter by having at least one active contract"
+// @Param sort query string false "Sort fields, comma-separated; prefix with - or suffix :desc for descending (id, surname, name, birthday, version, created_lk_at, updated_lk_at, risk_level, external_risk_level, fill_stage, sp_status, sp_due_at); fields masked for the caller are rejected"
 // @Success 200 {object} models.ListClientsResponse "List of clients"
 // @Failure 400 {object} models.ErrorResponse "Invalid parameters"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /clients [get]
 func (h *AppHandlers) ListClients(c *fiber.Ctx) error {
-	page := c.QueryInt("page", 1)
-	perPage := c.QueryInt("per_page", 10)
-
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 10
+	page, err := parsePageRequest(c, 10, 100)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
-	var needsSecondPart *bool
-	if needsSecondPartStr := c.Query("needs_second_part"); needsSecondPartStr != "" {
-		if nssp, err := strconv.ParseBool(needsSecondPartStr); err == nil {
-			needsSecondPart = &nssp
-		}
+	filter, err := parseClientListFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
 	}
 
-	var spStatus *string
-	if spStatusStr := c.Query("sp_status"); spStatusStr != "" {
-		spStatus = &spStatusStr
+	clients, info, err := h.appService.ListClientsWithSP(page, filter)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
 	}
-
-	clients, total, err := h.appService.ListClientsWithSP(page, perPage, needsSecondPart, spStatus, nil)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to get clients: " + err.Error()})
 	}
 
+	policy := currentPolicy(c)
 	clientResponses := make([]models.ClientDetailResponse, len(clients))
 	for i, client := range clients {
 		clientItem := models.ClientListItem{
@@ -493,9 +794,11 @@ func (h *AppHandlers) ListClients(c *fiber.Ctx) error {
 			SecondPartCreated: client.SecondPartCreated,
 			Version:           client.ClientVersion,
 		}
+		applyPolicyToClientListItem(&clientItem, policy)
 
 		clientResponse := models.ClientDetailResponse{
-			ClientListItem: clientItem,
+			ClientListItem:         clientItem,
+			NeedsSecondPartReasons: client.NeedsSecondPartReasons,
 		}
 
 		if client.SpStatus != nil {
@@ -510,20 +813,59 @@ func (h *AppHandlers) ListClients(c *fiber.Ctx) error {
 		clientResponses[i] = clientResponse
 	}
 
-	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
-
 	response := models.ListClientsResponse{
-		Success:    true,
-		Clients:    clientResponses,
-		Page:       page,
-		PerPage:    perPage,
-		Total:      total,
-		TotalPages: totalPages,
+		Success:  true,
+		Clients:  clientResponses,
+		PageMeta: models.NewPageMeta(page, info),
 	}
 
 	return c.JSON(response)
 }
 
+// SearchClients godoc
+// @Summary Search clients
+// @Description Full-text and fuzzy search over current client versions by name, INN, SNILS, passport, phone, email or login. The query type is detected automatically.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param q query string true "Search query"
+// @Param limit query int false "Max results" default(20)
+// @Success 200 {object} models.ClientSearchResponse "Ranked search results"
+// @Failure 400 {object} models.ErrorResponse "Empty query"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /clients/search [get]
+func (h *AppHandlers) SearchClients(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(q) < 2 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "query must contain at least 2 characters"})
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query, results, err := h.appService.SearchClients(q, limit)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to search clients: " + err.Error()})
+	}
+
+	policy := currentPolicy(c)
+	for i := range results {
+		applyPolicyToSearchResult(&results[i], policy)
+	}
+
+	return c.JSON(models.ClientSearchResponse{
+		Success:    true,
+		Query:      query.Raw,
+		QueryTypes: query.Types,
+		Results:    results,
+		Total:      len(results),
+	})
+}
+
 // ... existing code ...
 
 // GetSecondPartCurrent godoc
@@ -533,6 +875,7 @@ func (h *AppHandlers) ListClients(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Client ID"
 // @Success 200 {object} models.GetSecondPartResponse "Current second part information"
 // @Failure 400 {object} models.ErrorResponse "Invalid client ID"
@@ -563,6 +906,7 @@ func (h *AppHandlers) GetSecondPartCurrent(c *fiber.Ctx) error {
 		ClientID:         secondPart.ClientID,
 		ClientVersion:    secondPart.ClientVersion,
 		Version:          secondPart.Version,
+		Revision:         secondPart.Revision,
 		Status:           secondPart.Status,
 		RiskLevel:        secondPart.RiskLevel,
 		IsCurrent:        secondPart.IsCurrent,
@@ -570,23 +914,35 @@ func (h *AppHandlers) GetSecondPartCurrent(c *fiber.Ctx) error {
 		ValidFrom:        secondPart.ValidFrom,
 		ValidTo:          secondPart.ValidTo,
 		Data:             dataMap,
+		SchemaVersion:    secondPart.SchemaVersion,
 		Reason:           secondPart.Reason,
 		CreatedByUserID:  secondPart.CreatedByUserID,
 		UpdatedByUserID:  secondPart.UpdatedByUserID,
 		ApprovedByUserID: secondPart.ApprovedByUserID,
+
+		RiskProposedLevel:   secondPart.RiskProposedLevel,
+		RiskAssessment:      riskAssessment(secondPart),
+		RiskDecision:        secondPart.RiskDecision,
+		RiskOverrideReason:  secondPart.RiskOverrideReason,
+		RiskDecidedByUserID: secondPart.RiskDecidedByUserID,
+		RiskDecidedAt:       secondPart.RiskDecidedAt,
 	}
 
+	c.Set(fiber.HeaderETag, secondPartETag(secondPart))
 	return c.JSON(response)
 }
 
 // GetClientHistory godoc
 // @Summary Get client history (all versions)
-// @Description Get all versions of a specific client ordered by version (newest first)
+// @Description Get versions of a specific client ordered by version (newest first). Without limit/cursor all versions are returned.
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Client ID"
+// @Param limit query int false "Versions per page (enables cursor pagination)"
+// @Param cursor query string false "Opaque cursor from next_cursor/prev_cursor of a previous response"
 // @Success 200 {object} models.ClientHistoryResponse "Client history"
 // @Failure 400 {object} models.ErrorResponse "Invalid client ID"
 // @Failure 404 {object} models.ErrorResponse "Client not found"
@@ -598,7 +954,26 @@ func (h *AppHandlers) GetClientHistory(c *fiber.Ctx) error {
 		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid client id"})
 	}
 
-	versions, err := h.appService.GetClientHistory(id)
+	var (
+		versions []models.ClientVersion
+		info     models.PageInfo
+		paged    = c.Query("limit") != "" || c.Query("cursor") != ""
+	)
+	if paged {
+		limit := c.QueryInt("limit", 50)
+		if limit < 1 || limit > 500 {
+			limit = 50
+		}
+		versions, info, err = h.appService.ListClientHistory(id, models.PageRequest{
+			PerPage: limit,
+			Cursor:  strings.TrimSpace(c.Query("cursor")),
+		})
+	} else {
+		versions, err = h.appService.GetClientHistory(id)
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to get client history: " + err.Error()})
 	}
@@ -607,6 +982,16 @@ func (h *AppHandlers) GetClientHistory(c *fiber.Ctx) error {
 		return c.Status(404).JSON(models.ErrorResponse{Error: "client not found"})
 	}
 
+	// для последней версии страницы изменения считаются относительно предыдущей версии,
+	// которая попадет уже на следующую страницу
+	var older *models.ClientVersion
+	if info.HasMore {
+		last := versions[len(versions)-1]
+		if prev, err := h.appService.GetClientVersion(id, last.Version-1); err == nil {
+			older = &prev
+		}
+	}
+
 	versionSummaries := make([]models.ClientVersionSummary, len(versions))
 	for i, version := range versions {
 		var changes []string
@@ -616,7 +1001,7 @@ func (h *AppHandlers) GetClientHistory(c *fiber.Ctx) error {
 			changes = detectChanges(&version, &versions[i+1])
 		} else {
 
-			changes = detectChanges(&version, nil)
+			changes = detectChanges(&version, older)
 		}
 
 		versionSummaries[i] = models.ClientVersionSummary{
@@ -631,10 +1016,16 @@ func (h *AppHandlers) GetClientHistory(c *fiber.Ctx) error {
 	}
 
 	response := models.ClientVersionsListResponse{
-		Success:  true,
-		Versions: versionSummaries,
-		Total:    len(versionSummaries),
-		ClientID: id,
+		Success:    true,
+		Versions:   versionSummaries,
+		Total:      len(versionSummaries),
+		ClientID:   id,
+		HasMore:    info.HasMore,
+		NextCursor: info.NextCursor,
+		PrevCursor: info.PrevCursor,
+	}
+	if info.Total != nil {
+		response.Total = int(*info.Total)
 	}
 
 	return c.JSON(response)
@@ -642,11 +1033,12 @@ func (h *AppHandlers) GetClientHistory(c *fiber.Ctx) error {
 
 // GetClientVersion godoc
 // @Summary Get specific client version
-// @Description Get full data of a specific version of a client
+// @Description Get full data of a specific version of a client. Personal data is masked according to the caller's role.
 // @Tags clients
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Client ID"
 // @Param version path int true "Version number"
 // @Success 200 {object} models.GetClientVersionResponse "Client version data"
@@ -671,7 +1063,7 @@ func (h *AppHandlers) GetClientVersion(c *fiber.Ctx) error {
 	}
 
 	// Преобразуем в полный ответ
-	versionResponse := convertClientVersionToResponse(clientVersion)
+	versionResponse := convertClientVersionToResponse(clientVersion, currentPolicy(c))
 
 	response := models.GetClientVersionResponse{
 		Success:  true,
@@ -682,7 +1074,7 @@ func (h *AppHandlers) GetClientVersion(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
-func convertClientVersionToResponse(cur models.ClientVersion) models.GetClientResponse {
+func convertClientVersionToResponse(cur models.ClientVersion, policy masking.Policy) models.GetClientResponse {
 	response := models.GetClientResponse{
 		ID:            cur.ID,
 		ClientID:      cur.ClientID,
@@ -775,6 +1167,8 @@ func convertClientVersionToResponse(cur models.ClientVersion) models.GetClientRe
 	response.SignatureAllowedNumbers = convertJSONToMap(cur.SignatureAllowedNumbers)
 	response.Raw = convertJSONToMap(cur.Raw)
 
+	applyPolicyToClientResponse(&response, policy)
+
 	return response
 }
 
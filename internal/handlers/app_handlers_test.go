@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPatchSecondPartIfMatch(t *testing.T) {
+	app, clients := testAppHandlers(t, `{"purpose":"инвестиции"}`)
+
+	patch := func(ifMatch, body string) (*http.Response, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest("PATCH", "/clients/7/second-part/current", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		var out map[string]any
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatalf("decode %s: %v", raw, err)
+		}
+		return resp, out
+	}
+
+	if resp, _ := patch("", `{"position":"stable"}`); resp.StatusCode != 428 {
+		t.Fatalf("without If-Match = %d, want 428", resp.StatusCode)
+	}
+	for _, tag := range []string{`3.0`, `"3"`, `"a.b"`, `"0.0"`, `"3.-1"`, `*`} {
+		if resp, _ := patch(tag, `{"position":"stable"}`); resp.StatusCode != 400 {
+			t.Errorf("If-Match %s = %d, want 400", tag, resp.StatusCode)
+		}
+	}
+	if clients.sp.Revision != 0 {
+		t.Fatal("rejected requests changed the draft")
+	}
+
+	resp, body := patch(`"3.0"`, `{"position":"stable"}`)
+	if resp.StatusCode != 200 {
+		t.Fatalf("current ETag = %d %v, want 200", resp.StatusCode, body)
+	}
+	if etag := resp.Header.Get("ETag"); etag != `"3.1"` {
+		t.Fatalf("new ETag = %s, want \"3.1\"", etag)
+	}
+	if string(clients.sp.Data) != `{"position":"stable","purpose":"инвестиции"}` {
+		t.Fatalf("merged data = %s", clients.sp.Data)
+	}
+
+	// правка по устаревшему ETag — конфликт с текущим ETag в ответе
+	for _, stale := range []string{`"3.0"`, `W/"3.0"`, `"2.1"`, `"3.2"`} {
+		resp, body := patch(stale, `{"position":"unstable"}`)
+		if resp.StatusCode != 409 || body["etag"] != `"3.1"` {
+			t.Errorf("stale If-Match %s = %d %v, want 409 with etag \"3.1\"", stale, resp.StatusCode, body)
+		}
+	}
+	if clients.sp.Revision != 1 {
+		t.Fatal("stale requests changed the draft")
+	}
+
+	// слабый ETag принимается, нарушения схемы не мешают сохранению
+	resp, body = patch(`W/"3.1"`, `{"purpose":null}`)
+	if resp.StatusCode != 200 || resp.Header.Get("ETag") != `"3.2"` {
+		t.Fatalf("weak ETag = %d %v", resp.StatusCode, body)
+	}
+	if errs, _ := body["validation_errors"].([]any); len(errs) != 1 {
+		t.Fatalf("validation_errors = %v, want the missing purpose", body["validation_errors"])
+	}
+
+	if resp, _ := patch(`"3.2"`, `[1]`); resp.StatusCode != 400 {
+		t.Fatalf("non-object patch = %d, want 400", resp.StatusCode)
+	}
+
+	// отправленная версия не редактируется
+	clients.sp.Status = "submitted"
+	if resp, _ := patch(`"3.2"`, `{"purpose":"x"}`); resp.StatusCode != 409 {
+		t.Fatalf("submitted version = %d, want 409", resp.StatusCode)
+	}
+}
+
+func TestParseSecondPartETag(t *testing.T) {
+	cases := map[string][3]int{
+		`"1.0"`:   {1, 0, 1},
+		`"12.34"`: {12, 34, 1},
+		`W/"2.5"`: {2, 5, 1},
+		`"1"`:     {0, 0, 0},
+		`1.0`:     {0, 0, 0},
+		`"1.0`:    {0, 0, 0},
+		`""`:      {0, 0, 0},
+		`"0.1"`:   {0, 0, 0},
+		`"1.x"`:   {0, 0, 0},
+		`"1.2.3"`: {0, 0, 0},
+		`w/"1.0"`: {0, 0, 0},
+	}
+	for tag, want := range cases {
+		v, r, ok := parseSecondPartETag(tag)
+		if v != want[0] || r != want[1] || ok != (want[2] == 1) {
+			t.Errorf("parseSecondPartETag(%s) = %d, %d, %v", tag, v, r, ok)
+		}
+	}
+}
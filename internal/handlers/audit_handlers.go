@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandlers struct {
+	auditService  *service.AuditService
+	exportService *service.ExportService
+}
+
+func NewAuditHandlers(auditService *service.AuditService, exportService *service.ExportService) *AuditHandlers {
+	return &AuditHandlers{
+		auditService:  auditService,
+		exportService: exportService,
+	}
+}
+
+// ListAuditEvents godoc
+// @Summary Search audit log
+// @Description Search the append-only audit log of user actions, newest first. Administrators only.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param page query int false "Page number (offset pagination, ignored when cursor is set)" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Param cursor query string false "Opaque cursor from next_cursor/prev_cursor of a previous response"
+// @Param with_total query string false "Total count mode: true, false or estimate (default: true without cursor, false with cursor)"
+// @Param actor_id query int false "Filter by user ID"
+// @Param actor_email query string false "Filter by user email"
+// @Param action query string false "Filter by action, comma-separated (http.request, auth.login_succeeded, auth.login_failed, user.created, second_part.draft_created, client.pii_revealed, export.started)"
+// @Param entity_type query string false "Filter by entity type (client, contract, user, export)"
+// @Param entity_id query string false "Filter by entity ID"
+// @Param request_id query string false "Filter by request ID (X-Request-ID)"
+// @Param method query string false "Filter by HTTP method"
+// @Param from query string false "Occurred at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Occurred at or before (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {object} models.ListAuditEventsResponse "Audit events"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /audit [get]
+func (h *AuditHandlers) ListAuditEvents(c *fiber.Ctx) error {
+	page, err := parsePageRequest(c, 50, 500)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	events, info, err := h.auditService.List(page, filter)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to get audit events: " + err.Error()})
+	}
+
+	return c.JSON(models.ListAuditEventsResponse{
+		Success:  true,
+		Events:   events,
+		PageMeta: models.NewPageMeta(page, info),
+	})
+}
+
+// ExportAuditEvents godoc
+// @Summary Export audit log
+// @Description Stream audit events matching the filters of GET /audit as CSV or XLSX. Administrators only.
+// @Tags audit
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format: csv or xlsx" default(csv)
+// @Param columns query string false "Columns to export, comma-separated (default: all). Allowed: id, occurred_at, actor_id, actor_email, actor_role, action, entity_type, entity_id, request_id, ip, method, path, status, before, after, metadata, prev_hash, hash"
+// @Param actor_id query int false "Filter by user ID"
+// @Param actor_email query string false "Filter by user email"
+// @Param action query string false "Filter by action, comma-separated"
+// @Param entity_type query string false "Filter by entity type"
+// @Param entity_id query string false "Filter by entity ID"
+// @Param request_id query string false "Filter by request ID"
+// @Param method query string false "Filter by HTTP method"
+// @Param from query string false "Occurred at or after (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "Occurred at or before (RFC3339 or YYYY-MM-DD)"
+// @Success 200 {file} file "Export file"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /audit/export [get]
+func (h *AuditHandlers) ExportAuditEvents(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	req, err := exportRequest(c)
+	if err != nil {
+		return c.Status(401).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	exp, err := h.exportService.PrepareAuditExport(req, filter)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	return streamExport(c, exp)
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"strings"
 	"vector/internal/middleware"
 	"vector/internal/models"
+	"vector/internal/pkg/signing"
 	"vector/internal/service"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 type AuthHandlers struct {
@@ -21,7 +26,8 @@ func NewAuthHandlers(authService *service.AuthService) *AuthHandlers {
 
 // Login godoc
 // @Summary Аутентификация пользователя
-// @Description Вход в систему с email и паролем, возвращает JWT токен
+// @Description Вход в систему с email и паролем, возвращает JWT токен.
+// @Description Если у пользователя подключена 2FA, токены не выдаются: ответ содержит mfa_required=true и mfa_token для POST /auth/login/mfa.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -29,6 +35,7 @@ func NewAuthHandlers(authService *service.AuthService) *AuthHandlers {
 // @Success 200 {object} models.LoginResponse "Успешная аутентификация"
 // @Failure 400 {object} map[string]interface{} "Неверные данные"
 // @Failure 401 {object} map[string]interface{} "Неверный email или пароль"
+// @Failure 429 {object} map[string]interface{} "Слишком много неудачных попыток (заголовок Retry-After)"
 // @Router /auth/login [post]
 func (h *AuthHandlers) Login(c *fiber.Ctx) error {
 	var req models.LoginRequest
@@ -48,7 +55,11 @@ func (h *AuthHandlers) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.authService.Login(req.Email, req.Password)
+	response, err := h.authService.Login(middleware.GetAuditActor(c), req.Email, req.Password)
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return throttledResponse(c, throttled)
+	}
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{
 			"error":   err.Error(),
@@ -62,6 +73,93 @@ func (h *AuthHandlers) Login(c *fiber.Ctx) error {
 	})
 }
 
+// JWKS godoc
+// @Summary Публичные ключи проверки токенов (JWKS)
+// @Description Ключи в формате RFC 7517 для проверки подписи access-токенов другими сервисами. Ключ выбирается по kid из заголовка токена; при ротации старые ключи остаются в наборе, пока выданные ими токены не истекут. Сервисы должны проверять iss=vector-app и aud=vector-api.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} signing.JWKSet "Набор ключей"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandlers) JWKS(c *fiber.Ctx) error {
+	var set signing.JWKSet = h.authService.JWKS()
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(set)
+}
+
+// throttledResponse ответ 429 при ограничении попыток входа
+func throttledResponse(c *fiber.Ctx, throttled *service.LoginThrottledError) error {
+	retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(429).JSON(fiber.Map{
+		"error":       throttled.Error(),
+		"success":     false,
+		"locked":      throttled.Locked,
+		"retry_after": retryAfter,
+	})
+}
+
+// Refresh godoc
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен на новую пару access/refresh. Каждый refresh-токен одноразовый: повторное использование завершает все сессии этой цепочки.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} models.LoginResponse "Новая пара токенов"
+// @Failure 400 {object} map[string]interface{} "Неверные данные"
+// @Failure 401 {object} map[string]interface{} "Недействительный refresh-токен"
+// @Router /auth/refresh [post]
+func (h *AuthHandlers) Refresh(c *fiber.Ctx) error {
+	var req models.RefreshRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.RefreshToken) == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "refresh_token обязателен",
+			"success": false,
+		})
+	}
+
+	response, err := h.authService.Refresh(middleware.GetAuditActor(c), req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка обновления токена",
+			"success": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// Logout godoc
+// @Summary Выход из системы
+// @Description Завершает текущую сессию: access- и refresh-токены этой сессии перестают действовать
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Сессия завершена"
+// @Failure 401 {object} map[string]interface{} "Пользователь не аутентифицирован"
+// @Router /auth/logout [post]
+func (h *AuthHandlers) Logout(c *fiber.Ctx) error {
+	if err := h.authService.Logout(middleware.GetAuditActor(c), middleware.GetSessionID(c)); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка завершения сессии",
+			"success": false,
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Сессия завершена",
+	})
+}
+
 // CreateUser godoc
 // @Summary Создание нового пользователя (только для администраторов)
 // @Description Создать нового пользователя в системе. Доступно только администраторам.
@@ -75,8 +173,7 @@ func (h *AuthHandlers) Login(c *fiber.Ctx) error {
 // @Router /auth/users [post]
 func (h *AuthHandlers) CreateUser(c *fiber.Ctx) error {
 	// Получаем текущего пользователя
-	currentUser, err := middleware.GetCurrentUser(c)
-	if err != nil {
+	if _, err := middleware.GetCurrentUser(c); err != nil {
 		return c.Status(401).JSON(fiber.Map{
 			"error":   "Пользователь не аутентифицирован",
 			"success": false,
@@ -101,7 +198,7 @@ func (h *AuthHandlers) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.authService.CreateUser(req, currentUser.Role)
+	user, err := h.authService.CreateUser(middleware.GetAuditActor(c), req)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error":   err.Error(),
@@ -118,7 +215,7 @@ func (h *AuthHandlers) CreateUser(c *fiber.Ctx) error {
 
 // GetProfile godoc
 // @Summary Получить профиль текущего пользователя
-// @Description Получить информацию о текущем аутентифицированном пользователе
+// @Description Получить информацию о текущем аутентифицированном пользователе и права его роли (permissions)
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -134,41 +231,356 @@ func (h *AuthHandlers) GetProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	permissions, err := h.authService.RolePermissions(currentUser.Role)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Не удалось получить права пользователя",
+			"success": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"data":        currentUser.PublicUser(),
+		"permissions": permissions.List(),
+	})
+}
+
+// userErrorStatus HTTP-статус для ошибок управления пользователями
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404
+	case errors.Is(err, models.ErrLastAdministrator), errors.Is(err, service.ErrSelfAction),
+		errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFARequired), errors.Is(err, service.ErrExternalAccount):
+		return 409
+	case errors.Is(err, service.ErrUserInvalid), errors.Is(err, service.ErrWrongPassword),
+		errors.Is(err, service.ErrMFAInvalidCode):
+		return 400
+	}
+	return 500
+}
+
+func userErrorResponse(c *fiber.Ctx, err error) error {
+	status := userErrorStatus(err)
+	msg := err.Error()
+	if status == 404 {
+		msg = "Пользователь не найден"
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   msg,
+		"success": false,
+	})
+}
+
+func userIDParam(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, errors.New("Неверный ID пользователя")
+	}
+	return uint(id), nil
+}
+
+// ListUsers godoc
+// @Summary Список пользователей (только для администраторов)
+// @Description Получить пользователей с фильтрами по роли, активности и строке поиска (email, имя, фамилия)
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param role query string false "Роли через запятую (Administrator, Podft, ClientManagement)"
+// @Param is_active query bool false "Фильтр по активности"
+// @Param q query string false "Подстрока email, имени или фамилии"
+// @Param auth_provider query string false "Источник учетной записи (local, oidc)"
+// @Success 200 {object} map[string]interface{} "Список пользователей"
+// @Failure 400 {object} map[string]interface{} "Неверные параметры"
+// @Router /auth/users [get]
+func (h *AuthHandlers) ListUsers(c *fiber.Ctx) error {
+	var (
+		filter models.UserListFilter
+		err    error
+	)
+	filter.Role = queryList(c, "role")
+	filter.Query = queryString(c, "q")
+	filter.AuthProvider = queryString(c, "auth_provider")
+	if filter.IsActive, err = queryBool(c, "is_active"); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	}
+
+	users, err := h.authService.ListUsers(filter)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	out := make([]models.AppUser, len(users))
+	for i := range users {
+		out[i] = users[i].PublicUser()
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    currentUser.PublicUser(),
+		"data":    out,
+		"total":   len(out),
 	})
 }
 
-// GetRoles godoc
-// @Summary Получить список доступных ролей
-// @Description Получить список всех доступных ролей в системе
+// GetUser godoc
+// @Summary Получить пользователя (только для администраторов)
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "Пользователь"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Router /auth/users/{id} [get]
+func (h *AuthHandlers) GetUser(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	user, err := h.authService.GetUser(id)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user.PublicUser(),
+	})
+}
+
+// UpdateUserRole godoc
+// @Summary Изменить роль пользователя (только для администраторов)
+// @Description Нельзя менять собственную роль и понижать последнего активного администратора
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]interface{} "Список ролей"
-// @Router /auth/roles [get]
-func (h *AuthHandlers) GetRoles(c *fiber.Ctx) error {
-	roles := []map[string]string{
-		{
-			"value":       models.RoleAdministrator,
-			"label":       "Администратор",
-			"description": "Полный доступ к системе",
-		},
-		{
-			"value":       models.RolePodft,
-			"label":       "Отдел ПОДФТ",
-			"description": "Работа с проверками ПОД/ФТ",
-		},
-		{
-			"value":       models.RoleClientManagement,
-			"label":       "Клиентский отдел",
-			"description": "Работа с клиентами",
-		},
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param request body models.UpdateRoleRequest true "Новая роль"
+// @Success 200 {object} map[string]interface{} "Роль изменена"
+// @Failure 400 {object} map[string]interface{} "Неверные данные"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Failure 409 {object} map[string]interface{} "Последний администратор или собственная учетная запись"
+// @Router /auth/users/{id}/role [patch]
+func (h *AuthHandlers) UpdateUserRole(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
 	}
 
+	var req models.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат данных", "success": false})
+	}
+
+	user, err := h.authService.UpdateUserRole(middleware.GetAuditActor(c), id, strings.TrimSpace(req.Role))
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user.PublicUser(),
+		"message": "Роль пользователя изменена",
+	})
+}
+
+// DeactivateUser godoc
+// @Summary Деактивировать пользователя (только для администраторов)
+// @Description Деактивированный пользователь не может войти, выданные токены перестают действовать. Нельзя деактивировать себя и последнего активного администратора.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "Пользователь деактивирован"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Failure 409 {object} map[string]interface{} "Последний администратор или собственная учетная запись"
+// @Router /auth/users/{id}/deactivate [post]
+func (h *AuthHandlers) DeactivateUser(c *fiber.Ctx) error {
+	return h.setUserActive(c, false)
+}
+
+// ActivateUser godoc
+// @Summary Повторно активировать пользователя (только для администраторов)
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "Пользователь активирован"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Router /auth/users/{id}/activate [post]
+func (h *AuthHandlers) ActivateUser(c *fiber.Ctx) error {
+	return h.setUserActive(c, true)
+}
+
+func (h *AuthHandlers) setUserActive(c *fiber.Ctx, active bool) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	user, err := h.authService.SetUserActive(middleware.GetAuditActor(c), id, active)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	message := "Пользователь деактивирован"
+	if active {
+		message = "Пользователь активирован"
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user.PublicUser(),
+		"message": message,
+	})
+}
+
+// ResetUserPassword godoc
+// @Summary Сбросить пароль пользователя (только для администраторов)
+// @Description Задает новый пароль. Если пароль не передан, генерируется временный и возвращается в ответе один раз.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param request body models.ResetPasswordRequest false "Новый пароль (необязательно)"
+// @Success 200 {object} map[string]interface{} "Пароль сброшен"
+// @Failure 400 {object} map[string]interface{} "Неверные данные"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Router /auth/users/{id}/reset-password [post]
+func (h *AuthHandlers) ResetUserPassword(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	var req models.ResetPasswordRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Неверный формат данных", "success": false})
+		}
+	}
+
+	temporary, err := h.authService.ResetUserPassword(middleware.GetAuditActor(c), id, req.Password)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+
+	resp := fiber.Map{
+		"success": true,
+		"message": "Пароль пользователя сброшен",
+	}
+	if temporary != "" {
+		resp["temporary_password"] = temporary
+	}
+	return c.JSON(resp)
+}
+
+// DeleteUser godoc
+// @Summary Удалить пользователя (только для администраторов)
+// @Description Мягкое удаление: пользователь деактивируется и скрывается из списков, записи журналов сохраняются. Нельзя удалить себя и последнего активного администратора.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "Пользователь удален"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Failure 409 {object} map[string]interface{} "Последний администратор или собственная учетная запись"
+// @Router /auth/users/{id} [delete]
+func (h *AuthHandlers) DeleteUser(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	if err := h.authService.DeleteUser(middleware.GetAuditActor(c), id); err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Пользователь удален",
+	})
+}
+
+// LogoutAllUserSessions godoc
+// @Summary Завершить все сессии пользователя (только для администраторов)
+// @Description Отзывает все refresh-токены пользователя; выданные access-токены перестают приниматься
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "Сессии завершены"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Router /auth/users/{id}/logout-all [post]
+func (h *AuthHandlers) LogoutAllUserSessions(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	revoked, err := h.authService.LogoutAllUserSessions(middleware.GetAuditActor(c), id)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"revoked": revoked,
+		"message": "Все сессии пользователя завершены",
+	})
+}
+
+// UnlockUser godoc
+// @Summary Снять блокировку входа (только для администраторов)
+// @Description Сбрасывает счетчик неудачных входов и блокировку аккаунта; с параметром ip — также блокировку адреса
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Param ip query string false "IP-адрес, блокировку которого нужно снять"
+// @Success 200 {object} map[string]interface{} "Блокировка снята"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Router /auth/users/{id}/unlock [post]
+func (h *AuthHandlers) UnlockUser(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	cleared, err := h.authService.UnlockUser(middleware.GetAuditActor(c), id, strings.TrimSpace(c.Query("ip")))
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"cleared": cleared,
+		"message": "Блокировка входа снята",
+	})
+}
+
+// ChangePassword godoc
+// @Summary Сменить свой пароль
+// @Description Смена пароля текущим пользователем; требуется текущий пароль. Остальные сессии пользователя завершаются.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} map[string]interface{} "Пароль изменен"
+// @Failure 400 {object} map[string]interface{} "Неверный текущий пароль или новый пароль не подходит"
+// @Router /auth/password [post]
+func (h *AuthHandlers) ChangePassword(c *fiber.Ctx) error {
+	var req models.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат данных", "success": false})
+	}
+
+	if err := h.authService.ChangeOwnPassword(middleware.GetAuditActor(c), middleware.GetSessionID(c), req.CurrentPassword, req.NewPassword); err != nil {
+		return userErrorResponse(c, err)
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    roles,
+		"message": "Пароль изменен",
 	})
 }
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/pkg/masking"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// currentPolicy политика отображения персональных данных для пользователя запроса
+func currentPolicy(c *fiber.Ctx) masking.Policy {
+	return masking.PolicyFor(middleware.GetPermissions(c))
+}
+
+func applyPolicyToClientResponse(r *models.GetClientResponse, p masking.Policy) {
+	if p.IsFull() {
+		return
+	}
+
+	r.PassSeries = p.Apply(masking.FieldPassSeries, r.PassSeries)
+	r.PassNumber = p.Apply(masking.FieldPassNumber, r.PassNumber)
+	r.PassIssuer = p.Apply(masking.FieldPassIssuer, r.PassIssuer)
+	r.PassIssuerCode = p.Apply(masking.FieldPassIssuerCode, r.PassIssuerCode)
+	r.PassIssueDate = p.Apply(masking.FieldPassIssueDate, r.PassIssueDate)
+	r.Inn = p.Apply(masking.FieldInn, r.Inn)
+	r.Snils = p.Apply(masking.FieldSnils, r.Snils)
+	r.MainPhone = p.Apply(masking.FieldMainPhone, r.MainPhone)
+	r.ContactEmail = p.Apply(masking.FieldContactEmail, r.ContactEmail)
+	r.Birthday = p.Apply(masking.FieldBirthday, r.Birthday)
+	r.BirthPlace = p.Apply(masking.FieldBirthPlace, r.BirthPlace)
+
+	if p.Mode(masking.FieldAddress) != masking.ModeFull {
+		r.Street = p.Apply(masking.FieldAddress, r.Street)
+		r.House = p.Apply(masking.FieldAddress, r.House)
+		r.Index, r.Corps, r.Flat = nil, nil, nil
+	}
+
+	if r.PersonInfo != nil {
+		p.RedactJSON(*r.PersonInfo, "person_info")
+	}
+	if r.Raw != nil {
+		p.RedactJSON(*r.Raw, "")
+	}
+
+	r.MaskedFields = p.Restricted()
+}
+
+func applyPolicyToClientListItem(r *models.ClientListItem, p masking.Policy) {
+	if p.IsFull() {
+		return
+	}
+
+	r.PassSeries = p.Apply(masking.FieldPassSeries, r.PassSeries)
+	r.PassNumber = p.Apply(masking.FieldPassNumber, r.PassNumber)
+	r.PassIssuer = p.Apply(masking.FieldPassIssuer, r.PassIssuer)
+	r.PassIssuerCode = p.Apply(masking.FieldPassIssuerCode, r.PassIssuerCode)
+	r.PassIssueDate = p.Apply(masking.FieldPassIssueDate, r.PassIssueDate)
+	r.Inn = p.Apply(masking.FieldInn, r.Inn)
+	r.Snils = p.Apply(masking.FieldSnils, r.Snils)
+	r.MainPhone = p.Apply(masking.FieldMainPhone, r.MainPhone)
+	r.ContactEmail = p.Apply(masking.FieldContactEmail, r.ContactEmail)
+	r.Birthday = p.Apply(masking.FieldBirthday, r.Birthday)
+	r.BirthPlace = p.Apply(masking.FieldBirthPlace, r.BirthPlace)
+
+	r.MaskedFields = p.Restricted()
+}
+
+// applyPolicyToSearchResult дополнительно убирает подсветку замаскированных полей,
+// иначе она раскрыла бы исходное значение
+func applyPolicyToSearchResult(r *models.ClientSearchResult, p masking.Policy) {
+	if p.IsFull() {
+		return
+	}
+	applyPolicyToClientListItem(&r.ClientListItem, p)
+	for field := range r.Highlights {
+		if p.Mode(field) != masking.ModeFull {
+			delete(r.Highlights, field)
+		}
+	}
+	if len(r.Highlights) == 0 {
+		r.Highlights = nil
+	}
+}
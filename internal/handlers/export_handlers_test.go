@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// serveExport поднимает выгрузку клиентов на настоящем соединении: разрыв ответа через app.Test не виден
+func serveExport(t *testing.T, clients *fakeClientRepo, logs *fakeExportLogRepo) string {
+	t.Helper()
+	audit := service.NewAuditService(fakeAuditRepo{})
+	h := NewExportHandlers(service.NewExportService(clients, nil, logs, fakeAuditRepo{}, audit))
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", models.AppUser{ID: 1, Email: "analyst@example.com"})
+		c.Locals("permissions", models.NewPermissionSet(models.PermClientsExport, models.PermPIIViewFull))
+		return c.Next()
+	})
+	app.Get("/clients/export", h.ExportClients)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return "http://" + ln.Addr().String()
+}
+
+func exportPage(ids ...int) []models.ClientWithSP {
+	page := make([]models.ClientWithSP, len(ids))
+	for i, id := range ids {
+		page[i] = models.ClientWithSP{ClientID: id, Surname: "Петров"}
+	}
+	return page
+}
+
+func TestExportStreamsAllPages(t *testing.T) {
+	logs := &fakeExportLogRepo{}
+	base := serveExport(t, &fakeClientRepo{pages: [][]models.ClientWithSP{exportPage(1, 2), exportPage(3)}}, logs)
+
+	resp, err := http.Get(base + "/clients/export?format=csv&columns=id,surname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read complete export: %v", err)
+	}
+	if lines := strings.Count(string(body), "\n"); resp.StatusCode != 200 || lines != 4 {
+		t.Fatalf("status %d, %d lines:\n%s", resp.StatusCode, lines, body)
+	}
+	if status, rows := logs.result(); status != models.ExportStatusCompleted || rows != 3 {
+		t.Fatalf("export log = %s/%d", status, rows)
+	}
+}
+
+func TestExportAbortsResponseOnMidStreamError(t *testing.T) {
+	for _, format := range []string{"csv", "xlsx"} {
+		t.Run(format, func(t *testing.T) {
+			logs := &fakeExportLogRepo{}
+			// первая страница больше буфера ответа: заголовки и часть файла уже отправлены
+			first := make([]int, 2000)
+			for i := range first {
+				first[i] = i + 1
+			}
+			clients := &fakeClientRepo{pages: [][]models.ClientWithSP{exportPage(first...), exportPage(3)}, listErrAt: 1}
+			base := serveExport(t, clients, logs)
+
+			resp, err := http.Get(base + "/clients/export?format=" + format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != 200 {
+				t.Fatalf("status %d", resp.StatusCode)
+			}
+			// ответ не должен закончиться нормально: клиент обязан увидеть обрыв
+			body, err := io.ReadAll(resp.Body)
+			if err == nil {
+				t.Fatal("failed export ended as a complete response")
+			}
+			if len(body) == 0 {
+				t.Fatal("nothing was streamed before the failure")
+			}
+			if status, _ := logs.result(); status != models.ExportStatusFailed {
+				t.Fatalf("export log status = %q, want failed", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"vector/internal/models"
+	"vector/internal/repository"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Репозитории в памяти для тестов обработчиков на настоящих сервисах.
+
+type fakeAuditRepo struct{}
+
+func (fakeAuditRepo) Append(event models.AuditEvent) (models.AuditEvent, error) { return event, nil }
+
+func (fakeAuditRepo) List(models.PageRequest, models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	return nil, models.PageInfo{}, nil
+}
+
+type fakeQuestionnaireRepo struct {
+	versions []models.QuestionnaireSchema
+}
+
+func (r *fakeQuestionnaireRepo) Current() (models.QuestionnaireSchema, error) {
+	if len(r.versions) == 0 {
+		return models.QuestionnaireSchema{}, models.ErrNoQuestionnaireSchema
+	}
+	return r.versions[len(r.versions)-1], nil
+}
+
+func (r *fakeQuestionnaireRepo) Get(version int) (models.QuestionnaireSchema, error) {
+	if version < 1 || version > len(r.versions) {
+		return models.QuestionnaireSchema{}, gorm.ErrRecordNotFound
+	}
+	return r.versions[version-1], nil
+}
+
+func (r *fakeQuestionnaireRepo) List() ([]models.QuestionnaireSchema, error) {
+	return r.versions, nil
+}
+
+func (r *fakeQuestionnaireRepo) Create(s models.QuestionnaireSchema) (models.QuestionnaireSchema, error) {
+	s.Version = len(r.versions) + 1
+	r.versions = append(r.versions, s)
+	return s, nil
+}
+
+// fakeClientRepo текущая версия второй части одного клиента; остальные методы не реализованы
+type fakeClientRepo struct {
+	repository.AppClientRepository
+
+	mu sync.Mutex
+	sp models.SecondPartVersion
+	// pages страницы списка клиентов по курсору ("" — первая); ошибка — на странице listErrAt
+	pages     [][]models.ClientWithSP
+	listErrAt int
+}
+
+func (r *fakeClientRepo) ListClientsWithSP(page models.PageRequest, filter models.ClientListFilter) ([]models.ClientWithSP, models.PageInfo, error) {
+	n := 0
+	if page.Cursor != "" {
+		n, _ = strconv.Atoi(page.Cursor)
+	}
+	if r.listErrAt > 0 && n == r.listErrAt {
+		return nil, models.PageInfo{}, errors.New("connection reset by peer")
+	}
+	info := models.PageInfo{HasMore: n+1 < len(r.pages)}
+	if info.HasMore {
+		info.NextCursor = strconv.Itoa(n + 1)
+	}
+	return r.pages[n], info, nil
+}
+
+type fakeExportLogRepo struct {
+	mu     sync.Mutex
+	status string
+	rows   int64
+}
+
+func (r *fakeExportLogRepo) Create(log models.ExportLog) (models.ExportLog, error) {
+	log.ID = 1
+	return log, nil
+}
+
+func (r *fakeExportLogRepo) Finish(id uint, status string, rows int64, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status, r.rows = status, rows
+	return nil
+}
+
+func (r *fakeExportLogRepo) result() (string, int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status, r.rows
+}
+
+func (r *fakeClientRepo) GetSecondPartCurrent(clientID int) (models.SecondPartVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sp.ClientID != clientID {
+		return models.SecondPartVersion{}, gorm.ErrRecordNotFound
+	}
+	return r.sp, nil
+}
+
+func (r *fakeClientRepo) UpdateSecondPartDraftData(clientID, version, revision int, data datatypes.JSON, schemaVersion *int, changes []models.SecondPartChange, actorID *int) (models.SecondPartVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sp.Version != version || r.sp.Revision != revision {
+		return r.sp, models.ErrSecondPartVersionConflict
+	}
+	r.sp.Revision++
+	r.sp.Data = data
+	r.sp.SchemaVersion = schemaVersion
+	return r.sp, nil
+}
+
+func (r *fakeClientRepo) SubmitSecondPart(clientID int, userID *int, spVersion, revision, schemaVersion int) (models.SecondPartVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sp.Version != spVersion || r.sp.Revision != revision {
+		return r.sp, models.ErrSecondPartVersionConflict
+	}
+	r.sp.Status = "submitted"
+	r.sp.SchemaVersion = &schemaVersion
+	return r.sp, nil
+}
+
+// testQuestionnaire сервис анкеты со схемой: обязательное поле purpose и перечисление position
+func testQuestionnaire(t *testing.T) (*service.QuestionnaireService, *service.AuditService) {
+	t.Helper()
+	audit := service.NewAuditService(fakeAuditRepo{})
+	repo := &fakeQuestionnaireRepo{}
+	repo.Create(models.QuestionnaireSchema{Title: "Анкета", Schema: datatypes.JSON(`{
+		"type": "object",
+		"required": ["purpose"],
+		"properties": {
+			"purpose": {"type": "string", "minLength": 1},
+			"position": {"type": "string", "enum": ["stable", "unstable"]}
+		}
+	}`)})
+	return service.NewQuestionnaireService(repo, audit), audit
+}
+
+// testAppHandlers обработчики клиентов над черновиком второй части клиента 7 (версия 3, правка 0)
+func testAppHandlers(t *testing.T, data string) (*fiber.App, *fakeClientRepo) {
+	t.Helper()
+	questionnaire, audit := testQuestionnaire(t)
+	clients := &fakeClientRepo{sp: models.SecondPartVersion{ClientID: 7, Version: 3, Status: "draft", Data: datatypes.JSON(data)}}
+	h := NewAppHandlers(service.NewAppService(clients, nil, nil, nil, nil, audit, nil, questionnaire))
+
+	app := fiber.New()
+	app.Patch("/clients/:id/second-part/current", h.PatchSecondPartCurrent)
+	app.Post("/clients/:id/second-part/submit", h.SubmitSecondPart)
+	return app, clients
+}
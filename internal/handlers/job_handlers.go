@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobHandlers struct {
+	recalcService *service.RecalcService
+	jobRunner     *service.JobRunner
+}
+
+func NewJobHandlers(recalcService *service.RecalcService, jobRunner *service.JobRunner) *JobHandlers {
+	return &JobHandlers{
+		recalcService: recalcService,
+		jobRunner:     jobRunner,
+	}
+}
+
+// RunRecalc godoc
+// @Summary Run needs_second_part recalculation
+// @Description Runs all recalculation rules now (the same job as APP_CRON) and returns how many requirements each rule opened and resolved.
+// @Description The run is recorded in the job log. Only one recalculation runs at a time across all instances.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.RecalcResult "Requirements opened and resolved per reason (questionnaire_expired, data_changed, passport_expired)"
+// @Failure 409 {object} map[string]interface{} "Recalculation is already running"
+// @Failure 500 {object} map[string]interface{} "Some rules failed; data contains the rules that succeeded"
+// @Router /admin/recalc [post]
+func (h *JobHandlers) RunRecalc(c *fiber.Ctx) error {
+	result, err := h.recalcService.Run(c.UserContext(), middleware.GetAuditActor(c).Email)
+	if errors.Is(err, service.ErrJobSkipped) {
+		return c.Status(409).JSON(fiber.Map{
+			"error":   "Пересчет уже выполняется",
+			"success": false,
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка пересчета: " + err.Error(),
+			"data":    result,
+			"success": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListJobRuns godoc
+// @Summary Background job log
+// @Description Runs of background jobs (sync.full, app.recalc, oidc.reconcile), newest first: instance, trigger, status and result.
+// @Description Skipped runs were started while another instance held the job.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (offset pagination, ignored when cursor is set)" default(1)
+// @Param per_page query int false "Items per page" default(50)
+// @Param cursor query string false "Opaque cursor from next_cursor/prev_cursor of a previous response"
+// @Param with_total query string false "Total count mode: true, false or estimate"
+// @Param job query string false "Filter by job (sync.full, app.recalc, oidc.reconcile)"
+// @Param status query string false "Filter by status (running, succeeded, failed, skipped)"
+// @Success 200 {object} models.ListJobRunsResponse "Job runs"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Router /admin/job-runs [get]
+func (h *JobHandlers) ListJobRuns(c *fiber.Ctx) error {
+	page, err := parsePageRequest(c, 50, 500)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+
+	var filter models.JobRunFilter
+	if v := strings.TrimSpace(c.Query("job")); v != "" {
+		filter.Job = &v
+	}
+	if v := strings.TrimSpace(c.Query("status")); v != "" {
+		filter.Status = &v
+	}
+
+	runs, info, err := h.jobRunner.ListRuns(page, filter)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "failed to get job runs: " + err.Error()})
+	}
+
+	return c.JSON(models.ListJobRunsResponse{
+		Success:  true,
+		Runs:     runs,
+		PageMeta: models.NewPageMeta(page, info),
+	})
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoginMFA godoc
+// @Summary Второй шаг входа (2FA)
+// @Description Обменивает mfa_token из ответа /auth/login и код из приложения-аутентификатора (или код восстановления) на пару токенов. mfa_token одноразовый: после успешного входа повторно не принимается
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.LoginMFARequest true "Токен второго шага и код"
+// @Success 200 {object} models.LoginResponse "Успешная аутентификация"
+// @Failure 400 {object} map[string]interface{} "Неверные данные"
+// @Failure 401 {object} map[string]interface{} "Неверный код или просроченный или уже использованный mfa_token"
+// @Failure 429 {object} map[string]interface{} "Слишком много неудачных попыток (заголовок Retry-After)"
+// @Router /auth/login/mfa [post]
+func (h *AuthHandlers) LoginMFA(c *fiber.Ctx) error {
+	var req models.LoginMFARequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.MFAToken) == "" || strings.TrimSpace(req.Code) == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "mfa_token и code обязательны",
+			"success": false,
+		})
+	}
+
+	response, err := h.authService.LoginMFA(middleware.GetAuditActor(c), req.MFAToken, req.Code)
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return throttledResponse(c, throttled)
+	}
+	if errors.Is(err, service.ErrMFAInvalidToken) || errors.Is(err, service.ErrMFAInvalidCode) {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка входа",
+			"success": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// GetMFAStatus godoc
+// @Summary Состояние 2FA
+// @Description Подключена ли двухфакторная аутентификация, обязательна ли она для роли и сколько осталось кодов восстановления
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.MFAStatusResponse "Состояние 2FA"
+// @Failure 401 {object} map[string]interface{} "Пользователь не аутентифицирован"
+// @Router /auth/mfa [get]
+func (h *AuthHandlers) GetMFAStatus(c *fiber.Ctx) error {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	status, err := h.authService.GetMFAStatus(*user)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// BeginMFAEnrollment godoc
+// @Summary Начать подключение 2FA
+// @Description Создает секрет TOTP и возвращает его вместе с otpauth:// URI для QR-кода. 2FA включается после подтверждения кодом (POST /auth/mfa/verify).
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.MFAEnrollResponse "Секрет и URI для приложения"
+// @Failure 409 {object} map[string]interface{} "2FA уже подключена"
+// @Router /auth/mfa/enroll [post]
+func (h *AuthHandlers) BeginMFAEnrollment(c *fiber.Ctx) error {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	enroll, err := h.authService.BeginMFAEnrollment(user.ID)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    enroll,
+	})
+}
+
+// ConfirmMFAEnrollment godoc
+// @Summary Подтвердить подключение 2FA
+// @Description Проверяет первый код из приложения, включает 2FA и возвращает коды восстановления (показываются один раз). Остальные сессии пользователя завершаются.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "Код из приложения"
+// @Success 200 {object} models.MFARecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {object} map[string]interface{} "Неверный код"
+// @Failure 409 {object} map[string]interface{} "2FA уже подключена или подключение не начато"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandlers) ConfirmMFAEnrollment(c *fiber.Ctx) error {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code обязателен", "success": false})
+	}
+
+	codes, err := h.authService.ConfirmMFAEnrollment(middleware.GetAuditActor(c), user.ID, middleware.GetSessionID(c), req.Code)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    codes,
+		"message": "Двухфакторная аутентификация подключена",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Новые коды восстановления
+// @Description Выдает новый набор кодов восстановления по коду из приложения; прежние коды перестают действовать
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "Код из приложения"
+// @Success 200 {object} models.MFARecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {object} map[string]interface{} "Неверный код"
+// @Failure 409 {object} map[string]interface{} "2FA не подключена"
+// @Router /auth/mfa/recovery-codes [post]
+func (h *AuthHandlers) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code обязателен", "success": false})
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(middleware.GetAuditActor(c), user.ID, req.Code)
+	if err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    codes,
+	})
+}
+
+// DisableMFA godoc
+// @Summary Отключить 2FA
+// @Description Отключает двухфакторную аутентификацию по коду из приложения или коду восстановления. Недоступно для ролей, где 2FA обязательна.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} map[string]interface{} "2FA отключена"
+// @Failure 400 {object} map[string]interface{} "Неверный код"
+// @Failure 409 {object} map[string]interface{} "2FA не подключена или обязательна для роли"
+// @Router /auth/mfa/disable [post]
+func (h *AuthHandlers) DisableMFA(c *fiber.Ctx) error {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+	var req models.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "code обязателен", "success": false})
+	}
+
+	if err := h.authService.DisableOwnMFA(middleware.GetAuditActor(c), user.ID, middleware.GetSessionID(c), req.Code); err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Двухфакторная аутентификация отключена",
+	})
+}
+
+// ResetUserMFA godoc
+// @Summary Сбросить 2FA пользователя (только для администраторов)
+// @Description Удаляет секрет и коды восстановления пользователя (например, при потере устройства) и завершает все его сессии. При следующем входе 2FA подключается заново.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} map[string]interface{} "2FA сброшена"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Failure 409 {object} map[string]interface{} "Нельзя сбросить собственную 2FA"
+// @Router /auth/users/{id}/mfa/reset [post]
+func (h *AuthHandlers) ResetUserMFA(c *fiber.Ctx) error {
+	id, err := userIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "success": false})
+	}
+
+	if err := h.authService.ResetUserMFA(middleware.GetAuditActor(c), id); err != nil {
+		return userErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Двухфакторная аутентификация пользователя сброшена",
+	})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type OIDCHandlers struct {
+	oidcService *service.OIDCService
+}
+
+func NewOIDCHandlers(oidcService *service.OIDCService) *OIDCHandlers {
+	return &OIDCHandlers{
+		oidcService: oidcService,
+	}
+}
+
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOIDCState), errors.Is(err, service.ErrOIDCLoginCode), errors.Is(err, service.ErrOIDCEmail):
+		return 400
+	case errors.Is(err, service.ErrOIDCNoRole):
+		return 403
+	}
+	return 401
+}
+
+// Login godoc
+// @Summary Вход через корпоративный SSO (OIDC)
+// @Description Перенаправляет браузер на страницу входа провайдера (authorization code + PKCE)
+// @Tags auth
+// @Success 302 "Переход к провайдеру"
+// @Failure 502 {object} map[string]interface{} "Провайдер недоступен"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandlers) Login(c *fiber.Ctx) error {
+	target, err := h.oidcService.BeginLogin(c.UserContext())
+	if err != nil {
+		log.Printf("oidc: begin login: %v", err)
+		return c.Status(502).JSON(fiber.Map{
+			"error":   "Провайдер входа недоступен",
+			"success": false,
+		})
+	}
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// Callback godoc
+// @Summary Возврат от провайдера SSO
+// @Description Проверяет state, обменивает код на токены провайдера, проверяет ID-токен и создает или обновляет пользователя.
+// @Description Если настроен OIDC_FRONTEND_REDIRECT_URL, браузер перенаправляется туда с одноразовым login_code для POST /auth/oidc/exchange; иначе ответ содержит токены.
+// @Tags auth
+// @Produce json
+// @Param code query string true "Код авторизации"
+// @Param state query string true "State"
+// @Success 200 {object} models.LoginResponse "Успешная аутентификация"
+// @Success 302 "Переход на frontend с login_code"
+// @Failure 400 {object} map[string]interface{} "Недействительный запрос входа"
+// @Failure 401 {object} map[string]interface{} "Вход отклонен"
+// @Failure 403 {object} map[string]interface{} "Нет роли в корпоративном каталоге"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandlers) Callback(c *fiber.Ctx) error {
+	if providerErr := c.Query("error"); providerErr != "" {
+		return c.Status(401).JSON(fiber.Map{
+			"error":   "Провайдер отклонил вход: " + providerErr,
+			"success": false,
+		})
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "code и state обязательны",
+			"success": false,
+		})
+	}
+
+	loginCode, response, err := h.oidcService.Callback(c.UserContext(), middleware.GetAuditActor(c), code, state)
+	if err != nil {
+		log.Printf("oidc: callback: %v", err)
+		return c.Status(oidcErrorStatus(err)).JSON(fiber.Map{
+			"error":   "Вход через SSO не выполнен: " + err.Error(),
+			"success": false,
+		})
+	}
+	if loginCode != "" {
+		return c.Redirect(h.oidcService.FrontendRedirect(loginCode), fiber.StatusFound)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// Exchange godoc
+// @Summary Обмен кода входа SSO на токены
+// @Description Одноразовый login_code (действует минуту) из перенаправления callback обменивается на пару токенов или на mfa_token, если у пользователя подключена 2FA
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.OIDCExchangeRequest true "Код входа"
+// @Success 200 {object} models.LoginResponse "Успешная аутентификация"
+// @Failure 400 {object} map[string]interface{} "Недействительный или просроченный код"
+// @Router /auth/oidc/exchange [post]
+func (h *OIDCHandlers) Exchange(c *fiber.Ctx) error {
+	var req models.OIDCExchangeRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.LoginCode) == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "login_code обязателен",
+			"success": false,
+		})
+	}
+
+	response, err := h.oidcService.ExchangeLoginCode(middleware.GetAuditActor(c), strings.TrimSpace(req.LoginCode))
+	if errors.Is(err, service.ErrOIDCLoginCode) {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка входа",
+			"success": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// Reconcile godoc
+// @Summary Сверка пользователей SSO с провайдером (только для администраторов)
+// @Description Обновляет роли пользователей SSO по группам провайдера и деактивирует тех, чей доступ у провайдера отозван. Выполняется также по расписанию (OIDC_RECONCILE_CRON).
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.OIDCReconcileResult "Итог сверки"
+// @Failure 500 {object} map[string]interface{} "Ошибка сверки"
+// @Router /auth/oidc/reconcile [post]
+func (h *OIDCHandlers) Reconcile(c *fiber.Ctx) error {
+	result, err := h.oidcService.Reconcile(c.UserContext(), middleware.GetAuditActor(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Ошибка сверки с провайдером: " + err.Error(),
+			"success": false,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/masking"
+	"vector/internal/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// queryBool возвращает nil, если параметр не задан
+func queryBool(c *fiber.Ctx, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected true or false", key)
+	}
+	return &v, nil
+}
+
+func queryInt(c *fiber.Ctx, key string) (*int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected integer", key)
+	}
+	return &v, nil
+}
+
+func queryString(c *fiber.Ctx, key string) *string {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return nil
+	}
+	return &raw
+}
+
+// queryList разбирает значения через запятую: risk_level=high,medium
+func queryList(c *fiber.Ctx, key string) []string {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil
+	}
+	var out []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// queryTime принимает RFC3339 или дату YYYY-MM-DD (начало суток UTC).
+// Для верхней границы диапазона дата без времени означает конец суток.
+func queryTime(c *fiber.Ctx, key string, endOfDay bool) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected RFC3339 or YYYY-MM-DD", key)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+// parsePageRequest разбирает page/per_page (OFFSET, для совместимости), cursor и with_total.
+// with_total: true|false|estimate; по умолчанию точный total считается только без курсора.
+func parsePageRequest(c *fiber.Ctx, defaultPerPage, maxPerPage int) (models.PageRequest, error) {
+	req := models.PageRequest{
+		Page:    c.QueryInt("page", 1),
+		PerPage: c.QueryInt("per_page", defaultPerPage),
+		Cursor:  strings.TrimSpace(c.Query("cursor")),
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PerPage < 1 || req.PerPage > maxPerPage {
+		req.PerPage = defaultPerPage
+	}
+
+	switch strings.ToLower(c.Query("with_total")) {
+	case "":
+	case "true", "1", models.TotalModeExact:
+		req.TotalMode = models.TotalModeExact
+	case "false", "0", models.TotalModeNone:
+		req.TotalMode = models.TotalModeNone
+	case models.TotalModeEstimate:
+		req.TotalMode = models.TotalModeEstimate
+	default:
+		return req, fmt.Errorf("invalid with_total: expected true, false or estimate")
+	}
+
+	return req, nil
+}
+
+// parseClientListFilter собирает фильтры и сортировку списка клиентов из query
+func parseClientListFilter(c *fiber.Ctx) (models.ClientListFilter, error) {
+	var (
+		f   models.ClientListFilter
+		err error
+	)
+
+	if f.NeedsSecondPart, err = queryBool(c, "needs_second_part"); err != nil {
+		return f, err
+	}
+	if f.Blocked, err = queryBool(c, "blocked"); err != nil {
+		return f, err
+	}
+	if f.IsRfResident, err = queryBool(c, "is_rf_resident"); err != nil {
+		return f, err
+	}
+	if f.QualifiedInvestor, err = queryBool(c, "qualified_investor"); err != nil {
+		return f, err
+	}
+	if f.HasActiveContract, err = queryBool(c, "has_active_contract"); err != nil {
+		return f, err
+	}
+	if f.SpDueBefore, err = queryTime(c, "sp_due_before", true); err != nil {
+		return f, err
+	}
+	if f.SpDueAfter, err = queryTime(c, "sp_due_after", false); err != nil {
+		return f, err
+	}
+	if f.CreatedLKFrom, err = queryTime(c, "created_lk_from", false); err != nil {
+		return f, err
+	}
+	if f.CreatedLKTo, err = queryTime(c, "created_lk_to", true); err != nil {
+		return f, err
+	}
+
+	f.NeedsSecondPartReason = queryList(c, "needs_second_part_reason")
+	for _, r := range f.NeedsSecondPartReason {
+		if !models.IsSecondPartRequirementReason(r) {
+			return f, fmt.Errorf("invalid needs_second_part_reason: %s", r)
+		}
+	}
+	f.SpStatus = queryList(c, "sp_status")
+	f.RiskLevel = queryList(c, "risk_level")
+	f.ExternalRiskLevel = queryList(c, "external_risk_level")
+	f.FillStage = queryList(c, "fill_stage")
+
+	if f.Sort, err = utils.ParseSortParam(c.Query("sort"), models.ClientSortFields); err != nil {
+		return f, err
+	}
+	// порядок строк и курсор страницы раскрыли бы значение поля, которое политика маскирует или скрывает;
+	// поля сортировки названы так же, как поля политики
+	policy := currentPolicy(c)
+	for _, s := range f.Sort {
+		if policy.Mode(s.Field) != masking.ModeFull {
+			return f, fmt.Errorf("sorting by %s is not allowed: the field is masked for your role", s.Field)
+		}
+	}
+
+	return f, nil
+}
+
+// parseContractListFilter собирает фильтры и сортировку списка контрактов из query.
+// tariff/strategy принимают id (число) или название.
+func parseContractListFilter(c *fiber.Ctx) (models.ContractListFilter, error) {
+	var (
+		f   models.ContractListFilter
+		err error
+	)
+
+	if f.UserID, err = queryInt(c, "user_id"); err != nil {
+		return f, err
+	}
+	if f.SignedFrom, err = queryTime(c, "signed_from", false); err != nil {
+		return f, err
+	}
+	if f.SignedTo, err = queryTime(c, "signed_to", true); err != nil {
+		return f, err
+	}
+	if f.ClosedFrom, err = queryTime(c, "closed_from", false); err != nil {
+		return f, err
+	}
+	if f.ClosedTo, err = queryTime(c, "closed_to", true); err != nil {
+		return f, err
+	}
+
+	f.Status = queryList(c, "status")
+	f.Kind = queryList(c, "kind")
+
+	if tariff := queryString(c, "tariff"); tariff != nil {
+		if id, err := strconv.Atoi(*tariff); err == nil {
+			f.TariffID = &id
+		} else {
+			f.TariffName = tariff
+		}
+	}
+	if strategy := queryString(c, "strategy"); strategy != nil {
+		if id, err := strconv.Atoi(*strategy); err == nil {
+			f.StrategyID = &id
+		} else {
+			f.StrategyName = strategy
+		}
+	}
+
+	if f.Sort, err = utils.ParseSortParam(c.Query("sort"), models.ContractSortFields); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
+
+// parseAuditFilter собирает фильтры журнала аудита из query
+func parseAuditFilter(c *fiber.Ctx) (models.AuditFilter, error) {
+	var (
+		f   models.AuditFilter
+		err error
+	)
+
+	if f.ActorID, err = queryInt(c, "actor_id"); err != nil {
+		return f, err
+	}
+	if f.From, err = queryTime(c, "from", false); err != nil {
+		return f, err
+	}
+	if f.To, err = queryTime(c, "to", true); err != nil {
+		return f, err
+	}
+	f.ActorEmail = queryString(c, "actor_email")
+	f.Action = queryList(c, "action")
+	f.EntityType = queryString(c, "entity_type")
+	f.EntityID = queryString(c, "entity_id")
+	f.RequestID = queryString(c, "request_id")
+	f.Method = queryString(c, "method")
+
+	return f, nil
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"vector/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClientSortRespectsMaskingPolicy(t *testing.T) {
+	cases := []struct {
+		name  string
+		perms models.PermissionSet
+		sort  string
+		want  int
+	}{
+		{"full view sorts by birthday", models.NewPermissionSet(models.PermPIIViewFull), "-birthday,id", 200},
+		{"masked view sorts by birthday", models.NewPermissionSet(models.PermPIIViewMasked), "birthday", 200},
+		{"hidden birthday", models.NewPermissionSet(models.PermClientsRead), "surname,-birthday", 400},
+		{"hidden fields do not affect other sorts", models.NewPermissionSet(models.PermClientsRead), "surname,-id", 200},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/clients", func(c *fiber.Ctx) error {
+				c.Locals("permissions", tc.perms)
+				if _, err := parseClientListFilter(c); err != nil {
+					return c.Status(400).SendString(err.Error())
+				}
+				return c.SendStatus(200)
+			})
+			resp, err := app.Test(httptest.NewRequest("GET", "/clients?sort="+tc.sort, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Fatalf("sort=%s: status %d, want %d", tc.sort, resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type QuestionnaireHandlers struct {
+	questionnaireService *service.QuestionnaireService
+}
+
+func NewQuestionnaireHandlers(questionnaireService *service.QuestionnaireService) *QuestionnaireHandlers {
+	return &QuestionnaireHandlers{questionnaireService: questionnaireService}
+}
+
+// GetCurrentQuestionnaireSchema godoc
+// @Summary Get the current questionnaire schema
+// @Description JSON Schema of the second part questionnaire that drafts are validated against and submissions must match. Keys prefixed with x- are UI hints and do not affect validation.
+// @Tags questionnaire-schemas
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.QuestionnaireSchema "Current schema"
+// @Failure 404 {object} models.ErrorResponse "No schema published"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /questionnaire-schemas/current [get]
+func (h *QuestionnaireHandlers) GetCurrentQuestionnaireSchema(c *fiber.Ctx) error {
+	s, err := h.questionnaireService.Current()
+	if errors.Is(err, models.ErrNoQuestionnaireSchema) {
+		return c.Status(404).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(s)
+}
+
+// GetQuestionnaireSchema godoc
+// @Summary Get a questionnaire schema version
+// @Description Schema version referenced by schema_version of a second part version.
+// @Tags questionnaire-schemas
+// @Produce json
+// @Security BearerAuth
+// @Param version path int true "Schema version"
+// @Success 200 {object} models.QuestionnaireSchema "Schema"
+// @Failure 400 {object} models.ErrorResponse "Invalid version"
+// @Failure 404 {object} models.ErrorResponse "Schema version not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /questionnaire-schemas/{version} [get]
+func (h *QuestionnaireHandlers) GetQuestionnaireSchema(c *fiber.Ctx) error {
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil || version < 1 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid schema version"})
+	}
+
+	s, err := h.questionnaireService.Get(version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "schema version not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(s)
+}
+
+// ListQuestionnaireSchemas godoc
+// @Summary List questionnaire schema versions
+// @Description Published schema versions without their content, newest (current) first.
+// @Tags questionnaire-schemas
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.QuestionnaireSchemaInfo "Schema versions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /questionnaire-schemas [get]
+func (h *QuestionnaireHandlers) ListQuestionnaireSchemas(c *fiber.Ctx) error {
+	list, err := h.questionnaireService.List()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// CreateQuestionnaireSchema godoc
+// @Summary Publish a questionnaire schema version
+// @Description Publish a new schema version; it becomes current immediately. Supported keywords: type, properties, required, additionalProperties, enum, const, minLength, maxLength, pattern, format (date, date-time, email), minimum, maximum, exclusiveMinimum, exclusiveMaximum, items, minItems, maxItems, uniqueItems; annotations and x- keys are allowed.
+// @Description Existing second part versions keep the schema_version they were validated against.
+// @Tags questionnaire-schemas
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateQuestionnaireSchemaRequest true "Schema"
+// @Success 201 {object} models.QuestionnaireSchema "Published schema"
+// @Failure 400 {object} models.ErrorResponse "Invalid schema"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /questionnaire-schemas [post]
+func (h *QuestionnaireHandlers) CreateQuestionnaireSchema(c *fiber.Ctx) error {
+	var req models.CreateQuestionnaireSchemaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid request body"})
+	}
+
+	s, err := h.questionnaireService.Create(middleware.GetAuditActor(c), req)
+	if errors.Is(err, models.ErrInvalidQuestionnaireSchema) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.Status(201).JSON(s)
+}
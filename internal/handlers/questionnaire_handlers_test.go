@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"vector/internal/pkg/jsonschema"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetQuestionnaireSchemaVersion(t *testing.T) {
+	questionnaire, _ := testQuestionnaire(t)
+	h := NewQuestionnaireHandlers(questionnaire)
+	app := fiber.New()
+	app.Get("/questionnaire-schemas/:version", h.GetQuestionnaireSchema)
+
+	cases := map[string]int{
+		"/questionnaire-schemas/1":   200,
+		"/questionnaire-schemas/2":   404,
+		"/questionnaire-schemas/99":  404,
+		"/questionnaire-schemas/0":   400,
+		"/questionnaire-schemas/abc": 400,
+	}
+	for path, want := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestSubmitSecondPartValidatesQuestionnaire(t *testing.T) {
+	app, clients := testAppHandlers(t, `{"position":"rich"}`)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/clients/7/second-part/submit", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 422 {
+		t.Fatalf("submit invalid draft = %d, want 422", resp.StatusCode)
+	}
+	var body struct {
+		SchemaVersion    int                          `json:"schema_version"`
+		ValidationErrors []jsonschema.ValidationError `json:"validation_errors"`
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	if body.SchemaVersion != 1 || len(body.ValidationErrors) != 2 ||
+		body.ValidationErrors[0].Path != "/position" || body.ValidationErrors[1].Path != "/purpose" {
+		t.Fatalf("422 body = %s", raw)
+	}
+	if clients.sp.Status != "draft" {
+		t.Fatal("invalid draft was submitted")
+	}
+
+	clients.sp.Data = []byte(`{"purpose":"инвестиции","position":"stable"}`)
+	resp, _ = app.Test(httptest.NewRequest("POST", "/clients/7/second-part/submit", nil))
+	if resp.StatusCode != 200 || clients.sp.Status != "submitted" {
+		t.Fatalf("submit valid draft = %d, status %s", resp.StatusCode, clients.sp.Status)
+	}
+
+	resp, _ = app.Test(httptest.NewRequest("POST", "/clients/7/second-part/submit", nil))
+	if resp.StatusCode != 409 {
+		t.Fatalf("resubmit = %d, want 409", resp.StatusCode)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReviewPolicyHandlers struct {
+	reviewPolicyService *service.ReviewPolicyService
+}
+
+func NewReviewPolicyHandlers(reviewPolicyService *service.ReviewPolicyService) *ReviewPolicyHandlers {
+	return &ReviewPolicyHandlers{reviewPolicyService: reviewPolicyService}
+}
+
+// ListReviewPolicies godoc
+// @Summary List second part review periods
+// @Description Review period (months until the next second part review) per risk level with effective-from dates. effective contains the periods in force today; the levels listed there are the ones a risk decision may use.
+// @Tags review-policies
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.ListReviewPoliciesResponse "Review periods"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /review-policies [get]
+func (h *ReviewPolicyHandlers) ListReviewPolicies(c *fiber.Ctx) error {
+	all, effective, err := h.reviewPolicyService.List()
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(models.ListReviewPoliciesResponse{Success: true, Policies: all, Effective: effective})
+}
+
+// CreateReviewPolicy godoc
+// @Summary Add a review period for a risk level
+// @Description Set the review period for a risk level (existing or new) from effective_from (today or later). Second parts approved after that date get due_at from the new period; to recompute already approved second parts run migrate -action=recompute-due-at.
+// @Tags review-policies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.CreateReviewPolicyRequest true "Review period"
+// @Success 201 {object} models.ReviewPolicy "Created review period"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 409 {object} models.ErrorResponse "Period for the level and date already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /review-policies [post]
+func (h *ReviewPolicyHandlers) CreateReviewPolicy(c *fiber.Ctx) error {
+	var req models.CreateReviewPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "invalid request body"})
+	}
+
+	p, err := h.reviewPolicyService.Create(middleware.GetAuditActor(c), req)
+	switch {
+	case errors.Is(err, models.ErrInvalidReviewPolicy):
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, models.ErrReviewPolicyExists):
+		return c.Status(409).JSON(models.ErrorResponse{Error: err.Error()})
+	case err != nil:
+		return c.Status(500).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	return c.Status(201).JSON(p)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type RoleHandlers struct {
+	roleService *service.RoleService
+}
+
+func NewRoleHandlers(roleService *service.RoleService) *RoleHandlers {
+	return &RoleHandlers{
+		roleService: roleService,
+	}
+}
+
+func roleErrorResponse(c *fiber.Ctx, err error) error {
+	status, msg := 500, err.Error()
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		status, msg = 404, "Роль не найдена"
+	case errors.Is(err, service.ErrRoleSystem), errors.Is(err, models.ErrRoleInUse):
+		status = 409
+	case errors.Is(err, service.ErrRoleInvalid):
+		status = 400
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":   msg,
+		"success": false,
+	})
+}
+
+// GetRoles godoc
+// @Summary Получить список доступных ролей
+// @Description Получить список всех доступных ролей в системе
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Список ролей"
+// @Router /auth/roles [get]
+func (h *RoleHandlers) GetRoles(c *fiber.Ctx) error {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	out := make([]map[string]string, 0, len(roles))
+	for _, r := range roles {
+		out = append(out, map[string]string{
+			"value":       r.Name,
+			"label":       r.Label,
+			"description": r.Description,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    out,
+	})
+}
+
+// ListPermissions godoc
+// @Summary Справочник прав и права ролей (только для управляющих ролями)
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.PermissionMatrix "Права и роли"
+// @Router /auth/permissions [get]
+func (h *RoleHandlers) ListPermissions(c *fiber.Ctx) error {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": models.PermissionMatrix{
+			Permissions: models.Permissions,
+			Roles:       roles,
+		},
+	})
+}
+
+// GetRole godoc
+// @Summary Роль с правами (только для управляющих ролями)
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Имя роли"
+// @Success 200 {object} models.RoleResponse "Роль"
+// @Failure 404 {object} map[string]interface{} "Роль не найдена"
+// @Router /auth/roles/{name} [get]
+func (h *RoleHandlers) GetRole(c *fiber.Ctx) error {
+	role, err := h.roleService.GetRole(c.Params("name"))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// CreateRole godoc
+// @Summary Создать роль (только для управляющих ролями)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RoleCreateRequest true "Роль и ее права"
+// @Success 201 {object} models.RoleResponse "Роль создана"
+// @Failure 400 {object} map[string]interface{} "Неверные данные или роль уже существует"
+// @Router /auth/roles [post]
+func (h *RoleHandlers) CreateRole(c *fiber.Ctx) error {
+	var req models.RoleCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат данных", "success": false})
+	}
+
+	role, err := h.roleService.CreateRole(middleware.GetAuditActor(c), req)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// UpdateRole godoc
+// @Summary Изменить роль и ее права (только для управляющих ролями)
+// @Description permissions заменяет набор прав целиком; права роли Administrator не меняются (у нее все права)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Имя роли"
+// @Param request body models.RoleUpdateRequest true "Изменения"
+// @Success 200 {object} models.RoleResponse "Роль изменена"
+// @Failure 400 {object} map[string]interface{} "Неверные данные"
+// @Failure 404 {object} map[string]interface{} "Роль не найдена"
+// @Failure 409 {object} map[string]interface{} "Права администратора не редактируются"
+// @Router /auth/roles/{name} [patch]
+func (h *RoleHandlers) UpdateRole(c *fiber.Ctx) error {
+	var req models.RoleUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Неверный формат данных", "success": false})
+	}
+
+	role, err := h.roleService.UpdateRole(middleware.GetAuditActor(c), c.Params("name"), req)
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// DeleteRole godoc
+// @Summary Удалить роль (только для управляющих ролями)
+// @Description Системные роли и роли, назначенные пользователям, не удаляются
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Имя роли"
+// @Success 200 {object} map[string]interface{} "Роль удалена"
+// @Failure 404 {object} map[string]interface{} "Роль не найдена"
+// @Failure 409 {object} map[string]interface{} "Системная роль или роль назначена пользователям"
+// @Router /auth/roles/{name} [delete]
+func (h *RoleHandlers) DeleteRole(c *fiber.Ctx) error {
+	if err := h.roleService.DeleteRole(middleware.GetAuditActor(c), c.Params("name")); err != nil {
+		return roleErrorResponse(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Роль удалена",
+	})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"vector/internal/middleware"
 	"vector/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -60,7 +62,14 @@ func (h *SyncHandlers) SyncFull(c *fiber.Ctx) error {
 	resp, err := h.fullSyncService.SyncFull(c.UserContext(), service.FullSyncRequest{
 		PerPage:       perPage,
 		SyncContracts: true,
+		TriggeredBy:   middleware.GetAuditActor(c).Email,
 	})
+	if errors.Is(err, service.ErrSyncInProgress) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	}
 	if err != nil {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"strings"
+	"vector/internal/models"
+	"vector/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// auditEntityRoutes сопоставляет роуты с :id сущностям журнала аудита
+var auditEntityRoutes = []struct {
+	prefix string
+	entity string
+}{
+	{"/clients/", models.AuditEntityClient},
+	{"/contracts/", models.AuditEntityContract},
+	{"/auth/users/", models.AuditEntityUser},
+}
+
+// AuditTrail записывает в журнал аудита каждый запрос аутентифицированного пользователя.
+// Подключается после JWTMiddleware, но до проверки ролей, чтобы отказы в доступе тоже попадали в журнал.
+func AuditTrail(audit *service.AuditService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+
+		entry := service.AuditEntry{
+			Action: models.AuditActionHTTPRequest,
+			Method: c.Method(),
+			Path:   utils.CopyString(c.Path()),
+			Status: status,
+			Metadata: map[string]any{
+				"route": c.Route().Path,
+			},
+		}
+		if q := c.Request().URI().QueryString(); len(q) > 0 {
+			entry.Metadata["query"] = string(q)
+		}
+		if id := c.Params("id"); id != "" {
+			for _, r := range auditEntityRoutes {
+				if strings.HasPrefix(c.Route().Path, r.prefix) {
+					entry.EntityType = r.entity
+					entry.EntityID = utils.CopyString(id)
+					break
+				}
+			}
+		}
+
+		audit.RecordAfter(GetAuditActor(c), entry)
+		return err
+	}
+}
+
+// GetAuditActor автор действия для журнала аудита: пользователь или API-ключ (если аутентифицирован), IP и request id
+func GetAuditActor(c *fiber.Ctx) models.AuditActor {
+	actor := models.AuditActor{
+		IP:        utils.CopyString(c.IP()),
+		UserAgent: utils.CopyString(c.Get(fiber.HeaderUserAgent)),
+	}
+	if rid, ok := c.Locals("requestid").(string); ok {
+		actor.RequestID = rid
+	}
+	if user, ok := c.Locals("user").(models.AppUser); ok {
+		id := user.ID
+		actor.UserID = &id
+		actor.Email = user.Email
+		actor.Role = user.Role
+	} else if key, ok := c.Locals("api_key").(models.APIKey); ok {
+		actor.Email = key.ActorEmail()
+		actor.Role = models.APIKeyActorRole
+	}
+	return actor
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"errors"
+	"math"
+	"strconv"
 	"strings"
 	"vector/internal/models"
 	"vector/internal/service"
@@ -8,9 +11,25 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// JWTMiddleware middleware для проверки JWT токенов
+// apiKeyFromRequest API-ключ из заголовка X-API-Key или Authorization: ApiKey <ключ>
+func apiKeyFromRequest(c *fiber.Ctx) string {
+	if key := c.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := c.Get("Authorization"); strings.HasPrefix(auth, "ApiKey ") {
+		return strings.TrimPrefix(auth, "ApiKey ")
+	}
+	return ""
+}
+
+// JWTMiddleware middleware для проверки JWT токенов.
+// Вместо токена можно передать API-ключ: запрос выполняется от имени ключа с его правами.
 func JWTMiddleware(authService *service.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if raw := apiKeyFromRequest(c); raw != "" {
+			return authenticateAPIKey(c, authService, raw)
+		}
+
 		auth := c.Get("Authorization")
 		if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
 			return c.Status(401).JSON(fiber.Map{
@@ -21,7 +40,7 @@ func JWTMiddleware(authService *service.AuthService) fiber.Handler {
 
 		token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
 
-		user, err := authService.ValidateToken(token)
+		user, sessionID, err := authService.ValidateToken(token)
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{
 				"error":   err.Error(),
@@ -29,50 +48,91 @@ func JWTMiddleware(authService *service.AuthService) fiber.Handler {
 			})
 		}
 
-		// Сохраняем пользователя в контексте запроса
+		// Сохраняем пользователя и сессию в контексте запроса
 		c.Locals("user", *user)
+		c.Locals("session_id", sessionID)
 		return c.Next()
 	}
 }
 
-// RequireRole middleware для проверки роли пользователя
-func RequireRole(allowedRoles ...string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		user, ok := c.Locals("user").(models.AppUser)
-		if !ok {
-			return c.Status(401).JSON(fiber.Map{
-				"error":   "пользователь не аутентифицирован",
-				"success": false,
-			})
-		}
-
-		// Проверяем, есть ли роль пользователя среди разрешенных
-		for _, allowedRole := range allowedRoles {
-			if user.Role == allowedRole {
-				return c.Next()
-			}
-		}
-
-		return c.Status(403).JSON(fiber.Map{
-			"error":   "недостаточно прав доступа",
+func authenticateAPIKey(c *fiber.Ctx, authService *service.AuthService, raw string) error {
+	key, rate, err := authService.AuthenticateAPIKey(raw, c.IP())
+	if rate.Limit > 0 {
+		c.Set("X-RateLimit-Limit", strconv.Itoa(rate.Limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(rate.Remaining))
+	}
+	switch {
+	case errors.Is(err, service.ErrAPIKeyRateLimited):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(rate.RetryAfter.Seconds()))))
+		return c.Status(429).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	case errors.Is(err, service.ErrAPIKeyInvalid):
+		return c.Status(401).JSON(fiber.Map{
+			"error":   err.Error(),
+			"success": false,
+		})
+	case err != nil:
+		return c.Status(503).JSON(fiber.Map{
+			"error":   "не удалось проверить API-ключ",
 			"success": false,
 		})
 	}
+
+	c.Locals("api_key", key)
+	return c.Next()
 }
 
-// RequireAdmin middleware для проверки прав администратора
-func RequireAdmin() fiber.Handler {
-	return RequireRole(models.RoleAdministrator)
+// GetAPIKey ключ, которым аутентифицирован запрос
+func GetAPIKey(c *fiber.Ctx) (models.APIKey, bool) {
+	key, ok := c.Locals("api_key").(models.APIKey)
+	return key, ok
 }
 
-// RequireAdminOrPodft middleware для администратора или отдела ПОДФТ
-func RequireAdminOrPodft() fiber.Handler {
-	return RequireRole(models.RoleAdministrator, models.RolePodft)
+// RequirePermission пропускает пользователя, роль которого имеет все перечисленные права
+// (для API-ключа — права ключа). Права сохраняются в контексте запроса (см. GetPermissions).
+func RequirePermission(roles *service.RoleService, permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var perms models.PermissionSet
+		if key, ok := GetAPIKey(c); ok {
+			perms = key.ScopeSet()
+		} else {
+			user, ok := c.Locals("user").(models.AppUser)
+			if !ok {
+				return c.Status(401).JSON(fiber.Map{
+					"error":   "пользователь не аутентифицирован",
+					"success": false,
+				})
+			}
+
+			var err error
+			if perms, err = roles.Permissions(user.Role); err != nil {
+				return c.Status(503).JSON(fiber.Map{
+					"error":   "не удалось проверить права доступа",
+					"success": false,
+				})
+			}
+		}
+		c.Locals("permissions", perms)
+
+		for _, p := range permissions {
+			if !perms.Has(p) {
+				return c.Status(403).JSON(fiber.Map{
+					"error":      "недостаточно прав доступа",
+					"permission": p,
+					"success":    false,
+				})
+			}
+		}
+		return c.Next()
+	}
 }
 
-// RequireAnyRole middleware для любой роли (только аутентифицированные пользователи)
-func RequireAnyRole() fiber.Handler {
-	return RequireRole(models.RoleAdministrator, models.RolePodft, models.RoleClientManagement)
+// GetPermissions права текущего пользователя, загруженные RequirePermission
+func GetPermissions(c *fiber.Ctx) models.PermissionSet {
+	perms, _ := c.Locals("permissions").(models.PermissionSet)
+	return perms
 }
 
 // GetCurrentUser helper функция для получения текущего пользователя из контекста
@@ -83,3 +143,41 @@ func GetCurrentUser(c *fiber.Ctx) (*models.AppUser, error) {
 	}
 	return &user, nil
 }
+
+// RequirePasswordChanged не пускает пользователя, которому нужно сменить временный пароль.
+// Профиль, смена пароля и выход остаются доступны.
+func RequirePasswordChanged() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(models.AppUser)
+		if ok && user.MustChangePassword {
+			return c.Status(403).JSON(fiber.Map{
+				"error":                    "необходимо сменить пароль",
+				"password_change_required": true,
+				"success":                  false,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// RequireMFAEnrolled не пускает к данным пользователя, для роли которого 2FA обязательна,
+// пока он ее не подключит. Профиль, подключение 2FA и выход остаются доступны.
+func RequireMFAEnrolled(authService *service.AuthService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := c.Locals("user").(models.AppUser)
+		if ok && authService.MFAEnrollmentRequired(user) {
+			return c.Status(403).JSON(fiber.Map{
+				"error":                   "необходимо подключить двухфакторную аутентификацию",
+				"mfa_enrollment_required": true,
+				"success":                 false,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// GetSessionID идентификатор сессии (sid) текущего access-токена
+func GetSessionID(c *fiber.Ctx) string {
+	sid, _ := c.Locals("session_id").(string)
+	return sid
+}
@@ -1,9 +1,14 @@
 package migrations
 
 import (
+	_ "embed"
+	"errors"
 	"fmt"
 	"log"
+	"time"
+	"vector/internal/config"
 	"vector/internal/db"
+	appdb "vector/internal/db/app"
 	"vector/internal/models"
 	"vector/internal/repository"
 
@@ -33,14 +38,34 @@ func (m *Migrator) MigrateAll() error {
 		return fmt.Errorf("core clients migration failed: %w", err)
 	}
 
+	if err := m.MigrateClientSearch(); err != nil {
+		return fmt.Errorf("client search migration failed: %w", err)
+	}
+
 	if err := m.MigrateCoreSecondPart(); err != nil {
 		return fmt.Errorf("core second part migration failed: %w", err)
 	}
 
+	if err := m.MigrateCoreReviewPolicies(); err != nil {
+		return fmt.Errorf("core review policies migration failed: %w", err)
+	}
+
+	if err := m.MigrateCoreQuestionnaireSchemas(); err != nil {
+		return fmt.Errorf("core questionnaire schemas migration failed: %w", err)
+	}
+
+	if err := m.MigrateCoreSecondPartRequirements(); err != nil {
+		return fmt.Errorf("core second part requirements migration failed: %w", err)
+	}
+
 	if err := m.MigrateCoreUsers(); err != nil {
 		return fmt.Errorf("core users migration failed: %w", err)
 	}
 
+	if err := m.MigrateCoreRoles(); err != nil {
+		return fmt.Errorf("core roles migration failed: %w", err)
+	}
+
 	if err := m.MigrateCoreChecks(); err != nil {
 		return fmt.Errorf("core checks migration failed: %w", err)
 	}
@@ -49,6 +74,22 @@ func (m *Migrator) MigrateAll() error {
 		return fmt.Errorf("core contracts migration failed: %w", err)
 	}
 
+	if err := m.MigrateCoreExports(); err != nil {
+		return fmt.Errorf("core exports migration failed: %w", err)
+	}
+
+	if err := m.MigrateCorePiiReveal(); err != nil {
+		return fmt.Errorf("core pii reveal migration failed: %w", err)
+	}
+
+	if err := m.MigrateCoreAudit(); err != nil {
+		return fmt.Errorf("core audit migration failed: %w", err)
+	}
+
+	if err := m.MigrateCoreJobs(); err != nil {
+		return fmt.Errorf("core jobs migration failed: %w", err)
+	}
+
 	log.Println("All migrations completed successfully")
 	return nil
 }
@@ -103,6 +144,73 @@ func (m *Migrator) MigrateCoreClients() error {
 		}
 	}
 
+	// Даты в Raw приходят как DD.MM.YYYY или YYYY-MM-DD[...]; некорректная дата дает NULL, а не ошибку запроса
+	if err := m.db.Exec(`CREATE OR REPLACE FUNCTION core.parse_client_date(s text) RETURNS date AS $$
+	BEGIN
+		s := btrim(s);
+		IF s ~ '^\d{2}\.\d{2}\.\d{4}$' THEN
+			RETURN to_date(s, 'DD.MM.YYYY');
+		ELSIF s ~ '^\d{4}-\d{2}-\d{2}' THEN
+			RETURN to_date(left(s, 10), 'YYYY-MM-DD');
+		END IF;
+		RETURN NULL;
+	EXCEPTION WHEN others THEN
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql STABLE`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MigrateClientSearch создает pg_trgm и индексы полнотекстового/нечеткого поиска по текущим версиям
+func (m *Migrator) MigrateClientSearch() error {
+	log.Println("Migrating client search indexes...")
+	if err := m.db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+
+	queries := []string{
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_name_tsv
+		 ON core.clients_versions USING GIN (` + appdb.ClientNameTSVExpr + `)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_name_trgm
+		 ON core.clients_versions USING GIN (` + appdb.ClientFullNameExpr + ` gin_trgm_ops)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_email_trgm
+		 ON core.clients_versions USING GIN (lower(contact_email) gin_trgm_ops)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_login_trgm
+		 ON core.clients_versions USING GIN (lower(login) gin_trgm_ops)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_phone_norm
+		 ON core.clients_versions (` + appdb.ClientPhoneExpr + `)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_snils_norm
+		 ON core.clients_versions (` + appdb.ClientSnilsExpr + `)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_passport_norm
+		 ON core.clients_versions (` + appdb.ClientPassportExpr + `)
+		 WHERE is_current = true`,
+
+		`CREATE INDEX IF NOT EXISTS idx_clients_versions_pass_number
+		 ON core.clients_versions (pass_number)
+		 WHERE is_current = true`,
+	}
+
+	for _, query := range queries {
+		if err := m.db.Exec(query).Error; err != nil {
+			log.Printf("Warning: could not create search index: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -111,7 +219,7 @@ func (m *Migrator) MigrateCoreSecondPart() error {
 	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
 		return err
 	}
-	if err := m.db.AutoMigrate(&models.SecondPartVersion{}); err != nil {
+	if err := m.db.AutoMigrate(&models.SecondPartVersion{}, &models.SecondPartChange{}); err != nil {
 		return err
 	}
 	if err := m.db.Exec(`
@@ -122,13 +230,126 @@ func (m *Migrator) MigrateCoreSecondPart() error {
 		return err
 	}
 
-	return m.db.Exec(`
+	if err := m.db.Exec(`
 		CREATE INDEX IF NOT EXISTS idx_sp_client_version_current
 		ON core.second_part_versions (client_id, client_version)
 		WHERE is_current = true
+	`).Error; err != nil {
+		return err
+	}
+
+	return m.db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_sp_due_at_current
+		ON core.second_part_versions (due_at)
+		WHERE is_current = true AND due_at IS NOT NULL
 	`).Error
 }
 
+// MigrateCoreReviewPolicies создает таблицу сроков пересмотра второй части. Уровням без записей
+// назначаются прежние сроки (DefaultReviewPolicies), действующие с reviewPoliciesEpoch.
+func (m *Migrator) MigrateCoreReviewPolicies() error {
+	log.Println("Migrating core review policies table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	if err := m.db.AutoMigrate(&models.ReviewPolicy{}); err != nil {
+		return err
+	}
+
+	for _, p := range models.DefaultReviewPolicies {
+		if err := m.db.Exec(`
+			INSERT INTO core.second_part_review_policies (risk_level, interval_months, effective_from, comment, created_at)
+			SELECT ?, ?, ?::date, ?, NOW()
+			WHERE NOT EXISTS (SELECT 1 FROM core.second_part_review_policies WHERE risk_level = ?)`,
+			p.RiskLevel, p.IntervalMonths, reviewPoliciesEpoch, p.Comment, p.RiskLevel).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reviewPoliciesEpoch дата начала действия исходных сроков: раньше любой второй части
+var reviewPoliciesEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//go:embed questionnaire_v1.json
+var questionnaireSchemaV1 []byte
+
+// MigrateCoreQuestionnaireSchemas создает таблицу версий схемы анкеты второй части и, если она пуста,
+// записывает исходную схему версией 1. Существующие версии второй части остаются без schema_version.
+func (m *Migrator) MigrateCoreQuestionnaireSchemas() error {
+	log.Println("Migrating core questionnaire schemas table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	if err := m.db.AutoMigrate(&models.QuestionnaireSchema{}); err != nil {
+		return err
+	}
+	return m.db.Exec(`
+		INSERT INTO core.second_part_schemas (version, title, schema, comment, created_at)
+		SELECT 1, ?, ?::jsonb, ?, NOW()
+		WHERE NOT EXISTS (SELECT 1 FROM core.second_part_schemas)`,
+		"Анкета второй части", string(questionnaireSchemaV1), "Исходная схема").Error
+}
+
+// MigrateCoreSecondPartRequirements создает таблицу причин needs_second_part. Клиентам, у которых
+// признак уже выставлен, причина восстанавливается по текущему состоянию; колонка
+// needs_second_part_reason заменяется таблицей и удаляется.
+func (m *Migrator) MigrateCoreSecondPartRequirements() error {
+	log.Println("Migrating core second part requirements table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	if err := m.db.AutoMigrate(&models.SecondPartRequirement{}); err != nil {
+		return err
+	}
+	if err := m.db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_sp_requirements_open
+		ON core.second_part_requirements (client_id, reason)
+		WHERE resolved_at IS NULL
+	`).Error; err != nil {
+		return err
+	}
+	if err := m.db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_sp_requirements_reason_open
+		ON core.second_part_requirements (reason)
+		WHERE resolved_at IS NULL
+	`).Error; err != nil {
+		return err
+	}
+
+	passportReason := "NULL"
+	if m.db.Migrator().HasColumn(&models.ClientVersion{}, "needs_second_part_reason") {
+		passportReason = "c.needs_second_part_reason"
+	}
+	if err := m.db.Exec(`
+		INSERT INTO core.second_part_requirements (client_id, reason, client_version, detected_at)
+		SELECT c.client_id,
+		  CASE
+		    WHEN `+passportReason+` = ? THEN ?
+		    WHEN sp.client_id IS NULL THEN ?
+		    WHEN sp.due_at IS NOT NULL AND sp.due_at <= NOW() THEN ?
+		    ELSE ?
+		  END,
+		  c.version,
+		  NOW()
+		FROM core.clients_versions c
+		LEFT JOIN core.second_part_versions sp
+		  ON sp.client_id = c.client_id AND sp.is_current = true
+		WHERE c.is_current = true
+		  AND c.needs_second_part = true
+		  AND NOT EXISTS (
+		    SELECT 1 FROM core.second_part_requirements r
+		    WHERE r.client_id = c.client_id AND r.resolved_at IS NULL
+		  )`,
+		models.RequirementPassportExpired, models.RequirementPassportExpired,
+		models.RequirementNewClient, models.RequirementQuestionnaireExpired, models.RequirementDataChanged,
+	).Error; err != nil {
+		return err
+	}
+
+	return m.db.Exec(`ALTER TABLE core.clients_versions DROP COLUMN IF EXISTS needs_second_part_reason`).Error
+}
+
 func (m *Migrator) MigrateCoreUsers() error {
 	log.Println("Migrating core users tables...")
 	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
@@ -136,7 +357,7 @@ func (m *Migrator) MigrateCoreUsers() error {
 	}
 
 	// Миграция таблицы пользователей с новой структурой (JWT)
-	if err := m.db.AutoMigrate(&models.AppUser{}); err != nil {
+	if err := m.db.AutoMigrate(&models.AppUser{}, &models.PasswordHistory{}, &models.AuthSession{}, &models.AuthThrottle{}, &models.MFARecoveryCode{}, &models.MFAUsedToken{}, &models.OIDCLoginState{}, &models.APIKey{}, &models.APIKeyRateWindow{}); err != nil {
 		return err
 	}
 
@@ -158,6 +379,41 @@ func (m *Migrator) MigrateCoreUsers() error {
 		}
 	}
 
+	// срок действия пароля существующих пользователей отсчитывается от последнего изменения записи
+	return m.db.Exec(`
+		UPDATE core.app_users
+		SET password_changed_at = COALESCE(updated_at, now())
+		WHERE password_changed_at IS NULL AND auth_provider = 'local'
+	`).Error
+}
+
+// MigrateCoreRoles создает справочник ролей и прав, исходные роли и связь пользователей с ролями
+func (m *Migrator) MigrateCoreRoles() error {
+	log.Println("Migrating core roles tables...")
+	if err := m.db.AutoMigrate(&models.Role{}, &models.RolePermission{}); err != nil {
+		return err
+	}
+	if err := appdb.SeedRoles(m.db, models.DefaultRoles); err != nil {
+		return err
+	}
+
+	queries := []string{
+		`DO $$ BEGIN
+			ALTER TABLE core.role_permissions
+				ADD CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_name) REFERENCES core.roles (name) ON DELETE CASCADE;
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+		`DO $$ BEGIN
+			ALTER TABLE core.app_users
+				ADD CONSTRAINT fk_app_users_role FOREIGN KEY (role) REFERENCES core.roles (name);
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+	}
+	for _, query := range queries {
+		if err := m.db.Exec(query).Error; err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -198,6 +454,12 @@ func (m *Migrator) MigrateCoreContracts() error {
 
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_contracts_external_id
 		 ON core.contracts (external_id)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_contracts_signed_at
+		 ON core.contracts (signed_at)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_contracts_closed_at
+		 ON core.contracts (closed_at)`,
 	}
 
 	for _, query := range queries {
@@ -209,18 +471,110 @@ func (m *Migrator) MigrateCoreContracts() error {
 	return nil
 }
 
+func (m *Migrator) MigrateCoreExports() error {
+	log.Println("Migrating core export logs table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	return m.db.AutoMigrate(&models.ExportLog{})
+}
+
+func (m *Migrator) MigrateCorePiiReveal() error {
+	log.Println("Migrating core pii reveal logs table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	return m.db.AutoMigrate(&models.PiiRevealLog{})
+}
+
+// MigrateCoreJobs создает журнал запусков фоновых задач
+func (m *Migrator) MigrateCoreJobs() error {
+	log.Println("Migrating core job runs table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	if err := m.db.AutoMigrate(&models.JobRun{}); err != nil {
+		return err
+	}
+	// запуск по расписанию выполняется один раз за слот: повторный запуск слота возможен только после неудачи
+	return m.db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_job_runs_slot
+		ON core.job_runs (job, scheduled_at)
+		WHERE scheduled_at IS NOT NULL AND status IN ('running', 'succeeded')`).Error
+}
+
+// MigrateCoreAudit создает журнал аудита. Таблица только на добавление:
+// триггеры запрещают UPDATE, DELETE и TRUNCATE даже владельцу таблицы,
+// а подмену строк в обход триггеров выявляет проверка цепочки хешей (-action=audit-verify).
+func (m *Migrator) MigrateCoreAudit() error {
+	log.Println("Migrating core audit events table...")
+	if err := m.db.Exec("CREATE SCHEMA IF NOT EXISTS core").Error; err != nil {
+		return err
+	}
+	if err := m.db.AutoMigrate(&models.AuditEvent{}); err != nil {
+		return err
+	}
+
+	queries := []string{
+		`CREATE OR REPLACE FUNCTION core.audit_events_immutable() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'core.audit_events is append-only: % is not allowed', TG_OP;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_no_update ON core.audit_events`,
+		`CREATE TRIGGER audit_events_no_update
+			BEFORE UPDATE OR DELETE ON core.audit_events
+			FOR EACH ROW EXECUTE FUNCTION core.audit_events_immutable()`,
+		`DROP TRIGGER IF EXISTS audit_events_no_truncate ON core.audit_events`,
+		`CREATE TRIGGER audit_events_no_truncate
+			BEFORE TRUNCATE ON core.audit_events
+			FOR EACH STATEMENT EXECUTE FUNCTION core.audit_events_immutable()`,
+		`REVOKE UPDATE, DELETE, TRUNCATE ON core.audit_events FROM PUBLIC`,
+	}
+	for _, query := range queries {
+		if err := m.db.Exec(query).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyAudit проверяет целостность цепочки хешей журнала аудита
+func (m *Migrator) VerifyAudit() (int64, *appdb.AuditChainBreak, error) {
+	return appdb.VerifyAuditChain(m.db, 1000)
+}
+
 func (m *Migrator) SeedUsers() error {
 	log.Println("Seeding default users...")
 
 	// Создаем репозиторий пользователей
 	userRepo := repository.NewUserRepository(m.db)
 
-	// Используем метод Seed из репозитория
-	if err := userRepo.Seed(); err != nil {
+	seed, err := config.GetAdminSeedConfig()
+	if err != nil {
+		return fmt.Errorf("failed to prepare admin seed: %w", err)
+	}
+
+	// Администратор создается, только если нет ни одного активного
+	created, err := userRepo.Seed(seed.Email, seed.Password)
+	if errors.Is(err, models.ErrAdminSeedEmailTaken) {
+		return fmt.Errorf("no active administrator and the initial admin cannot be seeded: %w; restore that account manually or set ADMIN_EMAIL to an unused address", err)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to seed users: %w", err)
 	}
 
-	log.Println("✅ Default users seeded successfully")
+	switch {
+	case !created:
+		log.Println("✅ Active administrator already exists, nothing to seed")
+	case seed.Generated:
+		where, err := seed.RevealGeneratedPassword()
+		if err != nil {
+			return fmt.Errorf("initial admin %s seeded, but the generated password could not be delivered: %w", seed.Email, err)
+		}
+		log.Printf("✅ Initial admin user seeded: %s (generated password written to %s, change required on first login)", seed.Email, where)
+	default:
+		log.Printf("✅ Initial admin user seeded: %s (password change required on first login)", seed.Email)
+	}
 	return nil
 }
 
@@ -0,0 +1,363 @@
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+	"vector/internal/models"
+	"vector/internal/pkg/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	ReparseEntityClients   = "clients"
+	ReparseEntityContracts = "contracts"
+	ReparseEntityAll       = "all"
+)
+
+// ReparseOptions параметры пересчета типизированных колонок из Raw
+type ReparseOptions struct {
+	Entity      string
+	FromVersion int // 0 — без ограничения
+	ToVersion   int // 0 — без ограничения
+	BatchSize   int
+	DryRun      bool
+}
+
+// ReparseStats итоги пересчета по одной сущности
+type ReparseStats struct {
+	Entity        string
+	Scanned       int
+	Changed       int
+	Unparsable    int
+	ChangedFields map[string]int
+}
+
+// Reparse пересчитывает типизированные колонки из сохраненного Raw
+// для всех хранимых версий. Новые версии не создаются.
+func (m *Migrator) Reparse(opts ReparseOptions) ([]ReparseStats, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.FromVersion > 0 && opts.ToVersion > 0 && opts.FromVersion > opts.ToVersion {
+		return nil, fmt.Errorf("from-version (%d) is greater than to-version (%d)", opts.FromVersion, opts.ToVersion)
+	}
+
+	var out []ReparseStats
+	switch opts.Entity {
+	case ReparseEntityClients:
+		st, err := m.reparseClients(opts)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	case ReparseEntityContracts:
+		st, err := m.reparseContracts(opts)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	case ReparseEntityAll, "":
+		st, err := m.reparseClients(opts)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+		st, err = m.reparseContracts(opts)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	default:
+		return nil, fmt.Errorf("unknown entity %q (expected clients, contracts or all)", opts.Entity)
+	}
+
+	return out, nil
+}
+
+func (m *Migrator) reparseClients(opts ReparseOptions) (ReparseStats, error) {
+	stats := ReparseStats{Entity: ReparseEntityClients, ChangedFields: map[string]int{}}
+	log.Printf("Reparsing client versions (batch=%d, from=%d, to=%d, dry-run=%v)...",
+		opts.BatchSize, opts.FromVersion, opts.ToVersion, opts.DryRun)
+
+	lastClientID, lastVersion := 0, 0
+	for {
+		q := m.db.Model(&models.ClientVersion{}).
+			Where("(client_id, version) > (?, ?)", lastClientID, lastVersion)
+		if opts.FromVersion > 0 {
+			q = q.Where("version >= ?", opts.FromVersion)
+		}
+		if opts.ToVersion > 0 {
+			q = q.Where("version <= ?", opts.ToVersion)
+		}
+
+		var batch []models.ClientVersion
+		if err := q.Order("client_id ASC, version ASC").Limit(opts.BatchSize).Find(&batch).Error; err != nil {
+			return stats, fmt.Errorf("failed to load client versions: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			for _, cur := range batch {
+				stats.Scanned++
+				if len(cur.Raw) == 0 || !json.Valid(cur.Raw) {
+					stats.Unparsable++
+					continue
+				}
+
+				parsed := utils.ParseClientVersion(json.RawMessage(cur.Raw))
+				updates := diffColumns(clientTypedColumns(cur), clientTypedColumns(parsed))
+				if len(updates) == 0 {
+					continue
+				}
+
+				stats.Changed++
+				for col := range updates {
+					stats.ChangedFields[col]++
+				}
+				if opts.DryRun {
+					continue
+				}
+
+				if err := tx.Model(&models.ClientVersion{}).
+					Where("client_id = ? AND version = ?", cur.ClientID, cur.Version).
+					Updates(updates).Error; err != nil {
+					return fmt.Errorf("client %d version %d: %w", cur.ClientID, cur.Version, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+
+		last := batch[len(batch)-1]
+		lastClientID, lastVersion = last.ClientID, last.Version
+		log.Printf("  ...clients: scanned=%d changed=%d", stats.Scanned, stats.Changed)
+	}
+
+	return stats, nil
+}
+
+func (m *Migrator) reparseContracts(opts ReparseOptions) (ReparseStats, error) {
+	stats := ReparseStats{Entity: ReparseEntityContracts, ChangedFields: map[string]int{}}
+	if opts.FromVersion > 0 || opts.ToVersion > 0 {
+		log.Println("Note: contracts are not versioned, version range is ignored")
+	}
+	log.Printf("Reparsing contracts (batch=%d, dry-run=%v)...", opts.BatchSize, opts.DryRun)
+
+	lastExternalID := 0
+	for {
+		var batch []models.Contract
+		if err := m.db.Table("core.contracts").
+			Where("external_id > ?", lastExternalID).
+			Order("external_id ASC").
+			Limit(opts.BatchSize).
+			Find(&batch).Error; err != nil {
+			return stats, fmt.Errorf("failed to load contracts: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			for _, cur := range batch {
+				stats.Scanned++
+				if len(cur.Raw) == 0 || !json.Valid(cur.Raw) {
+					stats.Unparsable++
+					continue
+				}
+
+				parsed := utils.ParseContract(json.RawMessage(cur.Raw))
+				updates := diffColumns(contractTypedColumns(cur), contractTypedColumns(parsed))
+				if len(updates) == 0 {
+					continue
+				}
+
+				stats.Changed++
+				for col := range updates {
+					stats.ChangedFields[col]++
+				}
+				if opts.DryRun {
+					continue
+				}
+
+				if err := tx.Table("core.contracts").
+					Where("external_id = ?", cur.ExternalID).
+					Updates(updates).Error; err != nil {
+					return fmt.Errorf("contract %d: %w", cur.ExternalID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+
+		lastExternalID = batch[len(batch)-1].ExternalID
+		log.Printf("  ...contracts: scanned=%d changed=%d", stats.Scanned, stats.Changed)
+	}
+
+	return stats, nil
+}
+
+// clientTypedColumns колонки clients_versions, которые заполняет utils.ParseClientVersion.
+// Служебные поля версии (хеши, флаги второй части, валидность) сюда не входят.
+func clientTypedColumns(v models.ClientVersion) map[string]any {
+	return map[string]any{
+		"surname":                       v.Surname,
+		"name":                          v.Name,
+		"patronymic":                    v.Patronymic,
+		"birthday":                      v.Birthday,
+		"birth_place":                   v.BirthPlace,
+		"contact_email":                 v.ContactEmail,
+		"inn":                           v.Inn,
+		"snils":                         v.Snils,
+		"created_lk_at":                 v.CreatedLKAt,
+		"updated_lk_at":                 v.UpdatedLKAt,
+		"pass_issuer_code":              v.PassIssuerCode,
+		"pass_series":                   v.PassSeries,
+		"pass_number":                   v.PassNumber,
+		"pass_issue_date":               v.PassIssueDate,
+		"pass_issuer":                   v.PassIssuer,
+		"main_phone":                    v.MainPhone,
+		"external_id":                   v.ID,
+		"login":                         v.Login,
+		"locked_at":                     v.LockedAt,
+		"current_sign_in_at":            v.CurrentSignInAt,
+		"sign_in_count":                 v.SignInCount,
+		"need_to_set_password":          v.NeedToSetPassword,
+		"blocked":                       v.Blocked,
+		"blocked_reason":                v.BlockedReason,
+		"block_type":                    v.BlockType,
+		"male":                          v.Male,
+		"is_rf_resident":                v.IsRfResident,
+		"document_type":                 v.DocumentType,
+		"document_country":              v.DocumentCountry,
+		"legal_capacity":                v.LegalCapacity,
+		"is_rf_taxpayer":                v.IsRfTaxpayer,
+		"pifs_portfolio_code":           v.PifsPortfolioCode,
+		"external_id_str":               v.ExternalIDStr,
+		"is_valid_info":                 v.IsValidInfo,
+		"qualified_investor":            v.QualifiedInvestor,
+		"risk_level":                    v.RiskLevel,
+		"fill_stage":                    v.FillStage,
+		"is_filled":                     v.IsFilled,
+		"esia_id":                       v.EsiaID,
+		"identification_type":           v.IdentificationType,
+		"agent_id":                      v.AgentID,
+		"agent_point_id":                v.AgentPointID,
+		"tax_status":                    v.TaxStatus,
+		"is_american_national":          v.IsAmericanNational,
+		"country":                       v.Country,
+		"region":                        v.Region,
+		"index":                         v.Index,
+		"city":                          v.City,
+		"street":                        v.Street,
+		"house":                         v.House,
+		"corps":                         v.Corps,
+		"flat":                          v.Flat,
+		"district":                      v.District,
+		"signature_type":                v.SignatureType,
+		"data_received_digital_profile": v.DataReceivedDigitalProfile,
+		"from_company_settings":         v.FromCompanySettings,
+		"settings":                      v.Settings,
+		"person_info":                   v.PersonInfo,
+		"manager":                       v.Manager,
+		"checks":                        v.Checks,
+		"note":                          v.Note,
+		"ad_source":                     v.AdSource,
+		"signature_allowed_numbers":     v.SignatureAllowedNumbers,
+		"external_risk_level":           v.ExternalRiskLevel,
+	}
+}
+
+// contractTypedColumns колонки core.contracts, которые заполняет utils.ParseContract.
+// external_id, hash и synced_at задаются при синхронизации и не пересчитываются.
+func contractTypedColumns(c models.Contract) map[string]any {
+	return map[string]any{
+		"user_id":                        c.UserID,
+		"comment":                        c.Comment,
+		"created_at":                     c.CreatedAt,
+		"updated_at":                     c.UpdatedAt,
+		"inner_code":                     c.InnerCode,
+		"is_personal_invest_account":     c.IsPersonalInvestAccount,
+		"is_personal_invest_account_new": c.IsPersonalInvestAccountNew,
+		"kind":                           c.Kind,
+		"rialto_code":                    c.RialtoCode,
+		"signed_at":                      c.SignedAt,
+		"closed_at":                      c.ClosedAt,
+		"status":                         c.Status,
+		"contract_owner_type":            c.ContractOwnerType,
+		"contract_owner_id":              c.ContractOwnerID,
+		"anketa":                         c.Anketa,
+		"owner_id":                       c.OwnerID,
+		"calculated_profile_id":          c.CalculatedProfileID,
+		"depo_accounts_type":             c.DepoAccountsType,
+		"strategy_id":                    c.StrategyID,
+		"strategy_name":                  c.StrategyName,
+		"tariff_id":                      c.TariffID,
+		"tariff_name":                    c.TariffName,
+		"user_login":                     c.UserLogin,
+	}
+}
+
+// diffColumns возвращает колонки, значения которых в parsed отличаются от stored
+func diffColumns(stored, parsed map[string]any) map[string]any {
+	updates := map[string]any{}
+	for col, newVal := range parsed {
+		if !typedValueEqual(stored[col], newVal) {
+			updates[col] = newVal
+		}
+	}
+	return updates
+}
+
+// typedValueEqual сравнивает значения с учетом того, что Postgres
+// нормализует jsonb и часовой пояс timestamptz
+func typedValueEqual(a, b any) bool {
+	switch av := a.(type) {
+	case datatypes.JSON:
+		bv, _ := b.(datatypes.JSON)
+		if len(av) == 0 || len(bv) == 0 {
+			return len(av) == len(bv)
+		}
+		var x, y any
+		if json.Unmarshal(av, &x) != nil || json.Unmarshal(bv, &y) != nil {
+			return string(av) == string(bv)
+		}
+		return reflect.DeepEqual(x, y)
+	case *time.Time:
+		bv, _ := b.(*time.Time)
+		if av == nil || bv == nil {
+			return av == nil && bv == nil
+		}
+		return av.Equal(*bv)
+	case time.Time:
+		bv, _ := b.(time.Time)
+		return av.Equal(bv)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// SortedFields возвращает измененные колонки по убыванию количества изменений
+func (s ReparseStats) SortedFields() []string {
+	fields := make([]string, 0, len(s.ChangedFields))
+	for f := range s.ChangedFields {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		if s.ChangedFields[fields[i]] != s.ChangedFields[fields[j]] {
+			return s.ChangedFields[fields[i]] > s.ChangedFields[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	return fields
+}
@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+	appdb "vector/internal/db/app"
+
+	"gorm.io/gorm"
+)
+
+// DueAtOptions параметры пересчета сроков пересмотра утвержденных вторых частей
+type DueAtOptions struct {
+	AsOf      time.Time // сроки, действующие на эту дату (по умолчанию — сегодня)
+	BatchSize int
+	DryRun    bool
+}
+
+// DueAtLevelStats итоги по одному уровню риска
+type DueAtLevelStats struct {
+	RiskLevel      string
+	IntervalMonths *int // nil — для уровня нет срока
+	Approved       int
+	Changed        int
+	Earlier        int // срок станет раньше прежнего
+	Later          int
+	BecameOverdue  int // новый срок уже наступил, а прежний — нет
+	NoPolicy       int
+}
+
+// DueAtReport отчет пересчета; Samples — первые изменения для проверки вручную
+type DueAtReport struct {
+	AsOf    time.Time
+	DryRun  bool
+	Levels  []DueAtLevelStats
+	Samples []appdb.DueAtChange
+}
+
+const dueAtSampleSize = 20
+
+// RecomputeDueAt пересчитывает due_at текущих утвержденных вторых частей по срокам пересмотра,
+// действующим на AsOf, от даты утверждения. В режиме DryRun только строит отчет.
+// Вторые части, срок которых стал наступившим, получат причину questionnaire_expired при следующем пересчете (APP_CRON).
+func (m *Migrator) RecomputeDueAt(opts DueAtOptions) (DueAtReport, error) {
+	if opts.AsOf.IsZero() {
+		opts.AsOf = time.Now().UTC()
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	report := DueAtReport{AsOf: opts.AsOf, DryRun: opts.DryRun}
+
+	now := time.Now().UTC()
+	levels := map[string]*DueAtLevelStats{}
+	var changes []appdb.DueAtChange
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		err := appdb.PlanSecondPartDueAt(tx, opts.AsOf, opts.BatchSize, func(batch []appdb.DueAtChange) error {
+			for _, c := range batch {
+				st := levels[c.RiskLevel]
+				if st == nil {
+					st = &DueAtLevelStats{RiskLevel: c.RiskLevel, IntervalMonths: c.IntervalMonths}
+					levels[c.RiskLevel] = st
+				}
+				st.Approved++
+				if c.NewDueAt == nil {
+					st.NoPolicy++
+					continue
+				}
+				if !c.Changed() {
+					continue
+				}
+				st.Changed++
+				if c.OldDueAt != nil && c.NewDueAt.Before(*c.OldDueAt) {
+					st.Earlier++
+				} else {
+					st.Later++
+				}
+				if !c.NewDueAt.After(now) && (c.OldDueAt == nil || c.OldDueAt.After(now)) {
+					st.BecameOverdue++
+				}
+				changes = append(changes, c)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if opts.DryRun {
+			return nil
+		}
+		// изменения пишутся после чтения: курсор и UPDATE в одном соединении не совмещаются
+		for i, c := range changes {
+			if err := appdb.SetSecondPartDueAt(tx, c); err != nil {
+				return fmt.Errorf("client %d: %w", c.ClientID, err)
+			}
+			if (i+1)%opts.BatchSize == 0 {
+				log.Printf("  due_at updated: %d/%d", i+1, len(changes))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for _, st := range levels {
+		report.Levels = append(report.Levels, *st)
+	}
+	sort.Slice(report.Levels, func(i, j int) bool { return report.Levels[i].RiskLevel < report.Levels[j].RiskLevel })
+	if len(changes) > dueAtSampleSize {
+		changes = changes[:dueAtSampleSize]
+	}
+	report.Samples = changes
+	return report, nil
+}
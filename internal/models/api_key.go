@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// APIKeyActorRole роль в журнале аудита для запросов по API-ключу
+const APIKeyActorRole = "api_key"
+
+// APIKeyScopes права, которые можно выдать API-ключу: чтение и выгрузка данных, запуск синхронизации
+var APIKeyScopes = []string{
+	PermClientsRead, PermClientsExport, PermPIIViewMasked, PermPIIViewFull,
+	PermContractsRead, PermContractsExport, PermAuditRead, PermSyncRun,
+}
+
+// IsAPIKeyScope право допустимо для API-ключа
+func IsAPIKeyScope(code string) bool {
+	for _, s := range APIKeyScopes {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey ключ доступа к API для сервисов и интеграций. Ключ имеет вид <prefix>_<secret>;
+// prefix хранится открыто (для поиска и отображения), от секрета — только SHA-256.
+type APIKey struct {
+	ID         uint                        `gorm:"primaryKey" json:"id"`
+	Name       string                      `gorm:"type:text;not null" json:"name"`
+	Prefix     string                      `gorm:"type:text;not null;uniqueIndex" json:"prefix"`
+	SecretHash string                      `gorm:"type:text;not null" json:"-"`
+	Scopes     datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"scopes" swaggertype:"array,string"`
+	RateLimit  int                         `gorm:"not null" json:"rate_limit"` // запросов в минуту
+	ExpiresAt  *time.Time                  `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time                  `json:"last_used_at,omitempty"`
+	LastUsedIP string                      `gorm:"type:text" json:"last_used_ip,omitempty"`
+	CreatedBy  *uint                       `json:"created_by,omitempty"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	RevokedAt  *time.Time                  `json:"revoked_at,omitempty"`
+	RevokedBy  *uint                       `json:"revoked_by,omitempty"`
+}
+
+func (APIKey) TableName() string {
+	return "core.api_keys"
+}
+
+// APIKeyRateWindow счетчик запросов ключа в текущем окне лимита.
+// Хранится в Postgres, чтобы лимит действовал на всех репликах.
+type APIKeyRateWindow struct {
+	APIKeyID    uint      `gorm:"primaryKey;autoIncrement:false"`
+	WindowStart time.Time `gorm:"not null"`
+	Requests    int       `gorm:"not null;default:0"`
+	UpdatedAt   time.Time
+}
+
+func (APIKeyRateWindow) TableName() string {
+	return "core.api_key_rate_windows"
+}
+
+// ScopeSet права ключа
+func (k APIKey) ScopeSet() PermissionSet {
+	return NewPermissionSet(k.Scopes...)
+}
+
+// Active ключ не отозван и не истек
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// ActorEmail идентификатор ключа в журнале аудита
+func (k APIKey) ActorEmail() string {
+	return "api-key:" + k.Prefix
+}
+
+// APIKeyConfig настройки API-ключей
+type APIKeyConfig struct {
+	DefaultRateLimit int           // запросов в минуту, если для ключа не задано
+	DefaultTTL       time.Duration // срок действия, если не задан при выпуске
+	MaxTTL           time.Duration
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	RateLimit     int      `json:"rate_limit"`      // запросов в минуту; 0 — по умолчанию
+	ExpiresInDays int      `json:"expires_in_days"` // 0 — срок по умолчанию
+}
+
+// CreateAPIKeyResponse ключ показывается только один раз, при выпуске
+type CreateAPIKeyResponse struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
@@ -22,6 +22,7 @@ type ClientWithSP struct {
 	PassIssueDate     string `json:"pass_issue_date"`
 	PassIssuer        string `json:"pass_issuer"`
 	MainPhone         string `json:"main_phone"`
+	RiskLevel         string `gorm:"column:risk_level" json:"risk_level"`
 	ExternalRiskLevel string `gorm:"column:external_risk_level" json:"external_risk_level"`
 	NeedsSecondPart   bool   `gorm:"column:needs_second_part" json:"needs_second_part"`
 	SecondPartCreated bool   `gorm:"column:second_part_created" json:"second_part_created"`
@@ -30,4 +31,6 @@ type ClientWithSP struct {
 	SpStatus        *string    `gorm:"column:sp_status" json:"sp_status,omitempty"`
 	SpDueAt         *time.Time `gorm:"column:sp_due_at" json:"sp_due_at,omitempty"`
 	SpClientVersion *int       `gorm:"column:sp_client_version" json:"sp_client_version,omitempty"`
+
+	NeedsSecondPartReasons []string `json:"needs_second_part_reasons,omitempty"`
 }
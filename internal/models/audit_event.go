@@ -0,0 +1,159 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Действия доменных событий аудита (события запросов пишутся с AuditActionHTTPRequest)
+const (
+	AuditActionHTTPRequest        = "http.request"
+	AuditActionLoginSucceeded     = "auth.login_succeeded"
+	AuditActionLoginFailed        = "auth.login_failed"
+	AuditActionLoginLocked        = "auth.login_locked"
+	AuditActionLoginUnlocked      = "auth.login_unlocked"
+	AuditActionLogout             = "auth.logout"
+	AuditActionRefreshReuse       = "auth.refresh_reuse_detected"
+	AuditActionSessionsRevoked    = "auth.sessions_revoked"
+	AuditActionMFAEnabled         = "auth.mfa_enabled"
+	AuditActionMFADisabled        = "auth.mfa_disabled"
+	AuditActionMFARecoveryCodes   = "auth.mfa_recovery_codes_regenerated"
+	AuditActionMFARecoveryUsed    = "auth.mfa_recovery_code_used"
+	AuditActionUserMFAReset       = "user.mfa_reset"
+	AuditActionUserCreated        = "user.created"
+	AuditActionUserRoleChanged    = "user.role_changed"
+	AuditActionUserDeactivated    = "user.deactivated"
+	AuditActionUserActivated      = "user.activated"
+	AuditActionUserPasswordReset  = "user.password_reset"
+	AuditActionUserPasswordChange = "user.password_changed"
+	AuditActionUserDeleted        = "user.deleted"
+	AuditActionSecondPartDraft    = "second_part.draft_created"
+	AuditActionSecondPartRisk     = "second_part.risk_decided"
+	AuditActionReviewPolicy       = "review_policy.created"
+	AuditActionQuestionnaire      = "questionnaire_schema.created"
+	AuditActionSecondPartSubmit   = "second_part.submitted"
+	AuditActionSecondPartEdit     = "second_part.draft_edited"
+	AuditActionClientPiiRevealed  = "client.pii_revealed"
+	AuditActionExportStarted      = "export.started"
+	AuditActionRoleCreated        = "role.created"
+	AuditActionRoleUpdated        = "role.updated"
+	AuditActionRoleDeleted        = "role.deleted"
+	AuditActionAPIKeyCreated      = "api_key.created"
+	AuditActionAPIKeyRevoked      = "api_key.revoked"
+)
+
+const (
+	AuditEntityClient   = "client"
+	AuditEntityContract = "contract"
+	AuditEntityUser     = "user"
+	AuditEntityExport   = "export"
+	AuditEntityRole     = "role"
+	AuditEntityAPIKey   = "api_key"
+	AuditEntityPolicy   = "review_policy"
+	AuditEntitySchema   = "questionnaire_schema"
+)
+
+// AuditEvent запись журнала аудита. Таблица только на добавление: UPDATE/DELETE
+// запрещены триггером, а каждая запись содержит хеш предыдущей (hash chain).
+type AuditEvent struct {
+	ID         uint64         `gorm:"primaryKey" json:"id"`
+	OccurredAt time.Time      `gorm:"not null;index" json:"occurred_at"`
+	ActorID    *uint          `gorm:"index" json:"actor_id,omitempty"`
+	ActorEmail string         `gorm:"type:text" json:"actor_email,omitempty"`
+	ActorRole  string         `gorm:"type:text" json:"actor_role,omitempty"`
+	Action     string         `gorm:"type:text;not null;index" json:"action"`
+	EntityType string         `gorm:"type:text;index:idx_audit_events_entity" json:"entity_type,omitempty"`
+	EntityID   string         `gorm:"type:text;index:idx_audit_events_entity" json:"entity_id,omitempty"`
+	RequestID  string         `gorm:"type:text;index" json:"request_id,omitempty"`
+	IP         string         `gorm:"type:text" json:"ip,omitempty"`
+	Method     string         `gorm:"type:text" json:"method,omitempty"`
+	Path       string         `gorm:"type:text" json:"path,omitempty"`
+	Status     int            `json:"status,omitempty"`
+	Before     datatypes.JSON `gorm:"type:jsonb" json:"before,omitempty" swaggertype:"object"`
+	After      datatypes.JSON `gorm:"type:jsonb" json:"after,omitempty" swaggertype:"object"`
+	Metadata   datatypes.JSON `gorm:"type:jsonb" json:"metadata,omitempty" swaggertype:"object"`
+	PrevHash   string         `gorm:"type:text;not null" json:"prev_hash"`
+	Hash       string         `gorm:"type:text;not null;uniqueIndex" json:"hash"`
+}
+
+func (AuditEvent) TableName() string {
+	return "core.audit_events"
+}
+
+// canonicalJSON приводит jsonb к стабильному виду: Postgres переупорядочивает ключи,
+// поэтому хешируем результат повторной сериализации, а не исходные байты
+func canonicalJSON(raw datatypes.JSON) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// ComputeHash хеш записи вместе с хешем предыдущей. OccurredAt должен быть
+// усечен до микросекунд (точность timestamptz), иначе проверка цепочки не сойдется.
+func (e AuditEvent) ComputeHash() string {
+	actor := ""
+	if e.ActorID != nil {
+		actor = strconv.FormatUint(uint64(*e.ActorID), 10)
+	}
+	parts := []string{
+		e.PrevHash,
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		actor, e.ActorEmail, e.ActorRole,
+		e.Action, e.EntityType, e.EntityID,
+		e.RequestID, e.IP, e.Method, e.Path, strconv.Itoa(e.Status),
+		canonicalJSON(e.Before), canonicalJSON(e.After), canonicalJSON(e.Metadata),
+	}
+	payload, _ := json.Marshal(parts)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditActor кто и откуда выполняет действие
+type AuditActor struct {
+	UserID    *uint
+	Email     string
+	Role      string
+	RequestID string
+	IP        string
+	UserAgent string // не пишется в журнал аудита, используется для сессий
+}
+
+// ActorFromUser actor для действий без HTTP-контекста (cron, CLI)
+func ActorFromUser(u AppUser) AuditActor {
+	id := u.ID
+	return AuditActor{UserID: &id, Email: u.Email, Role: u.Role}
+}
+
+// SystemActor actor фоновых задач без пользователя (сверка с провайдером, расписание)
+func SystemActor(component string) AuditActor {
+	return AuditActor{Email: "system:" + component, Role: "system"}
+}
+
+type AuditFilter struct {
+	ActorID    *int
+	ActorEmail *string
+	Action     []string
+	EntityType *string
+	EntityID   *string
+	RequestID  *string
+	Method     *string
+	From       *time.Time
+	To         *time.Time
+}
+
+type ListAuditEventsResponse struct {
+	Success bool         `json:"success" example:"true"`
+	Events  []AuditEvent `json:"events"`
+	PageMeta
+}
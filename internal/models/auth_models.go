@@ -2,14 +2,16 @@ package models
 
 import (
 	"time"
+	"vector/internal/pkg/signing"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -19,9 +21,22 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token     string  `json:"token"`
-	ExpiresAt int64   `json:"expires_at"`
-	User      AppUser `json:"user"`
+	Token            string   `json:"token,omitempty"`
+	ExpiresAt        int64    `json:"expires_at,omitempty"`
+	RefreshToken     string   `json:"refresh_token,omitempty"`
+	RefreshExpiresAt int64    `json:"refresh_expires_at,omitempty"`
+	User             *AppUser `json:"user,omitempty"`
+	// Пароль верный, но нужен код 2FA: токены не выдаются, вместо них mfa_token
+	// для POST /auth/login/mfa
+	MFARequired     bool   `json:"mfa_required,omitempty"`
+	MFAToken        string `json:"mfa_token,omitempty"`
+	MFATokenExpires int64  `json:"mfa_token_expires_at,omitempty"`
+	// Для роли пользователя 2FA обязательна, но еще не подключена
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 type CreateUserRequest struct {
@@ -34,10 +49,30 @@ type CreateUserRequest struct {
 }
 
 type JWTConfig struct {
-	SecretKey     string
-	TokenDuration time.Duration
+	Keys                 *signing.KeySet // ключи подписи; активный подписывает, все проверяют
+	TokenDuration        time.Duration   // время жизни access-токена
+	RefreshTokenDuration time.Duration
 }
 
 type UpdateRoleRequest struct {
 	Role string `json:"role" validate:"required"`
 }
+
+type ResetPasswordRequest struct {
+	// Пустой пароль — сгенерировать временный
+	Password string `json:"password,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// UserListFilter фильтры списка пользователей
+type UserListFilter struct {
+	Role     []string
+	IsActive *bool
+	Query    *string // подстрока email, имени или фамилии
+	// local | oidc
+	AuthProvider *string
+}
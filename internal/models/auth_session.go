@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSessionReused refresh-токен уже был обменян: вероятна кража, семейство токенов отзывается
+var ErrSessionReused = errors.New("refresh token reuse detected")
+
+// Причины отзыва сессий
+const (
+	SessionRevokeLogout         = "logout"
+	SessionRevokeLogoutAll      = "logout_all"
+	SessionRevokeReuse          = "reuse_detected"
+	SessionRevokeUserChanged    = "user_changed"
+	SessionRevokePasswordChange = "password_changed"
+	SessionRevokeMFAChanged     = "mfa_changed"
+)
+
+// AuthSession refresh-токен сессии. При каждом обновлении создается новая запись
+// того же семейства (FamilyID, он же sid в access-токене), старая помечается RotatedAt.
+// Хранится только SHA-256 токена.
+type AuthSession struct {
+	ID           uint64 `gorm:"primaryKey"`
+	FamilyID     string `gorm:"type:text;not null;index"`
+	UserID       uint   `gorm:"not null;index"`
+	TokenHash    string `gorm:"type:text;not null;uniqueIndex"`
+	IP           string `gorm:"type:text"`
+	UserAgent    string `gorm:"type:text"`
+	CreatedAt    time.Time
+	ExpiresAt    time.Time `gorm:"not null"`
+	RotatedAt    *time.Time
+	RevokedAt    *time.Time
+	RevokeReason string `gorm:"type:text"`
+}
+
+func (AuthSession) TableName() string {
+	return "core.auth_sessions"
+}
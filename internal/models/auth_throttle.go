@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AuthThrottle счетчик неудачных входов по ключу (account:<email> или ip:<адрес>).
+// Хранится в Postgres, чтобы ограничения действовали на всех репликах.
+type AuthThrottle struct {
+	Key           string    `gorm:"primaryKey;type:text"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time
+}
+
+func (AuthThrottle) TableName() string {
+	return "core.auth_throttle"
+}
+
+const (
+	ThrottleKeyAccount = "account:"
+	ThrottleKeyIP      = "ip:"
+)
@@ -94,6 +94,12 @@ type ClientVersion struct {
 	IsCurrent         bool `gorm:"not null;index"`
 }
 
+// Паспорт РФ подлежит замене по достижении 20 и 45 лет, если выдан до соответствующего дня рождения;
+// на замену дается PassportGraceDays дней
+var PassportReplacementAges = []int{20, 45}
+
+const PassportGraceDays = 90
+
 func (ClientVersion) TableName() string {
 	return "core.clients_versions"
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	ExportStatusStarted   = "started"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// ExportLog журнал выгрузок реестров (кто, что и с какими фильтрами выгружал)
+type ExportLog struct {
+	ID         uint           `gorm:"primaryKey"`
+	UserID     uint           `gorm:"not null;index"`
+	UserEmail  string         `gorm:"type:text;not null"`
+	Entity     string         `gorm:"type:text;not null"` // clients | contracts
+	Format     string         `gorm:"type:text;not null"` // csv | xlsx
+	Columns    datatypes.JSON `gorm:"type:jsonb"`
+	Filters    string         `gorm:"type:text"` // query string запроса
+	Status     string         `gorm:"type:text;not null"`
+	RowCount   int64          `gorm:"not null;default:0"`
+	Error      string         `gorm:"type:text"`
+	StartedAt  time.Time      `gorm:"not null;index"`
+	FinishedAt *time.Time
+}
+
+func (ExportLog) TableName() string {
+	return "core.export_logs"
+}
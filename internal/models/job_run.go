@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusSkipped   = "skipped" // блокировку держал другой экземпляр
+)
+
+// Фоновые задачи; имя задачи — и имя ее advisory-блокировки
+const (
+	JobSyncFull      = "sync.full"
+	JobAppRecalc     = "app.recalc"
+	JobOIDCReconcile = "oidc.reconcile"
+)
+
+// JobTriggerCron TriggeredBy для запусков по расписанию
+const JobTriggerCron = "cron"
+
+// ErrJobSlotTaken за этот слот расписания уже есть выполняемый или успешный запуск задачи
+var ErrJobSlotTaken = errors.New("job run for this schedule slot already exists")
+
+// JobRun журнал запусков фоновых задач: какой экземпляр выполнял задачу и чем закончилось
+type JobRun struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Job         string         `gorm:"type:text;not null;index:idx_job_runs_job_started,priority:1" json:"job"`
+	Instance    string         `gorm:"type:text;not null" json:"instance"`
+	TriggeredBy string         `gorm:"type:text;not null" json:"triggered_by"` // cron или email/ключ инициатора
+	Status      string         `gorm:"type:text;not null" json:"status"`
+	Result      datatypes.JSON `gorm:"type:jsonb" json:"result,omitempty" swaggertype:"object"`
+	Error       string         `gorm:"type:text" json:"error,omitempty"`
+	// ScheduledAt слот расписания запуска по cron; у запусков вручную не заполнен
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	StartedAt   time.Time  `gorm:"not null;index:idx_job_runs_job_started,priority:2,sort:desc" json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+func (JobRun) TableName() string {
+	return "core.job_runs"
+}
+
+// JobRunFilter фильтры журнала запусков
+type JobRunFilter struct {
+	Job    *string
+	Status *string
+}
+
+type ListJobRunsResponse struct {
+	Success bool     `json:"success" example:"true"`
+	Runs    []JobRun `json:"runs"`
+	PageMeta
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// SortField одно поле сортировки списка (имя из whitelist API, не колонка БД)
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ClientSortFields допустимые значения sort= для списка клиентов
+var ClientSortFields = []string{
+	"id", "surname", "name", "birthday", "version",
+	"created_lk_at", "updated_lk_at",
+	"risk_level", "external_risk_level", "fill_stage",
+	"sp_status", "sp_due_at",
+}
+
+// ContractSortFields допустимые значения sort= для списка контрактов
+var ContractSortFields = []string{
+	"id", "user_id", "inner_code", "kind", "status",
+	"created_at", "updated_at", "signed_at", "closed_at",
+	"tariff_name", "strategy_name",
+}
+
+type ClientListFilter struct {
+	NeedsSecondPart *bool
+	// NeedsSecondPartReason есть открытая причина с одним из кодов
+	NeedsSecondPartReason []string
+	SpStatus              []string
+	SpDueBefore           *time.Time
+	SpDueAfter            *time.Time
+	RiskLevel             []string
+	ExternalRiskLevel     []string
+	Blocked               *bool
+	IsRfResident          *bool
+	QualifiedInvestor     *bool
+	CreatedLKFrom         *time.Time
+	CreatedLKTo           *time.Time
+	FillStage             []string
+	HasActiveContract     *bool
+	Sort                  []SortField
+}
+
+type ContractListFilter struct {
+	UserID       *int
+	Status       []string
+	Kind         []string
+	SignedFrom   *time.Time
+	SignedTo     *time.Time
+	ClosedFrom   *time.Time
+	ClosedTo     *time.Time
+	TariffID     *int
+	TariffName   *string
+	StrategyID   *int
+	StrategyName *string
+	Sort         []SortField
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MFARecoveryCode одноразовый код восстановления доступа при потере устройства.
+// Хранится только SHA-256 кода.
+type MFARecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"type:text;not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "core.mfa_recovery_codes"
+}
+
+// MFAUsedToken использованный токен второго шага входа (jti). Хранится до истечения
+// токена, чтобы перехваченный токен нельзя было обменять повторно.
+type MFAUsedToken struct {
+	JTI       string    `gorm:"column:jti;primaryKey;type:text"`
+	UserID    uint      `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	UsedAt    time.Time `gorm:"not null"`
+}
+
+func (MFAUsedToken) TableName() string {
+	return "core.mfa_used_tokens"
+}
+
+// MFAConfig настройки двухфакторной аутентификации
+type MFAConfig struct {
+	Issuer        string        // имя сервиса в приложении-аутентификаторе
+	EncryptionKey []byte        // ключ AES-256 для секретов TOTP
+	RequiredRoles []string      // роли, для которых 2FA обязательна
+	TokenDuration time.Duration // время жизни токена второго шага входа
+}
+
+// MFAClaims токен второго шага входа: пароль проверен, код еще нет.
+// Не дает доступа к API, принимается только /auth/login/mfa.
+type MFAClaims struct {
+	UserID uint `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// MFATokenAudience аудитория токена второго шага; access-токены ее не содержат
+const MFATokenAudience = "vector-mfa"
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	// Код из приложения (6 цифр) или код восстановления
+	Code string `json:"code" validate:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFAEnrollResponse данные для подключения приложения-аутентификатора
+type MFAEnrollResponse struct {
+	Secret          string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/Vector:user@vector.com?algorithm=SHA1&digits=6&issuer=Vector&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// MFARecoveryCodesResponse коды восстановления; показываются один раз
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatusResponse состояние 2FA текущего пользователя
+type MFAStatusResponse struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
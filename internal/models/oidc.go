@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// OIDCLoginState попытка входа через OIDC. Создается при переходе к провайдеру,
+// погашается в callback; после успешного callback хранит одноразовый код входа,
+// который frontend обменивает на токены. state и код входа хранятся только как SHA-256.
+type OIDCLoginState struct {
+	ID            uint64 `gorm:"primaryKey"`
+	StateHash     string `gorm:"type:text;not null;uniqueIndex"`
+	Nonce         string `gorm:"type:text;not null"`
+	CodeVerifier  string `gorm:"type:text;not null"`
+	CreatedAt     time.Time
+	ExpiresAt     time.Time `gorm:"not null;index"`
+	ConsumedAt    *time.Time
+	UserID        *uint
+	LoginCodeHash *string `gorm:"type:text;uniqueIndex"`
+	LoginCodeUsed *time.Time
+}
+
+func (OIDCLoginState) TableName() string {
+	return "core.oidc_login_states"
+}
+
+// OIDCConfig настройки входа через корпоративного провайдера
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // callback приложения: .../auth/oidc/callback
+	Scopes       []string
+	// Claim со списком групп и группы, дающие роли; роли проверяются по порядку RoleGroups
+	GroupsClaim string
+	RoleGroups  []OIDCRoleGroups
+	// Куда вернуть браузер после входа (к адресу добавляется ?login_code=...);
+	// пусто — callback сразу отвечает токенами в JSON
+	FrontendRedirectURL string
+	StateTTL            time.Duration
+}
+
+// OIDCRoleGroups группы провайдера, дающие роль (встроенную или созданную администратором)
+type OIDCRoleGroups struct {
+	Role   string
+	Groups []string
+}
+
+// Enabled вход через OIDC настроен
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+type OIDCExchangeRequest struct {
+	LoginCode string `json:"login_code" validate:"required"`
+}
+
+// OIDCReconcileResult итог сверки пользователей с провайдером
+type OIDCReconcileResult struct {
+	Checked     int `json:"checked"`
+	Deactivated int `json:"deactivated"`
+	RoleChanged int `json:"role_changed"`
+	Skipped     int `json:"skipped"` // нет refresh-токена провайдера или провайдер недоступен
+	Failed      int `json:"failed"`
+}
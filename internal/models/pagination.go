@@ -0,0 +1,59 @@
+package models
+
+import "errors"
+
+// ErrInvalidCursor курсор поврежден или выдан для другой сортировки
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	TotalModeExact    = "exact"
+	TotalModeEstimate = "estimate"
+	TotalModeNone     = "none"
+)
+
+// PageRequest параметры страницы списка. Если задан Cursor, Page игнорируется
+// и используется keyset-пагинация без OFFSET.
+type PageRequest struct {
+	Page      int
+	PerPage   int
+	Cursor    string
+	TotalMode string // exact | estimate | none
+}
+
+// PageInfo сведения о полученной странице
+type PageInfo struct {
+	Total          *int64
+	TotalEstimated bool
+	HasMore        bool
+	NextCursor     string
+	PrevCursor     string
+}
+
+// PageMeta поля пагинации в ответах списков
+type PageMeta struct {
+	Page           int    `json:"page" example:"1"`
+	PerPage        int    `json:"per_page" example:"10"`
+	Total          *int64 `json:"total,omitempty" example:"150"`
+	TotalPages     *int   `json:"total_pages,omitempty" example:"15"`
+	TotalEstimated bool   `json:"total_estimated,omitempty" example:"false"`
+	HasMore        bool   `json:"has_more" example:"true"`
+	NextCursor     string `json:"next_cursor,omitempty" example:"eyJ2IjpbIjEwMjQiXSwicyI6IjFhMmIzYzRkNWU2ZiJ9"`
+	PrevCursor     string `json:"prev_cursor,omitempty"`
+}
+
+func NewPageMeta(req PageRequest, info PageInfo) PageMeta {
+	meta := PageMeta{
+		Page:           req.Page,
+		PerPage:        req.PerPage,
+		Total:          info.Total,
+		TotalEstimated: info.TotalEstimated,
+		HasMore:        info.HasMore,
+		NextCursor:     info.NextCursor,
+		PrevCursor:     info.PrevCursor,
+	}
+	if info.Total != nil && req.PerPage > 0 {
+		pages := int((*info.Total + int64(req.PerPage) - 1) / int64(req.PerPage))
+		meta.TotalPages = &pages
+	}
+	return meta
+}
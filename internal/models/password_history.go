@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PasswordHistory предыдущие хеши паролей пользователя (для запрета повторного использования)
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"not null;index:idx_password_history_user,priority:1"`
+	PasswordHash string    `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"not null;index:idx_password_history_user,priority:2"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "core.password_history"
+}
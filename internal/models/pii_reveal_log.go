@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// PiiRevealLog журнал раскрытий замаскированных персональных данных
+type PiiRevealLog struct {
+	ID            uint           `gorm:"primaryKey"`
+	UserID        uint           `gorm:"not null;index"`
+	UserEmail     string         `gorm:"type:text;not null"`
+	UserRole      string         `gorm:"type:text;not null"`
+	ClientID      int            `gorm:"not null;index"`
+	ClientVersion int            `gorm:"not null"`
+	Fields        datatypes.JSON `gorm:"type:jsonb;not null"`
+	Reason        string         `gorm:"type:text;not null"`
+	IP            string         `gorm:"type:text"`
+	CreatedAt     time.Time      `gorm:"not null;index"`
+}
+
+func (PiiRevealLog) TableName() string {
+	return "core.pii_reveal_logs"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	// ErrNoQuestionnaireSchema в core.second_part_schemas нет ни одной версии
+	ErrNoQuestionnaireSchema = errors.New("no questionnaire schema")
+	// ErrInvalidQuestionnaireSchema схема не разбирается или использует неподдерживаемые ключевые слова
+	ErrInvalidQuestionnaireSchema = errors.New("invalid questionnaire schema")
+	// ErrSecondPartVersionConflict вторая часть изменилась после того, как ее прочитали
+	ErrSecondPartVersionConflict = errors.New("second part has been changed by another request")
+)
+
+// QuestionnaireSchema версия схемы анкеты второй части (JSON Schema, см. internal/pkg/jsonschema).
+// Текущая — последняя версия; версии не изменяются, новая схема — новая версия.
+type QuestionnaireSchema struct {
+	Version         int            `gorm:"primaryKey;autoIncrement:false" json:"version" example:"2"`
+	Title           string         `gorm:"type:text;not null;default:''" json:"title" example:"Анкета второй части"`
+	Schema          datatypes.JSON `gorm:"type:jsonb;not null" json:"schema" swaggertype:"object"`
+	Comment         string         `gorm:"type:text;not null;default:''" json:"comment,omitempty"`
+	CreatedByUserID *int           `json:"created_by_user_id,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+func (QuestionnaireSchema) TableName() string {
+	return "core.second_part_schemas"
+}
+
+// CreateQuestionnaireSchemaRequest новая версия схемы
+type CreateQuestionnaireSchemaRequest struct {
+	Title   string         `json:"title" example:"Анкета второй части"`
+	Schema  map[string]any `json:"schema"`
+	Comment string         `json:"comment,omitempty" example:"Добавлен источник происхождения средств"`
+}
+
+// QuestionnaireSchemaInfo версия схемы без содержимого (для списка)
+type QuestionnaireSchemaInfo struct {
+	Version         int       `json:"version" example:"2"`
+	Title           string    `json:"title" example:"Анкета второй части"`
+	Comment         string    `json:"comment,omitempty"`
+	CreatedByUserID *int      `json:"created_by_user_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	IsCurrent       bool      `json:"is_current"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RecalcResult итог пересчета. Flagged — сколько новых причин открыто каждым правилом
+// (ключ — код причины: questionnaire_expired, data_changed, passport_expired); уже открытые не учитываются.
+// Resolved — сколько причин снято, потому что условие перестало выполняться.
+type RecalcResult struct {
+	RunID        uint             `json:"run_id"`
+	Instance     string           `json:"instance"`
+	Flagged      map[string]int64 `json:"flagged"`
+	TotalFlagged int64            `json:"total_flagged"`
+	Resolved     map[string]int64 `json:"resolved"`
+	StartedAt    time.Time        `json:"started_at"`
+	FinishedAt   time.Time        `json:"finished_at"`
+}
+
+// RecalcRuleResult итог одного правила пересчета
+type RecalcRuleResult struct {
+	Flagged  int64
+	Resolved int64
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNoReviewPolicy для уровня риска нет действующего срока пересмотра
+	ErrNoReviewPolicy = errors.New("no review policy for risk level")
+	// ErrInvalidReviewPolicy некорректный уровень, срок или дата начала действия
+	ErrInvalidReviewPolicy = errors.New("invalid review policy")
+	// ErrReviewPolicyExists для уровня уже есть запись с той же датой начала действия
+	ErrReviewPolicyExists = errors.New("review policy for this risk level and effective date already exists")
+)
+
+// ReviewPolicyDefaultLevel уровень, по которому считается срок второй части без решения по риску
+const ReviewPolicyDefaultLevel = RiskLevelHigh
+
+// ReviewPolicy срок пересмотра второй части для уровня риска, действующий с EffectiveFrom.
+// На дату применяется запись уровня с наибольшим EffectiveFrom не позже этой даты;
+// изменение срока — новая запись, прежние остаются историей.
+type ReviewPolicy struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	RiskLevel       string    `gorm:"type:text;not null;uniqueIndex:idx_review_policy_level_from" json:"risk_level" example:"low"`
+	IntervalMonths  int       `gorm:"not null" json:"interval_months" example:"36"`
+	EffectiveFrom   time.Time `gorm:"type:date;not null;uniqueIndex:idx_review_policy_level_from" json:"effective_from" example:"2026-01-01T00:00:00Z"`
+	Comment         string    `gorm:"type:text;not null;default:''" json:"comment,omitempty"`
+	CreatedByUserID *int      `json:"created_by_user_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (ReviewPolicy) TableName() string {
+	return "core.second_part_review_policies"
+}
+
+// DefaultReviewPolicies сроки, действовавшие до появления таблицы (3 года для low, 1 год для остальных)
+var DefaultReviewPolicies = []ReviewPolicy{
+	{RiskLevel: RiskLevelLow, IntervalMonths: 36, Comment: "initial"},
+	{RiskLevel: RiskLevelMedium, IntervalMonths: 12, Comment: "initial"},
+	{RiskLevel: RiskLevelHigh, IntervalMonths: 12, Comment: "initial"},
+}
+
+// CreateReviewPolicyRequest новый срок пересмотра для уровня риска
+type CreateReviewPolicyRequest struct {
+	RiskLevel      string `json:"risk_level" example:"medium"`
+	IntervalMonths int    `json:"interval_months" example:"24"`
+	// EffectiveFrom дата YYYY-MM-DD; пусто — с сегодняшнего дня
+	EffectiveFrom string `json:"effective_from,omitempty" example:"2027-01-01"`
+	Comment       string `json:"comment,omitempty" example:"Решение правления от 01.12.2026"`
+}
+
+// ListReviewPoliciesResponse все записи и действующие сегодня сроки по уровням
+type ListReviewPoliciesResponse struct {
+	Success   bool                    `json:"success"`
+	Policies  []ReviewPolicy          `json:"policies"`
+	Effective map[string]ReviewPolicy `json:"effective"`
+}
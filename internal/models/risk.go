@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Уровни риска второй части
+const (
+	RiskLevelLow    = "low"
+	RiskLevelMedium = "medium"
+	RiskLevelHigh   = "high"
+)
+
+// RiskLevels уровни по возрастанию
+var RiskLevels = []string{RiskLevelLow, RiskLevelMedium, RiskLevelHigh}
+
+// RiskLevelRank порядок уровня (0 — low); -1 для неизвестного
+func RiskLevelRank(level string) int {
+	for i, l := range RiskLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Решение сотрудника по предложенному уровню риска
+const (
+	RiskDecisionAccepted   = "accepted"
+	RiskDecisionOverridden = "overridden"
+)
+
+var (
+	ErrRiskOverrideReason     = errors.New("risk_override_reason is required when risk_level differs from the proposed level")
+	ErrSecondPartNotEditable  = errors.New("second part is not a draft")
+	ErrRiskDowngradeForbidden = errors.New("lowering a proposed high risk level requires the second_part.approve_high_risk permission")
+)
+
+// RiskFactor сработавшее правило скоринга
+type RiskFactor struct {
+	Code        string `json:"code" example:"non_resident"`
+	Description string `json:"description" example:"Не является резидентом РФ"`
+	Fact        string `json:"fact" example:"is_rf_resident"`
+	Value       any    `json:"value" swaggertype:"string" example:"false"`
+	Points      int    `json:"points" example:"25"`
+	MinLevel    string `json:"min_level,omitempty" example:"high"`
+}
+
+// RiskAssessment предложение движка скоринга: уровень, сумма баллов и объясняющие факторы
+type RiskAssessment struct {
+	Level        string       `json:"level" example:"medium"`
+	Score        int          `json:"score" example:"40"`
+	Factors      []RiskFactor `json:"factors"`
+	RulesVersion string       `json:"rules_version" example:"2026-10"`
+	AssessedAt   time.Time    `json:"assessed_at"`
+}
+
+// SecondPartRisk предложение и решение по уровню риска для черновика второй части.
+// Level пуст, пока сотрудник не принял или не изменил предложение.
+type SecondPartRisk struct {
+	Assessment     RiskAssessment
+	Level          string
+	Decision       string
+	OverrideReason string
+	DecidedBy      *int
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrRoleInUse роль назначена пользователям
+var ErrRoleInUse = errors.New("роль назначена пользователям, сначала смените им роль")
+
+// Права доступа. Роль — именованный набор прав; проверки в API идут по правам, а не по ролям.
+const (
+	PermClientsRead       = "clients.read"
+	PermClientsExport     = "clients.export"
+	PermPIIViewMasked     = "pii.view_masked"
+	PermPIIViewFull       = "pii.view_full"
+	PermPIIReveal         = "pii.reveal"
+	PermSecondPartEdit    = "second_part.edit"
+	PermSecondPartApprove = "second_part.approve_high_risk"
+	PermContractsRead     = "contracts.read"
+	PermContractsExport   = "contracts.export"
+	PermAuditRead         = "audit.read"
+	PermUsersManage       = "users.manage"
+	PermRolesManage       = "roles.manage"
+	PermAPIKeysManage     = "api_keys.manage"
+	PermSyncRun           = "sync.run"
+	PermJobsManage        = "jobs.manage"
+	PermReviewPolicies    = "review_policies.manage"
+	PermQuestionnaire     = "questionnaire_schemas.manage"
+)
+
+// PermissionInfo описание права для администраторов
+type PermissionInfo struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Permissions справочник всех прав
+var Permissions = []PermissionInfo{
+	{PermClientsRead, "Просмотр клиентов, их истории и второй части"},
+	{PermClientsExport, "Выгрузка реестра клиентов"},
+	{PermPIIViewMasked, "Просмотр персональных данных в замаскированном виде"},
+	{PermPIIViewFull, "Просмотр персональных (в т.ч. паспортных) данных полностью"},
+	{PermPIIReveal, "Раскрытие замаскированных персональных данных с указанием причины"},
+	{PermSecondPartEdit, "Создание черновиков второй части"},
+	{PermSecondPartApprove, "Утверждение второй части клиентов высокого риска"},
+	{PermContractsRead, "Просмотр контрактов"},
+	{PermContractsExport, "Выгрузка реестра контрактов"},
+	{PermAuditRead, "Просмотр и выгрузка журнала аудита"},
+	{PermUsersManage, "Управление пользователями"},
+	{PermRolesManage, "Управление ролями и правами"},
+	{PermAPIKeysManage, "Выпуск и отзыв API-ключей для интеграций"},
+	{PermSyncRun, "Запуск синхронизации с внешней системой"},
+	{PermJobsManage, "Ручной запуск пересчетов и просмотр журнала фоновых задач"},
+	{PermReviewPolicies, "Настройка сроков пересмотра второй части по уровням риска"},
+	{PermQuestionnaire, "Публикация новых версий схемы анкеты второй части"},
+}
+
+// IsPermission true для права из справочника
+func IsPermission(code string) bool {
+	for _, p := range Permissions {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet набор прав роли
+type PermissionSet map[string]bool
+
+func NewPermissionSet(codes ...string) PermissionSet {
+	set := make(PermissionSet, len(codes))
+	for _, c := range codes {
+		set[c] = true
+	}
+	return set
+}
+
+func (s PermissionSet) Has(code string) bool {
+	return s[code]
+}
+
+// List права в отсортированном виде
+func (s PermissionSet) List() []string {
+	out := make([]string, 0, len(s))
+	for c, ok := range s {
+		if ok {
+			out = append(out, c)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Role роль пользователя (core.app_users.role ссылается на name)
+type Role struct {
+	Name        string `gorm:"primaryKey;type:text"`
+	Label       string `gorm:"type:text;not null"`
+	Description string `gorm:"type:text;not null;default:''"`
+	// системные роли нельзя удалить; права администратора не редактируются (всегда все права)
+	IsSystem  bool `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Role) TableName() string {
+	return "core.roles"
+}
+
+type RolePermission struct {
+	RoleName   string `gorm:"primaryKey;type:text"`
+	Permission string `gorm:"primaryKey;type:text"`
+}
+
+func (RolePermission) TableName() string {
+	return "core.role_permissions"
+}
+
+// RoleDefault роль, создаваемая миграцией
+type RoleDefault struct {
+	Role        Role
+	Permissions []string
+}
+
+// DefaultRoles исходные роли; права соответствуют прежним проверкам ролей в API
+var DefaultRoles = []RoleDefault{
+	{
+		Role: Role{Name: RoleAdministrator, Label: "Администратор", Description: "Полный доступ к системе", IsSystem: true},
+	},
+	{
+		Role: Role{Name: RolePodft, Label: "Отдел ПОДФТ", Description: "Работа с проверками ПОД/ФТ", IsSystem: true},
+		Permissions: []string{
+			PermClientsRead, PermClientsExport, PermPIIViewFull, PermPIIReveal,
+			PermSecondPartEdit, PermSecondPartApprove, PermContractsRead, PermContractsExport,
+		},
+	},
+	{
+		Role: Role{Name: RoleClientManagement, Label: "Клиентский отдел", Description: "Работа с клиентами", IsSystem: true},
+		Permissions: []string{
+			PermClientsRead, PermPIIViewMasked, PermPIIReveal, PermContractsRead,
+		},
+	},
+}
+
+// RoleResponse роль с правами
+type RoleResponse struct {
+	Name        string    `json:"name"`
+	Label       string    `json:"label"`
+	Description string    `json:"description"`
+	IsSystem    bool      `json:"is_system"`
+	Permissions []string  `json:"permissions"`
+	UsersCount  int64     `json:"users_count"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PermissionMatrix справочник прав и права всех ролей
+type PermissionMatrix struct {
+	Permissions []PermissionInfo `json:"permissions"`
+	Roles       []RoleResponse   `json:"roles"`
+}
+
+type RoleCreateRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	Label       string   `json:"label" validate:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleUpdateRequest незаданные поля не меняются; permissions заменяет набор прав целиком
+type RoleUpdateRequest struct {
+	Label       *string   `json:"label"`
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+}
@@ -17,12 +17,24 @@ type SecondPartVersion struct {
 
 	// draft | submitted | approved | rejected | doc_requested
 	Status string `gorm:"type:text;not null"`
+	// Revision номер правки анкеты внутри версии (PATCH черновика); вместе с Version образует ETag
+	Revision int `gorm:"not null;default:0"`
 
 	Data datatypes.JSON `gorm:"type:jsonb"`
+	// SchemaVersion версия схемы анкеты, по которой проверены Data (при сохранении черновика и при отправке)
+	SchemaVersion *int
 
-	RiskLevel string `gorm:"type:text"` // low | high
+	RiskLevel string `gorm:"type:text"` // low | medium | high; пусто — решение по риску не принято
 	DueAt     *time.Time
 
+	// Предложение движка скоринга (RiskAssessment) и решение сотрудника по нему
+	RiskProposedLevel   string         `gorm:"type:text"`
+	RiskAssessment      datatypes.JSON `gorm:"type:jsonb"`
+	RiskDecision        string         `gorm:"type:text"` // accepted | overridden
+	RiskOverrideReason  string         `gorm:"type:text"`
+	RiskDecidedByUserID *int
+	RiskDecidedAt       *time.Time
+
 	CreatedByUserID  *int
 	UpdatedByUserID  *int
 	ApprovedByUserID *int
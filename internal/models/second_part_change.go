@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ErrInvalidMergePatch тело PATCH не является JSON-объектом
+var ErrInvalidMergePatch = errors.New("merge patch must be a JSON object")
+
+// SecondPartChange изменение поля анкеты при правке черновика (PATCH /clients/:id/second-part/current).
+// OldValue отсутствует у добавленного поля, NewValue — у удаленного.
+type SecondPartChange struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	ClientID        int            `gorm:"not null;index:idx_sp_changes_version,priority:1" json:"client_id" example:"123"`
+	Version         int            `gorm:"not null;index:idx_sp_changes_version,priority:2" json:"version" example:"4"`
+	Revision        int            `gorm:"not null" json:"revision" example:"7"`
+	Path            string         `gorm:"type:text;not null" json:"path" example:"/source_of_funds"`
+	OldValue        datatypes.JSON `gorm:"type:jsonb" json:"old_value,omitempty" swaggertype:"object"`
+	NewValue        datatypes.JSON `gorm:"type:jsonb" json:"new_value,omitempty" swaggertype:"object"`
+	ChangedByUserID *int           `json:"changed_by_user_id,omitempty" example:"456"`
+	ChangedAt       time.Time      `gorm:"not null" json:"changed_at"`
+}
+
+func (SecondPartChange) TableName() string {
+	return "core.second_part_changes"
+}
+
+// ListSecondPartChangesResponse журнал изменений анкеты
+type ListSecondPartChangesResponse struct {
+	Success bool               `json:"success" example:"true"`
+	Changes []SecondPartChange `json:"changes"`
+	PageMeta
+}
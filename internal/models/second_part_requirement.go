@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Причины, по которым клиенту нужна (новая) вторая часть
+const (
+	RequirementNewClient            = "new_client"            // второй части еще не было
+	RequirementDataChanged          = "data_changed"          // анкета изменилась после утвержденной второй части
+	RequirementQuestionnaireExpired = "questionnaire_expired" // наступил срок пересмотра (due_at)
+	RequirementPassportExpired      = "passport_expired"      // паспорт подлежит замене по возрасту
+)
+
+// SecondPartRequirementReasons все причины
+var SecondPartRequirementReasons = []string{
+	RequirementNewClient, RequirementDataChanged, RequirementQuestionnaireExpired, RequirementPassportExpired,
+}
+
+func IsSecondPartRequirementReason(reason string) bool {
+	for _, r := range SecondPartRequirementReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Чем снята причина
+const (
+	ResolutionSecondPartApproved = "second_part_approved"
+	ResolutionPassportReplaced   = "passport_replaced"
+	ResolutionConditionCleared   = "condition_cleared" // условие перестало выполняться при пересчете
+)
+
+// SecondPartRequirement причина выставления needs_second_part. Открытая причина (ResolvedAt == nil)
+// по каждому коду у клиента одна; needs_second_part = есть хотя бы одна открытая причина.
+type SecondPartRequirement struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	ClientID      int            `gorm:"not null;index" json:"client_id"`
+	Reason        string         `gorm:"type:text;not null" json:"reason" example:"passport_expired"`
+	ClientVersion int            `gorm:"not null" json:"client_version"` // версия анкеты, на которой выявлена причина
+	Details       datatypes.JSON `gorm:"type:jsonb" json:"details,omitempty" swaggertype:"object"`
+	DetectedAt    time.Time      `gorm:"not null" json:"detected_at"`
+	ResolvedAt    *time.Time     `json:"resolved_at,omitempty"`
+	Resolution    *string        `gorm:"type:text" json:"resolution,omitempty" example:"second_part_approved"`
+}
+
+func (SecondPartRequirement) TableName() string {
+	return "core.second_part_requirements"
+}
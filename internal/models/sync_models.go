@@ -24,6 +24,9 @@ type ClientListItem struct {
 	NeedsSecondPart   bool   `gorm:"column:needs_second_part" json:"needs_second_part"`
 	SecondPartCreated bool   `gorm:"column:second_part_created" json:"second_part_created"`
 	Version           int    `json:"version"`
+
+	// MaskedFields поля, замаскированные или скрытые политикой роли
+	MaskedFields []string `gorm:"-" json:"masked_fields,omitempty" example:"inn,snils"`
 }
 
 type SecondPartResponse struct {
@@ -35,9 +38,28 @@ type SecondPartResponse struct {
 	IsCurrent     bool       `json:"is_current" example:"true"`
 	Stale         bool       `json:"stale,omitempty"`
 }
+type ClientSearchResult struct {
+	ClientListItem
+	Login         string   `json:"login,omitempty" example:"user_login"`
+	Rank          float64  `json:"rank" example:"0.87"`
+	MatchedFields []string `json:"matched_fields" example:"surname,name"`
+	// Highlights совпавшие фрагменты в <em>, остальной текст экранирован как HTML
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+type ClientSearchResponse struct {
+	Success    bool                 `json:"success" example:"true"`
+	Query      string               `json:"query" example:"Иванов Иван"`
+	QueryTypes []string             `json:"query_types" example:"text"`
+	Results    []ClientSearchResult `json:"results"`
+	Total      int                  `json:"total" example:"3"`
+}
+
 type ClientDetailResponse struct {
 	ClientListItem
-	SecondPart *SecondPartResponse `json:"second_part,omitempty"`
+	// NeedsSecondPartReasons коды открытых причин needs_second_part
+	NeedsSecondPartReasons []string            `json:"needs_second_part_reasons,omitempty" example:"data_changed,passport_expired"`
+	SecondPart             *SecondPartResponse `json:"second_part,omitempty"`
 }
 
 type GetClientResponse struct {
@@ -130,9 +152,11 @@ type GetClientResponse struct {
 	Status                string `json:"status" example:"unchanged"`
 	SecondPartTriggerHash string `json:"second_part_trigger_hash" example:"xyz789abc123"`
 
-	NeedsSecondPart   bool `json:"needs_second_part" example:"true"`
-	SecondPartCreated bool `json:"second_part_created" example:"true"`
-	SecondPart        *struct {
+	NeedsSecondPart bool `json:"needs_second_part" example:"true"`
+	// SecondPartRequirements открытые причины needs_second_part
+	SecondPartRequirements []SecondPartRequirement `json:"second_part_requirements,omitempty"`
+	SecondPartCreated      bool                    `json:"second_part_created" example:"true"`
+	SecondPart             *struct {
 		ClientVersion int        `json:"client_version" example:"1"`
 		Version       int        `json:"version" example:"1"`
 		Status        string     `json:"status" example:"draft"`
@@ -140,6 +164,9 @@ type GetClientResponse struct {
 		IsCurrent     bool       `json:"is_current" example:"true"`
 		DueAt         *time.Time `json:"due_at" swaggertype:"string" format:"date-time"`
 	} `json:"second_part,omitempty"`
+
+	// MaskedFields поля, замаскированные или скрытые политикой роли (см. POST /clients/{id}/reveal)
+	MaskedFields []string `json:"masked_fields,omitempty" example:"inn,snils"`
 }
 
 type GetContractResponse struct {
@@ -180,27 +207,22 @@ type GetContractResponse struct {
 }
 
 type ListContractsResponse struct {
-	Success    bool                  `json:"success" example:"true"`
-	Contracts  []GetContractResponse `json:"contracts"`
-	Page       int                   `json:"page" example:"1"`
-	PerPage    int                   `json:"per_page" example:"10"`
-	Total      int64                 `json:"total" example:"150"`
-	TotalPages int                   `json:"total_pages" example:"15"`
+	Success   bool                  `json:"success" example:"true"`
+	Contracts []GetContractResponse `json:"contracts"`
+	PageMeta
 }
 
 type ListClientsResponse struct {
-	Success    bool                   `json:"success" example:"true"`
-	Clients    []ClientDetailResponse `json:"clients"`
-	Page       int                    `json:"page" example:"1"`
-	PerPage    int                    `json:"per_page" example:"10"`
-	Total      int64                  `json:"total" example:"150"`
-	TotalPages int                    `json:"total_pages" example:"15"`
+	Success bool                   `json:"success" example:"true"`
+	Clients []ClientDetailResponse `json:"clients"`
+	PageMeta
 }
 
 type GetSecondPartResponse struct {
 	ClientID         int                     `json:"client_id" example:"123"`
 	ClientVersion    int                     `json:"client_version" example:"1"`
 	Version          int                     `json:"version" example:"2"`
+	Revision         int                     `json:"revision" example:"0"`
 	Status           string                  `json:"status" example:"draft"`
 	RiskLevel        string                  `json:"risk_level" example:"low"`
 	IsCurrent        bool                    `json:"is_current" example:"true"`
@@ -208,10 +230,39 @@ type GetSecondPartResponse struct {
 	ValidFrom        time.Time               `json:"valid_from" swaggertype:"string" format:"date-time"`
 	ValidTo          *time.Time              `json:"valid_to,omitempty" swaggertype:"string" format:"date-time"`
 	Data             *map[string]interface{} `json:"data,omitempty"`
+	SchemaVersion    *int                    `json:"schema_version,omitempty" example:"1"`
 	Reason           string                  `json:"reason,omitempty" example:"Additional documents required"`
 	CreatedByUserID  *int                    `json:"created_by_user_id,omitempty" example:"456"`
 	UpdatedByUserID  *int                    `json:"updated_by_user_id,omitempty" example:"789"`
 	ApprovedByUserID *int                    `json:"approved_by_user_id,omitempty" example:"101"`
+
+	// Предложение скоринга и решение сотрудника по нему
+	RiskProposedLevel   string          `json:"risk_proposed_level,omitempty" example:"medium"`
+	RiskAssessment      *RiskAssessment `json:"risk_assessment,omitempty"`
+	RiskDecision        string          `json:"risk_decision,omitempty" example:"overridden"`
+	RiskOverrideReason  string          `json:"risk_override_reason,omitempty" example:"Подтвержден источник средств"`
+	RiskDecidedByUserID *int            `json:"risk_decided_by_user_id,omitempty" example:"456"`
+	RiskDecidedAt       *time.Time      `json:"risk_decided_at,omitempty" swaggertype:"string" format:"date-time"`
+}
+
+// SecondPartRiskRequest решение по предложенному уровню риска
+type SecondPartRiskRequest struct {
+	// RiskLevel уровень, для которого задан срок пересмотра (low, medium, high, ...); пусто — принять предложенный
+	RiskLevel string `json:"risk_level,omitempty" example:"low"`
+	// RiskOverrideReason обязательна, если уровень отличается от предложенного
+	RiskOverrideReason string `json:"risk_override_reason,omitempty" example:"Подтвержден источник средств"`
+}
+
+type RevealClientRequest struct {
+	Fields []string `json:"fields" example:"pass_series,pass_number"`
+	Reason string   `json:"reason" example:"Запрос клиента по телефону, сверка паспорта"`
+}
+
+type RevealClientResponse struct {
+	Success  bool              `json:"success" example:"true"`
+	ClientID int               `json:"client_id" example:"123"`
+	Version  int               `json:"version" example:"3"`
+	Fields   map[string]string `json:"fields"`
 }
 
 type ErrorResponse struct {
@@ -236,10 +287,13 @@ type ClientVersionSummary struct {
 }
 
 type ClientVersionsListResponse struct {
-	Success  bool                   `json:"success" example:"true"`
-	Versions []ClientVersionSummary `json:"versions"`
-	Total    int                    `json:"total" example:"5"`
-	ClientID int                    `json:"client_id" example:"123"`
+	Success    bool                   `json:"success" example:"true"`
+	Versions   []ClientVersionSummary `json:"versions"`
+	Total      int                    `json:"total" example:"5"`
+	ClientID   int                    `json:"client_id" example:"123"`
+	HasMore    bool                   `json:"has_more,omitempty" example:"false"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	PrevCursor string                 `json:"prev_cursor,omitempty"`
 }
 
 type GetClientVersionResponse struct {
@@ -1,9 +1,11 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 const (
@@ -12,6 +14,19 @@ const (
 	RoleClientManagement = "ClientManagement"
 )
 
+// Источник учетной записи: локальный пароль или корпоративный провайдер (OIDC)
+const (
+	AuthProviderLocal = "local"
+	AuthProviderOIDC  = "oidc"
+)
+
+// ErrLastAdministrator операция оставила бы систему без активного администратора
+var ErrLastAdministrator = errors.New("нельзя отключить, понизить или удалить последнего активного администратора")
+
+// ErrAdminSeedEmailTaken активного администратора нет, а email начального администратора занят
+// существующей (в т.ч. деактивированной или удаленной) учетной записью
+var ErrAdminSeedEmailTaken = errors.New("email начального администратора занят существующей учетной записью")
+
 type AppUser struct {
 	ID           uint   `gorm:"primaryKey"`
 	Email        string `gorm:"uniqueIndex;not null"`
@@ -21,8 +36,26 @@ type AppUser struct {
 	MiddleName   string `gorm:""`         // Отчество (может быть пустым)
 	Role         string `gorm:"type:text;not null"`
 	IsActive     bool   `gorm:"default:true"` // Активен ли пользователь
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	// Пароль задан при начальной настройке или сброшен администратором и должен быть сменен
+	MustChangePassword bool `gorm:"not null;default:false"`
+	PasswordChangedAt  *time.Time
+	// Двухфакторная аутентификация (TOTP). Секрет хранится зашифрованным,
+	// MFALastStep — последний принятый шаг времени (защита от повтора кода)
+	MFAEnabled   bool   `gorm:"not null;default:false"`
+	MFASecret    string `gorm:"type:text" json:"-"`
+	MFALastStep  int64  `gorm:"not null;default:0" json:"-"`
+	MFAEnabledAt *time.Time
+	// Учетные записи OIDC создаются при первом входе и не имеют локального пароля.
+	// ExternalSubject — sub провайдера, ExternalRefreshToken (зашифрован) нужен для проверки,
+	// что пользователь не удален у провайдера
+	AuthProvider         string  `gorm:"type:text;not null;default:'local'"`
+	ExternalSubject      *string `gorm:"type:text;uniqueIndex"`
+	ExternalRefreshToken string  `gorm:"type:text" json:"-"`
+	ExternalCheckedAt    *time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	// Мягкое удаление: запись и email сохраняются для журнала аудита
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (AppUser) TableName() string {
@@ -45,6 +78,11 @@ func (u *AppUser) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// IsExternal учетная запись управляется провайдером OIDC
+func (u *AppUser) IsExternal() bool {
+	return u.AuthProvider == AuthProviderOIDC
+}
+
 // GetFullName возвращает полное имя пользователя
 func (u *AppUser) GetFullName() string {
 	if u.MiddleName != "" {
@@ -56,14 +94,20 @@ func (u *AppUser) GetFullName() string {
 // PublicUser возвращает пользователя без чувствительных данных
 func (u *AppUser) PublicUser() AppUser {
 	return AppUser{
-		ID:         u.ID,
-		Email:      u.Email,
-		FirstName:  u.FirstName,
-		LastName:   u.LastName,
-		MiddleName: u.MiddleName,
-		Role:       u.Role,
-		IsActive:   u.IsActive,
-		CreatedAt:  u.CreatedAt,
-		UpdatedAt:  u.UpdatedAt,
+		ID:                 u.ID,
+		Email:              u.Email,
+		FirstName:          u.FirstName,
+		LastName:           u.LastName,
+		MiddleName:         u.MiddleName,
+		Role:               u.Role,
+		IsActive:           u.IsActive,
+		MustChangePassword: u.MustChangePassword,
+		PasswordChangedAt:  u.PasswordChangedAt,
+		MFAEnabled:         u.MFAEnabled,
+		MFAEnabledAt:       u.MFAEnabledAt,
+		AuthProvider:       u.AuthProvider,
+		ExternalCheckedAt:  u.ExternalCheckedAt,
+		CreatedAt:          u.CreatedAt,
+		UpdatedAt:          u.UpdatedAt,
 	}
 }
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// RowWriter построчная запись табличного файла; данные уходят в w сразу,
+// без накопления всего результата в памяти
+type RowWriter interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// NewRowWriter создает writer нужного формата и записывает строку заголовков
+func NewRowWriter(format string, w io.Writer, sheetName string, header []string) (RowWriter, error) {
+	var (
+		rw  RowWriter
+		err error
+	)
+	switch format {
+	case FormatCSV:
+		rw, err = newCSVWriter(w)
+	case FormatXLSX:
+		rw, err = newXLSXWriter(w, sheetName)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if xw, ok := rw.(*xlsxWriter); ok {
+		return rw, xw.writeHeader(header)
+	}
+	return rw, rw.WriteRow(header)
+}
+
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// sanitizeCell защищает CSV от formula injection при открытии файла в Excel
+// (в XLSX ячейки пишутся строками и формулами не становятся).
+// Телефоны и числа со знаком (+79..., -5) не трогаем.
+func sanitizeCell(v string) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '@', '\t', '\r':
+		return "'" + v
+	case '+', '-':
+		if strings.TrimLeft(v[1:], "0123456789 ()-.,") != "" {
+			return "'" + v
+		}
+	}
+	return v
+}
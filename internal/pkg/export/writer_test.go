@@ -0,0 +1,64 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeCell(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Петров", "Петров"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"=1+1", "'=1+1"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"-2+3+cmd|' /C calc'!A0", "'-2+3+cmd|' /C calc'!A0"},
+		{"-SUM(A1)", "'-SUM(A1)"},
+		// телефоны и числа со знаком остаются как есть
+		{"+7 (912) 345-67-89", "+7 (912) 345-67-89"},
+		{"+79123456789", "+79123456789"},
+		{"-5", "-5"},
+		{"-1,5", "-1,5"},
+		{"+", "+"},
+		// знак формулы не в начале ячейки безопасен
+		{"a=b", "a=b"},
+	}
+	for _, tc := range cases {
+		if got := sanitizeCell(tc.in); got != tc.want {
+			t.Errorf("sanitizeCell(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCSVWriterSanitizesCells(t *testing.T) {
+	var buf bytes.Buffer
+	rw, err := NewRowWriter(FormatCSV, &buf, "clients", []string{"id", "note"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteRow([]string{"1", "=cmd"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\xef\xbb\xbf") {
+		t.Fatal("CSV without UTF-8 BOM")
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, "\xef\xbb\xbf"))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][1] != "'=cmd" {
+		t.Fatalf("records = %q", records)
+	}
+}
@@ -0,0 +1,151 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// xlsxSheet лист, прочитанный обратно из архива
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref   string `xml:"r,attr"`
+			Type  string `xml:"t,attr"`
+			Style string `xml:"s,attr"`
+			Text  string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func writeXLSX(t *testing.T, sheetName string, header []string, rows ...[]string) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	rw, err := NewRowWriter(FormatXLSX, &buf, sheetName, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := rw.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("xlsx is not a valid zip: %v", err)
+	}
+	return zr
+}
+
+func readPart(t *testing.T, zr *zip.Reader, name string) []byte {
+	t.Helper()
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("part %s: %v", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestXLSXOpensWithAllParts(t *testing.T) {
+	zr := writeXLSX(t, `Клиенты & "VIP"`, []string{"id"}, []string{"1"})
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		// каждая часть — корректный XML
+		dec := xml.NewDecoder(bytes.NewReader(readPart(t, zr, name)))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", name, err)
+			}
+		}
+	}
+
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(readPart(t, zr, "xl/workbook.xml"), &wb); err != nil {
+		t.Fatal(err)
+	}
+	if len(wb.Sheets) != 1 || wb.Sheets[0].Name != `Клиенты & "VIP"` {
+		t.Fatalf("sheets = %+v", wb.Sheets)
+	}
+}
+
+func TestXLSXHeaderAndInlineStringEscaping(t *testing.T) {
+	values := []string{`<b>Петров</b> & сын`, "=1+1", "  пробелы  ", "строка\nвторая", `"кавычки" 'и' апострофы`}
+	zr := writeXLSX(t, "", []string{"id", "name"}, values)
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(readPart(t, zr, "xl/worksheets/sheet1.xml"), &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(sheet.Rows))
+	}
+
+	header := sheet.Rows[0]
+	if header.R != 1 || len(header.Cells) != 2 || header.Cells[0].Text != "id" || header.Cells[1].Ref != "B1" {
+		t.Fatalf("header row = %+v", header)
+	}
+	for _, c := range header.Cells {
+		if c.Style != "1" {
+			t.Fatalf("header cell %s without bold style", c.Ref)
+		}
+	}
+
+	row := sheet.Rows[1]
+	for i, c := range row.Cells {
+		if c.Type != "inlineStr" || c.Style != "" {
+			t.Fatalf("cell %s: type %q style %q", c.Ref, c.Type, c.Style)
+		}
+		// значения приходят обратно без изменений: в XLSX ячейки — строки, формулами не становятся
+		if c.Text != values[i] {
+			t.Fatalf("cell %s = %q, want %q", c.Ref, c.Text, values[i])
+		}
+		if want := columnName(i) + "2"; c.Ref != want {
+			t.Fatalf("cell ref = %s, want %s", c.Ref, want)
+		}
+	}
+}
+
+func TestXLSXColumnsPastZ(t *testing.T) {
+	header := make([]string, 60)
+	for i := range header {
+		header[i] = "c" + strconv.Itoa(i)
+	}
+	zr := writeXLSX(t, "", header)
+
+	var sheet xlsxSheet
+	if err := xml.Unmarshal(readPart(t, zr, "xl/worksheets/sheet1.xml"), &sheet); err != nil {
+		t.Fatal(err)
+	}
+	cells := sheet.Rows[0].Cells
+	for i, want := range map[int]string{0: "A1", 25: "Z1", 26: "AA1", 27: "AB1", 51: "AZ1", 52: "BA1", 59: "BH1"} {
+		if cells[i].Ref != want || cells[i].Text != header[i] {
+			t.Errorf("column %d: ref %s text %q, want %s %q", i, cells[i].Ref, cells[i].Text, want, header[i])
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA", 16383: "XFD"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
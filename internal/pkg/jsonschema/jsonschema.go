@@ -0,0 +1,434 @@
+// Package jsonschema проверяет JSON-документы по подмножеству JSON Schema (draft 2020-12),
+// достаточному для анкет: type, properties, required, additionalProperties, enum, const,
+// minLength, maxLength, pattern, format (date, date-time, email), minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, items, minItems, maxItems, uniqueItems.
+// Неподдерживаемые ключевые слова проверки ($ref, oneOf и т.п.) отклоняются при компиляции,
+// чтобы схема не выглядела строже, чем проверяется на самом деле.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema скомпилированная схема
+type Schema struct {
+	types                []string
+	properties           map[string]*Schema
+	required             []string
+	additionalAllowed    bool
+	additionalSchema     *Schema
+	enum                 []any
+	constValue           any
+	hasConst             bool
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	format               string
+	minimum, maximum     *float64
+	exclMin, exclMax     *float64
+	items                *Schema
+	minItems, maxItems   *int
+	uniqueItems          bool
+}
+
+// ValidationError нарушение схемы; Path — JSON Pointer на значение ("" — корень документа)
+type ValidationError struct {
+	Path    string `json:"path" example:"/source_of_funds"`
+	Message string `json:"message" example:"is required"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// annotations ключевые слова без влияния на проверку
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "readOnly": true, "writeOnly": true, "deprecated": true,
+}
+
+var types = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+var formats = map[string]bool{"date": true, "date-time": true, "email": true}
+
+// Compile разбирает схему. Ключи x-* (подсказки для интерфейса) допускаются и игнорируются.
+func Compile(data []byte) (*Schema, error) {
+	var raw any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid schema json: %w", err)
+	}
+	return compile(raw, "")
+}
+
+func compile(raw any, path string) (*Schema, error) {
+	if b, ok := raw.(bool); ok {
+		// true — любое значение, false — никакое
+		if b {
+			return &Schema{additionalAllowed: true}, nil
+		}
+		return &Schema{additionalAllowed: true, enum: []any{}}, nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", pathOrRoot(path))
+	}
+
+	s := &Schema{additionalAllowed: true}
+	for key, v := range m {
+		at := path + "/" + key
+		var err error
+		switch key {
+		case "type":
+			s.types, err = compileTypes(v, at)
+		case "properties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be an object", at)
+			}
+			s.properties = make(map[string]*Schema, len(props))
+			for name, ps := range props {
+				if s.properties[name], err = compile(ps, at+"/"+escape(name)); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			s.required, err = stringList(v, at)
+		case "additionalProperties":
+			if b, ok := v.(bool); ok {
+				s.additionalAllowed = b
+			} else {
+				s.additionalSchema, err = compile(v, at)
+			}
+		case "enum":
+			list, ok := v.([]any)
+			if !ok || len(list) == 0 {
+				return nil, fmt.Errorf("%s: must be a non-empty array", at)
+			}
+			s.enum = list
+		case "const":
+			s.constValue, s.hasConst = v, true
+		case "minLength":
+			s.minLength, err = nonNegInt(v, at)
+		case "maxLength":
+			s.maxLength, err = nonNegInt(v, at)
+		case "minItems":
+			s.minItems, err = nonNegInt(v, at)
+		case "maxItems":
+			s.maxItems, err = nonNegInt(v, at)
+		case "pattern":
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a string", at)
+			}
+			if s.pattern, err = regexp.Compile(str); err != nil {
+				return nil, fmt.Errorf("%s: %w", at, err)
+			}
+		case "format":
+			str, ok := v.(string)
+			if !ok || !formats[str] {
+				return nil, fmt.Errorf("%s: unsupported format %v (expected date, date-time or email)", at, v)
+			}
+			s.format = str
+		case "minimum":
+			s.minimum, err = num(v, at)
+		case "maximum":
+			s.maximum, err = num(v, at)
+		case "exclusiveMinimum":
+			s.exclMin, err = num(v, at)
+		case "exclusiveMaximum":
+			s.exclMax, err = num(v, at)
+		case "items":
+			s.items, err = compile(v, at)
+		case "uniqueItems":
+			b, ok := v.(bool)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a boolean", at)
+			}
+			s.uniqueItems = b
+		default:
+			if !annotations[key] && !strings.HasPrefix(key, "x-") {
+				return nil, fmt.Errorf("%s: unsupported keyword", at)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Validate проверяет документ; пустой результат — документ соответствует схеме
+func (s *Schema) Validate(doc []byte) []ValidationError {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return []ValidationError{{Message: "invalid json: " + err.Error()}}
+	}
+	var errs []ValidationError
+	s.validate(v, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func (s *Schema) validate(v any, path string, errs *[]ValidationError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.types) > 0 && !matchesType(v, s.types) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+		return
+	}
+	if s.enum != nil && !contains(s.enum, v) {
+		if len(s.enum) == 0 {
+			fail("no value is allowed")
+		} else {
+			fail("must be one of %s", listValues(s.enum))
+		}
+	}
+	if s.hasConst && !equal(s.constValue, v) {
+		fail("must be %s", listValues([]any{s.constValue}))
+	}
+
+	switch x := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(x)
+		if s.minLength != nil && n < *s.minLength {
+			fail("must be at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("must be at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(x) {
+			fail("does not match pattern %s", s.pattern.String())
+		}
+		if s.format != "" && !validFormat(s.format, x) {
+			fail("must be a valid %s", s.format)
+		}
+	case json.Number:
+		f, _ := x.Float64()
+		if s.minimum != nil && f < *s.minimum {
+			fail("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			fail("must be <= %v", *s.maximum)
+		}
+		if s.exclMin != nil && f <= *s.exclMin {
+			fail("must be > %v", *s.exclMin)
+		}
+		if s.exclMax != nil && f >= *s.exclMax {
+			fail("must be < %v", *s.exclMax)
+		}
+	case []any:
+		if s.minItems != nil && len(x) < *s.minItems {
+			fail("must contain at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(x) > *s.maxItems {
+			fail("must contain at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+			for i := range x {
+				if contains(x[:i], x[i]) {
+					fail("items must be unique")
+					break
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range x {
+				s.items.validate(item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := x[name]; !ok {
+				*errs = append(*errs, ValidationError{Path: path + "/" + escape(name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(x))
+		for name := range x {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			at := path + "/" + escape(name)
+			if ps, ok := s.properties[name]; ok {
+				ps.validate(x[name], at, errs)
+				continue
+			}
+			switch {
+			case s.additionalSchema != nil:
+				s.additionalSchema.validate(x[name], at, errs)
+			case !s.additionalAllowed:
+				*errs = append(*errs, ValidationError{Path: at, Message: "is not allowed"})
+			}
+		}
+	}
+}
+
+func matchesType(v any, want []string) bool {
+	got := typeOf(v)
+	for _, t := range want {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := x.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return "unknown"
+}
+
+func validFormat(format, v string) bool {
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "email":
+		a, err := mail.ParseAddress(v)
+		return err == nil && a.Address == v
+	}
+	return true
+}
+
+func contains(list []any, v any) bool {
+	for _, x := range list {
+		if equal(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal сравнивает значения JSON; числа — по величине (1 и 1.0 равны)
+func equal(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok || bok {
+		if !aok || !bok {
+			return false
+		}
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func listValues(list []any) string {
+	parts := make([]string, len(list))
+	for i, v := range list {
+		b, _ := json.Marshal(v)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func compileTypes(v any, at string) ([]string, error) {
+	var list []string
+	switch x := v.(type) {
+	case string:
+		list = []string{x}
+	case []any:
+		var err error
+		if list, err = stringList(x, at); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s: must be a string or an array of strings", at)
+	}
+	for _, t := range list {
+		if !types[t] {
+			return nil, fmt.Errorf("%s: unknown type %q", at, t)
+		}
+	}
+	return list, nil
+}
+
+func stringList(v any, at string) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an array of strings", at)
+	}
+	out := make([]string, len(list))
+	for i, x := range list {
+		str, ok := x.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: must be an array of strings", at)
+		}
+		out[i] = str
+	}
+	return out, nil
+}
+
+func nonNegInt(v any, at string) (*int, error) {
+	n, ok := v.(json.Number)
+	if ok {
+		if i, err := n.Int64(); err == nil && i >= 0 {
+			x := int(i)
+			return &x, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: must be a non-negative integer", at)
+}
+
+func num(v any, at string) (*float64, error) {
+	n, ok := v.(json.Number)
+	if ok {
+		if f, err := n.Float64(); err == nil {
+			return &f, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: must be a number", at)
+}
+
+// escape экранирует имя свойства для JSON Pointer (RFC 6901)
+func escape(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "schema"
+	}
+	return path
+}
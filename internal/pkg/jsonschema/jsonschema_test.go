@@ -0,0 +1,160 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "Анкета",
+  "type": "object",
+  "required": ["purpose", "position"],
+  "additionalProperties": false,
+  "properties": {
+    "purpose": {"type": "string", "minLength": 1, "maxLength": 5, "x-widget": "textarea"},
+    "position": {"type": "string", "enum": ["stable", "unstable"]},
+    "beneficiary": {"type": "boolean"},
+    "share": {"type": "number", "minimum": 0, "maximum": 100},
+    "count": {"type": "integer", "exclusiveMinimum": 0},
+    "birth_date": {"type": "string", "format": "date"},
+    "email": {"type": ["string", "null"], "format": "email"},
+    "code": {"type": "string", "pattern": "^[0-9]{3}$"},
+    "kind": {"const": "person"},
+    "tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2, "uniqueItems": true},
+    "owner": {
+      "type": "object",
+      "required": ["full_name"],
+      "properties": {"full_name": {"type": "string"}, "a/b": {"type": "integer"}}
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		doc  string
+		want []ValidationError
+	}{
+		{"valid minimal", `{"purpose":"inv","position":"stable"}`, nil},
+		{"valid full", `{"purpose":"inv","position":"unstable","beneficiary":false,"share":12.5,"count":3,
+			"birth_date":"1990-01-31","email":null,"code":"042","kind":"person","tags":["a","b"],
+			"owner":{"full_name":"Иванов","a/b":1}}`, nil},
+		{"required fields", `{}`, []ValidationError{
+			{"/position", "is required"},
+			{"/purpose", "is required"},
+		}},
+		{"nested required", `{"purpose":"x","position":"stable","owner":{}}`, []ValidationError{
+			{"/owner/full_name", "is required"},
+		}},
+		{"root type", `[]`, []ValidationError{{"", "expected object, got array"}}},
+		{"property types", `{"purpose":1,"position":"stable","beneficiary":"yes","share":"5","count":1.5}`, []ValidationError{
+			{"/beneficiary", "expected boolean, got string"},
+			{"/count", "expected integer, got number"},
+			{"/purpose", "expected string, got integer"},
+			{"/share", "expected number, got string"},
+		}},
+		{"null type", `{"purpose":"x","position":"stable","email":null,"owner":null}`, []ValidationError{
+			{"/owner", "expected object, got null"},
+		}},
+		{"enum", `{"purpose":"x","position":"rich"}`, []ValidationError{
+			{"/position", `must be one of "stable", "unstable"`},
+		}},
+		{"const", `{"purpose":"x","position":"stable","kind":"company"}`, []ValidationError{
+			{"/kind", `must be "person"`},
+		}},
+		{"string limits", `{"purpose":"","position":"stable","code":"12a"}`, []ValidationError{
+			{"/code", "does not match pattern ^[0-9]{3}$"},
+			{"/purpose", "must be at least 1 characters"},
+		}},
+		{"length counts characters", `{"purpose":"длина","position":"stable"}`, nil},
+		{"max length", `{"purpose":"слишком","position":"stable"}`, []ValidationError{
+			{"/purpose", "must be at most 5 characters"},
+		}},
+		{"number limits", `{"purpose":"x","position":"stable","share":100.5,"count":0}`, []ValidationError{
+			{"/count", "must be > 0"},
+			{"/share", "must be <= 100"},
+		}},
+		{"formats", `{"purpose":"x","position":"stable","birth_date":"31.01.1990","email":"Ivan <ivan@example.com>"}`, []ValidationError{
+			{"/birth_date", "must be a valid date"},
+			{"/email", "must be a valid email"},
+		}},
+		{"arrays", `{"purpose":"x","position":"stable","tags":["a","a",1]}`, []ValidationError{
+			{"/tags", "must contain at most 2 items"},
+			{"/tags", "items must be unique"},
+			{"/tags/2", "expected string, got integer"},
+		}},
+		{"empty array", `{"purpose":"x","position":"stable","tags":[]}`, []ValidationError{
+			{"/tags", "must contain at least 1 items"},
+		}},
+		{"additional properties", `{"purpose":"x","position":"stable","salary":1}`, []ValidationError{
+			{"/salary", "is not allowed"},
+		}},
+		{"escaped pointer", `{"purpose":"x","position":"stable","owner":{"full_name":"x","a/b":"1"}}`, []ValidationError{
+			{"/owner/a~1b", "expected integer, got string"},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := s.Validate([]byte(tc.doc))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("Validate() =\n%v\nwant\n%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	s, err := Compile([]byte(`{"type":"object"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := s.Validate([]byte(`{"a":`))
+	if len(errs) != 1 || errs[0].Path != "" {
+		t.Fatalf("Validate(invalid json) = %v", errs)
+	}
+}
+
+func TestBooleanSchemas(t *testing.T) {
+	s, err := Compile([]byte(`{"properties":{"any":true,"none":false}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := s.Validate([]byte(`{"any":[1,{"x":null}]}`)); errs != nil {
+		t.Fatalf("true schema rejected a value: %v", errs)
+	}
+	if errs := s.Validate([]byte(`{"none":1}`)); len(errs) != 1 || errs[0].Path != "/none" {
+		t.Fatalf("false schema accepted a value: %v", errs)
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	cases := map[string]string{
+		"not json":            `{"type":`,
+		"not an object":       `"object"`,
+		"unsupported keyword": `{"$ref":"#/definitions/x"}`,
+		"nested unsupported":  `{"properties":{"a":{"oneOf":[{"type":"string"}]}}}`,
+		"unknown type":        `{"type":"date"}`,
+		"type not a string":   `{"type":1}`,
+		"required not list":   `{"required":"a"}`,
+		"required not string": `{"required":[1]}`,
+		"empty enum":          `{"enum":[]}`,
+		"negative minLength":  `{"minLength":-1}`,
+		"fraction maxItems":   `{"maxItems":1.5}`,
+		"bad pattern":         `{"pattern":"("}`,
+		"unsupported format":  `{"format":"uuid"}`,
+		"minimum not number":  `{"minimum":"0"}`,
+		"properties not map":  `{"properties":[]}`,
+		"uniqueItems string":  `{"uniqueItems":"yes"}`,
+	}
+	for name, schema := range cases {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("%s: expected a compile error", name)
+		}
+	}
+}
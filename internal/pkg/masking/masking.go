@@ -0,0 +1,272 @@
+package masking
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"vector/internal/models"
+)
+
+// Mode режим отображения поля с персональными данными
+type Mode string
+
+const (
+	ModeFull   Mode = "full"
+	ModeMasked Mode = "masked"
+	ModeHidden Mode = "hidden"
+)
+
+// Поля с персональными данными. Имена совпадают с ключами в ответах API и в Raw.
+const (
+	FieldPassSeries     = "pass_series"
+	FieldPassNumber     = "pass_number"
+	FieldPassIssuer     = "pass_issuer"
+	FieldPassIssuerCode = "pass_issuer_code"
+	FieldPassIssueDate  = "pass_issue_date"
+	FieldInn            = "inn"
+	FieldSnils          = "snils"
+	FieldMainPhone      = "main_phone"
+	FieldContactEmail   = "contact_email"
+	FieldBirthday       = "birthday"
+	FieldBirthPlace     = "birth_place"
+	FieldAddress        = "address"
+)
+
+// Fields все поля, на которые распространяется политика
+var Fields = []string{
+	FieldPassSeries, FieldPassNumber, FieldPassIssuer, FieldPassIssuerCode, FieldPassIssueDate,
+	FieldInn, FieldSnils, FieldMainPhone, FieldContactEmail,
+	FieldBirthday, FieldBirthPlace, FieldAddress,
+}
+
+// addressKeys ключи адреса в Raw/person_info, управляемые полем FieldAddress
+var addressKeys = []string{"street", "house", "corps", "flat", "index"}
+
+// Policy режимы полей; поля, отсутствующие в карте, отдаются полностью
+type Policy struct {
+	modes map[string]Mode
+}
+
+var fullPolicy = Policy{}
+
+// maskedPolicy просмотр без права на полные персональные данные
+var maskedPolicy = Policy{modes: map[string]Mode{
+	FieldPassSeries:     ModeMasked,
+	FieldPassNumber:     ModeMasked,
+	FieldPassIssuer:     ModeHidden,
+	FieldPassIssuerCode: ModeHidden,
+	FieldPassIssueDate:  ModeHidden,
+	FieldInn:            ModeMasked,
+	FieldSnils:          ModeMasked,
+	FieldMainPhone:      ModeMasked,
+	FieldContactEmail:   ModeMasked,
+	FieldBirthPlace:     ModeHidden,
+	FieldAddress:        ModeHidden,
+}}
+
+// PolicyFor политика по правам пользователя: pii.view_full — все поля, pii.view_masked —
+// маскирование, без этих прав все поля скрыты
+func PolicyFor(perms models.PermissionSet) Policy {
+	switch {
+	case perms.Has(models.PermPIIViewFull):
+		return fullPolicy
+	case perms.Has(models.PermPIIViewMasked):
+		return maskedPolicy
+	}
+	modes := make(map[string]Mode, len(Fields))
+	for _, f := range Fields {
+		modes[f] = ModeHidden
+	}
+	return Policy{modes: modes}
+}
+
+func (p Policy) Mode(field string) Mode {
+	if m, ok := p.modes[field]; ok {
+		return m
+	}
+	return ModeFull
+}
+
+// IsFull true, если политика ничего не маскирует
+func (p Policy) IsFull() bool {
+	for _, m := range p.modes {
+		if m != ModeFull {
+			return false
+		}
+	}
+	return true
+}
+
+// Restricted поля, которые политика маскирует или скрывает (для masked_fields в ответе)
+func (p Policy) Restricted() []string {
+	var out []string
+	for f, m := range p.modes {
+		if m != ModeFull {
+			out = append(out, f)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Apply возвращает значение поля в соответствии с политикой
+func (p Policy) Apply(field, value string) string {
+	switch p.Mode(field) {
+	case ModeHidden:
+		return ""
+	case ModeMasked:
+		return Mask(field, value)
+	default:
+		return value
+	}
+}
+
+// Mask маскирует значение по правилам поля: серия паспорта 12**, номер ****56,
+// ИНН/СНИЛС — видны первые и последние 2 цифры, телефон — код страны и 2 последние цифры,
+// email — первая буква и домен
+func Mask(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	switch field {
+	case FieldPassSeries:
+		return maskChars(value, 2, 0)
+	case FieldPassNumber:
+		return maskChars(value, 0, 2)
+	case FieldInn, FieldSnils:
+		return maskChars(value, 2, 2)
+	case FieldMainPhone:
+		return maskChars(value, 1, 2)
+	case FieldContactEmail:
+		return maskEmail(value)
+	default:
+		return maskChars(value, 0, 0)
+	}
+}
+
+// maskChars заменяет буквы и цифры на *, оставляя keepStart первых и keepEnd последних;
+// разделители (пробелы, дефисы, скобки) сохраняются
+func maskChars(value string, keepStart, keepEnd int) string {
+	total := 0
+	for _, r := range value {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			total++
+		}
+	}
+	// короткие значения маскируем полностью, чтобы не раскрыть их целиком
+	if keepStart+keepEnd >= total {
+		keepStart, keepEnd = 0, 0
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+	idx := 0
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if idx < keepStart || idx >= total-keepEnd {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('*')
+		}
+		idx++
+	}
+	return b.String()
+}
+
+func maskEmail(value string) string {
+	local, domain, ok := strings.Cut(value, "@")
+	if !ok {
+		return maskChars(value, 1, 0)
+	}
+	first, size := utf8.DecodeRuneInString(local)
+	if size == 0 {
+		return "***@" + domain
+	}
+	return string(first) + "***@" + domain
+}
+
+// RedactJSON применяет политику к JSON-объекту клиента (Raw, person_info) по путям.
+// prefix — путь объекта относительно корня Raw ("" для Raw, "person_info" для person_info).
+func (p Policy) RedactJSON(m map[string]any, prefix string) {
+	if m == nil || p.IsFull() {
+		return
+	}
+	for _, path := range rawPaths {
+		rel, ok := relativePath(path.path, prefix)
+		if !ok {
+			continue
+		}
+		redactPath(m, strings.Split(rel, "."), path.field, p)
+	}
+}
+
+type rawPath struct {
+	path  string
+	field string
+}
+
+// rawPaths пути в Raw клиента, где встречаются персональные данные
+var rawPaths = func() []rawPath {
+	var out []rawPath
+	for _, f := range Fields {
+		if f == FieldAddress {
+			continue
+		}
+		out = append(out, rawPath{f, f}, rawPath{"person_info." + f, f})
+	}
+	for _, k := range addressKeys {
+		out = append(out, rawPath{k, FieldAddress}, rawPath{"person_info." + k, FieldAddress})
+	}
+	return out
+}()
+
+func relativePath(path, prefix string) (string, bool) {
+	if prefix == "" {
+		return path, true
+	}
+	if strings.HasPrefix(path, prefix+".") {
+		return strings.TrimPrefix(path, prefix+"."), true
+	}
+	return "", false
+}
+
+func redactPath(m map[string]any, parts []string, field string, p Policy) {
+	key := parts[0]
+	v, ok := m[key]
+	if !ok {
+		return
+	}
+
+	if len(parts) > 1 {
+		switch t := v.(type) {
+		case map[string]any:
+			redactPath(t, parts[1:], field, p)
+		case []any:
+			for _, item := range t {
+				if im, ok := item.(map[string]any); ok {
+					redactPath(im, parts[1:], field, p)
+				}
+			}
+		}
+		return
+	}
+
+	switch p.Mode(field) {
+	case ModeHidden:
+		delete(m, key)
+	case ModeMasked:
+		switch t := v.(type) {
+		case string:
+			m[key] = Mask(field, t)
+		case nil:
+		default:
+			// числа и прочие значения маскируем целиком
+			m[key] = "***"
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package mergepatch применяет JSON Merge Patch (RFC 7396) и сравнивает документы по полям.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change изменение значения; Path — JSON Pointer. Old == nil — поле добавлено, New == nil — удалено.
+type Change struct {
+	Path string          `json:"path" example:"/source_of_funds"`
+	Old  json.RawMessage `json:"old,omitempty" swaggertype:"object"`
+	New  json.RawMessage `json:"new,omitempty" swaggertype:"object"`
+}
+
+// Apply применяет patch к doc. Пустой doc считается пустым объектом.
+func Apply(doc, patch []byte) ([]byte, error) {
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	var target any = map[string]any{}
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if target, err = decode(doc); err != nil {
+			return nil, fmt.Errorf("invalid document: %w", err)
+		}
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = map[string]any{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = merge(tm[k], v)
+	}
+	return tm
+}
+
+// Diff изменения от before к after: объекты сравниваются по ключам, массивы и скаляры — целиком.
+// Пустой документ и null, как и в Apply, считаются пустым объектом. Результат упорядочен по Path.
+func Diff(before, after []byte) ([]Change, error) {
+	a, err := decodeDoc(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decodeDoc(after)
+	if err != nil {
+		return nil, err
+	}
+	var out []Change
+	if err := diff(a, b, "", &out); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+func diff(a, b any, path string, out *[]Change) error {
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if aok && bok {
+		for k, av := range am {
+			bv, ok := bm[k]
+			if !ok {
+				bv = nil
+			}
+			if err := diff(av, bv, path+"/"+escape(k), out); err != nil {
+				return err
+			}
+		}
+		for k, bv := range bm {
+			if _, ok := am[k]; !ok {
+				if err := diff(nil, bv, path+"/"+escape(k), out); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	ra, err := raw(a)
+	if err != nil {
+		return err
+	}
+	rb, err := raw(b)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(ra, rb) {
+		return nil
+	}
+	*out = append(*out, Change{Path: path, Old: ra, New: rb})
+	return nil
+}
+
+// raw сериализует значение; json.Marshal сортирует ключи, поэтому равные значения дают равные байты
+func raw(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func decodeDoc(data []byte) (any, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return map[string]any{}, nil
+	}
+	v, err := decode(data)
+	if v == nil && err == nil {
+		v = map[string]any{}
+	}
+	return v, err
+}
+
+func decode(data []byte) (any, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
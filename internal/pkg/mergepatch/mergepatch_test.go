@@ -0,0 +1,117 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func sameJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var a, b any
+	if err := json.Unmarshal(got, &a); err != nil {
+		t.Fatalf("result %s is not JSON: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &b); err != nil {
+		t.Fatalf("want %s is not JSON: %v", want, err)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Примеры из RFC 7396, Appendix A
+func TestApplyRFC7396Examples(t *testing.T) {
+	cases := []struct {
+		original, patch, result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		got, err := Apply([]byte(tc.original), []byte(tc.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s): %v", tc.original, tc.patch, err)
+			continue
+		}
+		if !sameJSON(t, got, tc.result) {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tc.original, tc.patch, got, tc.result)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	// пустой документ — пустой объект
+	got, err := Apply(nil, []byte(`{"a":1,"b":null}`))
+	if err != nil || !sameJSON(t, got, `{"a":1}`) {
+		t.Fatalf("Apply(empty) = %s, %v", got, err)
+	}
+	// большие числа не теряют точность
+	got, err = Apply([]byte(`{"inn":1234567890123456789}`), []byte(`{"x":0.1}`))
+	if err != nil || string(got) != `{"inn":1234567890123456789,"x":0.1}` {
+		t.Fatalf("Apply(numbers) = %s, %v", got, err)
+	}
+
+	for name, tc := range map[string][2]string{
+		"invalid patch":      {`{}`, `{"a":`},
+		"trailing data":      {`{}`, `{"a":1} {"b":2}`},
+		"invalid document":   {`{"a"`, `{"a":1}`},
+		"empty patch":        {`{}`, ``},
+		"document not value": {`nope`, `{}`},
+	} {
+		if _, err := Apply([]byte(tc[0]), []byte(tc[1])); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := `{"purpose":"x","position":"stable","owner":{"name":"a","share":10},"tags":["a"],"a/b":1,"same":{"k":[1,2]}}`
+	after := `{"purpose":"y","owner":{"name":"a","share":10.0,"birth":"1990-01-01"},"tags":["a","b"],"a/b":1,"same":{"k":[1,2]}}`
+
+	got, err := Diff([]byte(before), []byte(after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Path: "/owner/birth", New: json.RawMessage(`"1990-01-01"`)},
+		{Path: "/owner/share", Old: json.RawMessage(`10`), New: json.RawMessage(`10.0`)},
+		{Path: "/position", Old: json.RawMessage(`"stable"`)},
+		{Path: "/purpose", Old: json.RawMessage(`"x"`), New: json.RawMessage(`"y"`)},
+		{Path: "/tags", Old: json.RawMessage(`["a"]`), New: json.RawMessage(`["a","b"]`)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Diff() = %+v", got)
+	}
+	for i := range want {
+		if got[i].Path != want[i].Path || string(got[i].Old) != string(want[i].Old) || string(got[i].New) != string(want[i].New) {
+			t.Errorf("change %d = {%s %s %s}, want {%s %s %s}", i,
+				got[i].Path, got[i].Old, got[i].New, want[i].Path, want[i].Old, want[i].New)
+		}
+	}
+
+	if d, err := Diff([]byte(`{"a":{"b":1}}`), []byte(`{"a":{"b":1}}`)); err != nil || len(d) != 0 {
+		t.Fatalf("Diff(equal) = %v, %v", d, err)
+	}
+	// пустой документ и null сравниваются как пустой объект, по полям
+	for _, empty := range []string{``, `null`, `{}`} {
+		d, err := Diff([]byte(empty), []byte(`{"a~b":1,"c/d":{"e":2}}`))
+		if err != nil || len(d) != 2 || d[0].Path != "/a~0b" || d[1].Path != "/c~1d" || d[0].Old != nil {
+			t.Fatalf("Diff(%q, doc) = %+v, %v", empty, d, err)
+		}
+	}
+	if d, _ := Diff([]byte(`{"a":1}`), nil); len(d) != 1 || d[0].Path != "/a" || d[0].New != nil {
+		t.Fatalf("Diff(doc, empty) = %+v", d)
+	}
+}
@@ -0,0 +1,358 @@
+// Package oidc клиент OpenID Connect: discovery, authorization code + PKCE,
+// обмен кода на токены и проверка ID-токена по JWKS провайдера
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"vector/internal/pkg/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidGrant провайдер отклонил код или refresh-токен (отозван, пользователь удален)
+	ErrInvalidGrant = errors.New("oidc: invalid grant")
+	ErrInvalidToken = errors.New("oidc: invalid id token")
+)
+
+// алгоритмы подписи ID-токена; HS* не принимаются
+var idTokenAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+const (
+	// JWKS провайдера перечитывается при неизвестном kid, но не чаще раза в minKeysRefresh
+	minKeysRefresh = time.Minute
+	clockLeeway    = time.Minute
+)
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // пусто для публичного клиента (только PKCE)
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Discovery поля документа /.well-known/openid-configuration
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Tokens ответ token endpoint
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// IDToken проверенный ID-токен
+type IDToken struct {
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+// String строковый claim (пусто, если нет или другой тип)
+func (t *IDToken) String(name string) string {
+	v, _ := t.Claims[name].(string)
+	return v
+}
+
+// Strings claim-список; строка с пробелами или запятыми тоже разбирается как список
+func (t *IDToken) Strings(name string) []string {
+	switch v := t.Claims[name].(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
+
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	discovery   *Discovery
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// PKCE пара code_verifier / code_challenge (метод S256)
+func PKCE() (verifier, challenge string, err error) {
+	verifier, err = RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString случайная строка base64url из n байт (state, nonce)
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, rawURL string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s: status %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dst)
+}
+
+// Discover загружает и кеширует документ discovery; issuer в нем должен совпадать с настроенным
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.IssuerURL, "/")
+	var d Discovery
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: issuer mismatch: configured %q, discovered %q", p.cfg.IssuerURL, d.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc: incomplete discovery document")
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// AuthCodeURL адрес страницы входа провайдера
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange обменивает код авторизации на токены
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Tokens, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	return p.token(ctx, form)
+}
+
+// Refresh получает новые токены по refresh-токену провайдера.
+// ErrInvalidGrant означает, что доступ пользователя у провайдера прекращен.
+func (p *Provider) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+	return p.token(ctx, form)
+}
+
+func (p *Provider) token(ctx context.Context, form url.Values) (*Tokens, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form.Set("client_id", p.cfg.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		if oauthErr.Error == "invalid_grant" {
+			return nil, ErrInvalidGrant
+		}
+		return nil, fmt.Errorf("oidc: token endpoint status %d: %s %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+	}
+
+	var tokens Tokens
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc: bad token response: %w", err)
+	}
+	return &tokens, nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return err
+	}
+	var set signing.JWKSet
+	if err := p.getJSON(ctx, d.JWKSURI, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			continue // ключи неподдерживаемых типов пропускаются
+		}
+		keys[jwk.Kid] = pub
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysFetched = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *Provider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, true
+	}
+	// токен без kid допустим, если у провайдера единственный ключ
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// keyfunc ключ по kid; при неизвестном kid JWKS перечитывается (ротация ключей провайдера)
+func (p *Provider) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := p.lookupKey(kid); ok {
+			return key, nil
+		}
+
+		p.mu.Lock()
+		stale := time.Since(p.keysFetched) > minKeysRefresh
+		p.mu.Unlock()
+		if stale {
+			if err := p.fetchKeys(ctx); err != nil {
+				return nil, err
+			}
+			if key, ok := p.lookupKey(kid); ok {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+}
+
+// VerifyIDToken проверяет подпись, iss, aud, срок действия и nonce ID-токена.
+// nonce пустой для токенов, полученных по refresh-токену.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, p.keyfunc(ctx),
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	token := &IDToken{Claims: claims}
+	token.Subject = token.String("sub")
+	if token.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+	if nonce != "" && token.String("nonce") != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	// при нескольких аудиториях токен должен быть выдан именно этому клиенту
+	if aud, _ := claims.GetAudience(); len(aud) > 1 && token.String("azp") != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp mismatch", ErrInvalidToken)
+	}
+	return token, nil
+}
@@ -0,0 +1,143 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+var (
+	commonOnce sync.Once
+	common     map[string]struct{}
+)
+
+func isCommon(password string) bool {
+	commonOnce.Do(func() {
+		common = make(map[string]struct{})
+		sc := bufio.NewScanner(strings.NewReader(commonPasswordsFile))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			common[strings.ToLower(line)] = struct{}{}
+		}
+	})
+	_, ok := common[strings.ToLower(password)]
+	return ok
+}
+
+// Policy требования к паролям пользователей приложения
+type Policy struct {
+	MinLength      int
+	MinCharClasses int           // из четырех: строчные, заглавные, цифры, прочие символы
+	HistorySize    int           // сколько последних паролей нельзя использовать повторно
+	MaxAge         time.Duration // срок действия пароля; 0 — без ограничения
+	BcryptCost     int
+}
+
+// DefaultPolicy значения по умолчанию
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:      10,
+		MinCharClasses: 3,
+		HistorySize:    5,
+		MaxAge:         90 * 24 * time.Hour,
+		BcryptCost:     12,
+	}
+}
+
+// PolicyError пароль не соответствует политике; Violations — список нарушений
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "пароль не соответствует требованиям: " + strings.Join(e.Violations, "; ")
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate проверяет длину, классы символов, список распространенных паролей
+// и совпадение с email. История проверяется отдельно (CheckHistory).
+func (p Policy) Validate(password, email string) error {
+	var v []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		v = append(v, fmt.Sprintf("не менее %d символов", p.MinLength))
+	}
+	if charClasses(password) < p.MinCharClasses {
+		v = append(v, fmt.Sprintf("символы не менее %d типов из: строчные, заглавные буквы, цифры, спецсимволы", p.MinCharClasses))
+	}
+	if isCommon(password) {
+		v = append(v, "пароль входит в список распространенных")
+	}
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) >= 3 &&
+		strings.Contains(strings.ToLower(password), local) {
+		v = append(v, "пароль не должен содержать имя из email")
+	}
+	if len(v) > 0 {
+		return &PolicyError{Violations: v}
+	}
+	return nil
+}
+
+// CheckHistory отклоняет пароль, совпадающий с одним из предыдущих хешей
+func (p Policy) CheckHistory(password string, hashes []string) error {
+	for _, h := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(h), []byte(password)) == nil {
+			return &PolicyError{Violations: []string{fmt.Sprintf("пароль совпадает с одним из %d последних", p.HistorySize)}}
+		}
+	}
+	return nil
+}
+
+// Hash хеширует пароль с cost политики
+func (p Policy) Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+	return string(b), err
+}
+
+// NeedsRehash хеш создан с меньшим cost, чем требует политика
+func (p Policy) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < p.BcryptCost
+}
+
+// Expired истек ли срок действия пароля, установленного в changedAt
+func (p Policy) Expired(changedAt *time.Time, now time.Time) bool {
+	if p.MaxAge <= 0 || changedAt == nil {
+		return false
+	}
+	return now.Sub(*changedAt) > p.MaxAge
+}
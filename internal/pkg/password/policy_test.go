@@ -0,0 +1,165 @@
+package password
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidate(t *testing.T) {
+	p := DefaultPolicy()
+
+	cases := []struct {
+		name       string
+		password   string
+		email      string
+		violations int
+	}{
+		{"valid three classes", "Correct-horse", "user@example.com", 0},
+		{"valid four classes", "Tr0ub4dor&3x", "user@example.com", 0},
+		{"cyrillic letters count as classes", "Пароль-длинный", "user@example.com", 0},
+		{"exactly min length", "Abcdefgh1!", "", 0},
+		{"one short", "Abcdefg1!", "", 1},
+		{"length counts characters, not bytes", "Пароль123", "", 1},
+		{"two classes", "abcdefghij1", "", 1},
+		{"one class", "abcdefghijkl", "", 1},
+		{"spaces count as symbols", "abc def ghi1", "", 0},
+		{"short and one class", "abc", "", 2},
+		{"common password", "P@ssw0rd", "", 2},
+		{"common password any case", "Password123", "", 1},
+		{"contains email name", "Ivanov-2026!", "ivanov@example.com", 1},
+		{"email name any case", "IVANOV-2026!", "Ivanov@Example.com", 1},
+		{"short email name ignored", "Al-2026-pass", "al@example.com", 0},
+		{"not an email", "Ivanov-2026!", "ivanov", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := p.Validate(tc.password, tc.email)
+			if tc.violations == 0 {
+				if err != nil {
+					t.Fatalf("Validate(%q) = %v, want nil", tc.password, err)
+				}
+				return
+			}
+			var pe *PolicyError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Validate(%q) = %v, want *PolicyError", tc.password, err)
+			}
+			if len(pe.Violations) != tc.violations {
+				t.Fatalf("Validate(%q) violations = %q, want %d", tc.password, pe.Violations, tc.violations)
+			}
+		})
+	}
+}
+
+func TestValidateMinCharClasses(t *testing.T) {
+	cases := []struct {
+		password string
+		classes  int
+	}{
+		{"abcdefghij", 1},
+		{"ABCDEFGHIJ", 1},
+		{"1234509876", 1},
+		{"abcdeFGHIJ", 2},
+		{"abcde12345", 2},
+		{"abcDE12345", 3},
+		{"abcDE123!_", 4},
+	}
+	for _, tc := range cases {
+		if got := charClasses(tc.password); got != tc.classes {
+			t.Errorf("charClasses(%q) = %d, want %d", tc.password, got, tc.classes)
+		}
+		for min := 1; min <= 4; min++ {
+			p := Policy{MinLength: 1, MinCharClasses: min}
+			if err := p.Validate(tc.password, ""); (err == nil) != (tc.classes >= min) && !isCommon(tc.password) {
+				t.Errorf("MinCharClasses %d, %q: err = %v", min, tc.password, err)
+			}
+		}
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	p := Policy{HistorySize: 3, BcryptCost: bcrypt.MinCost}
+
+	var history []string
+	for _, old := range []string{"Old-password-1", "Old-password-2", "Old-password-3"} {
+		h, err := p.Hash(old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		history = append(history, h)
+	}
+
+	cases := []struct {
+		password string
+		reused   bool
+	}{
+		{"Old-password-1", true},
+		{"Old-password-3", true},
+		{"old-password-1", false},
+		{"Old-password-4", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		err := p.CheckHistory(tc.password, history)
+		var pe *PolicyError
+		if reused := errors.As(err, &pe); reused != tc.reused {
+			t.Errorf("CheckHistory(%q) = %v, reused want %v", tc.password, err, tc.reused)
+		}
+	}
+	if err := p.CheckHistory("Old-password-1", nil); err != nil {
+		t.Errorf("empty history: %v", err)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+	day := 24 * time.Hour
+
+	cases := []struct {
+		name      string
+		maxAge    time.Duration
+		changedAt *time.Time
+		expired   bool
+	}{
+		{"fresh", 90 * day, at(day), false},
+		{"exactly max age", 90 * day, at(90 * day), false},
+		{"past max age", 90 * day, at(90*day + time.Second), true},
+		{"long ago", 90 * day, at(400 * day), true},
+		{"never changed", 90 * day, nil, false},
+		{"expiry disabled", 0, at(400 * day), false},
+		{"negative disables", -day, at(400 * day), false},
+	}
+	for _, tc := range cases {
+		p := Policy{MaxAge: tc.maxAge}
+		if got := p.Expired(tc.changedAt, now); got != tc.expired {
+			t.Errorf("%s: Expired = %v, want %v", tc.name, got, tc.expired)
+		}
+	}
+}
+
+func TestHashAndRehash(t *testing.T) {
+	low := Policy{BcryptCost: bcrypt.MinCost}
+	h, err := low.Hash("Correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(h), []byte("Correct-horse")) != nil {
+		t.Fatal("hash does not match the password")
+	}
+	if low.NeedsRehash(h) {
+		t.Error("hash with the policy cost needs no rehash")
+	}
+	if !(Policy{BcryptCost: bcrypt.MinCost + 1}).NeedsRehash(h) {
+		t.Error("hash below the policy cost must be rehashed")
+	}
+	if (Policy{BcryptCost: bcrypt.MinCost + 1}).NeedsRehash("not a bcrypt hash") {
+		t.Error("unparsable hash must not be reported for rehash")
+	}
+}
@@ -0,0 +1,35 @@
+// Package ratelimit ограничение частоты запросов фиксированным окном.
+// Счетчик окна хранит вызывающий (например, в Postgres, чтобы лимит был общим для всех экземпляров
+// приложения); пакет только вычисляет по нему результат проверки.
+package ratelimit
+
+import "time"
+
+// Result итог проверки: Remaining — оставшиеся запросы в окне, RetryAfter — время до нового окна
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Unlimited результат для limit <= 0 — без ограничений
+func Unlimited(limit int) Result {
+	return Result{Allowed: true, Limit: limit, Remaining: -1}
+}
+
+// Check результат для окна длиной period, открытого в windowStart, в котором с учетом текущего
+// сделано requests запросов
+func Check(windowStart time.Time, requests, limit int, period time.Duration, now time.Time) Result {
+	if limit <= 0 {
+		return Unlimited(limit)
+	}
+	reset := windowStart.Add(period).Sub(now)
+	if reset < 0 {
+		reset = 0
+	}
+	if requests > limit {
+		return Result{Allowed: false, Limit: limit, Remaining: 0, RetryAfter: reset}
+	}
+	return Result{Allowed: true, Limit: limit, Remaining: limit - requests, RetryAfter: reset}
+}
@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckFixedWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		r := Check(start, i, 3, time.Minute, start.Add(time.Duration(i)*time.Second))
+		if !r.Allowed || r.Remaining != 3-i {
+			t.Fatalf("request %d: %+v", i, r)
+		}
+	}
+
+	r := Check(start, 4, 3, time.Minute, start.Add(9*time.Second))
+	if r.Allowed || r.Remaining != 0 {
+		t.Fatalf("over limit: %+v", r)
+	}
+	if want := 51 * time.Second; r.RetryAfter != want {
+		t.Fatalf("RetryAfter = %v, want %v", r.RetryAfter, want)
+	}
+
+	// часы экземпляра немного впереди часов, открывших окно
+	if r := Check(start, 4, 3, time.Minute, start.Add(61*time.Second)); r.RetryAfter != 0 {
+		t.Fatalf("RetryAfter past the window = %v", r.RetryAfter)
+	}
+}
+
+func TestCheckUnlimited(t *testing.T) {
+	now := time.Now()
+	for _, limit := range []int{0, -1} {
+		if r := Check(now, 1000, limit, time.Minute, now); !r.Allowed || r.Remaining != -1 {
+			t.Fatalf("limit %d must not restrict: %+v", limit, r)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package riskscore
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"vector/internal/models"
+)
+
+// Facts атрибуты клиента для правил: bool, string, int или []string.
+// Отсутствующий факт (нет данных) не срабатывает ни в одном правиле.
+type Facts map[string]any
+
+// Факты, доступные правилам
+const (
+	FactIsRfResident       = "is_rf_resident"
+	FactIsRfTaxpayer       = "is_rf_taxpayer"
+	FactTaxStatus          = "tax_status"
+	FactIsAmericanNational = "is_american_national"
+	FactDocumentType       = "document_type"
+	FactDocumentCountry    = "document_country"
+	FactCountry            = "country"
+	FactLegalCapacity      = "legal_capacity"
+	FactQualifiedInvestor  = "qualified_investor"
+	FactIdentificationType = "identification_type"
+	FactExternalRiskLevel  = "external_risk_level"
+	FactPEP                = "pep"                 // признак ПДЛ из Raw (is_pep, pep, is_public_official)
+	FactContractKinds      = "contract_kinds"      // виды действующих договоров
+	FactActiveContracts    = "active_contracts"    // число действующих договоров
+	FactHasIIS             = "has_iis"             // есть действующий ИИС
+	FactScreeningHits      = "screening_hits"      // проверки второй части со статусом hit
+	FactScreeningHitKinds  = "screening_hit_kinds" // виды проверок с совпадениями
+)
+
+var knownFacts = []string{
+	FactIsRfResident, FactIsRfTaxpayer, FactTaxStatus, FactIsAmericanNational, FactDocumentType,
+	FactDocumentCountry, FactCountry, FactLegalCapacity, FactQualifiedInvestor, FactIdentificationType,
+	FactExternalRiskLevel, FactPEP, FactContractKinds, FactActiveContracts, FactHasIIS,
+	FactScreeningHits, FactScreeningHitKinds,
+}
+
+func isKnownFact(name string) bool {
+	for _, f := range knownFacts {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckStatusHit статус проверки второй части, означающий совпадение (санкционные списки, ПДЛ и т.п.)
+const CheckStatusHit = "hit"
+
+var pepKeys = []string{"is_pep", "pep", "is_public_official"}
+
+// FactsFor собирает факты по текущей версии клиента, его действующим договорам и проверкам
+func FactsFor(cv models.ClientVersion, contracts []models.Contract, checks []models.SecondPartCheck) Facts {
+	f := Facts{}
+	setBool(f, FactIsRfResident, cv.IsRfResident)
+	setBool(f, FactIsRfTaxpayer, cv.IsRfTaxpayer)
+	setBool(f, FactIsAmericanNational, cv.IsAmericanNational)
+	setBool(f, FactQualifiedInvestor, cv.QualifiedInvestor)
+	setString(f, FactTaxStatus, cv.TaxStatus)
+	setString(f, FactDocumentType, cv.DocumentType)
+	setString(f, FactDocumentCountry, cv.DocumentCountry)
+	setString(f, FactCountry, cv.Country)
+	setString(f, FactLegalCapacity, cv.LegalCapacity)
+	setString(f, FactIdentificationType, cv.IdentificationType)
+	setString(f, FactExternalRiskLevel, cv.ExternalRiskLevel)
+
+	if pep, ok := rawPEP(cv); ok {
+		f[FactPEP] = pep
+	}
+
+	kinds := map[string]bool{}
+	active, iis := 0, false
+	for _, c := range contracts {
+		if c.Status != "active" {
+			continue
+		}
+		active++
+		kinds[c.Kind] = true
+		iis = iis || c.IsPersonalInvestAccount || c.IsPersonalInvestAccountNew
+	}
+	f[FactActiveContracts] = active
+	f[FactContractKinds] = sortedKeys(kinds)
+	f[FactHasIIS] = iis
+
+	hits, hitKinds := 0, map[string]bool{}
+	for _, ch := range checks {
+		if strings.EqualFold(ch.Status, CheckStatusHit) {
+			hits++
+			hitKinds[ch.Kind] = true
+		}
+	}
+	f[FactScreeningHits] = hits
+	f[FactScreeningHitKinds] = sortedKeys(hitKinds)
+
+	return f
+}
+
+// rawPEP ищет признак ПДЛ в Raw: на верхнем уровне или в person_info
+func rawPEP(cv models.ClientVersion) (bool, bool) {
+	var raw map[string]any
+	if len(cv.Raw) == 0 || json.Unmarshal(cv.Raw, &raw) != nil {
+		return false, false
+	}
+	sources := []map[string]any{raw}
+	if pi, ok := raw["person_info"].(map[string]any); ok {
+		sources = append(sources, pi)
+	}
+	for _, m := range sources {
+		for _, key := range pepKeys {
+			if v, ok := m[key].(bool); ok {
+				return v, true
+			}
+		}
+	}
+	return false, false
+}
+
+func setBool(f Facts, name string, v *bool) {
+	if v != nil {
+		f[name] = *v
+	}
+}
+
+func setString(f Facts, name, v string) {
+	if v = strings.TrimSpace(v); v != "" {
+		f[name] = v
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,218 @@
+// Package riskscore предлагает уровень риска второй части по настраиваемым правилам.
+// Баллы сработавших правил суммируются, уровень определяется порогами; правило с min_level
+// поднимает уровень не ниже указанного. Каждое сработавшее правило попадает в факторы предложения.
+package riskscore
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+)
+
+//go:embed default_rules.json
+var defaultRulesFile []byte
+
+// Операторы сравнения факта со значением правила
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpGte      = "gte"
+	OpLte      = "lte"
+	OpContains = "contains" // факт-список содержит значение
+)
+
+// Rules набор правил скоринга
+type Rules struct {
+	Version    string `json:"version"`
+	Thresholds struct {
+		Medium int `json:"medium"`
+		High   int `json:"high"`
+	} `json:"thresholds"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule правило: если факт Fact удовлетворяет Op/Value, к сумме добавляется Points
+type Rule struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Fact        string `json:"fact"`
+	Op          string `json:"op"`
+	Value       any    `json:"value"`
+	Points      int    `json:"points"`
+	MinLevel    string `json:"min_level,omitempty"`
+}
+
+// Default правила по умолчанию (default_rules.json)
+func Default() Rules {
+	r, err := Parse(defaultRulesFile)
+	if err != nil {
+		panic("riskscore: invalid default rules: " + err.Error())
+	}
+	return r
+}
+
+// Parse читает и проверяет правила в формате default_rules.json
+func Parse(data []byte) (Rules, error) {
+	var r Rules
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("invalid rules json: %w", err)
+	}
+	return r, r.Validate()
+}
+
+// Validate проверяет пороги, коды, факты и операторы правил
+func (r Rules) Validate() error {
+	if r.Thresholds.Medium <= 0 || r.Thresholds.High < r.Thresholds.Medium {
+		return fmt.Errorf("thresholds: expected 0 < medium <= high")
+	}
+	seen := make(map[string]bool, len(r.Rules))
+	for i, rule := range r.Rules {
+		if rule.Code == "" {
+			return fmt.Errorf("rule %d: code is required", i)
+		}
+		if seen[rule.Code] {
+			return fmt.Errorf("rule %s: duplicate code", rule.Code)
+		}
+		seen[rule.Code] = true
+
+		if !isKnownFact(rule.Fact) {
+			return fmt.Errorf("rule %s: unknown fact %q", rule.Code, rule.Fact)
+		}
+		if rule.MinLevel != "" && models.RiskLevelRank(rule.MinLevel) < 0 {
+			return fmt.Errorf("rule %s: invalid min_level %q", rule.Code, rule.MinLevel)
+		}
+		switch rule.Op {
+		case OpEq, OpNe, OpContains:
+			if rule.Value == nil {
+				return fmt.Errorf("rule %s: value is required", rule.Code)
+			}
+		case OpIn, OpNotIn:
+			if _, ok := rule.Value.([]any); !ok {
+				return fmt.Errorf("rule %s: %s expects a list value", rule.Code, rule.Op)
+			}
+		case OpGte, OpLte:
+			if _, ok := number(rule.Value); !ok {
+				return fmt.Errorf("rule %s: %s expects a number value", rule.Code, rule.Op)
+			}
+		default:
+			return fmt.Errorf("rule %s: unknown op %q", rule.Code, rule.Op)
+		}
+	}
+	return nil
+}
+
+// Score считает предложение по фактам клиента
+func (r Rules) Score(facts Facts) models.RiskAssessment {
+	out := models.RiskAssessment{
+		Factors:      []models.RiskFactor{},
+		RulesVersion: r.Version,
+		AssessedAt:   time.Now().UTC(),
+	}
+
+	minRank := 0
+	for _, rule := range r.Rules {
+		value, ok := facts[rule.Fact]
+		if !ok || value == nil || !rule.matches(value) {
+			continue
+		}
+		out.Score += rule.Points
+		out.Factors = append(out.Factors, models.RiskFactor{
+			Code:        rule.Code,
+			Description: rule.Description,
+			Fact:        rule.Fact,
+			Value:       value,
+			Points:      rule.Points,
+			MinLevel:    rule.MinLevel,
+		})
+		if rank := models.RiskLevelRank(rule.MinLevel); rank > minRank {
+			minRank = rank
+		}
+	}
+
+	rank := 0
+	switch {
+	case out.Score >= r.Thresholds.High:
+		rank = 2
+	case out.Score >= r.Thresholds.Medium:
+		rank = 1
+	}
+	if minRank > rank {
+		rank = minRank
+	}
+	out.Level = models.RiskLevels[rank]
+	return out
+}
+
+func (rule Rule) matches(value any) bool {
+	switch rule.Op {
+	case OpEq:
+		return equal(value, rule.Value)
+	case OpNe:
+		return !equal(value, rule.Value)
+	case OpIn, OpNotIn:
+		found := false
+		for _, v := range rule.Value.([]any) {
+			if equal(value, v) {
+				found = true
+				break
+			}
+		}
+		return found == (rule.Op == OpIn)
+	case OpGte, OpLte:
+		a, ok := number(value)
+		b, _ := number(rule.Value)
+		if !ok {
+			return false
+		}
+		if rule.Op == OpGte {
+			return a >= b
+		}
+		return a <= b
+	case OpContains:
+		list, ok := value.([]string)
+		if !ok {
+			return false
+		}
+		for _, v := range list {
+			if equal(v, rule.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// equal сравнивает факт со значением из JSON: строки без учета регистра, числа как float64
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return ok && strings.EqualFold(strings.TrimSpace(x), strings.TrimSpace(y))
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	return false
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
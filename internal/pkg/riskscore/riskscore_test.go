@@ -0,0 +1,196 @@
+package riskscore
+
+import (
+	"strings"
+	"testing"
+
+	"vector/internal/models"
+)
+
+func mustParse(t *testing.T, data string) Rules {
+	t.Helper()
+	r, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return r
+}
+
+func TestRuleMatches(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  string
+		value any
+		want  bool
+	}{
+		{"eq bool", `{"op":"eq","value":false}`, false, true},
+		{"eq bool mismatch", `{"op":"eq","value":false}`, true, false},
+		{"eq string ignores case and spaces", `{"op":"eq","value":"High"}`, " high ", true},
+		{"eq int against json number", `{"op":"eq","value":2}`, 2, true},
+		{"eq type mismatch", `{"op":"eq","value":"1"}`, 1, false},
+		{"ne", `{"op":"ne","value":"RU"}`, "KZ", true},
+		{"ne equal", `{"op":"ne","value":"RU"}`, "ru", false},
+		{"in", `{"op":"in","value":["high","critical"]}`, "HIGH", true},
+		{"in missing", `{"op":"in","value":["high"]}`, "low", false},
+		{"not_in", `{"op":"not_in","value":["RU","643"]}`, "US", true},
+		{"not_in listed", `{"op":"not_in","value":["RU","643"]}`, "643", false},
+		{"gte equal", `{"op":"gte","value":1}`, 1, true},
+		{"gte below", `{"op":"gte","value":2}`, 1, false},
+		{"lte", `{"op":"lte","value":0}`, 0, true},
+		{"gte non-number fact", `{"op":"gte","value":1}`, "5", false},
+		{"contains", `{"op":"contains","value":"iis"}`, []string{"broker", "IIS"}, true},
+		{"contains missing", `{"op":"contains","value":"iis"}`, []string{"broker"}, false},
+		{"contains non-list fact", `{"op":"contains","value":"iis"}`, "iis", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := mustParse(t, `{"version":"t","thresholds":{"medium":1,"high":2},"rules":[`+
+				strings.Replace(tc.rule, "{", `{"code":"r","fact":"country","points":1,`, 1)+`]}`)
+			if got := r.Rules[0].matches(tc.value); got != tc.want {
+				t.Fatalf("matches(%v) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+const scoringRules = `{
+  "version": "test-1",
+  "thresholds": {"medium": 20, "high": 50},
+  "rules": [
+    {"code": "pep", "fact": "pep", "op": "eq", "value": true, "points": 5, "min_level": "high"},
+    {"code": "non_resident", "fact": "is_rf_resident", "op": "eq", "value": false, "points": 25},
+    {"code": "american", "fact": "is_american_national", "op": "eq", "value": true, "points": 25},
+    {"code": "many_contracts", "fact": "active_contracts", "op": "gte", "value": 3, "points": 10},
+    {"code": "iis", "fact": "has_iis", "op": "eq", "value": true, "points": 0, "min_level": "medium"}
+  ]
+}`
+
+func TestScore(t *testing.T) {
+	rules := mustParse(t, scoringRules)
+
+	cases := []struct {
+		name    string
+		facts   Facts
+		score   int
+		level   string
+		factors []string
+	}{
+		{"no facts", Facts{}, 0, models.RiskLevelLow, nil},
+		{"nothing matches", Facts{FactIsRfResident: true, FactActiveContracts: 1}, 0, models.RiskLevelLow, nil},
+		{"below medium", Facts{FactActiveContracts: 3}, 10, models.RiskLevelLow, []string{"many_contracts"}},
+		{"exactly medium", Facts{FactIsRfResident: false}, 25, models.RiskLevelMedium, []string{"non_resident"}},
+		{"points accumulate to high", Facts{FactIsRfResident: false, FactIsAmericanNational: true},
+			50, models.RiskLevelHigh, []string{"non_resident", "american"}},
+		{"all accumulate", Facts{FactIsRfResident: false, FactIsAmericanNational: true, FactActiveContracts: 4},
+			60, models.RiskLevelHigh, []string{"non_resident", "american", "many_contracts"}},
+		{"min_level raises low score", Facts{FactPEP: true}, 5, models.RiskLevelHigh, []string{"pep"}},
+		{"zero-point rule still a factor", Facts{FactHasIIS: true}, 0, models.RiskLevelMedium, []string{"iis"}},
+		{"min_level does not lower", Facts{FactHasIIS: true, FactIsRfResident: false, FactIsAmericanNational: true},
+			50, models.RiskLevelHigh, []string{"non_resident", "american", "iis"}},
+		{"nil fact is missing data", Facts{FactPEP: nil}, 0, models.RiskLevelLow, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rules.Score(tc.facts)
+			if got.Score != tc.score || got.Level != tc.level {
+				t.Fatalf("score=%d level=%s, want %d %s", got.Score, got.Level, tc.score, tc.level)
+			}
+			if got.RulesVersion != "test-1" || got.AssessedAt.IsZero() {
+				t.Fatalf("assessment metadata = %q %v", got.RulesVersion, got.AssessedAt)
+			}
+			if len(got.Factors) != len(tc.factors) {
+				t.Fatalf("factors = %+v, want %v", got.Factors, tc.factors)
+			}
+			sum := 0
+			for i, f := range got.Factors {
+				if f.Code != tc.factors[i] {
+					t.Errorf("factor %d = %s, want %s", i, f.Code, tc.factors[i])
+				}
+				if f.Value != tc.facts[f.Fact] {
+					t.Errorf("factor %s value = %v, want fact value %v", f.Code, f.Value, tc.facts[f.Fact])
+				}
+				sum += f.Points
+			}
+			if sum != got.Score {
+				t.Errorf("factor points sum to %d, score is %d", sum, got.Score)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	rule := func(body string) string {
+		return `{"version":"t","thresholds":{"medium":10,"high":20},"rules":[` + body + `]}`
+	}
+	cases := map[string]string{
+		"not json":           `{"rules": [`,
+		"wrong field type":   `{"version":"t","thresholds":{"medium":"10","high":20},"rules":[]}`,
+		"no thresholds":      `{"version":"t","rules":[]}`,
+		"high below medium":  `{"version":"t","thresholds":{"medium":30,"high":20},"rules":[]}`,
+		"zero medium":        `{"version":"t","thresholds":{"medium":0,"high":20},"rules":[]}`,
+		"no code":            rule(`{"fact":"pep","op":"eq","value":true,"points":1}`),
+		"duplicate code":     rule(`{"code":"a","fact":"pep","op":"eq","value":true},{"code":"a","fact":"pep","op":"eq","value":false}`),
+		"unknown fact":       rule(`{"code":"a","fact":"salary","op":"gte","value":1}`),
+		"unknown op":         rule(`{"code":"a","fact":"pep","op":"like","value":true}`),
+		"no op":              rule(`{"code":"a","fact":"pep","value":true}`),
+		"eq without value":   rule(`{"code":"a","fact":"pep","op":"eq"}`),
+		"in with scalar":     rule(`{"code":"a","fact":"country","op":"in","value":"RU"}`),
+		"not_in with scalar": rule(`{"code":"a","fact":"country","op":"not_in","value":"RU"}`),
+		"gte with string":    rule(`{"code":"a","fact":"active_contracts","op":"gte","value":"3"}`),
+		"lte without value":  rule(`{"code":"a","fact":"active_contracts","op":"lte"}`),
+		"invalid min_level":  rule(`{"code":"a","fact":"pep","op":"eq","value":true,"min_level":"critical"}`),
+	}
+	for name, data := range cases {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDefaultRules(t *testing.T) {
+	r := Default()
+	if r.Version == "" || len(r.Rules) == 0 {
+		t.Fatalf("default rules are empty: %+v", r)
+	}
+	// ПДЛ всегда высокий риск
+	if got := r.Score(Facts{FactPEP: true}); got.Level != models.RiskLevelHigh {
+		t.Fatalf("pep level = %s, want high", got.Level)
+	}
+}
+
+func TestFactsFor(t *testing.T) {
+	no, yes := false, true
+	cv := models.ClientVersion{
+		IsRfResident:       &no,
+		IsAmericanNational: &yes,
+		Country:            "  ",
+		DocumentCountry:    "KZ",
+		Raw:                []byte(`{"person_info":{"is_pep":true}}`),
+	}
+	contracts := []models.Contract{
+		{Status: "active", Kind: "broker"},
+		{Status: "active", Kind: "iis", IsPersonalInvestAccount: true},
+		{Status: "closed", Kind: "trust"},
+	}
+	checks := []models.SecondPartCheck{{Kind: "sanctions", Status: "HIT"}, {Kind: "pep", Status: "clear"}}
+
+	f := FactsFor(cv, contracts, checks)
+	if f[FactIsRfResident] != false || f[FactIsAmericanNational] != true || f[FactPEP] != true {
+		t.Fatalf("bool facts = %v", f)
+	}
+	if _, set := f[FactCountry]; set {
+		t.Error("blank string must be missing data")
+	}
+	if _, set := f[FactIsRfTaxpayer]; set {
+		t.Error("nil bool must be missing data")
+	}
+	if f[FactActiveContracts] != 2 || f[FactHasIIS] != true {
+		t.Errorf("contracts facts = %v %v", f[FactActiveContracts], f[FactHasIIS])
+	}
+	if kinds := f[FactContractKinds].([]string); strings.Join(kinds, ",") != "broker,iis" {
+		t.Errorf("contract kinds = %v", kinds)
+	}
+	if f[FactScreeningHits] != 1 || strings.Join(f[FactScreeningHitKinds].([]string), ",") != "sanctions" {
+		t.Errorf("screening facts = %v %v", f[FactScreeningHits], f[FactScreeningHitKinds])
+	}
+}
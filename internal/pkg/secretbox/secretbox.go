@@ -0,0 +1,60 @@
+// Package secretbox шифрование небольших секретов для хранения в БД (AES-256-GCM)
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize длина ключа AES-256
+const KeySize = 32
+
+var ErrMalformed = errors.New("secretbox: malformed ciphertext")
+
+type Box struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secretbox: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal шифрует plaintext; результат — base64(nonce || ciphertext).
+// additional связывает шифротекст с записью (например, id пользователя):
+// скопированное в другую строку значение не расшифруется.
+func (b *Box) Seal(plaintext, additional []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := b.aead.Seal(nonce, nonce, plaintext, additional)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+func (b *Box) Open(sealed string, additional []byte) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
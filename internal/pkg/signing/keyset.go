@@ -0,0 +1,316 @@
+// Package signing ключи подписи JWT (RS256, EdDSA) с идентификаторами kid и публикацией JWKS
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// минимальный размер ключа RSA
+const minRSABits = 2048
+
+// ErrVerifyOnly набор загружен из публичных ключей и подписывать не может
+var ErrVerifyOnly = errors.New("signing key set is verify-only")
+
+// Key ключ подписи; в наборе сервиса, выдающего токены, приватная часть есть у всех ключей,
+// но подписывает только активный — остальные нужны для проверки уже выданных токенов.
+// У ключей набора только для проверки (LoadPublicDir) Private пуст.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+
+	public crypto.PublicKey
+}
+
+func (k *Key) Public() crypto.PublicKey {
+	return k.public
+}
+
+// KeySet набор ключей с одним активным; у набора только для проверки активного ключа нет
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+func newKey(kid string, signer crypto.Signer) (*Key, error) {
+	key, err := newPublicKey(kid, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.Private = signer
+	return key, nil
+}
+
+func newPublicKey(kid string, pub crypto.PublicKey) (*Key, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("key %s: RSA key must be at least %d bits", kid, minRSABits)
+		}
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, public: pub}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, public: pub}, nil
+	}
+	return nil, fmt.Errorf("key %s: unsupported key type %T (RSA or Ed25519 expected)", kid, pub)
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return signer, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// parsePublicKey разбирает публичный ключ PKIX ("PUBLIC KEY", openssl pkey -pubout).
+// Приватный ключ отклоняется: его не должно быть у сервиса, который только проверяет токены.
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if strings.Contains(block.Type, "PRIVATE") {
+		return nil, errors.New("private key found, only public keys are expected (export with openssl pkey -pubout)")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// readDir читает *.pem каталога по порядку имен; kid — имя файла без расширения
+func readDir(dir string, parse func(kid string, data []byte) (*Key, error)) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.pem keys in %s", dir)
+	}
+	sort.Strings(paths)
+
+	ks := &KeySet{keys: make(map[string]*Key, len(paths))}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parse(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[kid] = key
+		ks.active = key
+	}
+	return ks, nil
+}
+
+// LoadDir загружает приватные ключи из *.pem в каталоге; kid — имя файла без расширения.
+// Подписывает ключ activeKID, а если он не задан — последний по имени
+// (удобно называть ключи датой выпуска: 2026-01, 2026-07).
+func LoadDir(dir, activeKID string) (*KeySet, error) {
+	ks, err := readDir(dir, func(kid string, data []byte) (*Key, error) {
+		signer, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		return newKey(kid, signer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if activeKID != "" {
+		key, ok := ks.keys[activeKID]
+		if !ok {
+			return nil, fmt.Errorf("active key %q not found in %s", activeKID, dir)
+		}
+		ks.active = key
+	}
+	return ks, nil
+}
+
+// LoadPublicDir загружает набор только для проверки токенов из публичных ключей *.pem в каталоге;
+// kid — имя файла без расширения и должен совпадать с именем приватного ключа у выдающего сервиса
+func LoadPublicDir(dir string) (*KeySet, error) {
+	ks, err := readDir(dir, func(kid string, data []byte) (*Key, error) {
+		pub, err := parsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		return newPublicKey(kid, pub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ks.active = nil
+	return ks, nil
+}
+
+// Ephemeral набор из одного случайного ключа Ed25519 (только для разработки:
+// после перезапуска выданные access-токены перестают приниматься)
+func Ephemeral() (*KeySet, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newKey("ephemeral", priv)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{active: key, keys: map[string]*Key{key.ID: key}}, nil
+}
+
+// ActiveKID идентификатор ключа, которым подписываются новые токены; пусто у набора только для проверки
+func (ks *KeySet) ActiveKID() string {
+	if ks.active == nil {
+		return ""
+	}
+	return ks.active.ID
+}
+
+// Sign подписывает claims активным ключом и указывает kid в заголовке
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.active == nil {
+		return "", ErrVerifyOnly
+	}
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Private)
+}
+
+// Keyfunc выбирает ключ проверки по kid; алгоритм токена должен совпадать с алгоритмом ключа
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+	return key.Public(), nil
+}
+
+// Algorithms алгоритмы ключей набора (для jwt.WithValidMethods)
+func (ks *KeySet) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range ks.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// JWK публичный ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC и Ed25519 (OKP)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey разбирает публичный ключ JWK (RSA, EC P-256/384/521, Ed25519)
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwk %s: bad modulus", j.Kid)
+		}
+		e, err := b64.DecodeString(j.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("jwk %s: bad exponent", j.Kid)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.Kid, j.Crv)
+		}
+		x, errX := b64.DecodeString(j.X)
+		y, errY := b64.DecodeString(j.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("jwk %s: bad coordinates", j.Kid)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("jwk %s: point is not on curve", j.Kid)
+		}
+		return pub, nil
+	case "OKP":
+		x, err := b64.DecodeString(j.X)
+		if j.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwk %s: unsupported OKP key", j.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("jwk %s: unsupported key type %q", j.Kid, j.Kty)
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS публичные ключи всех ключей набора, отсортированные по kid
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(ks.keys))}
+	b64 := base64.RawURLEncoding
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64.EncodeToString(pub.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
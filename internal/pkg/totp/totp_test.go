@@ -0,0 +1,126 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// секрет тестовых векторов RFC 6238, Appendix B (HMAC-SHA1)
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC приводит 8-значные коды; 6-значный код — их последние 6 цифр
+	cases := []struct {
+		unix int64
+		step int64
+		code string
+	}{
+		{59, 0x1, "94287082"},
+		{1111111109, 0x23523EC, "07081804"},
+		{1111111111, 0x23523ED, "14050471"},
+		{1234567890, 0x273EF07, "89005924"},
+		{2000000000, 0x3F940AA, "69279037"},
+		{20000000000, 0x27BC86AA, "65353130"},
+	}
+	for _, tc := range cases {
+		at := time.Unix(tc.unix, 0).UTC()
+		if got := Step(at); got != tc.step {
+			t.Errorf("Step(%d) = %#x, want %#x", tc.unix, got, tc.step)
+		}
+		want := tc.code[len(tc.code)-Digits:]
+		if got := Code(rfcSecret, tc.step); got != want {
+			t.Errorf("Code(step %#x) = %s, want %s", tc.step, got, want)
+		}
+		if step, ok := Validate(rfcSecret, want, at, 0); !ok || step != tc.step {
+			t.Errorf("Validate(%s at %d) = %d, %v", want, tc.unix, step, ok)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	cases := []struct {
+		offset int64
+		skew   int
+		ok     bool
+	}{
+		{0, 0, true},
+		{-1, 0, false},
+		{1, 0, false},
+		{-1, 1, true},
+		{1, 1, true},
+		{-2, 1, false},
+		{2, 1, false},
+		{-2, 2, true},
+	}
+	for _, tc := range cases {
+		code := Code(rfcSecret, current+tc.offset)
+		step, ok := Validate(rfcSecret, code, now, tc.skew)
+		if ok != tc.ok {
+			t.Errorf("offset %d skew %d: ok = %v, want %v", tc.offset, tc.skew, ok, tc.ok)
+		}
+		// шаг возвращается для защиты от повторного использования
+		if ok && step != current+tc.offset {
+			t.Errorf("offset %d skew %d: step = %d, want %d", tc.offset, tc.skew, step, current+tc.offset)
+		}
+	}
+
+	// границы шага: 30 секунд одного шага принимают один и тот же код
+	start := time.Unix(current*30, 0)
+	code := Code(rfcSecret, current)
+	for _, at := range []time.Time{start, start.Add(29 * time.Second)} {
+		if _, ok := Validate(rfcSecret, code, at, 0); !ok {
+			t.Errorf("code rejected at %v within its step", at)
+		}
+	}
+	if _, ok := Validate(rfcSecret, code, start.Add(30*time.Second), 0); ok {
+		t.Error("code accepted in the next step without skew")
+	}
+}
+
+func TestValidateInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	if _, ok := Validate(rfcSecret, " 287 082 ", now, 0); !ok {
+		t.Error("spaces must be ignored")
+	}
+	for _, code := range []string{"", "28708", "2870820", "94287082", "abcdef", "287083"} {
+		if _, ok := Validate(rfcSecret, code, now, 1); ok {
+			t.Errorf("Validate(%q) accepted", code)
+		}
+	}
+	if _, ok := Validate([]byte("another secret 12345"), "287082", now, 1); ok {
+		t.Error("code accepted for another secret")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	if got := EncodeSecret(rfcSecret); got != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Fatalf("EncodeSecret = %s", got)
+	}
+
+	raw := ProvisioningURI("Vector", "admin@vector.com", rfcSecret)
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Vector:admin@vector.com" {
+		t.Fatalf("URI = %s", raw)
+	}
+	q := u.Query()
+	if q.Get("secret") != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" || q.Get("issuer") != "Vector" ||
+		q.Get("algorithm") != "SHA1" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Fatalf("URI params = %v", q)
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil || len(secret) != SecretSize {
+		t.Fatalf("GenerateSecret = %d bytes, %v", len(secret), err)
+	}
+	if strings.Contains(EncodeSecret(secret), "=") {
+		t.Fatal("encoded secret must not be padded")
+	}
+}
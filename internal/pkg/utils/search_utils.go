@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	SearchTypeINN      = "inn"
+	SearchTypeSNILS    = "snils"
+	SearchTypePassport = "passport"
+	SearchTypePhone    = "phone"
+	SearchTypeEmail    = "email"
+	SearchTypeText     = "text"
+)
+
+// SearchQuery разобранный поисковый запрос по клиентам
+type SearchQuery struct {
+	Raw    string
+	Digits string
+	// Words слова запроса (только буквы и цифры) для полнотекстового поиска
+	Words []string
+	// Types возможные типы запроса в порядке приоритета
+	Types []string
+}
+
+var (
+	identifierChars = regexp.MustCompile(`^[\d\s\-+().]+$`)
+	snilsFormat     = regexp.MustCompile(`^\d{3}-\d{3}-\d{3}[\s-]\d{2}$`)
+	passportFormat  = regexp.MustCompile(`^\d{2}\s?\d{2}\s*[№N]?\s*\d{6}$`)
+	nonDigits       = regexp.MustCompile(`\D`)
+)
+
+// ParseSearchQuery определяет тип поискового запроса:
+// 10/12 цифр — ИНН, 11 цифр — СНИЛС, серия+номер — паспорт, телефон с нормализацией,
+// строка с @ — email, остальное — ФИО/логин.
+func ParseSearchQuery(raw string) SearchQuery {
+	raw = strings.TrimSpace(raw)
+	q := SearchQuery{Raw: raw, Digits: nonDigits.ReplaceAllString(raw, "")}
+
+	switch {
+	case strings.Contains(raw, "@"):
+		q.Types = []string{SearchTypeEmail, SearchTypeText}
+	case passportFormat.MatchString(raw) && strings.ContainsAny(raw, " №N"):
+		q.Types = []string{SearchTypePassport}
+	case identifierChars.MatchString(raw) && q.Digits != "":
+		q.Types = detectIdentifierTypes(raw, q.Digits)
+	default:
+		q.Types = []string{SearchTypeText}
+	}
+
+	q.Words = searchWords(raw)
+	return q
+}
+
+func detectIdentifierTypes(raw, digits string) []string {
+	phoneLike := strings.HasPrefix(raw, "+") || strings.ContainsAny(raw, "()")
+	if phoneLike {
+		return []string{SearchTypePhone}
+	}
+
+	switch len(digits) {
+	case 12:
+		return []string{SearchTypeINN}
+	case 11:
+		if snilsFormat.MatchString(raw) {
+			return []string{SearchTypeSNILS}
+		}
+		if digits[0] == '7' || digits[0] == '8' {
+			return []string{SearchTypeSNILS, SearchTypePhone}
+		}
+		return []string{SearchTypeSNILS}
+	case 10:
+		return []string{SearchTypeINN, SearchTypePassport, SearchTypePhone}
+	case 6:
+		// только номер паспорта
+		return []string{SearchTypePassport}
+	}
+	if len(digits) >= 5 {
+		return []string{SearchTypePhone}
+	}
+	return []string{SearchTypeText}
+}
+
+// NormalizePhone приводит телефон к 10 значащим цифрам (без кода страны 7/8)
+func NormalizePhone(phone string) string {
+	d := nonDigits.ReplaceAllString(phone, "")
+	if len(d) == 11 && (d[0] == '7' || d[0] == '8') {
+		return d[1:]
+	}
+	return d
+}
+
+func searchWords(raw string) []string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		words = append(words, strings.ToLower(f))
+	}
+	return words
+}
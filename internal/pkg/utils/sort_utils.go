@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"vector/internal/models"
+)
+
+// ParseSortParam разбирает sort=field1,-field2,field3:desc.
+// Поля проверяются по whitelist, поэтому в SQL попадают только заранее известные колонки.
+func ParseSortParam(raw string, allowed []string) ([]models.SortField, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, a := range allowed {
+		allowedSet[a] = true
+	}
+
+	var out []models.SortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := models.SortField{}
+		switch {
+		case strings.HasPrefix(part, "-"):
+			field.Desc = true
+			part = part[1:]
+		case strings.HasPrefix(part, "+"):
+			part = part[1:]
+		}
+
+		if name, dir, ok := strings.Cut(part, ":"); ok {
+			switch strings.ToLower(dir) {
+			case "asc":
+			case "desc":
+				field.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction %q", dir)
+			}
+			part = name
+		}
+
+		if !allowedSet[part] {
+			return nil, fmt.Errorf("unsupported sort field %q (allowed: %s)", part, strings.Join(allowed, ", "))
+		}
+		if seen[part] {
+			continue
+		}
+		seen[part] = true
+
+		field.Field = part
+		out = append(out, field)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	database *gorm.DB
+}
+
+func NewAPIKeyRepository(database *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{database: database}
+}
+
+func (r *apiKeyRepository) Create(key models.APIKey) (models.APIKey, error) {
+	return appdb.CreateAPIKey(r.database, key)
+}
+
+func (r *apiKeyRepository) GetByPrefix(prefix string) (models.APIKey, error) {
+	return appdb.GetAPIKeyByPrefix(r.database, prefix)
+}
+
+func (r *apiKeyRepository) GetByID(id uint) (models.APIKey, error) {
+	return appdb.GetAPIKey(r.database, id)
+}
+
+func (r *apiKeyRepository) List(includeRevoked bool) ([]models.APIKey, error) {
+	return appdb.ListAPIKeys(r.database, includeRevoked)
+}
+
+func (r *apiKeyRepository) Revoke(id uint, revokedBy *uint) (models.APIKey, error) {
+	return appdb.RevokeAPIKey(r.database, id, revokedBy)
+}
+
+func (r *apiKeyRepository) HitRateWindow(id uint, period time.Duration) (models.APIKeyRateWindow, error) {
+	return appdb.HitAPIKeyRateWindow(r.database, id, period)
+}
+
+func (r *apiKeyRepository) Touch(id uint, ip string, at time.Time) error {
+	return appdb.TouchAPIKey(r.database, id, ip, at)
+}
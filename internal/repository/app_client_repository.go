@@ -1,9 +1,9 @@
 package repository
 
 import (
-	"time"
 	appdb "vector/internal/db/app"
 	"vector/internal/models"
+	"vector/internal/pkg/utils"
 
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
@@ -25,6 +25,10 @@ func (r *appClientRepository) GetClientHistory(clientID int) ([]models.ClientVer
 	return appdb.GetClientHistory(r.database, clientID)
 }
 
+func (r *appClientRepository) ListClientHistory(clientID int, page models.PageRequest) ([]models.ClientVersion, models.PageInfo, error) {
+	return appdb.ListClientHistory(r.database, clientID, page)
+}
+
 func (r *appClientRepository) GetSecondPartCurrent(clientID int) (models.SecondPartVersion, error) {
 	return appdb.GetSecondPartCurrent(r.database, clientID)
 }
@@ -33,12 +37,24 @@ func (r *appClientRepository) ListSecondPartHistory(clientID int) ([]models.Seco
 	return appdb.ListSecondPartHistory(r.database, clientID)
 }
 
-func (r *appClientRepository) CreateSecondPartDraft(clientID int, riskLevel *string, createdBy *int, dataOverride *datatypes.JSON) (models.SecondPartVersion, error) {
-	return appdb.CreateSecondPartDraft(r.database, clientID, riskLevel, createdBy, dataOverride)
+func (r *appClientRepository) CreateSecondPartDraft(clientID int, risk models.SecondPartRisk, createdBy *int, dataOverride *datatypes.JSON, schemaVersion *int) (models.SecondPartVersion, error) {
+	return appdb.CreateSecondPartDraft(r.database, clientID, risk, createdBy, dataOverride, schemaVersion)
 }
 
-func (r *appClientRepository) SubmitSecondPart(clientID int, userID *int) (models.SecondPartVersion, error) {
-	return appdb.SubmitSecondPart(r.database, clientID, userID)
+func (r *appClientRepository) DecideSecondPartRisk(clientID int, risk models.SecondPartRisk) (models.SecondPartVersion, error) {
+	return appdb.DecideSecondPartRisk(r.database, clientID, risk)
+}
+
+func (r *appClientRepository) SubmitSecondPart(clientID int, userID *int, spVersion, revision, schemaVersion int) (models.SecondPartVersion, error) {
+	return appdb.SubmitSecondPart(r.database, clientID, userID, spVersion, revision, schemaVersion)
+}
+
+func (r *appClientRepository) UpdateSecondPartDraftData(clientID, version, revision int, data datatypes.JSON, schemaVersion *int, changes []models.SecondPartChange, actorID *int) (models.SecondPartVersion, error) {
+	return appdb.UpdateSecondPartDraftData(r.database, clientID, version, revision, data, schemaVersion, changes, actorID)
+}
+
+func (r *appClientRepository) ListSecondPartChanges(clientID, version int, page models.PageRequest) ([]models.SecondPartChange, models.PageInfo, error) {
+	return appdb.ListSecondPartChanges(r.database, clientID, version, page)
 }
 
 func (r *appClientRepository) ApproveSecondPart(clientID int, approvedBy *int) (models.SecondPartVersion, error) {
@@ -53,46 +69,92 @@ func (r *appClientRepository) RequestDocsSecondPart(clientID int, userID *int, r
 	return appdb.RequestDocsSecondPart(r.database, clientID, userID, reason)
 }
 
-func (r *appClientRepository) ListClientsWithSP(page, perPage int, needsSecondPart *bool, spStatus *string, dueBefore *time.Time) ([]models.ClientWithSP, int64, error) {
+func (r *appClientRepository) ListSecondPartRequirements(clientID int, includeResolved bool) ([]models.SecondPartRequirement, error) {
+	return appdb.ListSecondPartRequirements(r.database, clientID, includeResolved)
+}
+
+func (r *appClientRepository) ListClientsWithSP(page models.PageRequest, filter models.ClientListFilter) ([]models.ClientWithSP, models.PageInfo, error) {
 
-	dbItems, total, err := appdb.ListClientsWithSP(r.database, page, perPage, needsSecondPart, spStatus, dueBefore)
+	dbItems, info, err := appdb.ListClientsWithSP(r.database, page, filter)
 	if err != nil {
-		return nil, 0, err
+		return nil, info, err
 	}
 
 	items := make([]models.ClientWithSP, len(dbItems))
 	for i, dbItem := range dbItems {
 		items[i] = models.ClientWithSP{
-			ClientID:          dbItem.ClientID,
-			Surname:           dbItem.Surname,
-			Name:              dbItem.Name,
-			Patronymic:        dbItem.Patronymic,
-			Birthday:          dbItem.Birthday,
-			BirthPlace:        dbItem.BirthPlace,
-			ContactEmail:      dbItem.ContactEmail,
-			Inn:               dbItem.Inn,
-			Snils:             dbItem.Snils,
-			CreatedLKAt:       dbItem.CreatedLKAt,
-			UpdatedLKAt:       dbItem.UpdatedLKAt,
-			PassIssuerCode:    dbItem.PassIssuerCode,
-			PassSeries:        dbItem.PassSeries,
-			PassNumber:        dbItem.PassNumber,
-			PassIssueDate:     dbItem.PassIssueDate,
-			PassIssuer:        dbItem.PassIssuer,
-			MainPhone:         dbItem.MainPhone,
-			ExternalRiskLevel: dbItem.ExternalRiskLevel,
-			NeedsSecondPart:   dbItem.NeedsSecondPart,
-			SecondPartCreated: dbItem.SecondPartCreated,
-			ClientVersion:     dbItem.ClientVersion,
-			SpStatus:          dbItem.SpStatus,
-			SpDueAt:           dbItem.SpDueAt,
-			SpClientVersion:   dbItem.SpClientVersion,
+			ClientID:               dbItem.ClientID,
+			Surname:                dbItem.Surname,
+			Name:                   dbItem.Name,
+			Patronymic:             dbItem.Patronymic,
+			Birthday:               dbItem.Birthday,
+			BirthPlace:             dbItem.BirthPlace,
+			ContactEmail:           dbItem.ContactEmail,
+			Inn:                    dbItem.Inn,
+			Snils:                  dbItem.Snils,
+			CreatedLKAt:            dbItem.CreatedLKAt,
+			UpdatedLKAt:            dbItem.UpdatedLKAt,
+			PassIssuerCode:         dbItem.PassIssuerCode,
+			PassSeries:             dbItem.PassSeries,
+			PassNumber:             dbItem.PassNumber,
+			PassIssueDate:          dbItem.PassIssueDate,
+			PassIssuer:             dbItem.PassIssuer,
+			MainPhone:              dbItem.MainPhone,
+			RiskLevel:              dbItem.RiskLevel,
+			ExternalRiskLevel:      dbItem.ExternalRiskLevel,
+			NeedsSecondPart:        dbItem.NeedsSecondPart,
+			SecondPartCreated:      dbItem.SecondPartCreated,
+			ClientVersion:          dbItem.ClientVersion,
+			SpStatus:               dbItem.SpStatus,
+			SpDueAt:                dbItem.SpDueAt,
+			SpClientVersion:        dbItem.SpClientVersion,
+			NeedsSecondPartReasons: dbItem.NeedsSecondPartReasons,
 		}
 	}
 
-	return items, total, nil
+	return items, info, nil
 }
 
 func (r *appClientRepository) GetClientVersion(clientID int, version int) (models.ClientVersion, error) {
 	return appdb.GetClientVersion(r.database, clientID, version)
 }
+
+func (r *appClientRepository) SearchClients(query utils.SearchQuery, limit int) ([]models.ClientSearchResult, error) {
+	rows, err := appdb.SearchClients(r.database, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ClientSearchResult, len(rows))
+	for i, row := range rows {
+		items[i] = models.ClientSearchResult{
+			ClientListItem: models.ClientListItem{
+				ClientID:          row.ClientID,
+				Surname:           row.Surname,
+				Name:              row.Name,
+				Patronymic:        row.Patronymic,
+				Birthday:          row.Birthday,
+				BirthPlace:        row.BirthPlace,
+				ContactEmail:      row.ContactEmail,
+				Inn:               row.Inn,
+				Snils:             row.Snils,
+				CreatedLKAt:       row.CreatedLKAt,
+				UpdatedLKAt:       row.UpdatedLKAt,
+				PassIssuerCode:    row.PassIssuerCode,
+				PassSeries:        row.PassSeries,
+				PassNumber:        row.PassNumber,
+				PassIssueDate:     row.PassIssueDate,
+				PassIssuer:        row.PassIssuer,
+				MainPhone:         row.MainPhone,
+				ExternalRiskLevel: row.ExternalRiskLevel,
+				NeedsSecondPart:   row.NeedsSecondPart,
+				SecondPartCreated: row.SecondPartCreated,
+				Version:           row.Version,
+			},
+			Login: row.Login,
+			Rank:  row.Rank,
+		}
+	}
+
+	return items, nil
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"time"
 	"vector/internal/models"
+	"vector/internal/pkg/utils"
 
 	"gorm.io/datatypes"
 )
@@ -11,26 +13,38 @@ type AppClientRepository interface {
 	GetCurrent(clientID int) (models.ClientVersion, error)
 	GetSecondPartCurrent(clientID int) (models.SecondPartVersion, error)
 	ListSecondPartHistory(clientID int) ([]models.SecondPartVersion, error)
-	CreateSecondPartDraft(clientID int, riskLevel *string, createdBy *int, dataOverride *datatypes.JSON) (models.SecondPartVersion, error)
-	SubmitSecondPart(clientID int, userID *int) (models.SecondPartVersion, error)
+	CreateSecondPartDraft(clientID int, risk models.SecondPartRisk, createdBy *int, dataOverride *datatypes.JSON, schemaVersion *int) (models.SecondPartVersion, error)
+	DecideSecondPartRisk(clientID int, risk models.SecondPartRisk) (models.SecondPartVersion, error)
+	SubmitSecondPart(clientID int, userID *int, spVersion, revision, schemaVersion int) (models.SecondPartVersion, error)
+	UpdateSecondPartDraftData(clientID, version, revision int, data datatypes.JSON, schemaVersion *int, changes []models.SecondPartChange, actorID *int) (models.SecondPartVersion, error)
+	ListSecondPartChanges(clientID, version int, page models.PageRequest) ([]models.SecondPartChange, models.PageInfo, error)
 	ApproveSecondPart(clientID int, approvedBy *int) (models.SecondPartVersion, error)
 	RejectSecondPart(clientID int, userID *int, reason string) (models.SecondPartVersion, error)
 	RequestDocsSecondPart(clientID int, userID *int, reason string) (models.SecondPartVersion, error)
 	GetClientHistory(clientID int) ([]models.ClientVersion, error)
+	ListClientHistory(clientID int, page models.PageRequest) ([]models.ClientVersion, models.PageInfo, error)
 	GetClientVersion(clientID int, version int) (models.ClientVersion, error)
-	ListClientsWithSP(page, perPage int, needsSecondPart *bool, spStatus *string, dueBefore *time.Time) ([]models.ClientWithSP, int64, error)
+	ListClientsWithSP(page models.PageRequest, filter models.ClientListFilter) ([]models.ClientWithSP, models.PageInfo, error)
+	SearchClients(query utils.SearchQuery, limit int) ([]models.ClientSearchResult, error)
+	ListSecondPartRequirements(clientID int, includeResolved bool) ([]models.SecondPartRequirement, error)
 }
 
 type UserRepository interface {
 	GetByID(id uint) (models.AppUser, error)
 	GetByEmail(email string) (models.AppUser, error)
+	GetByExternalSubject(subject string) (models.AppUser, error)
 	Create(user models.AppUser) (models.AppUser, error)
-	List() ([]models.AppUser, error)
+	LinkExternal(id uint, subject, firstName, lastName string) error
+	TouchExternal(id uint, sealedRefreshToken string) error
+	List(filter models.UserListFilter) ([]models.AppUser, error)
 	UpdateRole(id uint, role string) (models.AppUser, error)
-	UpdatePassword(id uint, passwordHash string) error
+	UpdatePassword(id uint, passwordHash string, mustChange bool) error
+	UpgradePasswordHash(id uint, passwordHash string) error
+	SetMustChangePassword(id uint, mustChange bool) error
+	RecentPasswordHashes(id uint, limit int) ([]string, error)
 	SetActive(id uint, isActive bool) error
 	Delete(id uint) error
-	Seed() error
+	Seed(email, password string) (bool, error)
 }
 
 type CheckRepository interface {
@@ -39,8 +53,103 @@ type CheckRepository interface {
 	ListByClient(clientID int, spVersion *int) ([]models.SecondPartCheck, error)
 }
 
+type ReviewPolicyRepository interface {
+	List() ([]models.ReviewPolicy, error)
+	Effective(at time.Time) ([]models.ReviewPolicy, error)
+	Create(p models.ReviewPolicy) (models.ReviewPolicy, error)
+}
+
+type QuestionnaireSchemaRepository interface {
+	Current() (models.QuestionnaireSchema, error)
+	Get(version int) (models.QuestionnaireSchema, error)
+	List() ([]models.QuestionnaireSchema, error)
+	Create(s models.QuestionnaireSchema) (models.QuestionnaireSchema, error)
+}
+
 type RecalcRepository interface {
-	RecalcNeedsSecondPart() (int64, error)
-	RecalcPassportExpiry() (int64, error)
-	RecalcAll() error
+	RecalcQuestionnaireExpired(ctx context.Context) (models.RecalcRuleResult, error)
+	RecalcDataChanged(ctx context.Context) (models.RecalcRuleResult, error)
+	RecalcPassportExpiry(ctx context.Context) (models.RecalcRuleResult, error)
+}
+
+// JobLocker блокировка, исключающая параллельный запуск задачи на всех экземплярах сервиса
+type JobLocker interface {
+	// TryLock не ждет: ok=false, если задача уже выполняется
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+type JobRunRepository interface {
+	Create(run models.JobRun) (models.JobRun, error)
+	Finish(id uint, status string, result datatypes.JSON, errMsg string) error
+	LatestRunning(job string) (models.JobRun, error)
+	AbandonRunning(job string) (int64, error)
+	List(page models.PageRequest, filter models.JobRunFilter) ([]models.JobRun, models.PageInfo, error)
+}
+
+type ExportLogRepository interface {
+	Create(log models.ExportLog) (models.ExportLog, error)
+	Finish(id uint, status string, rows int64, errMsg string) error
+}
+
+type PiiRevealRepository interface {
+	Create(log models.PiiRevealLog) (models.PiiRevealLog, error)
+}
+
+type AuditRepository interface {
+	Append(event models.AuditEvent) (models.AuditEvent, error)
+	List(page models.PageRequest, filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error)
+}
+
+type SessionRepository interface {
+	Create(session models.AuthSession) (models.AuthSession, error)
+	GetByTokenHash(tokenHash string) (models.AuthSession, error)
+	Rotate(current models.AuthSession, next models.AuthSession) (models.AuthSession, error)
+	RevokeFamily(familyID, reason string) (int64, error)
+	RevokeUser(userID uint, exceptFamilyID, reason string) (int64, error)
+	IsActive(familyID string) (bool, error)
+}
+
+type AuthThrottleRepository interface {
+	Get(key string) (*models.AuthThrottle, error)
+	RegisterFailure(key string, window time.Duration) (models.AuthThrottle, error)
+	Lock(key string, until time.Time) error
+	Clear(key string) (int64, error)
+}
+
+type MFARepository interface {
+	SetPendingSecret(userID uint, sealed string) error
+	Enable(userID uint, step int64, codeHashes []string) error
+	Disable(userID uint) error
+	AcceptStep(userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+	CountRecoveryCodes(userID uint) (int64, error)
+	UseToken(jti string, userID uint, expiresAt time.Time) (bool, error)
+}
+
+type OIDCStateRepository interface {
+	Create(state models.OIDCLoginState) (models.OIDCLoginState, error)
+	Consume(stateHash string) (models.OIDCLoginState, error)
+	SetLoginCode(id uint64, userID uint, codeHash string, expiresAt time.Time) error
+	RedeemLoginCode(codeHash string) (uint, error)
+}
+
+type RoleRepository interface {
+	List() ([]models.Role, error)
+	Get(name string) (models.Role, error)
+	ListPermissions() (map[string][]string, error)
+	CountUsers() (map[string]int64, error)
+	Create(role models.Role, permissions []string) (models.Role, error)
+	Update(role models.Role, permissions []string) (models.Role, error)
+	Delete(name string) error
+}
+
+type APIKeyRepository interface {
+	Create(key models.APIKey) (models.APIKey, error)
+	GetByPrefix(prefix string) (models.APIKey, error)
+	GetByID(id uint) (models.APIKey, error)
+	List(includeRevoked bool) ([]models.APIKey, error)
+	Revoke(id uint, revokedBy *uint) (models.APIKey, error)
+	HitRateWindow(id uint, period time.Duration) (models.APIKeyRateWindow, error)
+	Touch(id uint, ip string, at time.Time) error
 }
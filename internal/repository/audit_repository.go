@@ -0,0 +1,24 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	database *gorm.DB
+}
+
+func NewAuditRepository(database *gorm.DB) AuditRepository {
+	return &auditRepository{database: database}
+}
+
+func (r *auditRepository) Append(event models.AuditEvent) (models.AuditEvent, error) {
+	return appdb.AppendAuditEvent(r.database, event)
+}
+
+func (r *auditRepository) List(page models.PageRequest, filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	return appdb.ListAuditEvents(r.database, page, filter)
+}
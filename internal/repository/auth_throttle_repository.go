@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"time"
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type authThrottleRepository struct {
+	database *gorm.DB
+}
+
+func NewAuthThrottleRepository(database *gorm.DB) AuthThrottleRepository {
+	return &authThrottleRepository{database: database}
+}
+
+func (r *authThrottleRepository) Get(key string) (*models.AuthThrottle, error) {
+	return appdb.GetThrottle(r.database, key)
+}
+
+func (r *authThrottleRepository) RegisterFailure(key string, window time.Duration) (models.AuthThrottle, error) {
+	return appdb.RegisterThrottleFailure(r.database, key, window)
+}
+
+func (r *authThrottleRepository) Lock(key string, until time.Time) error {
+	return appdb.LockThrottle(r.database, key, until)
+}
+
+func (r *authThrottleRepository) Clear(key string) (int64, error) {
+	return appdb.ClearThrottle(r.database, key)
+}
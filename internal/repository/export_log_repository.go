@@ -0,0 +1,24 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type exportLogRepository struct {
+	database *gorm.DB
+}
+
+func NewExportLogRepository(database *gorm.DB) ExportLogRepository {
+	return &exportLogRepository{database: database}
+}
+
+func (r *exportLogRepository) Create(log models.ExportLog) (models.ExportLog, error) {
+	return appdb.CreateExportLog(r.database, log)
+}
+
+func (r *exportLogRepository) Finish(id uint, status string, rows int64, errMsg string) error {
+	return appdb.FinishExportLog(r.database, id, status, rows, errMsg)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"vector/internal/db"
+
+	"gorm.io/gorm"
+)
+
+type jobLocker struct {
+	database *gorm.DB
+}
+
+func NewJobLocker(database *gorm.DB) JobLocker {
+	return &jobLocker{database: database}
+}
+
+func (l *jobLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	return db.TryAdvisoryLock(ctx, l.database, name)
+}
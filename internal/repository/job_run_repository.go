@@ -0,0 +1,37 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type jobRunRepository struct {
+	database *gorm.DB
+}
+
+func NewJobRunRepository(database *gorm.DB) JobRunRepository {
+	return &jobRunRepository{database: database}
+}
+
+func (r *jobRunRepository) Create(run models.JobRun) (models.JobRun, error) {
+	return appdb.CreateJobRun(r.database, run)
+}
+
+func (r *jobRunRepository) Finish(id uint, status string, result datatypes.JSON, errMsg string) error {
+	return appdb.FinishJobRun(r.database, id, status, result, errMsg)
+}
+
+func (r *jobRunRepository) LatestRunning(job string) (models.JobRun, error) {
+	return appdb.LatestRunningJobRun(r.database, job)
+}
+
+func (r *jobRunRepository) AbandonRunning(job string) (int64, error) {
+	return appdb.AbandonJobRuns(r.database, job)
+}
+
+func (r *jobRunRepository) List(page models.PageRequest, filter models.JobRunFilter) ([]models.JobRun, models.PageInfo, error) {
+	return appdb.ListJobRuns(r.database, page, filter)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"time"
+
+	appdb "vector/internal/db/app"
+
+	"gorm.io/gorm"
+)
+
+type mfaRepository struct {
+	database *gorm.DB
+}
+
+func NewMFARepository(database *gorm.DB) MFARepository {
+	return &mfaRepository{database: database}
+}
+
+func (r *mfaRepository) SetPendingSecret(userID uint, sealed string) error {
+	return appdb.SetPendingMFASecret(r.database, userID, sealed)
+}
+
+func (r *mfaRepository) Enable(userID uint, step int64, codeHashes []string) error {
+	return appdb.EnableMFA(r.database, userID, step, codeHashes)
+}
+
+func (r *mfaRepository) Disable(userID uint) error {
+	return appdb.DisableMFA(r.database, userID)
+}
+
+func (r *mfaRepository) AcceptStep(userID uint, step int64) (bool, error) {
+	return appdb.AcceptMFAStep(r.database, userID, step)
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return appdb.ReplaceRecoveryCodes(r.database, userID, codeHashes)
+}
+
+func (r *mfaRepository) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	return appdb.UseRecoveryCode(r.database, userID, codeHash)
+}
+
+func (r *mfaRepository) CountRecoveryCodes(userID uint) (int64, error) {
+	return appdb.CountUnusedRecoveryCodes(r.database, userID)
+}
+
+func (r *mfaRepository) UseToken(jti string, userID uint, expiresAt time.Time) (bool, error) {
+	return appdb.UseMFAToken(r.database, jti, userID, expiresAt)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"time"
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type oidcStateRepository struct {
+	database *gorm.DB
+}
+
+func NewOIDCStateRepository(database *gorm.DB) OIDCStateRepository {
+	return &oidcStateRepository{database: database}
+}
+
+func (r *oidcStateRepository) Create(state models.OIDCLoginState) (models.OIDCLoginState, error) {
+	return appdb.CreateOIDCState(r.database, state)
+}
+
+func (r *oidcStateRepository) Consume(stateHash string) (models.OIDCLoginState, error) {
+	return appdb.ConsumeOIDCState(r.database, stateHash)
+}
+
+func (r *oidcStateRepository) SetLoginCode(id uint64, userID uint, codeHash string, expiresAt time.Time) error {
+	return appdb.SetOIDCLoginCode(r.database, id, userID, codeHash, expiresAt)
+}
+
+func (r *oidcStateRepository) RedeemLoginCode(codeHash string) (uint, error) {
+	return appdb.RedeemOIDCLoginCode(r.database, codeHash)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type piiRevealRepository struct {
+	database *gorm.DB
+}
+
+func NewPiiRevealRepository(database *gorm.DB) PiiRevealRepository {
+	return &piiRevealRepository{database: database}
+}
+
+func (r *piiRevealRepository) Create(log models.PiiRevealLog) (models.PiiRevealLog, error) {
+	return appdb.CreatePiiRevealLog(r.database, log)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type questionnaireSchemaRepository struct {
+	database *gorm.DB
+}
+
+func NewQuestionnaireSchemaRepository(database *gorm.DB) QuestionnaireSchemaRepository {
+	return &questionnaireSchemaRepository{database: database}
+}
+
+func (r *questionnaireSchemaRepository) Current() (models.QuestionnaireSchema, error) {
+	return appdb.CurrentQuestionnaireSchema(r.database)
+}
+
+func (r *questionnaireSchemaRepository) Get(version int) (models.QuestionnaireSchema, error) {
+	return appdb.GetQuestionnaireSchema(r.database, version)
+}
+
+func (r *questionnaireSchemaRepository) List() ([]models.QuestionnaireSchema, error) {
+	return appdb.ListQuestionnaireSchemas(r.database)
+}
+
+func (r *questionnaireSchemaRepository) Create(s models.QuestionnaireSchema) (models.QuestionnaireSchema, error) {
+	return appdb.CreateQuestionnaireSchema(r.database, s)
+}
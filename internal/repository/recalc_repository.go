@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"context"
 	appdb "vector/internal/db/app"
+	"vector/internal/models"
 
 	"gorm.io/gorm"
 )
@@ -14,14 +16,14 @@ func NewRecalcRepository(database *gorm.DB) RecalcRepository {
 	return &recalcRepository{database: database}
 }
 
-func (r *recalcRepository) RecalcNeedsSecondPart() (int64, error) {
-	return appdb.RecalcNeedsSecondPart(r.database)
+func (r *recalcRepository) RecalcQuestionnaireExpired(ctx context.Context) (models.RecalcRuleResult, error) {
+	return appdb.RecalcQuestionnaireExpired(r.database.WithContext(ctx))
 }
 
-func (r *recalcRepository) RecalcPassportExpiry() (int64, error) {
-	return appdb.RecalcPassportExpiry(r.database)
+func (r *recalcRepository) RecalcDataChanged(ctx context.Context) (models.RecalcRuleResult, error) {
+	return appdb.RecalcDataChanged(r.database.WithContext(ctx))
 }
 
-func (r *recalcRepository) RecalcAll() error {
-	return appdb.RecalcAll(r.database)
+func (r *recalcRepository) RecalcPassportExpiry(ctx context.Context) (models.RecalcRuleResult, error) {
+	return appdb.RecalcPassportExpiry(r.database.WithContext(ctx))
 }
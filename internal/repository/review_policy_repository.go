@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"time"
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type reviewPolicyRepository struct {
+	database *gorm.DB
+}
+
+func NewReviewPolicyRepository(database *gorm.DB) ReviewPolicyRepository {
+	return &reviewPolicyRepository{database: database}
+}
+
+func (r *reviewPolicyRepository) List() ([]models.ReviewPolicy, error) {
+	return appdb.ListReviewPolicies(r.database)
+}
+
+func (r *reviewPolicyRepository) Effective(at time.Time) ([]models.ReviewPolicy, error) {
+	return appdb.EffectiveReviewPolicies(r.database, at)
+}
+
+func (r *reviewPolicyRepository) Create(p models.ReviewPolicy) (models.ReviewPolicy, error) {
+	return appdb.CreateReviewPolicy(r.database, p)
+}
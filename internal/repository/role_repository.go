@@ -0,0 +1,44 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type roleRepository struct {
+	database *gorm.DB
+}
+
+func NewRoleRepository(database *gorm.DB) RoleRepository {
+	return &roleRepository{database: database}
+}
+
+func (r *roleRepository) List() ([]models.Role, error) {
+	return appdb.ListRoles(r.database)
+}
+
+func (r *roleRepository) Get(name string) (models.Role, error) {
+	return appdb.GetRole(r.database, name)
+}
+
+func (r *roleRepository) ListPermissions() (map[string][]string, error) {
+	return appdb.ListRolePermissions(r.database)
+}
+
+func (r *roleRepository) CountUsers() (map[string]int64, error) {
+	return appdb.CountUsersByRole(r.database)
+}
+
+func (r *roleRepository) Create(role models.Role, permissions []string) (models.Role, error) {
+	return appdb.CreateRole(r.database, role, permissions)
+}
+
+func (r *roleRepository) Update(role models.Role, permissions []string) (models.Role, error) {
+	return appdb.UpdateRole(r.database, role, permissions)
+}
+
+func (r *roleRepository) Delete(name string) error {
+	return appdb.DeleteRole(r.database, name)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	appdb "vector/internal/db/app"
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	database *gorm.DB
+}
+
+func NewSessionRepository(database *gorm.DB) SessionRepository {
+	return &sessionRepository{database: database}
+}
+
+func (r *sessionRepository) Create(session models.AuthSession) (models.AuthSession, error) {
+	return appdb.CreateSession(r.database, session)
+}
+
+func (r *sessionRepository) GetByTokenHash(tokenHash string) (models.AuthSession, error) {
+	return appdb.GetSessionByTokenHash(r.database, tokenHash)
+}
+
+func (r *sessionRepository) Rotate(current models.AuthSession, next models.AuthSession) (models.AuthSession, error) {
+	return appdb.RotateSession(r.database, current, next)
+}
+
+func (r *sessionRepository) RevokeFamily(familyID, reason string) (int64, error) {
+	return appdb.RevokeSessionFamily(r.database, familyID, reason)
+}
+
+func (r *sessionRepository) RevokeUser(userID uint, exceptFamilyID, reason string) (int64, error) {
+	return appdb.RevokeUserSessions(r.database, userID, exceptFamilyID, reason)
+}
+
+func (r *sessionRepository) IsActive(familyID string) (bool, error) {
+	return appdb.IsSessionActive(r.database, familyID)
+}
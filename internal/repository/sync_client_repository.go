@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"time"
+	appdb "vector/internal/db/app"
 	syncdb "vector/internal/db/sync"
 	"vector/internal/models"
 	"vector/internal/pkg/utils"
@@ -46,11 +47,11 @@ func (r *syncClientRepository) UpdateCurrentVersionStatus(ctx context.Context, c
 		Updates(updates).Error
 }
 
-func (r *syncClientRepository) ListCurrentClients(page, perPage int, needsSecondPart *bool) ([]models.ClientListItem, int64, error) {
+func (r *syncClientRepository) ListCurrentClients(page models.PageRequest, needsSecondPart *bool) ([]models.ClientListItem, models.PageInfo, error) {
 
-	dbItems, total, err := syncdb.ListCurrentClients(r.database, page, perPage, needsSecondPart)
+	dbItems, info, err := syncdb.ListCurrentClients(r.database, page, needsSecondPart)
 	if err != nil {
-		return nil, 0, err
+		return nil, info, err
 	}
 
 	items := make([]models.ClientListItem, len(dbItems))
@@ -79,7 +80,7 @@ func (r *syncClientRepository) ListCurrentClients(page, perPage int, needsSecond
 		}
 	}
 
-	return items, total, nil
+	return items, info, nil
 }
 
 func (r *syncClientRepository) ApplyUsersBatch(ctx context.Context, users []ApplyUserData) (ApplyStats, error) {
@@ -102,6 +103,9 @@ func (r *syncClientRepository) ApplyUsersBatch(ctx context.Context, users []Appl
 				if err := tx.Create(&newVersion).Error; err != nil {
 					return err
 				}
+				if err := appdb.OpenSecondPartRequirement(tx, newVersion.ClientID, newVersion.Version, models.RequirementNewClient, nil); err != nil {
+					return err
+				}
 				stats.Created++
 				continue
 			}
@@ -129,6 +133,18 @@ func (r *syncClientRepository) ApplyUsersBatch(ctx context.Context, users []Appl
 			if err := tx.Create(&newVersion).Error; err != nil {
 				return err
 			}
+
+			// пока второй части не было, изменение анкеты — все та же причина new_client
+			reason := models.RequirementNewClient
+			if cur.SecondPartCreated {
+				reason = models.RequirementDataChanged
+			}
+			if err := appdb.OpenSecondPartRequirement(tx, newVersion.ClientID, newVersion.Version, reason, map[string]any{
+				"from_version": cur.Version,
+				"to_version":   newVersion.Version,
+			}); err != nil {
+				return err
+			}
 			stats.Updated++
 		}
 		return nil
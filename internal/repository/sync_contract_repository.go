@@ -20,8 +20,8 @@ func (r *syncContractRepository) GetCurrentContract(ctx context.Context, contrac
 	return syncdb.GetCurrentContract(r.database, contractID)
 }
 
-func (r *syncContractRepository) ListContracts(page, perPage int, userID *int, status *string) ([]models.Contract, int64, error) {
-	return syncdb.ListContracts(r.database, page, perPage, userID, status)
+func (r *syncContractRepository) ListContracts(page models.PageRequest, filter models.ContractListFilter) ([]models.Contract, models.PageInfo, error) {
+	return syncdb.ListContracts(r.database, page, filter)
 }
 
 func (r *syncContractRepository) ApplyContractsBatch(ctx context.Context, contracts []ApplyContractData) (ApplyStats, error) {
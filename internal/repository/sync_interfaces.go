@@ -29,7 +29,7 @@ type SyncClientRepository interface {
 	GetCurrentVersion(ctx context.Context, clientID int) (*models.ClientVersion, error)
 	CreateVersion(ctx context.Context, version *models.ClientVersion) error
 	UpdateCurrentVersionStatus(ctx context.Context, clientID int, isCurrent bool, validTo *time.Time) error
-	ListCurrentClients(page, perPage int, needsSecondPart *bool) ([]models.ClientListItem, int64, error)
+	ListCurrentClients(page models.PageRequest, needsSecondPart *bool) ([]models.ClientListItem, models.PageInfo, error)
 	ApplyUsersBatch(ctx context.Context, users []ApplyUserData) (ApplyStats, error)
 }
 
@@ -47,7 +47,7 @@ type ApplyStats struct {
 
 type SyncContractRepository interface {
 	GetCurrentContract(ctx context.Context, contractID int) (*models.Contract, error)
-	ListContracts(page, perPage int, userID *int, status *string) ([]models.Contract, int64, error)
+	ListContracts(page models.PageRequest, filter models.ContractListFilter) ([]models.Contract, models.PageInfo, error)
 	ApplyContractsBatch(ctx context.Context, contracts []ApplyContractData) (ApplyStats, error)
 }
 
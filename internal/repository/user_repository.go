@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"fmt"
+	"strings"
+	"time"
 	"vector/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
@@ -26,61 +30,213 @@ func (r *userRepository) GetByEmail(email string) (models.AppUser, error) {
 	return user, err
 }
 
+func (r *userRepository) GetByExternalSubject(subject string) (models.AppUser, error) {
+	var user models.AppUser
+	err := r.database.Where("external_subject = ?", subject).First(&user).Error
+	return user, err
+}
+
 func (r *userRepository) Create(user models.AppUser) (models.AppUser, error) {
-	err := r.database.Create(&user).Error
+	// у внешних учетных записей локального пароля нет: ни истории, ни срока действия
+	if user.IsExternal() {
+		return user, r.database.Create(&user).Error
+	}
+
+	now := time.Now().UTC()
+	user.PasswordChangedAt = &now
+	err := r.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: user.PasswordHash}).Error
+	})
 	return user, err
 }
 
-func (r *userRepository) List() ([]models.AppUser, error) {
+// LinkExternal привязывает учетную запись к субъекту провайдера и обновляет имя из его данных.
+// Локальный пароль после привязки не действует.
+func (r *userRepository) LinkExternal(id uint, subject, firstName, lastName string) error {
+	return r.database.Model(&models.AppUser{}).Where("id = ?", id).Updates(map[string]any{
+		"auth_provider":        models.AuthProviderOIDC,
+		"external_subject":     subject,
+		"first_name":           firstName,
+		"last_name":            lastName,
+		"must_change_password": false,
+		"password_changed_at":  nil,
+		"external_checked_at":  time.Now().UTC(),
+	}).Error
+}
+
+// TouchExternal отмечает успешную проверку у провайдера; непустой sealedRefreshToken заменяет сохраненный
+func (r *userRepository) TouchExternal(id uint, sealedRefreshToken string) error {
+	updates := map[string]any{"external_checked_at": time.Now().UTC()}
+	if sealedRefreshToken != "" {
+		updates["external_refresh_token"] = sealedRefreshToken
+	}
+	return r.database.Model(&models.AppUser{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *userRepository) List(filter models.UserListFilter) ([]models.AppUser, error) {
+	q := r.database.Model(&models.AppUser{})
+	if len(filter.Role) > 0 {
+		q = q.Where("role IN ?", filter.Role)
+	}
+	if filter.IsActive != nil {
+		q = q.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.AuthProvider != nil {
+		q = q.Where("auth_provider = ?", *filter.AuthProvider)
+	}
+	if filter.Query != nil {
+		like := "%" + strings.ToLower(*filter.Query) + "%"
+		q = q.Where("lower(email) LIKE ? OR lower(first_name) LIKE ? OR lower(last_name) LIKE ?", like, like, like)
+	}
+
 	var users []models.AppUser
-	err := r.database.Order("created_at DESC").Find(&users).Error
+	err := q.Order("created_at DESC").Find(&users).Error
 	return users, err
 }
 
+// guardLastAdmin выполняет fn в транзакции, если после нее останется хотя бы один
+// активный администратор. Строки администраторов блокируются, чтобы два параллельных
+// запроса не понизили друг друга одновременно.
+func (r *userRepository) guardLastAdmin(id uint, fn func(tx *gorm.DB) error) error {
+	return r.database.Transaction(func(tx *gorm.DB) error {
+		var adminIDs []uint
+		if err := tx.Model(&models.AppUser{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role = ? AND is_active = ?", models.RoleAdministrator, true).
+			Pluck("id", &adminIDs).Error; err != nil {
+			return err
+		}
+		if len(adminIDs) == 1 && adminIDs[0] == id {
+			return models.ErrLastAdministrator
+		}
+		return fn(tx)
+	})
+}
+
 func (r *userRepository) UpdateRole(id uint, role string) (models.AppUser, error) {
 	var user models.AppUser
 	if err := r.database.First(&user, id).Error; err != nil {
 		return user, err
 	}
 
-	user.Role = role
-	err := r.database.Save(&user).Error
+	update := func(tx *gorm.DB) error {
+		return tx.Model(&user).Update("role", role).Error
+	}
+	var err error
+	if user.Role == models.RoleAdministrator && role != models.RoleAdministrator {
+		err = r.guardLastAdmin(id, update)
+	} else {
+		err = update(r.database)
+	}
+	if err == nil {
+		user.Role = role
+	}
 	return user, err
 }
 
-func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
+// UpdatePassword задает хеш пароля и добавляет его в историю;
+// mustChange требует сменить пароль при следующем входе
+func (r *userRepository) UpdatePassword(id uint, passwordHash string, mustChange bool) error {
+	return r.database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AppUser{}).Where("id = ?", id).Updates(map[string]any{
+			"password_hash":        passwordHash,
+			"must_change_password": mustChange,
+			"password_changed_at":  time.Now().UTC(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordHistory{UserID: id, PasswordHash: passwordHash}).Error
+	})
+}
+
+// UpgradePasswordHash заменяет хеш того же пароля (повышение cost), не меняя дату смены и историю
+func (r *userRepository) UpgradePasswordHash(id uint, passwordHash string) error {
 	return r.database.Model(&models.AppUser{}).Where("id = ?", id).Update("password_hash", passwordHash).Error
 }
 
+func (r *userRepository) SetMustChangePassword(id uint, mustChange bool) error {
+	return r.database.Model(&models.AppUser{}).Where("id = ?", id).Update("must_change_password", mustChange).Error
+}
+
+// RecentPasswordHashes последние limit хешей паролей пользователя, новые первыми
+func (r *userRepository) RecentPasswordHashes(id uint, limit int) ([]string, error) {
+	var hashes []string
+	err := r.database.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", id).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
+
 func (r *userRepository) SetActive(id uint, isActive bool) error {
-	return r.database.Model(&models.AppUser{}).Where("id = ?", id).Update("is_active", isActive).Error
+	update := func(tx *gorm.DB) error {
+		res := tx.Model(&models.AppUser{}).Where("id = ?", id).Update("is_active", isActive)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return res.Error
+	}
+	if isActive {
+		return update(r.database)
+	}
+	return r.guardLastAdmin(id, update)
 }
 
+// Delete мягко удаляет пользователя и деактивирует его
 func (r *userRepository) Delete(id uint) error {
-	return r.database.Delete(&models.AppUser{}, id).Error
+	return r.guardLastAdmin(id, func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AppUser{}).Where("id = ?", id).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&models.AppUser{}, id)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return res.Error
+	})
 }
 
-func (r *userRepository) Seed() error {
-	// Создаем администратора по умолчанию
-	admin := models.AppUser{
-		Email:      "admin@vector.com",
-		FirstName:  "Администратор",
-		LastName:   "Системы",
-		MiddleName: "",
-		Role:       models.RoleAdministrator,
-		IsActive:   true,
+// Seed создает начального администратора, только если в системе нет ни одного
+// активного администратора. Пароль потребуется сменить при первом входе.
+// Существующая учетная запись с тем же email (в т.ч. деактивированная или удаленная) не восстанавливается
+// и не повышается — ErrAdminSeedEmailTaken: доступ к ней восстанавливают вручную или меняют ADMIN_EMAIL.
+func (r *userRepository) Seed(email, password string) (bool, error) {
+	var admins int64
+	if err := r.database.Model(&models.AppUser{}).
+		Where("role = ? AND is_active = ?", models.RoleAdministrator, true).
+		Count(&admins).Error; err != nil {
+		return false, err
 	}
-
-	// Устанавливаем пароль по умолчанию
-	if err := admin.HashPassword("admin123"); err != nil {
-		return err
+	if admins > 0 {
+		return false, nil
 	}
 
-	// Проверяем, существует ли уже администратор
-	var existing models.AppUser
-	if err := r.database.Where("email = ?", admin.Email).First(&existing).Error; err == gorm.ErrRecordNotFound {
-		return r.database.Create(&admin).Error
+	var existing int64
+	if err := r.database.Unscoped().Model(&models.AppUser{}).Where("email = ?", email).Count(&existing).Error; err != nil {
+		return false, err
+	}
+	if existing > 0 {
+		return false, fmt.Errorf("%w: %s", models.ErrAdminSeedEmailTaken, email)
 	}
 
-	return nil
+	admin := models.AppUser{
+		Email:              email,
+		FirstName:          "Администратор",
+		LastName:           "Системы",
+		MiddleName:         "",
+		Role:               models.RoleAdministrator,
+		IsActive:           true,
+		MustChangePassword: true,
+	}
+	if err := admin.HashPassword(password); err != nil {
+		return false, err
+	}
+	if _, err := r.Create(admin); err != nil {
+		return false, err
+	}
+	return true, nil
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"vector/internal/handlers"
 	"vector/internal/middleware"
+	"vector/internal/models"
 	"vector/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,69 +13,202 @@ import (
 func SetupAuthRoutes(
 	app *fiber.App,
 	authHandlers *handlers.AuthHandlers,
+	roleHandlers *handlers.RoleHandlers,
 	authService *service.AuthService,
+	roleService *service.RoleService,
+	auditService *service.AuditService,
 ) {
 	// JWT middleware
 	jwtMiddleware := middleware.JWTMiddleware(authService)
 
+	// Публичные ключи проверки токенов для других сервисов
+	app.Get("/.well-known/jwks.json", authHandlers.JWKS)
+
 	// Публичные роуты (без аутентификации)
 	authGroup := app.Group("/auth")
 	{
 		// Вход в систему
 		authGroup.Post("/login", authHandlers.Login)
 
+		// Второй шаг входа: код 2FA
+		authGroup.Post("/login/mfa", authHandlers.LoginMFA)
+
+		// Обновление access-токена по refresh-токену
+		authGroup.Post("/refresh", authHandlers.Refresh)
+
 		// Получение списка ролей (для UI)
-		authGroup.Get("/roles", authHandlers.GetRoles)
+		authGroup.Get("/roles", roleHandlers.GetRoles)
 	}
 
-	// Защищенные роуты (требуют аутентификации)
-	protectedAuth := authGroup.Group("", jwtMiddleware)
+	// Защищенные роуты (требуют аутентификации).
+	// Журнал аудита подключен только здесь: группа покрывает и админские роуты ниже
+	protectedAuth := authGroup.Group("", jwtMiddleware, middleware.AuditTrail(auditService))
 	{
 		// Профиль текущего пользователя
 		protectedAuth.Get("/profile", authHandlers.GetProfile)
+
+		// Смена собственного пароля
+		protectedAuth.Post("/password", authHandlers.ChangePassword)
+
+		// Выход (завершение текущей сессии)
+		protectedAuth.Post("/logout", authHandlers.Logout)
+
+		// Двухфакторная аутентификация
+		protectedAuth.Get("/mfa", authHandlers.GetMFAStatus)
+		protectedAuth.Post("/mfa/enroll", authHandlers.BeginMFAEnrollment)
+		protectedAuth.Post("/mfa/verify", authHandlers.ConfirmMFAEnrollment)
+		protectedAuth.Post("/mfa/recovery-codes", authHandlers.RegenerateRecoveryCodes)
+		protectedAuth.Post("/mfa/disable", authHandlers.DisableMFA)
+	}
+
+	// Управление ролями и правами и API-ключами.
+	// Объявлены до группы управления пользователями: ее middleware действует на все последующие роуты /auth
+	manageRoles := []fiber.Handler{jwtMiddleware, middleware.RequirePasswordChanged(), middleware.RequireMFAEnrolled(authService), middleware.RequirePermission(roleService, models.PermRolesManage)}
+	authGroup.Get("/permissions", append(manageRoles, roleHandlers.ListPermissions)...)
+	rolesGroup := authGroup.Group("/roles", manageRoles...)
+	{
+		rolesGroup.Post("/", roleHandlers.CreateRole)
+		rolesGroup.Get("/:name", roleHandlers.GetRole)
+		rolesGroup.Patch("/:name", roleHandlers.UpdateRole)
+		rolesGroup.Delete("/:name", roleHandlers.DeleteRole)
 	}
 
-	// Роуты только для администраторов
-	adminAuth := authGroup.Group("", jwtMiddleware, middleware.RequireAdmin())
+	// API-ключи для интеграций
+	apiKeysGroup := authGroup.Group("/api-keys", jwtMiddleware, middleware.RequirePasswordChanged(), middleware.RequireMFAEnrolled(authService), middleware.RequirePermission(roleService, models.PermAPIKeysManage))
+	{
+		apiKeysGroup.Get("/", authHandlers.ListAPIKeys)
+		apiKeysGroup.Post("/", authHandlers.CreateAPIKey)
+		apiKeysGroup.Post("/:id/revoke", authHandlers.RevokeAPIKey)
+	}
+
+	// Роуты администрирования пользователей (право users.manage)
+	adminAuth := authGroup.Group("", jwtMiddleware, middleware.RequirePasswordChanged(), middleware.RequireMFAEnrolled(authService), middleware.RequirePermission(roleService, models.PermUsersManage))
 	{
 		// Управление пользователями
-		// adminAuth.Get("/users", authHandlers.ListUsers)
+		adminAuth.Get("/users", authHandlers.ListUsers)
 		adminAuth.Post("/users", authHandlers.CreateUser)
-		// adminAuth.Patch("/users/:id/role", authHandlers.UpdateUserRole)
-		// adminAuth.Post("/users/:id/deactivate", authHandlers.DeactivateUser)
+		adminAuth.Get("/users/:id", authHandlers.GetUser)
+		adminAuth.Patch("/users/:id/role", authHandlers.UpdateUserRole)
+		adminAuth.Post("/users/:id/deactivate", authHandlers.DeactivateUser)
+		adminAuth.Post("/users/:id/activate", authHandlers.ActivateUser)
+		adminAuth.Post("/users/:id/reset-password", authHandlers.ResetUserPassword)
+		adminAuth.Post("/users/:id/logout-all", authHandlers.LogoutAllUserSessions)
+		adminAuth.Post("/users/:id/unlock", authHandlers.UnlockUser)
+		adminAuth.Post("/users/:id/mfa/reset", authHandlers.ResetUserMFA)
+		adminAuth.Delete("/users/:id", authHandlers.DeleteUser)
 	}
 }
 
-// SetupProtectedRoutes настраивает защищенные роуты с проверкой ролей
+// SetupOIDCRoutes настраивает роуты входа через корпоративный SSO (только если OIDC настроен)
+func SetupOIDCRoutes(
+	app *fiber.App,
+	oidcHandlers *handlers.OIDCHandlers,
+	authService *service.AuthService,
+	roleService *service.RoleService,
+	auditService *service.AuditService,
+) {
+	oidcGroup := app.Group("/auth/oidc")
+	{
+		oidcGroup.Get("/login", oidcHandlers.Login)
+		oidcGroup.Get("/callback", oidcHandlers.Callback)
+		oidcGroup.Post("/exchange", oidcHandlers.Exchange)
+	}
+
+	// Ручная сверка с провайдером - для управляющих пользователями
+	oidcGroup.Post("/reconcile",
+		middleware.JWTMiddleware(authService),
+		middleware.AuditTrail(auditService),
+		middleware.RequirePasswordChanged(),
+		middleware.RequireMFAEnrolled(authService),
+		middleware.RequirePermission(roleService, models.PermUsersManage),
+		oidcHandlers.Reconcile,
+	)
+}
+
+// SetupProtectedRoutes настраивает защищенные роуты с проверкой прав
 func SetupProtectedRoutes(
 	app *fiber.App,
 	appHandlers *handlers.AppHandlers,
+	exportHandlers *handlers.ExportHandlers,
+	auditHandlers *handlers.AuditHandlers,
+	jobHandlers *handlers.JobHandlers,
+	reviewPolicyHandlers *handlers.ReviewPolicyHandlers,
+	questionnaireHandlers *handlers.QuestionnaireHandlers,
 	authService *service.AuthService,
+	roleService *service.RoleService,
+	auditService *service.AuditService,
 ) {
 	// JWT middleware
 	jwtMiddleware := middleware.JWTMiddleware(authService)
+	auditTrail := middleware.AuditTrail(auditService)
+	passwordChanged := middleware.RequirePasswordChanged()
+	mfaEnrolled := middleware.RequireMFAEnrolled(authService)
+	can := func(permission string) fiber.Handler {
+		return middleware.RequirePermission(roleService, permission)
+	}
 
-	// Клиенты - доступ для всех аутентифицированных пользователей
-	clientsGroup := app.Group("/clients", jwtMiddleware, middleware.RequireAnyRole())
+	// Клиенты
+	clientsGroup := app.Group("/clients", jwtMiddleware, auditTrail, passwordChanged, mfaEnrolled, can(models.PermClientsRead))
 	{
 		clientsGroup.Get("/", appHandlers.ListClients)
+		clientsGroup.Get("/search", appHandlers.SearchClients)
+		clientsGroup.Get("/export", can(models.PermClientsExport), exportHandlers.ExportClients)
 		clientsGroup.Get("/:id", appHandlers.GetClient)
+		// Раскрытие замаскированных персональных данных (с записью в журнал)
+		clientsGroup.Post("/:id/reveal", can(models.PermPIIReveal), appHandlers.RevealClient)
 		clientsGroup.Get("/:id/history", appHandlers.GetClientHistory)
 		clientsGroup.Get("/:id/history/:version", appHandlers.GetClientVersion)
 		clientsGroup.Get("/:id/second-part/current", appHandlers.GetSecondPartCurrent)
 		clientsGroup.Get("/:id/second-part/history", appHandlers.GetSecondPartHistory)
+		clientsGroup.Get("/:id/second-part/requirements", appHandlers.GetSecondPartRequirements)
+		clientsGroup.Get("/:id/second-part/changes", appHandlers.GetSecondPartChanges)
+		clientsGroup.Get("/:id/second-part/risk-assessment", appHandlers.GetSecondPartRiskAssessment)
 	}
 
-	// Операции с Second Part - только для администраторов и отдела ПОДФТ
-	secondPartGroup := clientsGroup.Group("/:id/second-part", middleware.RequireAdminOrPodft())
+	// Операции с Second Part
+	secondPartGroup := clientsGroup.Group("/:id/second-part", can(models.PermSecondPartEdit))
 	{
 		secondPartGroup.Post("/draft", appHandlers.CreateSecondPartDraft)
+		secondPartGroup.Post("/risk", appHandlers.DecideSecondPartRisk)
+		secondPartGroup.Post("/submit", appHandlers.SubmitSecondPart)
+		secondPartGroup.Patch("/current", appHandlers.PatchSecondPartCurrent)
 	}
 
-	// Контракты - доступ для всех аутентифицированных пользователей
-	contractsGroup := app.Group("/contracts", jwtMiddleware, middleware.RequireAnyRole())
+	// Контракты
+	contractsGroup := app.Group("/contracts", jwtMiddleware, auditTrail, passwordChanged, mfaEnrolled, can(models.PermContractsRead))
 	{
 		contractsGroup.Get("/", appHandlers.ListContracts)
+		contractsGroup.Get("/export", can(models.PermContractsExport), exportHandlers.ExportContracts)
 		contractsGroup.Get("/:id", appHandlers.GetContract)
 	}
+
+	// Журнал аудита
+	auditGroup := app.Group("/audit", jwtMiddleware, auditTrail, passwordChanged, mfaEnrolled, can(models.PermAuditRead))
+	{
+		auditGroup.Get("/", auditHandlers.ListAuditEvents)
+		auditGroup.Get("/export", auditHandlers.ExportAuditEvents)
+	}
+
+	// Фоновые задачи: ручной пересчет и журнал запусков
+	adminGroup := app.Group("/admin", jwtMiddleware, auditTrail, passwordChanged, mfaEnrolled, can(models.PermJobsManage))
+	{
+		adminGroup.Post("/recalc", jobHandlers.RunRecalc)
+		adminGroup.Get("/job-runs", jobHandlers.ListJobRuns)
+	}
+
+	// Сроки пересмотра второй части по уровням риска
+	reviewPoliciesGroup := app.Group("/review-policies", jwtMiddleware, auditTrail, passwordChanged, mfaEnrolled)
+	{
+		reviewPoliciesGroup.Get("/", can(models.PermClientsRead), reviewPolicyHandlers.ListReviewPolicies)
+		reviewPoliciesGroup.Post("/", can(models.PermReviewPolicies), reviewPolicyHandlers.CreateReviewPolicy)
+	}
+
+	// Версии схемы анкеты второй части
+	questionnaireGroup := app.Group("/questionnaire-schemas", jwtMiddleware, auditTrail, passwordChanged, mfaEnrolled)
+	{
+		questionnaireGroup.Get("/", can(models.PermClientsRead), questionnaireHandlers.ListQuestionnaireSchemas)
+		questionnaireGroup.Get("/current", can(models.PermClientsRead), questionnaireHandlers.GetCurrentQuestionnaireSchema)
+		questionnaireGroup.Get("/:version", can(models.PermClientsRead), questionnaireHandlers.GetQuestionnaireSchema)
+		questionnaireGroup.Post("/", can(models.PermQuestionnaire), questionnaireHandlers.CreateQuestionnaireSchema)
+	}
 }
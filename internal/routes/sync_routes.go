@@ -4,29 +4,38 @@ import (
 	"time"
 	"vector/internal/handlers"
 	"vector/internal/middleware"
+	"vector/internal/models"
+	"vector/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupSyncRoutes(app *fiber.App, syncHandlers *handlers.SyncHandlers, healthHandlers *handlers.HealthHandlers) {
+// SetupSyncRoutes настраивает роуты сервиса синхронизации.
+// Запуск синхронизации — по токену приложения или API-ключу с правом sync.run
+func SetupSyncRoutes(
+	app *fiber.App,
+	syncHandlers *handlers.SyncHandlers,
+	healthHandlers *handlers.HealthHandlers,
+	authService *service.AuthService,
+	roleService *service.RoleService,
+	auditService *service.AuditService,
+) {
 
 	app.Use(middleware.ErrorHandler())
 
 	app.Get("/healthz", healthHandlers.Health)
 	app.Get("/dbping", healthHandlers.DBPing)
 
-	syncGroup := app.Group("/sync")
-	syncGroup.Use(
-		middleware.RequestTimeout(30*time.Second),
+	syncGroup := app.Group("/sync",
+		middleware.JWTMiddleware(authService),
+		middleware.AuditTrail(auditService),
+		middleware.RequirePasswordChanged(),
+		middleware.RequireMFAEnrolled(authService),
+		middleware.RequirePermission(roleService, models.PermSyncRun),
 		middleware.ValidatePagination(),
 	)
 
-	syncGroup.Post("/staging", syncHandlers.SyncStaging)
-	syncGroup.Post("/apply", syncHandlers.SyncApply)
-
-	app.Post("/sync/full",
-		middleware.RequestTimeout(time.Hour),
-		middleware.ValidatePagination(),
-		syncHandlers.SyncFull,
-	)
+	syncGroup.Post("/staging", middleware.RequestTimeout(30*time.Second), syncHandlers.SyncStaging)
+	syncGroup.Post("/apply", middleware.RequestTimeout(30*time.Second), syncHandlers.SyncApply)
+	syncGroup.Post("/full", middleware.RequestTimeout(time.Hour), syncHandlers.SyncFull)
 }
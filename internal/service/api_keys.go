@@ -0,0 +1,255 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"vector/internal/models"
+	"vector/internal/pkg/ratelimit"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyInvalid     = errors.New("недействительный API-ключ")
+	ErrAPIKeyRateLimited = errors.New("превышен лимит запросов для API-ключа")
+	ErrAPIKeyRequest     = errors.New("некорректные параметры API-ключа")
+)
+
+// Ключ: vk_<8 символов>_<64 hex>. Первые apiKeyPrefixLen символов — открытый префикс.
+const (
+	apiKeyMarker    = "vk_"
+	apiKeyPrefixLen = len(apiKeyMarker) + 8
+	// last_used_at обновляется не чаще раза в apiKeyTouchInterval, а не на каждый запрос
+	apiKeyTouchInterval = time.Minute
+	// окно лимита запросов ключа (RateLimit — запросов в минуту)
+	apiKeyRatePeriod   = time.Minute
+	apiKeyMaxRateLimit = 100000
+)
+
+func newAPIKey() (prefix, secret string, err error) {
+	const alphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+	b := make([]byte, 8+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	id := make([]byte, 8)
+	for i := range id {
+		id[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return apiKeyMarker + string(id), hex.EncodeToString(b[8:]), nil
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitAPIKey разделяет ключ на префикс и секрет
+func splitAPIKey(raw string) (prefix, secret string, ok bool) {
+	if len(raw) <= apiKeyPrefixLen+1 || !strings.HasPrefix(raw, apiKeyMarker) || raw[apiKeyPrefixLen] != '_' {
+		return "", "", false
+	}
+	return raw[:apiKeyPrefixLen], raw[apiKeyPrefixLen+1:], true
+}
+
+// AuthenticateAPIKey проверяет ключ и лимит запросов. Result заполнен и при превышении лимита
+// (для заголовков Retry-After / X-RateLimit-*).
+func (s *AuthService) AuthenticateAPIKey(raw, ip string) (models.APIKey, ratelimit.Result, error) {
+	prefix, secret, ok := splitAPIKey(strings.TrimSpace(raw))
+	if !ok {
+		return models.APIKey{}, ratelimit.Result{}, ErrAPIKeyInvalid
+	}
+	key, err := s.apiKeyRepo.GetByPrefix(prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ratelimit.Result{}, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return key, ratelimit.Result{}, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.SecretHash)) != 1 || !key.Active(now) {
+		return key, ratelimit.Result{}, ErrAPIKeyInvalid
+	}
+	if key, err = s.apiKeyOwnerScopes(key); err != nil {
+		return key, ratelimit.Result{}, err
+	}
+
+	limit := key.RateLimit
+	if limit == 0 {
+		limit = s.apiKeyConfig.DefaultRateLimit
+	}
+	rate, err := s.apiKeyRate(key.ID, limit, now)
+	if err != nil {
+		return key, rate, err
+	}
+	if !rate.Allowed {
+		return key, rate, ErrAPIKeyRateLimited
+	}
+
+	s.touchAPIKey(key, ip, now)
+	return key, rate, nil
+}
+
+// apiKeyRate учитывает запрос в окне лимита ключа, общем для всех экземпляров приложения
+func (s *AuthService) apiKeyRate(keyID uint, limit int, now time.Time) (ratelimit.Result, error) {
+	if limit <= 0 {
+		return ratelimit.Unlimited(limit), nil
+	}
+	w, err := s.apiKeyRepo.HitRateWindow(keyID, apiKeyRatePeriod)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return ratelimit.Check(w.WindowStart, w.Requests, limit, apiKeyRatePeriod, now), nil
+}
+
+// apiKeyOwnerScopes оставляет у ключа только права, которые сейчас есть у роли его автора.
+// Ключ деактивированного или удаленного автора, как и ключ, у которого не осталось прав, недействителен:
+// права проверяются при каждом запросе, а не только при выпуске ключа.
+func (s *AuthService) apiKeyOwnerScopes(key models.APIKey) (models.APIKey, error) {
+	if key.CreatedBy == nil {
+		return key, ErrAPIKeyInvalid
+	}
+	owner, err := s.userRepo.GetByID(*key.CreatedBy)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return key, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return key, err
+	}
+	if !owner.IsActive {
+		return key, ErrAPIKeyInvalid
+	}
+
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, sc := range key.Scopes {
+		if s.roles.HasPermission(owner.Role, sc) {
+			scopes = append(scopes, sc)
+		}
+	}
+	if len(scopes) == 0 {
+		return key, ErrAPIKeyInvalid
+	}
+	key.Scopes = scopes
+	return key, nil
+}
+
+func (s *AuthService) touchAPIKey(key models.APIKey, ip string, now time.Time) {
+	if last, ok := s.apiKeyTouched.Load(key.ID); ok && now.Sub(last.(time.Time)) < apiKeyTouchInterval {
+		return
+	}
+	s.apiKeyTouched.Store(key.ID, now)
+	if err := s.apiKeyRepo.Touch(key.ID, ip, now); err != nil {
+		log.Printf("api keys: failed to update last use of %s: %v", key.Prefix, err)
+	}
+}
+
+func apiKeySummary(k models.APIKey) map[string]any {
+	return map[string]any{
+		"name":       k.Name,
+		"prefix":     k.Prefix,
+		"scopes":     []string(k.Scopes),
+		"rate_limit": k.RateLimit,
+		"expires_at": k.ExpiresAt,
+	}
+}
+
+// CreateAPIKey выпускает ключ. Выдать можно только права из APIKeyScopes, которые есть у самого автора.
+// Ключ возвращается открытым один раз.
+func (s *AuthService) CreateAPIKey(actor models.AuditActor, req models.CreateAPIKeyRequest) (models.CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: название обязательно (до 100 символов)", ErrAPIKeyRequest)
+	}
+
+	scopes := models.PermissionSet{}
+	for _, sc := range req.Scopes {
+		sc = strings.TrimSpace(sc)
+		if !models.IsAPIKeyScope(sc) {
+			return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: право %q нельзя выдать API-ключу (допустимы: %s)",
+				ErrAPIKeyRequest, sc, strings.Join(models.APIKeyScopes, ", "))
+		}
+		if !s.roles.HasPermission(actor.Role, sc) {
+			return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: нельзя выдать право %q, которого нет у вас", ErrAPIKeyRequest, sc)
+		}
+		scopes[sc] = true
+	}
+	if len(scopes) == 0 {
+		return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: нужно указать хотя бы одно право", ErrAPIKeyRequest)
+	}
+
+	rateLimit := req.RateLimit
+	if rateLimit == 0 {
+		rateLimit = s.apiKeyConfig.DefaultRateLimit
+	}
+	if rateLimit < 0 || rateLimit > apiKeyMaxRateLimit {
+		return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: rate_limit от 1 до %d запросов в минуту", ErrAPIKeyRequest, apiKeyMaxRateLimit)
+	}
+
+	ttl := s.apiKeyConfig.DefaultTTL
+	if req.ExpiresInDays < 0 {
+		return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: expires_in_days не может быть отрицательным", ErrAPIKeyRequest)
+	}
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	if s.apiKeyConfig.MaxTTL > 0 && ttl > s.apiKeyConfig.MaxTTL {
+		return models.CreateAPIKeyResponse{}, fmt.Errorf("%w: срок действия не больше %d дней", ErrAPIKeyRequest, int(s.apiKeyConfig.MaxTTL.Hours()/24))
+	}
+	expiresAt := time.Now().UTC().Add(ttl)
+
+	prefix, secret, err := newAPIKey()
+	if err != nil {
+		return models.CreateAPIKeyResponse{}, err
+	}
+	key, err := s.apiKeyRepo.Create(models.APIKey{
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hashAPIKeySecret(secret),
+		Scopes:     scopes.List(),
+		RateLimit:  rateLimit,
+		ExpiresAt:  &expiresAt,
+		CreatedBy:  actor.UserID,
+	})
+	if err != nil {
+		return models.CreateAPIKeyResponse{}, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionAPIKeyCreated,
+		EntityType: models.AuditEntityAPIKey,
+		EntityID:   strconv.FormatUint(uint64(key.ID), 10),
+		After:      apiKeySummary(key),
+	})
+	return models.CreateAPIKeyResponse{Key: prefix + "_" + secret, APIKey: key}, nil
+}
+
+func (s *AuthService) ListAPIKeys(includeRevoked bool) ([]models.APIKey, error) {
+	return s.apiKeyRepo.List(includeRevoked)
+}
+
+// RevokeAPIKey отзывает ключ; действует сразу, ключ проверяется по базе на каждый запрос
+func (s *AuthService) RevokeAPIKey(actor models.AuditActor, id uint) (models.APIKey, error) {
+	key, err := s.apiKeyRepo.Revoke(id, actor.UserID)
+	if err != nil {
+		return key, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionAPIKeyRevoked,
+		EntityType: models.AuditEntityAPIKey,
+		EntityID:   strconv.FormatUint(uint64(key.ID), 10),
+		Before:     apiKeySummary(key),
+	})
+	return key, nil
+}
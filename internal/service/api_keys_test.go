@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"vector/internal/models"
+)
+
+// issueKey выпускает ключ от имени пользователя с ролью role
+func issueKey(t *testing.T, ta *testAuth, role string, req models.CreateAPIKeyRequest) (models.AppUser, models.CreateAPIKeyResponse) {
+	t.Helper()
+	owner := ta.users.add(models.AppUser{Email: strings.ToLower(role) + "@example.com", Role: role, IsActive: true})
+	if req.Name == "" {
+		req.Name = "integration"
+	}
+	resp, err := ta.svc.CreateAPIKey(models.ActorFromUser(owner), req)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return owner, resp
+}
+
+func TestAuthenticateAPIKeyLookup(t *testing.T) {
+	ta := newTestAuth(t)
+	_, issued := issueKey(t, ta, models.RolePodft, models.CreateAPIKeyRequest{Scopes: []string{models.PermClientsRead}})
+
+	prefix, secret, ok := splitAPIKey(issued.Key)
+	if !ok || prefix != issued.APIKey.Prefix {
+		t.Fatalf("issued key %q does not split into its prefix %q", issued.Key, issued.APIKey.Prefix)
+	}
+	if issued.APIKey.SecretHash == secret || issued.APIKey.SecretHash != hashAPIKeySecret(secret) {
+		t.Fatal("only the hash of the secret must be stored")
+	}
+
+	key, _, err := ta.svc.AuthenticateAPIKey(issued.Key, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("valid key rejected: %v", err)
+	}
+	if key.ID != issued.APIKey.ID {
+		t.Fatalf("authenticated key %d, want %d", key.ID, issued.APIKey.ID)
+	}
+
+	otherSecret := strings.Repeat("0", len(secret))
+	cases := map[string]string{
+		"empty":          "",
+		"no marker":      "xx" + issued.Key[2:],
+		"no separator":   strings.Replace(issued.Key, "_", "-", 2),
+		"prefix only":    prefix,
+		"unknown prefix": "vk_zzzzzzzz_" + secret,
+		"wrong secret":   prefix + "_" + otherSecret,
+		"secret suffix":  issued.Key + "0",
+	}
+	for name, raw := range cases {
+		if _, _, err := ta.svc.AuthenticateAPIKey(raw, ""); !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("%s: got %v, want ErrAPIKeyInvalid", name, err)
+		}
+	}
+}
+
+func TestAuthenticateAPIKeyExpiredAndRevoked(t *testing.T) {
+	ta := newTestAuth(t)
+	owner, issued := issueKey(t, ta, models.RolePodft, models.CreateAPIKeyRequest{Scopes: []string{models.PermClientsRead}})
+
+	past := time.Now().Add(-time.Minute)
+	ta.apiKeys.keys[issued.APIKey.ID-1].ExpiresAt = &past
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued.Key, ""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("expired key: got %v, want ErrAPIKeyInvalid", err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	ta.apiKeys.keys[issued.APIKey.ID-1].ExpiresAt = &future
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued.Key, ""); err != nil {
+		t.Fatalf("key with future expiry rejected: %v", err)
+	}
+
+	if _, err := ta.svc.RevokeAPIKey(models.ActorFromUser(owner), issued.APIKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued.Key, ""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("revoked key: got %v, want ErrAPIKeyInvalid", err)
+	}
+}
+
+func TestAuthenticateAPIKeyRechecksOwner(t *testing.T) {
+	ta := newTestAuth(t)
+	owner, issued := issueKey(t, ta, models.RolePodft, models.CreateAPIKeyRequest{
+		Scopes: []string{models.PermClientsRead, models.PermAuditRead},
+	})
+
+	// роль потеряла одно из прав: ключ действует только с оставшимся
+	ta.roles.perms[models.RolePodft] = []string{models.PermClientsRead}
+	ta.svc.roles.invalidate()
+	key, _, err := ta.svc.AuthenticateAPIKey(issued.Key, "")
+	if err != nil {
+		t.Fatalf("key with remaining scope rejected: %v", err)
+	}
+	if set := key.ScopeSet(); !set.Has(models.PermClientsRead) || set.Has(models.PermAuditRead) {
+		t.Fatalf("scopes = %v, want only %s", key.Scopes, models.PermClientsRead)
+	}
+
+	// роль сменилась на роль без прав ключа
+	if _, err := ta.users.UpdateRole(owner.ID, models.RoleClientManagement); err != nil {
+		t.Fatal(err)
+	}
+	ta.roles.perms[models.RoleClientManagement] = []string{models.PermContractsRead}
+	ta.svc.roles.invalidate()
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued.Key, ""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("key without remaining scopes: got %v, want ErrAPIKeyInvalid", err)
+	}
+
+	// деактивированный автор
+	_, issued2 := issueKey(t, ta, models.RoleAdministrator, models.CreateAPIKeyRequest{Scopes: []string{models.PermClientsRead}})
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued2.Key, ""); err != nil {
+		t.Fatalf("administrator key rejected: %v", err)
+	}
+	if err := ta.users.SetActive(*issued2.APIKey.CreatedBy, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued2.Key, ""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("key of deactivated owner: got %v, want ErrAPIKeyInvalid", err)
+	}
+
+	// удаленный автор
+	if err := ta.users.Delete(*issued2.APIKey.CreatedBy); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ta.svc.AuthenticateAPIKey(issued2.Key, ""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("key of deleted owner: got %v, want ErrAPIKeyInvalid", err)
+	}
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	ta := newTestAuth(t)
+	owner := ta.users.add(models.AppUser{Email: "cm@example.com", Role: models.RoleClientManagement, IsActive: true})
+	actor := models.ActorFromUser(owner)
+
+	cases := map[string][]string{
+		"no scopes":          nil,
+		"not an api scope":   {models.PermUsersManage},
+		"scope actor lacks":  {models.PermAuditRead},
+		"one of them lacked": {models.PermClientsRead, models.PermClientsExport},
+	}
+	for name, scopes := range cases {
+		_, err := ta.svc.CreateAPIKey(actor, models.CreateAPIKeyRequest{Name: "k", Scopes: scopes})
+		if !errors.Is(err, ErrAPIKeyRequest) {
+			t.Errorf("%s: got %v, want ErrAPIKeyRequest", name, err)
+		}
+	}
+}
+
+func TestAuthenticateAPIKeyRateLimit(t *testing.T) {
+	ta := newTestAuth(t)
+	_, issued := issueKey(t, ta, models.RolePodft, models.CreateAPIKeyRequest{
+		Scopes:    []string{models.PermClientsRead},
+		RateLimit: 2,
+	})
+
+	for i := 1; i <= 2; i++ {
+		_, rate, err := ta.svc.AuthenticateAPIKey(issued.Key, "")
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if rate.Limit != 2 || rate.Remaining != 2-i {
+			t.Fatalf("request %d: limit=%d remaining=%d", i, rate.Limit, rate.Remaining)
+		}
+	}
+
+	_, rate, err := ta.svc.AuthenticateAPIKey(issued.Key, "")
+	if !errors.Is(err, ErrAPIKeyRateLimited) {
+		t.Fatalf("third request: got %v, want ErrAPIKeyRateLimited", err)
+	}
+	if rate.Allowed || rate.RetryAfter <= 0 || rate.RetryAfter > time.Minute {
+		t.Fatalf("rate limited result = %+v", rate)
+	}
+
+	// окно общее для всех экземпляров приложения: второй сервис на той же базе тоже отказывает
+	replica := NewAuthService(ta.users, &fakeSessionRepo{}, fakeThrottleRepo{}, ta.mfa, ta.apiKeys,
+		ta.svc.jwtConfig, ta.svc.mfa, ta.svc.apiKeyConfig, ta.svc.passwords, ta.svc.roles, ta.auditSvc)
+	if _, _, err := replica.AuthenticateAPIKey(issued.Key, ""); !errors.Is(err, ErrAPIKeyRateLimited) {
+		t.Fatalf("another instance: got %v, want ErrAPIKeyRateLimited", err)
+	}
+
+	// новое окно
+	ta.apiKeys.windows[issued.APIKey.ID].WindowStart = time.Now().UTC().Add(-apiKeyRatePeriod)
+	if _, rate, err := ta.svc.AuthenticateAPIKey(issued.Key, ""); err != nil || rate.Remaining != 1 {
+		t.Fatalf("new window: remaining=%d err=%v", rate.Remaining, err)
+	}
+
+	// неверный ключ с тем же префиксом лимит не расходует и не получает
+	prefix, _, _ := splitAPIKey(issued.Key)
+	if _, _, err := ta.svc.AuthenticateAPIKey(prefix+"_"+strings.Repeat("0", 64), ""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Fatalf("wrong secret under rate limit: got %v, want ErrAPIKeyInvalid", err)
+	}
+
+	// last_used_at пишется не на каждый запрос
+	if ta.apiKeys.touched != 1 {
+		t.Fatalf("last use recorded %d times, want 1", ta.apiKeys.touched)
+	}
+}
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"time"
+	"fmt"
+	"strconv"
 	"vector/internal/models"
+	"vector/internal/pkg/jsonschema"
+	"vector/internal/pkg/mergepatch"
 	"vector/internal/repository"
 
 	"gorm.io/datatypes"
@@ -13,22 +17,31 @@ import (
 type AppService struct {
 	clientRepo       repository.AppClientRepository
 	checkRepo        repository.CheckRepository
-	recalcRepo       repository.RecalcRepository
 	syncContractRepo repository.SyncContractRepository
+	piiRevealRepo    repository.PiiRevealRepository
+	audit            *AuditService
+	risk             *RiskScoringService
+	questionnaire    *QuestionnaireService
 }
 
 func NewAppService(
 	clientRepo repository.AppClientRepository,
 	userRepo repository.UserRepository,
 	checkRepo repository.CheckRepository,
-	recalcRepo repository.RecalcRepository,
 	syncContractRepo repository.SyncContractRepository,
+	piiRevealRepo repository.PiiRevealRepository,
+	audit *AuditService,
+	risk *RiskScoringService,
+	questionnaire *QuestionnaireService,
 ) *AppService {
 	return &AppService{
 		clientRepo:       clientRepo,
 		checkRepo:        checkRepo,
-		recalcRepo:       recalcRepo,
 		syncContractRepo: syncContractRepo,
+		piiRevealRepo:    piiRevealRepo,
+		audit:            audit,
+		risk:             risk,
+		questionnaire:    questionnaire,
 	}
 }
 
@@ -42,16 +55,24 @@ func (s *AppService) GetClientHistory(clientID int) ([]models.ClientVersion, err
 	return s.clientRepo.GetClientHistory(clientID)
 }
 
+func (s *AppService) ListClientHistory(clientID int, page models.PageRequest) ([]models.ClientVersion, models.PageInfo, error) {
+	return s.clientRepo.ListClientHistory(clientID, page)
+}
+
 func (s *AppService) GetClientVersion(clientID int, version int) (models.ClientVersion, error) {
 	return s.clientRepo.GetClientVersion(clientID, version)
 }
 
-func (s *AppService) ListClientsWithSP(page, perPage int, needsSecondPart *bool, spStatus *string, dueBefore *time.Time) ([]models.ClientWithSP, int64, error) {
-	return s.clientRepo.ListClientsWithSP(page, perPage, needsSecondPart, spStatus, dueBefore)
+func (s *AppService) ListClientsWithSP(page models.PageRequest, filter models.ClientListFilter) ([]models.ClientWithSP, models.PageInfo, error) {
+	return s.clientRepo.ListClientsWithSP(page, filter)
 }
 
 // ========== МЕТОДЫ ДЛЯ ВТОРОЙ ЧАСТИ ==========
 
+func (s *AppService) ListSecondPartRequirements(clientID int, includeResolved bool) ([]models.SecondPartRequirement, error) {
+	return s.clientRepo.ListSecondPartRequirements(clientID, includeResolved)
+}
+
 func (s *AppService) GetSecondPartCurrent(clientID int) (models.SecondPartVersion, error) {
 	return s.clientRepo.GetSecondPartCurrent(clientID)
 }
@@ -60,12 +81,161 @@ func (s *AppService) ListSecondPartHistory(clientID int) ([]models.SecondPartVer
 	return s.clientRepo.ListSecondPartHistory(clientID)
 }
 
-func (s *AppService) CreateSecondPartDraft(clientID int, riskLevel *string, createdBy *int, dataOverride *datatypes.JSON) (models.SecondPartVersion, error) {
-	return s.clientRepo.CreateSecondPartDraft(clientID, riskLevel, createdBy, dataOverride)
+// CreateSecondPartDraft создает черновик с предложением скоринга. Уровень риска из запроса
+// сразу записывается как решение по предложению; без него решение принимается позже (DecideSecondPartRisk).
+// Анкета проверяется по текущей схеме без отказа: неполный черновик сохраняется, нарушения возвращаются
+// вызывающему, а отправка (SubmitSecondPart) требует соответствия схеме.
+func (s *AppService) CreateSecondPartDraft(actor models.AuditActor, clientID int, in RiskDecisionInput, dataOverride *datatypes.JSON) (models.SecondPartVersion, []jsonschema.ValidationError, error) {
+	createdBy := actorUserID(actor)
+
+	var before any
+	var data datatypes.JSON
+	if cur, err := s.clientRepo.GetSecondPartCurrent(clientID); err == nil {
+		before = secondPartSummary(cur)
+		data = cur.Data
+	}
+	if dataOverride != nil {
+		data = *dataOverride
+	}
+	schemaVersion, violations, err := s.questionnaire.Validate(data)
+	if err != nil {
+		return models.SecondPartVersion{}, nil, err
+	}
+
+	assessment, err := s.risk.Assess(clientID)
+	if err != nil {
+		return models.SecondPartVersion{}, nil, err
+	}
+	risk, err := decideRisk(assessment, in, createdBy)
+	if err != nil {
+		return models.SecondPartVersion{}, nil, err
+	}
+
+	sp, err := s.clientRepo.CreateSecondPartDraft(clientID, risk, createdBy, dataOverride, &schemaVersion)
+	if err != nil {
+		return sp, nil, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionSecondPartDraft,
+		EntityType: models.AuditEntityClient,
+		EntityID:   strconv.Itoa(clientID),
+		Before:     before,
+		After:      secondPartSummary(sp),
+		Metadata: map[string]any{
+			"data_override":     dataOverride != nil,
+			"schema_version":    schemaVersion,
+			"validation_errors": len(violations),
+		},
+	})
+	return sp, violations, nil
+}
+
+// AssessSecondPartRisk предложение скоринга по текущим данным клиента без записи в черновик
+func (s *AppService) AssessSecondPartRisk(clientID int) (models.RiskAssessment, error) {
+	return s.risk.Assess(clientID)
+}
+
+// DecideSecondPartRisk принимает или изменяет предложенный уровень риска текущего черновика.
+// Решение сравнивается с предложением, сохраненным в черновике; у черновиков без предложения оно считается заново.
+func (s *AppService) DecideSecondPartRisk(actor models.AuditActor, clientID int, in RiskDecisionInput) (models.SecondPartVersion, error) {
+	cur, err := s.clientRepo.GetSecondPartCurrent(clientID)
+	if err != nil {
+		return cur, err
+	}
+
+	var assessment models.RiskAssessment
+	if len(cur.RiskAssessment) == 0 || json.Unmarshal(cur.RiskAssessment, &assessment) != nil || assessment.Level == "" {
+		if assessment, err = s.risk.Assess(clientID); err != nil {
+			return cur, err
+		}
+	}
+	if in.Level == "" {
+		in.Level = assessment.Level
+	}
+
+	risk, err := decideRisk(assessment, in, actorUserID(actor))
+	if err != nil {
+		return cur, err
+	}
+
+	sp, err := s.clientRepo.DecideSecondPartRisk(clientID, risk)
+	if err != nil {
+		return sp, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionSecondPartRisk,
+		EntityType: models.AuditEntityClient,
+		EntityID:   strconv.Itoa(clientID),
+		Before:     secondPartSummary(cur),
+		After:      secondPartSummary(sp),
+		Metadata: map[string]any{
+			"proposed_level":  assessment.Level,
+			"score":           assessment.Score,
+			"decision":        risk.Decision,
+			"override_reason": risk.OverrideReason,
+		},
+	})
+	return sp, nil
+}
+
+func actorUserID(actor models.AuditActor) *int {
+	if actor.UserID == nil {
+		return nil
+	}
+	id := int(*actor.UserID)
+	return &id
 }
 
-func (s *AppService) SubmitSecondPart(clientID int, userID *int) (models.SecondPartVersion, error) {
-	return s.clientRepo.SubmitSecondPart(clientID, userID)
+// secondPartSummary сводка версии второй части для журнала аудита (без содержимого анкеты)
+func secondPartSummary(sp models.SecondPartVersion) map[string]any {
+	return map[string]any{
+		"client_version": sp.ClientVersion,
+		"version":        sp.Version,
+		"revision":       sp.Revision,
+		"status":         sp.Status,
+		"risk_level":     sp.RiskLevel,
+		"risk_proposed":  sp.RiskProposedLevel,
+		"risk_decision":  sp.RiskDecision,
+		"due_at":         sp.DueAt,
+		"schema_version": sp.SchemaVersion,
+	}
+}
+
+// SubmitSecondPart отправляет текущий черновик (draft или doc_requested). Анкета должна соответствовать
+// текущей схеме, иначе — QuestionnaireInvalidError со списком нарушений.
+func (s *AppService) SubmitSecondPart(actor models.AuditActor, clientID int) (models.SecondPartVersion, error) {
+	cur, err := s.clientRepo.GetSecondPartCurrent(clientID)
+	if err != nil {
+		return cur, err
+	}
+	if cur.Status != "draft" && cur.Status != "doc_requested" {
+		return cur, models.ErrSecondPartNotEditable
+	}
+
+	schemaVersion, violations, err := s.questionnaire.Validate(cur.Data)
+	if err != nil {
+		return cur, err
+	}
+	if len(violations) > 0 {
+		return cur, &QuestionnaireInvalidError{SchemaVersion: schemaVersion, Errors: violations}
+	}
+
+	// отправляется ровно проверенная правка: если черновик изменили после проверки — конфликт
+	sp, err := s.clientRepo.SubmitSecondPart(clientID, actorUserID(actor), cur.Version, cur.Revision, schemaVersion)
+	if err != nil {
+		return sp, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionSecondPartSubmit,
+		EntityType: models.AuditEntityClient,
+		EntityID:   strconv.Itoa(clientID),
+		Before:     secondPartSummary(cur),
+		After:      secondPartSummary(sp),
+	})
+	return sp, nil
 }
 
 func (s *AppService) ApproveSecondPart(clientID int, approvedBy *int) (models.SecondPartVersion, error) {
@@ -80,6 +250,76 @@ func (s *AppService) RequestDocsSecondPart(clientID int, userID *int, reason str
 	return s.clientRepo.RequestDocsSecondPart(clientID, userID, reason)
 }
 
+// PatchSecondPartDraft правит анкету текущего черновика (draft или doc_requested) без новой версии:
+// patch — JSON Merge Patch (RFC 7396) к Data. version и revision — из If-Match; если черновик с тех пор
+// изменился — ErrSecondPartVersionConflict. Анкета проверяется по текущей схеме без отказа, как при
+// создании черновика. Патч без изменений не увеличивает правку.
+func (s *AppService) PatchSecondPartDraft(actor models.AuditActor, clientID, version, revision int, patch []byte) (models.SecondPartVersion, []jsonschema.ValidationError, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &obj); err != nil || obj == nil {
+		return models.SecondPartVersion{}, nil, models.ErrInvalidMergePatch
+	}
+
+	cur, err := s.clientRepo.GetSecondPartCurrent(clientID)
+	if err != nil {
+		return cur, nil, err
+	}
+	if cur.Version != version || cur.Revision != revision {
+		return cur, nil, models.ErrSecondPartVersionConflict
+	}
+	if cur.Status != "draft" && cur.Status != "doc_requested" {
+		return cur, nil, models.ErrSecondPartNotEditable
+	}
+
+	merged, err := mergepatch.Apply(cur.Data, patch)
+	if err != nil {
+		return cur, nil, fmt.Errorf("%w: %v", models.ErrInvalidMergePatch, err)
+	}
+	diff, err := mergepatch.Diff(cur.Data, merged)
+	if err != nil {
+		return cur, nil, err
+	}
+
+	schemaVersion, violations, err := s.questionnaire.Validate(merged)
+	if err != nil {
+		return cur, nil, err
+	}
+	if len(diff) == 0 {
+		return cur, violations, nil
+	}
+
+	changes := make([]models.SecondPartChange, len(diff))
+	paths := make([]string, len(diff))
+	for i, d := range diff {
+		changes[i] = models.SecondPartChange{Path: d.Path, OldValue: datatypes.JSON(d.Old), NewValue: datatypes.JSON(d.New)}
+		paths[i] = d.Path
+	}
+
+	sp, err := s.clientRepo.UpdateSecondPartDraftData(clientID, version, revision, merged, &schemaVersion, changes, actorUserID(actor))
+	if err != nil {
+		return sp, nil, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionSecondPartEdit,
+		EntityType: models.AuditEntityClient,
+		EntityID:   strconv.Itoa(clientID),
+		Metadata: map[string]any{
+			"version":           sp.Version,
+			"revision":          sp.Revision,
+			"changed_paths":     paths,
+			"schema_version":    schemaVersion,
+			"validation_errors": len(violations),
+		},
+	})
+	return sp, violations, nil
+}
+
+// ListSecondPartChanges журнал изменений анкеты; version > 0 — только для этой версии второй части
+func (s *AppService) ListSecondPartChanges(clientID, version int, page models.PageRequest) ([]models.SecondPartChange, models.PageInfo, error) {
+	return s.clientRepo.ListSecondPartChanges(clientID, version, page)
+}
+
 // ========== МЕТОДЫ ДЛЯ КОНТРАКТОВ ==========
 
 func (s *AppService) GetContract(contractID int) (models.Contract, error) {
@@ -94,8 +334,8 @@ func (s *AppService) GetContract(contractID int) (models.Contract, error) {
 	return *contract, nil
 }
 
-func (s *AppService) ListContracts(page, perPage int, userID *int, status *string) ([]models.Contract, int64, error) {
-	return s.syncContractRepo.ListContracts(page, perPage, userID, status)
+func (s *AppService) ListContracts(page models.PageRequest, filter models.ContractListFilter) ([]models.Contract, models.PageInfo, error) {
+	return s.syncContractRepo.ListContracts(page, filter)
 }
 
 // ========== МЕТОДЫ ДЛЯ ПРОВЕРОК ==========
@@ -111,17 +351,3 @@ func (s *AppService) UpdateCheckResult(checkID uint, status string, result *data
 func (s *AppService) ListChecksByClient(clientID int, spVersion *int) ([]models.SecondPartCheck, error) {
 	return s.checkRepo.ListByClient(clientID, spVersion)
 }
-
-// ========== МЕТОДЫ ДЛЯ ПЕРЕСЧЕТОВ ==========
-
-func (s *AppService) RecalcNeedsSecondPart() (int64, error) {
-	return s.recalcRepo.RecalcNeedsSecondPart()
-}
-
-func (s *AppService) RecalcPassportExpiry() (int64, error) {
-	return s.recalcRepo.RecalcPassportExpiry()
-}
-
-func (s *AppService) RecalcAll() error {
-	return s.recalcRepo.RecalcAll()
-}
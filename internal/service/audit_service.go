@@ -0,0 +1,82 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/repository"
+
+	"gorm.io/datatypes"
+)
+
+// AuditEntry событие для журнала аудита; Before/After — краткие сводки состояния
+// (без паролей и полных персональных данных), сериализуются в jsonb
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Method     string
+	Path       string
+	Status     int
+	Before     any
+	After      any
+	Metadata   map[string]any
+}
+
+type AuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+func auditJSON(v any) datatypes.JSON {
+	if v == nil {
+		return nil
+	}
+	if m, ok := v.(map[string]any); ok && len(m) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+	return datatypes.JSON(b)
+}
+
+// Record добавляет событие в журнал
+func (s *AuditService) Record(actor models.AuditActor, e AuditEntry) error {
+	_, err := s.auditRepo.Append(models.AuditEvent{
+		OccurredAt: time.Now(),
+		ActorID:    actor.UserID,
+		ActorEmail: actor.Email,
+		ActorRole:  actor.Role,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		RequestID:  actor.RequestID,
+		IP:         actor.IP,
+		Method:     e.Method,
+		Path:       e.Path,
+		Status:     e.Status,
+		Before:     auditJSON(e.Before),
+		After:      auditJSON(e.After),
+		Metadata:   auditJSON(e.Metadata),
+	})
+	return err
+}
+
+// RecordAfter записывает событие об уже выполненном действии: ошибка журнала
+// не отменяет действие, но попадает в лог
+func (s *AuditService) RecordAfter(actor models.AuditActor, e AuditEntry) {
+	if err := s.Record(actor, e); err != nil {
+		log.Printf("audit: failed to record %s %s/%s: %v", e.Action, e.EntityType, e.EntityID, err)
+	}
+}
+
+func (s *AuditService) List(page models.PageRequest, filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	return s.auditRepo.List(page, filter)
+}
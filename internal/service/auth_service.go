@@ -2,60 +2,170 @@ package service
 
 import (
 	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 	"vector/internal/models"
+	"vector/internal/pkg/password"
+	"vector/internal/pkg/signing"
 	"vector/internal/repository"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	// tokenIssuer значение iss в токенах приложения
+	tokenIssuer = "vector-app"
+	// AccessTokenAudience aud access-токенов; сервисы, проверяющие токены по JWKS,
+	// должны требовать ее, чтобы не принять токен второго шага входа
+	AccessTokenAudience = "vector-api"
+)
+
 type AuthService struct {
-	userRepo  repository.UserRepository
-	jwtConfig models.JWTConfig
+	userRepo     repository.UserRepository
+	sessionRepo  repository.SessionRepository
+	throttleRepo repository.AuthThrottleRepository
+	mfaRepo      repository.MFARepository
+	apiKeyRepo   repository.APIKeyRepository
+	jwtConfig    models.JWTConfig
+	mfa          models.MFAConfig
+	passwords    password.Policy
+	roles        *RoleService
+	audit        *AuditService
+
+	apiKeyConfig  models.APIKeyConfig
+	apiKeyTouched sync.Map // id ключа -> время последней записи last_used_at
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtConfig models.JWTConfig) *AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	throttleRepo repository.AuthThrottleRepository,
+	mfaRepo repository.MFARepository,
+	apiKeyRepo repository.APIKeyRepository,
+	jwtConfig models.JWTConfig,
+	mfaConfig models.MFAConfig,
+	apiKeyConfig models.APIKeyConfig,
+	passwords password.Policy,
+	roles *RoleService,
+	audit *AuditService,
+) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtConfig: jwtConfig,
+		userRepo:     userRepo,
+		sessionRepo:  sessionRepo,
+		throttleRepo: throttleRepo,
+		mfaRepo:      mfaRepo,
+		apiKeyRepo:   apiKeyRepo,
+		jwtConfig:    jwtConfig,
+		mfa:          mfaConfig,
+		passwords:    passwords,
+		roles:        roles,
+		audit:        audit,
+
+		apiKeyConfig: apiKeyConfig,
 	}
 }
 
-func (s *AuthService) Login(email, password string) (*models.LoginResponse, error) {
+// loginFailed фиксирует неудачную попытку входа; actor до входа известен только по email
+func (s *AuthService) loginFailed(actor models.AuditActor, email string, userID *uint, reason string) {
+	s.registerLoginFailure(actor, email)
+
+	actor.Email = email
+	actor.UserID = userID
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionLoginFailed,
+		EntityType: models.AuditEntityUser,
+		Metadata:   map[string]any{"reason": reason},
+	})
+}
+
+func (s *AuthService) Login(actor models.AuditActor, email, password string) (*models.LoginResponse, error) {
+	if err := s.checkLoginThrottle(actor, email); err != nil {
+		return nil, err
+	}
+
 	// Найти пользователя по email
 	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
+		s.loginFailed(actor, email, nil, "unknown_email")
 		return nil, errors.New("неверный email или пароль")
 	}
 
 	if !user.IsActive {
+		s.loginFailed(actor, email, &user.ID, "inactive")
 		return nil, errors.New("пользователь деактивирован")
 	}
 
+	// у учетных записей корпоративного SSO локального пароля нет
+	if user.IsExternal() {
+		s.loginFailed(actor, email, &user.ID, "external_account")
+		return nil, ErrExternalAccount
+	}
+
 	if !user.CheckPassword(password) {
+		s.loginFailed(actor, email, &user.ID, "bad_password")
 		return nil, errors.New("неверный email или пароль")
 	}
 
-	token, expiresAt, err := s.generateJWT(user)
+	expired := s.applyPasswordPolicyOnLogin(&user, password)
+
+	var metadata map[string]any
+	if expired {
+		metadata = map[string]any{"password_expired": true}
+	}
+	return s.finishLogin(actor, user, metadata)
+}
+
+// finishLogin после проверки первого фактора: с подключенной 2FA выдает только токен
+// второго шага (LoginMFA), иначе открывает сессию
+func (s *AuthService) finishLogin(actor models.AuditActor, user models.AppUser, metadata map[string]any) (*models.LoginResponse, error) {
+	if user.MFAEnabled {
+		token, expiresAt, err := s.issueMFAToken(user)
+		if err != nil {
+			return nil, errors.New("ошибка создания токена")
+		}
+		return &models.LoginResponse{
+			MFARequired:     true,
+			MFAToken:        token,
+			MFATokenExpires: expiresAt,
+		}, nil
+	}
+	return s.completeLogin(actor, user, metadata)
+}
+
+// completeLogin завершает вход после всех проверок: сбрасывает счетчик неудач и открывает сессию
+func (s *AuthService) completeLogin(actor models.AuditActor, user models.AppUser, metadata map[string]any) (*models.LoginResponse, error) {
+	if _, err := s.throttleRepo.Clear(accountThrottleKey(user.Email)); err != nil {
+		log.Printf("auth: failed to reset login failures for %s: %v", user.Email, err)
+	}
+
+	resp, err := s.startSession(actor, user)
 	if err != nil {
 		return nil, errors.New("ошибка создания токена")
 	}
 
-	return &models.LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User:      user.PublicUser(),
-	}, nil
+	actor.UserID, actor.Email, actor.Role = &user.ID, user.Email, user.Role
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionLoginSucceeded,
+		EntityType: models.AuditEntityUser,
+		EntityID:   strconv.FormatUint(uint64(user.ID), 10),
+		Metadata:   metadata,
+	})
+
+	return resp, nil
 }
 
-func (s *AuthService) CreateUser(req models.CreateUserRequest, creatorRole string) (*models.AppUser, error) {
+func (s *AuthService) CreateUser(actor models.AuditActor, req models.CreateUserRequest) (*models.AppUser, error) {
 
-	if creatorRole != models.RoleAdministrator {
+	if !s.roles.HasPermission(actor.Role, models.PermUsersManage) {
 		return nil, errors.New("недостаточно прав для создания пользователя")
 	}
 
-	if !isValidRole(req.Role) {
-		return nil, errors.New("недопустимая роль")
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if err := s.validateUserRequest(req); err != nil {
+		return nil, err
 	}
 
 	user := models.AppUser{
@@ -67,64 +177,97 @@ func (s *AuthService) CreateUser(req models.CreateUserRequest, creatorRole strin
 		IsActive:   true,
 	}
 
-	if err := user.HashPassword(req.Password); err != nil {
+	hash, err := s.passwords.Hash(req.Password)
+	if err != nil {
 		return nil, errors.New("ошибка хеширования пароля")
 	}
+	user.PasswordHash = hash
 
 	createdUser, err := s.userRepo.Create(user)
 	if err != nil {
 		return nil, err
 	}
 
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserCreated,
+		EntityType: models.AuditEntityUser,
+		EntityID:   strconv.FormatUint(uint64(createdUser.ID), 10),
+		After:      userSummary(createdUser),
+	})
+
 	return &createdUser, nil
 }
 
-func (s *AuthService) ValidateToken(tokenString string) (*models.AppUser, error) {
+// userSummary сводка пользователя для журнала аудита (без хеша пароля)
+func userSummary(u models.AppUser) map[string]any {
+	return map[string]any{
+		"email":      u.Email,
+		"first_name": u.FirstName,
+		"last_name":  u.LastName,
+		"role":       u.Role,
+		"is_active":  u.IsActive,
+	}
+}
+
+// ValidateToken проверяет access-токен и возвращает пользователя и идентификатор сессии (sid)
+func (s *AuthService) ValidateToken(tokenString string) (*models.AppUser, string, error) {
 
-	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("неверный метод подписи токена")
-		}
-		return []byte(s.jwtConfig.SecretKey), nil
-	})
+	// ключ выбирается по kid; HMAC и прочие алгоритмы вне набора ключей не принимаются
+	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, s.jwtConfig.Keys.Keyfunc,
+		jwt.WithValidMethods(s.jwtConfig.Keys.Algorithms()), jwt.WithIssuer(tokenIssuer), jwt.WithAudience(AccessTokenAudience))
 
 	if err != nil {
-		return nil, errors.New("неверный токен")
+		return nil, "", errors.New("неверный токен")
 	}
 
 	claims, ok := token.Claims.(*models.JWTClaims)
 	if !ok || !token.Valid {
-		return nil, errors.New("неверный токен")
+		return nil, "", errors.New("неверный токен")
+	}
+
+	// токен отозванной сессии (logout, смена роли, обнаружен повтор refresh-токена) недействителен
+	if claims.SessionID == "" {
+		return nil, "", errors.New("неверный токен")
+	}
+	active, err := s.sessionRepo.IsActive(claims.SessionID)
+	if err != nil || !active {
+		return nil, "", errors.New("сессия завершена")
 	}
 
 	user, err := s.userRepo.GetByID(claims.UserID)
 	if err != nil {
-		return nil, errors.New("пользователь не найден")
+		return nil, "", errors.New("пользователь не найден")
 	}
 
 	if !user.IsActive {
-		return nil, errors.New("пользователь деактивирован")
+		return nil, "", errors.New("пользователь деактивирован")
 	}
 
-	return &user, nil
+	return &user, claims.SessionID, nil
+}
+
+// JWKS публичные ключи проверки access-токенов для других сервисов
+func (s *AuthService) JWKS() signing.JWKSet {
+	return s.jwtConfig.Keys.JWKS()
 }
 
-func (s *AuthService) generateJWT(user models.AppUser) (string, int64, error) {
+func (s *AuthService) generateJWT(user models.AppUser, sessionID string) (string, int64, error) {
 	expiresAt := time.Now().Add(s.jwtConfig.TokenDuration)
 
 	claims := &models.JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{AccessTokenAudience},
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "vector-app",
+			Issuer:    tokenIssuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtConfig.SecretKey))
+	tokenString, err := s.jwtConfig.Keys.Sign(claims)
 	if err != nil {
 		return "", 0, err
 	}
@@ -132,10 +275,37 @@ func (s *AuthService) generateJWT(user models.AppUser) (string, int64, error) {
 	return tokenString, expiresAt.Unix(), nil
 }
 
-func isValidRole(role string) bool {
-	switch role {
-	case models.RoleAdministrator, models.RolePodft, models.RoleClientManagement:
+// applyPasswordPolicyOnLogin после успешной проверки пароля: перехеширует пароль,
+// если cost ниже требуемого, и требует смены истекшего пароля.
+// Возвращает true, если пароль истек при этом входе.
+func (s *AuthService) applyPasswordPolicyOnLogin(user *models.AppUser, plain string) bool {
+	if s.passwords.NeedsRehash(user.PasswordHash) {
+		if hash, err := s.passwords.Hash(plain); err == nil {
+			if err := s.userRepo.UpgradePasswordHash(user.ID, hash); err != nil {
+				log.Printf("auth: failed to upgrade password hash for user %d: %v", user.ID, err)
+			} else {
+				user.PasswordHash = hash
+			}
+		}
+	}
+
+	if !user.MustChangePassword && s.passwords.Expired(user.PasswordChangedAt, time.Now()) {
+		if err := s.userRepo.SetMustChangePassword(user.ID, true); err != nil {
+			log.Printf("auth: failed to flag expired password for user %d: %v", user.ID, err)
+			return false
+		}
+		user.MustChangePassword = true
 		return true
 	}
 	return false
 }
+
+// RolePermissions права роли пользователя
+func (s *AuthService) RolePermissions(role string) (models.PermissionSet, error) {
+	return s.roles.Permissions(role)
+}
+
+// isValidRole роль есть в справочнике ролей
+func (s *AuthService) isValidRole(role string) bool {
+	return s.roles.RoleExists(role)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+	"vector/internal/models"
+	"vector/internal/pkg/utils"
+)
+
+const (
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+)
+
+// SearchClients ищет клиентов по ФИО, ИНН, СНИЛС, паспорту, телефону, email или логину
+// и помечает совпавшие поля
+func (s *AppService) SearchClients(raw string, limit int) (utils.SearchQuery, []models.ClientSearchResult, error) {
+	query := utils.ParseSearchQuery(raw)
+
+	results, err := s.clientRepo.SearchClients(query, limit)
+	if err != nil {
+		return query, nil, err
+	}
+
+	for i := range results {
+		highlightSearchResult(&results[i], query)
+	}
+
+	return query, results, nil
+}
+
+func highlightSearchResult(r *models.ClientSearchResult, q utils.SearchQuery) {
+	r.MatchedFields = []string{}
+	r.Highlights = map[string]string{}
+
+	mark := func(field, value string) {
+		r.MatchedFields = append(r.MatchedFields, field)
+		r.Highlights[field] = highlightOpen + html.EscapeString(value) + highlightClose
+	}
+
+	for _, t := range q.Types {
+		switch t {
+		case utils.SearchTypeINN:
+			if r.Inn == q.Digits {
+				mark("inn", r.Inn)
+			}
+		case utils.SearchTypeSNILS:
+			if digitsOnly(r.Snils) == q.Digits {
+				mark("snils", r.Snils)
+			}
+		case utils.SearchTypePassport:
+			if digitsOnly(r.PassSeries+r.PassNumber) == q.Digits || r.PassNumber == q.Digits {
+				mark("pass_series", r.PassSeries)
+				mark("pass_number", r.PassNumber)
+			}
+		case utils.SearchTypePhone:
+			phone := utils.NormalizePhone(q.Digits)
+			if phone != "" && strings.Contains(utils.NormalizePhone(r.MainPhone), phone) {
+				mark("main_phone", r.MainPhone)
+			}
+		case utils.SearchTypeEmail:
+			if r.ContactEmail != "" {
+				if strings.EqualFold(r.ContactEmail, q.Raw) {
+					mark("contact_email", r.ContactEmail)
+				} else if h, ok := highlightWords(r.ContactEmail, q.Words); ok {
+					r.MatchedFields = append(r.MatchedFields, "contact_email")
+					r.Highlights["contact_email"] = h
+				}
+			}
+		case utils.SearchTypeText:
+			fields := []struct {
+				name  string
+				value string
+			}{
+				{"surname", r.Surname},
+				{"name", r.Name},
+				{"patronymic", r.Patronymic},
+				{"login", r.Login},
+			}
+			matched := false
+			for _, f := range fields {
+				if h, ok := highlightWords(f.value, q.Words); ok {
+					r.MatchedFields = append(r.MatchedFields, f.name)
+					r.Highlights[f.name] = h
+					matched = true
+				}
+			}
+			// совпадение только по триграммам (опечатка) — подсвечивать нечего
+			if !matched {
+				r.MatchedFields = append(r.MatchedFields, "full_name")
+			}
+		}
+	}
+
+	if len(r.Highlights) == 0 {
+		r.Highlights = nil
+	}
+}
+
+// highlightWords оборачивает вхождения слов запроса в значение (без учета регистра).
+// Текст между метками экранируется: подсветка вставляется в разметку как есть.
+func highlightWords(value string, words []string) (string, bool) {
+	if value == "" || len(words) == 0 {
+		return value, false
+	}
+
+	lower := strings.ToLower(value)
+	// Регистронезависимое сравнение требует одинаковой длины в байтах
+	if len(lower) != len(value) {
+		return value, false
+	}
+
+	marks := make([]bool, len(value))
+	found := false
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		for start := 0; ; {
+			idx := strings.Index(lower[start:], w)
+			if idx < 0 {
+				break
+			}
+			pos := start + idx
+			for k := pos; k < pos+len(w); k++ {
+				marks[k] = true
+			}
+			found = true
+			start = pos + len(w)
+		}
+	}
+	if !found {
+		return value, false
+	}
+
+	var b strings.Builder
+	open := false
+	from := 0
+	for i := 0; i < len(value); {
+		_, size := utf8.DecodeRuneInString(value[i:])
+		if marks[i] != open {
+			b.WriteString(html.EscapeString(value[from:i]))
+			from = i
+			if open {
+				b.WriteString(highlightClose)
+			} else {
+				b.WriteString(highlightOpen)
+			}
+			open = marks[i]
+		}
+		i += size
+	}
+	b.WriteString(html.EscapeString(value[from:]))
+	if open {
+		b.WriteString(highlightClose)
+	}
+	return b.String(), true
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
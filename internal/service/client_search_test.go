@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"vector/internal/models"
+	"vector/internal/pkg/utils"
+)
+
+func TestHighlightWords(t *testing.T) {
+	cases := []struct {
+		value string
+		words []string
+		want  string
+		ok    bool
+	}{
+		{"Иванов", []string{"иван"}, "<em>Иван</em>ов", true},
+		{"ivanov", []string{"iv", "an"}, "<em>ivan</em>ov", true},
+		{"petrov", []string{"ov"}, "petr<em>ov</em>", true},
+		{"petrov", []string{"xyz"}, "petrov", false},
+		{"", []string{"a"}, "", false},
+		// значения экранируются до вставки меток
+		{`<script>alert(1)</script>`, []string{"alert"}, "&lt;script&gt;<em>alert</em>(1)&lt;/script&gt;", true},
+		{`a&b"c`, []string{"b"}, "a&amp;<em>b</em>&#34;c", true},
+		{"o'<b>nil", []string{"<b>"}, "o&#39;<em>&lt;b&gt;</em>nil", true},
+	}
+	for _, tc := range cases {
+		got, ok := highlightWords(tc.value, tc.words)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("highlightWords(%q, %q) = %q, %v; want %q, %v", tc.value, tc.words, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestHighlightSearchResultEscapesValues(t *testing.T) {
+	r := models.ClientSearchResult{ClientListItem: models.ClientListItem{ContactEmail: `"><img src=x>@example.com`}}
+	highlightSearchResult(&r, utils.SearchQuery{Raw: `"><img src=x>@example.com`, Types: []string{utils.SearchTypeEmail}})
+
+	want := "<em>&#34;&gt;&lt;img src=x&gt;@example.com</em>"
+	if got := r.Highlights["contact_email"]; got != want {
+		t.Fatalf("highlight = %q, want %q", got, want)
+	}
+}
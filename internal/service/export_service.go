@@ -0,0 +1,347 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/export"
+	"vector/internal/pkg/masking"
+	"vector/internal/repository"
+
+	"gorm.io/datatypes"
+)
+
+// exportBatchSize размер порции при чтении списка для выгрузки (keyset, без OFFSET)
+const exportBatchSize = 500
+
+// exportColumn колонка выгрузки: ключ для ?columns= и значение из строки списка
+type exportColumn[T any] struct {
+	Key   string
+	Value func(*T) string
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatOptString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatOptInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+var clientExportColumns = []exportColumn[models.ClientWithSP]{
+	{"id", func(c *models.ClientWithSP) string { return strconv.Itoa(c.ClientID) }},
+	{"surname", func(c *models.ClientWithSP) string { return c.Surname }},
+	{"name", func(c *models.ClientWithSP) string { return c.Name }},
+	{"patronymic", func(c *models.ClientWithSP) string { return c.Patronymic }},
+	{"birthday", func(c *models.ClientWithSP) string { return c.Birthday }},
+	{"birth_place", func(c *models.ClientWithSP) string { return c.BirthPlace }},
+	{"contact_email", func(c *models.ClientWithSP) string { return c.ContactEmail }},
+	{"main_phone", func(c *models.ClientWithSP) string { return c.MainPhone }},
+	{"inn", func(c *models.ClientWithSP) string { return c.Inn }},
+	{"snils", func(c *models.ClientWithSP) string { return c.Snils }},
+	{"pass_series", func(c *models.ClientWithSP) string { return c.PassSeries }},
+	{"pass_number", func(c *models.ClientWithSP) string { return c.PassNumber }},
+	{"pass_issue_date", func(c *models.ClientWithSP) string { return c.PassIssueDate }},
+	{"pass_issuer", func(c *models.ClientWithSP) string { return c.PassIssuer }},
+	{"pass_issuer_code", func(c *models.ClientWithSP) string { return c.PassIssuerCode }},
+	{"created_lk_at", func(c *models.ClientWithSP) string { return c.CreatedLKAt }},
+	{"updated_lk_at", func(c *models.ClientWithSP) string { return c.UpdatedLKAt }},
+	{"risk_level", func(c *models.ClientWithSP) string { return c.RiskLevel }},
+	{"external_risk_level", func(c *models.ClientWithSP) string { return c.ExternalRiskLevel }},
+	{"needs_second_part", func(c *models.ClientWithSP) string { return strconv.FormatBool(c.NeedsSecondPart) }},
+	{"needs_second_part_reasons", func(c *models.ClientWithSP) string { return strings.Join(c.NeedsSecondPartReasons, ",") }},
+	{"second_part_created", func(c *models.ClientWithSP) string { return strconv.FormatBool(c.SecondPartCreated) }},
+	{"version", func(c *models.ClientWithSP) string { return strconv.Itoa(c.ClientVersion) }},
+	{"sp_status", func(c *models.ClientWithSP) string { return formatOptString(c.SpStatus) }},
+	{"sp_due_at", func(c *models.ClientWithSP) string { return formatTime(c.SpDueAt) }},
+}
+
+var contractExportColumns = []exportColumn[models.Contract]{
+	{"id", func(c *models.Contract) string { return strconv.Itoa(c.ExternalID) }},
+	{"user_id", func(c *models.Contract) string { return strconv.Itoa(c.UserID) }},
+	{"user_login", func(c *models.Contract) string { return formatOptString(c.UserLogin) }},
+	{"inner_code", func(c *models.Contract) string { return c.InnerCode }},
+	{"kind", func(c *models.Contract) string { return c.Kind }},
+	{"status", func(c *models.Contract) string { return c.Status }},
+	{"rialto_code", func(c *models.Contract) string { return formatOptString(c.RialtoCode) }},
+	{"is_personal_invest_account", func(c *models.Contract) string { return strconv.FormatBool(c.IsPersonalInvestAccount) }},
+	{"signed_at", func(c *models.Contract) string { return formatTime(c.SignedAt) }},
+	{"closed_at", func(c *models.Contract) string { return formatTime(c.ClosedAt) }},
+	{"created_at", func(c *models.Contract) string { return formatTime(&c.CreatedAt) }},
+	{"updated_at", func(c *models.Contract) string { return formatTime(&c.UpdatedAt) }},
+	{"tariff_id", func(c *models.Contract) string { return formatOptInt(c.TariffID) }},
+	{"tariff_name", func(c *models.Contract) string { return formatOptString(c.TariffName) }},
+	{"strategy_id", func(c *models.Contract) string { return formatOptInt(c.StrategyID) }},
+	{"strategy_name", func(c *models.Contract) string { return formatOptString(c.StrategyName) }},
+	{"comment", func(c *models.Contract) string { return formatOptString(c.Comment) }},
+}
+
+var auditExportColumns = []exportColumn[models.AuditEvent]{
+	{"id", func(e *models.AuditEvent) string { return strconv.FormatUint(e.ID, 10) }},
+	{"occurred_at", func(e *models.AuditEvent) string { return e.OccurredAt.UTC().Format(time.RFC3339Nano) }},
+	{"actor_id", func(e *models.AuditEvent) string {
+		if e.ActorID == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*e.ActorID), 10)
+	}},
+	{"actor_email", func(e *models.AuditEvent) string { return e.ActorEmail }},
+	{"actor_role", func(e *models.AuditEvent) string { return e.ActorRole }},
+	{"action", func(e *models.AuditEvent) string { return e.Action }},
+	{"entity_type", func(e *models.AuditEvent) string { return e.EntityType }},
+	{"entity_id", func(e *models.AuditEvent) string { return e.EntityID }},
+	{"request_id", func(e *models.AuditEvent) string { return e.RequestID }},
+	{"ip", func(e *models.AuditEvent) string { return e.IP }},
+	{"method", func(e *models.AuditEvent) string { return e.Method }},
+	{"path", func(e *models.AuditEvent) string { return e.Path }},
+	{"status", func(e *models.AuditEvent) string { return strconv.Itoa(e.Status) }},
+	{"before", func(e *models.AuditEvent) string { return string(e.Before) }},
+	{"after", func(e *models.AuditEvent) string { return string(e.After) }},
+	{"metadata", func(e *models.AuditEvent) string { return string(e.Metadata) }},
+	{"prev_hash", func(e *models.AuditEvent) string { return e.PrevHash }},
+	{"hash", func(e *models.AuditEvent) string { return e.Hash }},
+}
+
+// ClientExportColumns допустимые значения columns= для выгрузки клиентов
+func ClientExportColumns() []string { return columnKeys(clientExportColumns) }
+
+// ContractExportColumns допустимые значения columns= для выгрузки контрактов
+func ContractExportColumns() []string { return columnKeys(contractExportColumns) }
+
+// AuditExportColumns допустимые значения columns= для выгрузки журнала аудита
+func AuditExportColumns() []string { return columnKeys(auditExportColumns) }
+
+func columnKeys[T any](all []exportColumn[T]) []string {
+	keys := make([]string, len(all))
+	for i, c := range all {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// selectColumns возвращает колонки в запрошенном порядке; пустой список — все колонки
+func selectColumns[T any](all []exportColumn[T], requested []string) ([]exportColumn[T], error) {
+	if len(requested) == 0 {
+		return all, nil
+	}
+	byKey := make(map[string]exportColumn[T], len(all))
+	for _, c := range all {
+		byKey[c.Key] = c
+	}
+	out := make([]exportColumn[T], 0, len(requested))
+	for _, key := range requested {
+		c, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("unsupported export column %q (allowed: %s)", key, strings.Join(columnKeys(all), ", "))
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+type ExportRequest struct {
+	Format  string
+	Columns []string
+	Filters string // исходная query string, сохраняется в журнал
+	User    models.AppUser
+	Actor   models.AuditActor
+	Policy  masking.Policy // политика персональных данных пользователя
+}
+
+// Export подготовленная выгрузка; Write вызывается уже при отдаче тела ответа
+type Export struct {
+	Format   string
+	Filename string
+	write    func(w io.Writer) (int64, error)
+	logID    uint
+	logRepo  repository.ExportLogRepository
+}
+
+// Write пишет файл в w и фиксирует результат в журнале выгрузок
+func (e *Export) Write(w io.Writer) error {
+	rows, err := e.write(w)
+
+	status, errMsg := models.ExportStatusCompleted, ""
+	if err != nil {
+		status, errMsg = models.ExportStatusFailed, err.Error()
+	}
+	if logErr := e.logRepo.Finish(e.logID, status, rows, errMsg); logErr != nil && err == nil {
+		err = logErr
+	}
+	return err
+}
+
+type ExportService struct {
+	clientRepo       repository.AppClientRepository
+	syncContractRepo repository.SyncContractRepository
+	exportLogRepo    repository.ExportLogRepository
+	auditRepo        repository.AuditRepository
+	audit            *AuditService
+}
+
+func NewExportService(
+	clientRepo repository.AppClientRepository,
+	syncContractRepo repository.SyncContractRepository,
+	exportLogRepo repository.ExportLogRepository,
+	auditRepo repository.AuditRepository,
+	audit *AuditService,
+) *ExportService {
+	return &ExportService{
+		clientRepo:       clientRepo,
+		syncContractRepo: syncContractRepo,
+		exportLogRepo:    exportLogRepo,
+		auditRepo:        auditRepo,
+		audit:            audit,
+	}
+}
+
+func (s *ExportService) PrepareClientExport(req ExportRequest, filter models.ClientListFilter) (*Export, error) {
+	cols, err := selectColumns(clientExportColumns, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	fetch := func(cursor string) ([]models.ClientWithSP, models.PageInfo, error) {
+		return s.clientRepo.ListClientsWithSP(models.PageRequest{
+			PerPage:   exportBatchSize,
+			Cursor:    cursor,
+			TotalMode: models.TotalModeNone,
+		}, filter)
+	}
+	return prepareExport(s.exportLogRepo, s.audit, "clients", req, cols, fetch)
+}
+
+func (s *ExportService) PrepareContractExport(req ExportRequest, filter models.ContractListFilter) (*Export, error) {
+	cols, err := selectColumns(contractExportColumns, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	fetch := func(cursor string) ([]models.Contract, models.PageInfo, error) {
+		return s.syncContractRepo.ListContracts(models.PageRequest{
+			PerPage:   exportBatchSize,
+			Cursor:    cursor,
+			TotalMode: models.TotalModeNone,
+		}, filter)
+	}
+	return prepareExport(s.exportLogRepo, s.audit, "contracts", req, cols, fetch)
+}
+
+func (s *ExportService) PrepareAuditExport(req ExportRequest, filter models.AuditFilter) (*Export, error) {
+	cols, err := selectColumns(auditExportColumns, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	fetch := func(cursor string) ([]models.AuditEvent, models.PageInfo, error) {
+		return s.auditRepo.List(models.PageRequest{
+			PerPage:   exportBatchSize,
+			Cursor:    cursor,
+			TotalMode: models.TotalModeNone,
+		}, filter)
+	}
+	return prepareExport(s.exportLogRepo, s.audit, "audit", req, cols, fetch)
+}
+
+func prepareExport[T any](
+	logRepo repository.ExportLogRepository,
+	audit *AuditService,
+	entity string,
+	req ExportRequest,
+	cols []exportColumn[T],
+	fetch func(cursor string) ([]T, models.PageInfo, error),
+) (*Export, error) {
+	if req.Format != export.FormatCSV && req.Format != export.FormatXLSX {
+		return nil, fmt.Errorf("unsupported export format %q (allowed: csv, xlsx)", req.Format)
+	}
+
+	header := columnKeys(cols)
+	// в выгрузке действует та же политика персональных данных, что и в API
+	policy := req.Policy
+	colsJSON, _ := json.Marshal(header)
+
+	entry, err := logRepo.Create(models.ExportLog{
+		UserID:    req.User.ID,
+		UserEmail: req.User.Email,
+		Entity:    entity,
+		Format:    req.Format,
+		Columns:   datatypes.JSON(colsJSON),
+		Filters:   req.Filters,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write export log: %w", err)
+	}
+	if err := audit.Record(req.Actor, AuditEntry{
+		Action:     models.AuditActionExportStarted,
+		EntityType: models.AuditEntityExport,
+		EntityID:   strconv.FormatUint(uint64(entry.ID), 10),
+		Metadata: map[string]any{
+			"entity":  entity,
+			"format":  req.Format,
+			"columns": header,
+			"filters": req.Filters,
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write audit event: %w", err)
+	}
+
+	write := func(w io.Writer) (int64, error) {
+		rw, err := export.NewRowWriter(req.Format, w, entity, header)
+		if err != nil {
+			return 0, err
+		}
+
+		var (
+			rows   int64
+			cursor string
+			values = make([]string, len(cols))
+		)
+		for {
+			items, info, err := fetch(cursor)
+			if err != nil {
+				return rows, err
+			}
+			for i := range items {
+				for j, col := range cols {
+					values[j] = policy.Apply(col.Key, col.Value(&items[i]))
+				}
+				if err := rw.WriteRow(values); err != nil {
+					return rows, err
+				}
+				rows++
+			}
+			if !info.HasMore {
+				break
+			}
+			cursor = info.NextCursor
+		}
+
+		return rows, rw.Close()
+	}
+
+	return &Export{
+		Format:   req.Format,
+		Filename: fmt.Sprintf("%s_%s.%s", entity, time.Now().UTC().Format("20060102_150405"), req.Format),
+		write:    write,
+		logID:    entry.ID,
+		logRepo:  logRepo,
+	}, nil
+}
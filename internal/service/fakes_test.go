@@ -0,0 +1,620 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/password"
+	"vector/internal/pkg/signing"
+	"vector/internal/repository"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Репозитории в памяти для тестов сервисов: хранят ровно то, что нужно проверяемым сценариям.
+
+type fakeUserRepo struct {
+	mu     sync.Mutex
+	nextID uint
+	users  map[uint]*models.AppUser
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{users: map[uint]*models.AppUser{}}
+}
+
+func (r *fakeUserRepo) add(u models.AppUser) models.AppUser {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	u.ID = r.nextID
+	r.users[u.ID] = &u
+	return u
+}
+
+func (r *fakeUserRepo) get(id uint) models.AppUser {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.users[id]
+}
+
+func (r *fakeUserRepo) find(match func(u *models.AppUser) bool) (models.AppUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if match(u) {
+			return *u, nil
+		}
+	}
+	return models.AppUser{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) update(id uint, fn func(u *models.AppUser)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	fn(u)
+	return nil
+}
+
+func (r *fakeUserRepo) GetByID(id uint) (models.AppUser, error) {
+	return r.find(func(u *models.AppUser) bool { return u.ID == id })
+}
+
+func (r *fakeUserRepo) GetByEmail(email string) (models.AppUser, error) {
+	return r.find(func(u *models.AppUser) bool { return u.Email == email })
+}
+
+func (r *fakeUserRepo) GetByExternalSubject(subject string) (models.AppUser, error) {
+	return r.find(func(u *models.AppUser) bool { return u.ExternalSubject != nil && *u.ExternalSubject == subject })
+}
+
+func (r *fakeUserRepo) Create(user models.AppUser) (models.AppUser, error) {
+	if _, err := r.GetByEmail(user.Email); err == nil {
+		return models.AppUser{}, gorm.ErrDuplicatedKey
+	}
+	return r.add(user), nil
+}
+
+func (r *fakeUserRepo) LinkExternal(id uint, subject, firstName, lastName string) error {
+	return r.update(id, func(u *models.AppUser) {
+		u.AuthProvider, u.ExternalSubject, u.FirstName, u.LastName = models.AuthProviderOIDC, &subject, firstName, lastName
+	})
+}
+
+func (r *fakeUserRepo) TouchExternal(id uint, sealedRefreshToken string) error {
+	return r.update(id, func(u *models.AppUser) {
+		now := time.Now().UTC()
+		u.ExternalCheckedAt = &now
+		if sealedRefreshToken != "" {
+			u.ExternalRefreshToken = sealedRefreshToken
+		}
+	})
+}
+
+func (r *fakeUserRepo) List(filter models.UserListFilter) ([]models.AppUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []models.AppUser
+	for _, u := range r.users {
+		if filter.IsActive != nil && u.IsActive != *filter.IsActive {
+			continue
+		}
+		if filter.AuthProvider != nil && u.AuthProvider != *filter.AuthProvider {
+			continue
+		}
+		out = append(out, *u)
+	}
+	return out, nil
+}
+
+func (r *fakeUserRepo) UpdateRole(id uint, role string) (models.AppUser, error) {
+	if err := r.update(id, func(u *models.AppUser) { u.Role = role }); err != nil {
+		return models.AppUser{}, err
+	}
+	return r.GetByID(id)
+}
+
+func (r *fakeUserRepo) UpdatePassword(id uint, passwordHash string, mustChange bool) error {
+	return r.update(id, func(u *models.AppUser) { u.PasswordHash, u.MustChangePassword = passwordHash, mustChange })
+}
+
+func (r *fakeUserRepo) UpgradePasswordHash(id uint, passwordHash string) error {
+	return r.update(id, func(u *models.AppUser) { u.PasswordHash = passwordHash })
+}
+
+func (r *fakeUserRepo) SetMustChangePassword(id uint, mustChange bool) error {
+	return r.update(id, func(u *models.AppUser) { u.MustChangePassword = mustChange })
+}
+
+func (r *fakeUserRepo) RecentPasswordHashes(id uint, limit int) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeUserRepo) SetActive(id uint, isActive bool) error {
+	return r.update(id, func(u *models.AppUser) { u.IsActive = isActive })
+}
+
+func (r *fakeUserRepo) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepo) Seed(email, password string) (bool, error) {
+	return false, nil
+}
+
+type fakeSessionRepo struct {
+	mu       sync.Mutex
+	sessions []models.AuthSession
+}
+
+func (r *fakeSessionRepo) Create(session models.AuthSession) (models.AuthSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session.ID = uint64(len(r.sessions) + 1)
+	r.sessions = append(r.sessions, session)
+	return session, nil
+}
+
+func (r *fakeSessionRepo) GetByTokenHash(tokenHash string) (models.AuthSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.TokenHash == tokenHash {
+			return s, nil
+		}
+	}
+	return models.AuthSession{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeSessionRepo) Rotate(current models.AuthSession, next models.AuthSession) (models.AuthSession, error) {
+	return r.Create(next)
+}
+
+func (r *fakeSessionRepo) RevokeFamily(familyID, reason string) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeSessionRepo) RevokeUser(userID uint, exceptFamilyID, reason string) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeSessionRepo) IsActive(familyID string) (bool, error) {
+	return true, nil
+}
+
+type fakeThrottleRepo struct{}
+
+func (fakeThrottleRepo) Get(key string) (*models.AuthThrottle, error) { return nil, nil }
+func (fakeThrottleRepo) RegisterFailure(key string, window time.Duration) (models.AuthThrottle, error) {
+	return models.AuthThrottle{}, nil
+}
+func (fakeThrottleRepo) Lock(key string, until time.Time) error { return nil }
+func (fakeThrottleRepo) Clear(key string) (int64, error)        { return 0, nil }
+
+// fakeMFARepo хранит последний принятый шаг TOTP, как mfa_last_step,
+// и использованные jti токенов второго шага, как mfa_used_tokens
+type fakeMFARepo struct {
+	mu       sync.Mutex
+	lastStep map[uint]int64
+	used     map[string]bool
+}
+
+func (r *fakeMFARepo) SetPendingSecret(userID uint, sealed string) error { return nil }
+
+func (r *fakeMFARepo) Enable(userID uint, step int64, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastStep[userID] = step
+	return nil
+}
+
+func (r *fakeMFARepo) Disable(userID uint) error { return nil }
+
+func (r *fakeMFARepo) AcceptStep(userID uint, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if step <= r.lastStep[userID] {
+		return false, nil
+	}
+	r.lastStep[userID] = step
+	return true, nil
+}
+
+func (r *fakeMFARepo) ReplaceRecoveryCodes(userID uint, codeHashes []string) error { return nil }
+func (r *fakeMFARepo) UseRecoveryCode(userID uint, codeHash string) (bool, error)  { return false, nil }
+func (r *fakeMFARepo) CountRecoveryCodes(userID uint) (int64, error)               { return 0, nil }
+
+func (r *fakeMFARepo) UseToken(jti string, userID uint, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used == nil {
+		r.used = map[string]bool{}
+	}
+	if r.used[jti] {
+		return false, nil
+	}
+	r.used[jti] = true
+	return true, nil
+}
+
+type fakeAPIKeyRepo struct {
+	mu      sync.Mutex
+	keys    []models.APIKey
+	windows map[uint]*models.APIKeyRateWindow
+	touched int
+}
+
+func (r *fakeAPIKeyRepo) Create(key models.APIKey) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = uint(len(r.keys) + 1)
+	key.CreatedAt = time.Now().UTC()
+	r.keys = append(r.keys, key)
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepo) GetByPrefix(prefix string) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			return k, nil
+		}
+	}
+	return models.APIKey{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) GetByID(id uint) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.keys) {
+		return models.APIKey{}, gorm.ErrRecordNotFound
+	}
+	return r.keys[id-1], nil
+}
+
+func (r *fakeAPIKeyRepo) List(includeRevoked bool) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.APIKey(nil), r.keys...), nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(id uint, revokedBy *uint) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.keys) {
+		return models.APIKey{}, gorm.ErrRecordNotFound
+	}
+	now := time.Now().UTC()
+	r.keys[id-1].RevokedAt, r.keys[id-1].RevokedBy = &now, revokedBy
+	return r.keys[id-1], nil
+}
+
+func (r *fakeAPIKeyRepo) HitRateWindow(id uint, period time.Duration) (models.APIKeyRateWindow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	if r.windows == nil {
+		r.windows = map[uint]*models.APIKeyRateWindow{}
+	}
+	w, ok := r.windows[id]
+	if !ok || !w.WindowStart.After(now.Add(-period)) {
+		w = &models.APIKeyRateWindow{APIKeyID: id, WindowStart: now}
+		r.windows[id] = w
+	}
+	w.Requests++
+	return *w, nil
+}
+
+func (r *fakeAPIKeyRepo) Touch(id uint, ip string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touched++
+	return nil
+}
+
+// fakeRoleRepo системные роли с правами по умолчанию
+type fakeRoleRepo struct {
+	perms map[string][]string
+}
+
+func newFakeRoleRepo() *fakeRoleRepo {
+	return &fakeRoleRepo{perms: map[string][]string{
+		models.RoleAdministrator: nil,
+		models.RolePodft: {
+			models.PermClientsRead, models.PermClientsExport, models.PermContractsRead, models.PermAuditRead,
+		},
+		models.RoleClientManagement: {models.PermClientsRead, models.PermContractsRead},
+	}}
+}
+
+func (r *fakeRoleRepo) List() ([]models.Role, error) {
+	out := make([]models.Role, 0, len(r.perms))
+	for name := range r.perms {
+		out = append(out, models.Role{Name: name, IsSystem: true})
+	}
+	return out, nil
+}
+
+func (r *fakeRoleRepo) Get(name string) (models.Role, error) {
+	if _, ok := r.perms[name]; !ok {
+		return models.Role{}, gorm.ErrRecordNotFound
+	}
+	return models.Role{Name: name, IsSystem: true}, nil
+}
+
+func (r *fakeRoleRepo) ListPermissions() (map[string][]string, error) { return r.perms, nil }
+func (r *fakeRoleRepo) CountUsers() (map[string]int64, error)         { return nil, nil }
+func (r *fakeRoleRepo) Create(role models.Role, permissions []string) (models.Role, error) {
+	r.perms[role.Name] = permissions
+	return role, nil
+}
+func (r *fakeRoleRepo) Update(role models.Role, permissions []string) (models.Role, error) {
+	r.perms[role.Name] = permissions
+	return role, nil
+}
+func (r *fakeRoleRepo) Delete(name string) error {
+	delete(r.perms, name)
+	return nil
+}
+
+type fakeAuditRepo struct {
+	mu     sync.Mutex
+	events []models.AuditEvent
+}
+
+func (r *fakeAuditRepo) Append(event models.AuditEvent) (models.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.ID = uint64(len(r.events) + 1)
+	r.events = append(r.events, event)
+	return event, nil
+}
+
+func (r *fakeAuditRepo) List(page models.PageRequest, filter models.AuditFilter) ([]models.AuditEvent, models.PageInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.AuditEvent(nil), r.events...), models.PageInfo{}, nil
+}
+
+// actions действия записанных событий аудита по порядку
+func (r *fakeAuditRepo) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]string, len(r.events))
+	for i, e := range r.events {
+		out[i] = e.Action
+	}
+	return out
+}
+
+type fakeQuestionnaireRepo struct {
+	mu       sync.Mutex
+	versions []models.QuestionnaireSchema
+}
+
+func (r *fakeQuestionnaireRepo) Current() (models.QuestionnaireSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.versions) == 0 {
+		return models.QuestionnaireSchema{}, models.ErrNoQuestionnaireSchema
+	}
+	return r.versions[len(r.versions)-1], nil
+}
+
+func (r *fakeQuestionnaireRepo) Get(version int) (models.QuestionnaireSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version < 1 || version > len(r.versions) {
+		return models.QuestionnaireSchema{}, gorm.ErrRecordNotFound
+	}
+	return r.versions[version-1], nil
+}
+
+func (r *fakeQuestionnaireRepo) List() ([]models.QuestionnaireSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]models.QuestionnaireSchema, 0, len(r.versions))
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		out = append(out, r.versions[i])
+	}
+	return out, nil
+}
+
+func (r *fakeQuestionnaireRepo) Create(s models.QuestionnaireSchema) (models.QuestionnaireSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.Version = len(r.versions) + 1
+	s.CreatedAt = time.Now()
+	r.versions = append(r.versions, s)
+	return s, nil
+}
+
+// fakeClientRepo текущая версия второй части одного клиента. Методы, не нужные тестам,
+// не реализованы: вызов паникует через встроенный nil-интерфейс.
+type fakeClientRepo struct {
+	repository.AppClientRepository
+
+	mu        sync.Mutex
+	sp        models.SecondPartVersion
+	changes   []models.SecondPartChange
+	submitted *int // schema_version последней отправки
+	// beforeSubmit вызывается перед отправкой: конкурирующая правка между проверкой и записью
+	beforeSubmit func()
+}
+
+func (r *fakeClientRepo) GetSecondPartCurrent(clientID int) (models.SecondPartVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sp.ClientID != clientID {
+		return models.SecondPartVersion{}, gorm.ErrRecordNotFound
+	}
+	return r.sp, nil
+}
+
+func (r *fakeClientRepo) UpdateSecondPartDraftData(clientID, version, revision int, data datatypes.JSON, schemaVersion *int, changes []models.SecondPartChange, actorID *int) (models.SecondPartVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sp.ClientID != clientID || r.sp.Version != version || r.sp.Revision != revision {
+		return r.sp, models.ErrSecondPartVersionConflict
+	}
+	r.sp.Revision++
+	r.sp.Data = data
+	r.sp.SchemaVersion = schemaVersion
+	r.sp.UpdatedByUserID = actorID
+	for _, c := range changes {
+		c.ClientID, c.Version, c.Revision = clientID, version, r.sp.Revision
+		r.changes = append(r.changes, c)
+	}
+	return r.sp, nil
+}
+
+func (r *fakeClientRepo) SubmitSecondPart(clientID int, userID *int, spVersion, revision, schemaVersion int) (models.SecondPartVersion, error) {
+	if r.beforeSubmit != nil {
+		r.beforeSubmit()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sp.ClientID != clientID || r.sp.Version != spVersion || r.sp.Revision != revision {
+		return r.sp, models.ErrSecondPartVersionConflict
+	}
+	r.sp.Status = "submitted"
+	r.sp.SchemaVersion = &schemaVersion
+	r.submitted = &schemaVersion
+	return r.sp, nil
+}
+
+// testAuth AuthService на репозиториях в памяти
+type testAuth struct {
+	svc      *AuthService
+	users    *fakeUserRepo
+	apiKeys  *fakeAPIKeyRepo
+	mfa      *fakeMFARepo
+	roles    *fakeRoleRepo
+	audit    *fakeAuditRepo
+	auditSvc *AuditService
+}
+
+func newTestAuth(t *testing.T) *testAuth {
+	t.Helper()
+	keys, err := signing.Ephemeral()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ta := &testAuth{
+		users:   newFakeUserRepo(),
+		apiKeys: &fakeAPIKeyRepo{},
+		mfa:     &fakeMFARepo{lastStep: map[uint]int64{}},
+		roles:   newFakeRoleRepo(),
+		audit:   &fakeAuditRepo{},
+	}
+	ta.auditSvc = NewAuditService(ta.audit)
+	roleService := NewRoleService(ta.roles, ta.auditSvc)
+	ta.svc = NewAuthService(ta.users, &fakeSessionRepo{}, fakeThrottleRepo{}, ta.mfa, ta.apiKeys,
+		models.JWTConfig{Keys: keys, TokenDuration: 15 * time.Minute, RefreshTokenDuration: time.Hour},
+		models.MFAConfig{Issuer: "test", EncryptionKey: make([]byte, 32), TokenDuration: 5 * time.Minute},
+		models.APIKeyConfig{DefaultRateLimit: 60, DefaultTTL: 24 * time.Hour},
+		password.Policy{},
+		roleService, ta.auditSvc)
+	return ta
+}
+
+// fakeJobLocker advisory-блокировки, общие для всех экземпляров JobRunner в тесте
+type fakeJobLocker struct {
+	mu     sync.Mutex
+	locked map[string]bool
+}
+
+func (l *fakeJobLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locked == nil {
+		l.locked = map[string]bool{}
+	}
+	if l.locked[name] {
+		return nil, false, nil
+	}
+	l.locked[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.locked, name)
+	}, true, nil
+}
+
+// fakeJobRunRepo журнал запусков с уникальностью слота, как у idx_job_runs_slot
+type fakeJobRunRepo struct {
+	mu   sync.Mutex
+	runs []models.JobRun
+}
+
+func (r *fakeJobRunRepo) Create(run models.JobRun) (models.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	if run.Status == "" {
+		run.Status = models.JobStatusRunning
+	}
+	if run.ScheduledAt != nil {
+		for _, other := range r.runs {
+			if other.Job == run.Job && other.ScheduledAt != nil && other.ScheduledAt.Equal(*run.ScheduledAt) &&
+				(other.Status == models.JobStatusRunning || other.Status == models.JobStatusSucceeded) {
+				return run, models.ErrJobSlotTaken
+			}
+		}
+	}
+	run.ID = uint(len(r.runs) + 1)
+	r.runs = append(r.runs, run)
+	return run, nil
+}
+
+func (r *fakeJobRunRepo) Finish(id uint, status string, result datatypes.JSON, errMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	r.runs[id-1].Status, r.runs[id-1].Result, r.runs[id-1].Error, r.runs[id-1].FinishedAt = status, result, errMsg, &now
+	return nil
+}
+
+func (r *fakeJobRunRepo) LatestRunning(job string) (models.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.runs) - 1; i >= 0; i-- {
+		if r.runs[i].Job == job && r.runs[i].Status == models.JobStatusRunning {
+			return r.runs[i], nil
+		}
+	}
+	return models.JobRun{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeJobRunRepo) AbandonRunning(job string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for i := range r.runs {
+		if r.runs[i].Job == job && r.runs[i].Status == models.JobStatusRunning {
+			r.runs[i].Status = models.JobStatusFailed
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeJobRunRepo) List(page models.PageRequest, filter models.JobRunFilter) ([]models.JobRun, models.PageInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.JobRun(nil), r.runs...), models.PageInfo{}, nil
+}
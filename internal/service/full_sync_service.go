@@ -2,31 +2,43 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
+	"vector/internal/models"
 	"vector/internal/repository"
 )
 
+// ErrSyncInProgress полная синхронизация уже выполняется (в этом или другом экземпляре сервиса)
+var ErrSyncInProgress = errors.New("полная синхронизация уже выполняется")
+
 type FullSyncService struct {
 	applyService    *ApplyService
 	contractService *ContractService
 	externalAPI     repository.ExternalAPIClient
+	jobs            *JobRunner
 }
 
 func NewFullSyncService(
 	applyService *ApplyService,
 	contractService *ContractService,
 	externalAPI repository.ExternalAPIClient,
+	jobs *JobRunner,
 ) *FullSyncService {
 	return &FullSyncService{
 		applyService:    applyService,
 		contractService: contractService,
 		externalAPI:     externalAPI,
+		jobs:            jobs,
 	}
 }
 
 type FullSyncRequest struct {
 	PerPage       int
 	SyncContracts bool
+	TriggeredBy   string // cron или инициатор HTTP-запроса; пишется в журнал запусков
+	// ScheduledAt слот расписания (CronSlot) для запуска по cron: слот выполняется один раз на все экземпляры
+	ScheduledAt *time.Time
 }
 
 type FullSyncResponse struct {
@@ -54,11 +66,34 @@ type FullSyncResponse struct {
 	Unchanged int `json:"unchanged"`
 }
 
+// SyncFull выполняет полную синхронизацию как задачу sync.full: одновременно — не более одной
+// на все экземпляры (HTTP-запуски и cron), каждый запуск пишется в core.job_runs
 func (s *FullSyncService) SyncFull(ctx context.Context, req FullSyncRequest) (*FullSyncResponse, error) {
 	if req.PerPage <= 0 {
 		req.PerPage = 100
 	}
 
+	var stats *FullSyncResponse
+	fullSync := func(ctx context.Context) (any, error) {
+		var err error
+		if stats, err = s.syncFull(ctx, req); err != nil {
+			return nil, err
+		}
+		return stats, nil
+	}
+	var err error
+	if req.ScheduledAt != nil {
+		_, err = s.jobs.RunScheduled(ctx, models.JobSyncFull, *req.ScheduledAt, fullSync)
+	} else {
+		_, err = s.jobs.Run(ctx, models.JobSyncFull, req.TriggeredBy, fullSync)
+	}
+	if errors.Is(err, ErrJobSkipped) {
+		return nil, ErrSyncInProgress
+	}
+	return stats, err
+}
+
+func (s *FullSyncService) syncFull(ctx context.Context, req FullSyncRequest) (*FullSyncResponse, error) {
 	stats := &FullSyncResponse{Success: true}
 
 	log.Println("[full-sync] Starting users synchronization...")
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/repository"
+)
+
+var (
+	// ErrJobSkipped задачу уже выполняет другой экземпляр (или другой запуск этого экземпляра)
+	ErrJobSkipped = errors.New("задача уже выполняется")
+	// ErrJobSlotDone запуск по расписанию за этот слот уже выполнил другой экземпляр
+	ErrJobSlotDone = errors.New("запуск по расписанию уже выполнен")
+)
+
+// CronSlot слот расписания для запуска, сработавшего в now: выражения cron задаются с точностью до минуты,
+// поэтому все реплики, сработавшие по одному расписанию, получают один и тот же слот
+func CronSlot(now time.Time) time.Time {
+	return now.UTC().Truncate(time.Minute)
+}
+
+// JobRunner запускает фоновые задачи под advisory-блокировкой Postgres, чтобы при нескольких
+// репликах задача выполнялась один раз, и пишет каждый запуск (в т.ч. пропущенный) в core.job_runs
+type JobRunner struct {
+	locker   repository.JobLocker
+	runs     repository.JobRunRepository
+	instance string
+}
+
+func NewJobRunner(locker repository.JobLocker, runs repository.JobRunRepository, instance string) *JobRunner {
+	return &JobRunner{locker: locker, runs: runs, instance: instance}
+}
+
+// Run выполняет fn, если блокировка job свободна; иначе записывает пропуск и возвращает ErrJobSkipped.
+// Результат fn сохраняется в журнал как JSON. Ошибка записи в журнал задачу не останавливает.
+// Возвращает запись журнала о запуске.
+func (r *JobRunner) Run(ctx context.Context, job, triggeredBy string, fn func(ctx context.Context) (any, error)) (models.JobRun, error) {
+	return r.run(ctx, job, triggeredBy, nil, fn)
+}
+
+// RunScheduled выполняет fn как запуск по расписанию за слот scheduledAt (см. CronSlot). Кроме пересечения
+// запусков исключает повтор слота: если за него уже есть успешный запуск (например, реплика с отстающими
+// часами сработала после того, как другая закончила), записывает пропуск и возвращает ErrJobSlotDone.
+// После неудачного запуска слот может выполнить другая реплика.
+func (r *JobRunner) RunScheduled(ctx context.Context, job string, scheduledAt time.Time, fn func(ctx context.Context) (any, error)) (models.JobRun, error) {
+	return r.run(ctx, job, models.JobTriggerCron, &scheduledAt, fn)
+}
+
+func (r *JobRunner) run(ctx context.Context, job, triggeredBy string, scheduledAt *time.Time, fn func(ctx context.Context) (any, error)) (models.JobRun, error) {
+	unlock, ok, err := r.locker.TryLock(ctx, job)
+	if err != nil {
+		return models.JobRun{}, fmt.Errorf("блокировка задачи %s: %w", job, err)
+	}
+	if !ok {
+		reason := "блокировка задачи занята"
+		if holder, err := r.runs.LatestRunning(job); err == nil {
+			reason = fmt.Sprintf("выполняется на %s с %s", holder.Instance, holder.StartedAt.UTC().Format(time.RFC3339))
+		}
+		return r.skipped(job, triggeredBy, scheduledAt, reason), ErrJobSkipped
+	}
+	defer unlock()
+
+	if n, err := r.runs.AbandonRunning(job); err != nil {
+		log.Printf("[jobs] %s: failed to close abandoned runs: %v", job, err)
+	} else if n > 0 {
+		log.Printf("[jobs] %s: %d abandoned run(s) marked as failed", job, n)
+	}
+
+	run, err := r.runs.Create(models.JobRun{
+		Job:         job,
+		Instance:    r.instance,
+		TriggeredBy: triggeredBy,
+		ScheduledAt: scheduledAt,
+	})
+	if errors.Is(err, models.ErrJobSlotTaken) {
+		reason := fmt.Sprintf("слот %s уже выполнен", scheduledAt.UTC().Format(time.RFC3339))
+		return r.skipped(job, triggeredBy, scheduledAt, reason), ErrJobSlotDone
+	}
+	if err != nil {
+		log.Printf("[jobs] %s: failed to record run: %v", job, err)
+		run = models.JobRun{Job: job, Instance: r.instance, TriggeredBy: triggeredBy, ScheduledAt: scheduledAt, StartedAt: time.Now().UTC()}
+	}
+
+	result, jobErr := fn(ctx)
+
+	now := time.Now().UTC()
+	run.Status, run.Error, run.FinishedAt = models.JobStatusSucceeded, "", &now
+	if jobErr != nil {
+		run.Status, run.Error = models.JobStatusFailed, jobErr.Error()
+	}
+	if result != nil {
+		if run.Result, err = json.Marshal(result); err != nil {
+			log.Printf("[jobs] %s: failed to encode result: %v", job, err)
+			run.Result = nil
+		}
+	}
+	if run.ID != 0 {
+		if err := r.runs.Finish(run.ID, run.Status, run.Result, run.Error); err != nil {
+			log.Printf("[jobs] %s: failed to finish run %d: %v", job, run.ID, err)
+		}
+	}
+	return run, jobErr
+}
+
+// ListRuns журнал запусков, новые первыми
+func (r *JobRunner) ListRuns(page models.PageRequest, filter models.JobRunFilter) ([]models.JobRun, models.PageInfo, error) {
+	return r.runs.List(page, filter)
+}
+
+// skipped записывает пропуск запуска с причиной (какой экземпляр держит задачу или что слот уже выполнен)
+func (r *JobRunner) skipped(job, triggeredBy string, scheduledAt *time.Time, reason string) models.JobRun {
+	log.Printf("[jobs] %s skipped on %s: %s", job, r.instance, reason)
+
+	now := time.Now().UTC()
+	run := models.JobRun{
+		Job:         job,
+		Instance:    r.instance,
+		TriggeredBy: triggeredBy,
+		ScheduledAt: scheduledAt,
+		Status:      models.JobStatusSkipped,
+		Error:       reason,
+		StartedAt:   now,
+		FinishedAt:  &now,
+	}
+	created, err := r.runs.Create(run)
+	if err != nil {
+		log.Printf("[jobs] %s: failed to record skipped run: %v", job, err)
+		return run
+	}
+	return created
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"vector/internal/models"
+)
+
+func TestJobRunnerScheduledSlotRunsOnce(t *testing.T) {
+	ctx := context.Background()
+	locker, runs := &fakeJobLocker{}, &fakeJobRunRepo{}
+	a := NewJobRunner(locker, runs, "app-a")
+	b := NewJobRunner(locker, runs, "app-b")
+
+	calls := 0
+	ok := func(ctx context.Context) (any, error) { calls++; return nil, nil }
+	slot := CronSlot(time.Date(2024, 3, 1, 3, 0, 0, 500_000_000, time.UTC))
+	if want := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC); !slot.Equal(want) {
+		t.Fatalf("CronSlot = %v, want %v", slot, want)
+	}
+
+	if _, err := a.RunScheduled(ctx, models.JobAppRecalc, slot, ok); err != nil {
+		t.Fatal(err)
+	}
+	// реплика с отстающими часами срабатывает, когда первая уже закончила: блокировка свободна, слот выполнен
+	run, err := b.RunScheduled(ctx, models.JobAppRecalc, slot, ok)
+	if !errors.Is(err, ErrJobSlotDone) {
+		t.Fatalf("second run of the slot: got %v, want ErrJobSlotDone", err)
+	}
+	if calls != 1 {
+		t.Fatalf("job executed %d times for one slot", calls)
+	}
+	if run.Status != models.JobStatusSkipped || run.Instance != "app-b" || run.ScheduledAt == nil || !run.ScheduledAt.Equal(slot) {
+		t.Fatalf("skipped run = %+v", run)
+	}
+
+	// другая задача и следующий слот выполняются
+	if _, err := b.RunScheduled(ctx, models.JobOIDCReconcile, slot, ok); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.RunScheduled(ctx, models.JobAppRecalc, slot.Add(24*time.Hour), ok); err != nil {
+		t.Fatal(err)
+	}
+	// запуск вручную слотом не ограничен
+	if _, err := a.Run(ctx, models.JobAppRecalc, "admin@example.com", ok); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4", calls)
+	}
+}
+
+func TestJobRunnerScheduledSlotRetriedAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	locker, runs := &fakeJobLocker{}, &fakeJobRunRepo{}
+	a := NewJobRunner(locker, runs, "app-a")
+	b := NewJobRunner(locker, runs, "app-b")
+	slot := CronSlot(time.Now())
+
+	failure := errors.New("external API unavailable")
+	if _, err := a.RunScheduled(ctx, models.JobSyncFull, slot, func(ctx context.Context) (any, error) { return nil, failure }); !errors.Is(err, failure) {
+		t.Fatalf("got %v, want the job error", err)
+	}
+	run, err := b.RunScheduled(ctx, models.JobSyncFull, slot, func(ctx context.Context) (any, error) { return nil, nil })
+	if err != nil || run.Status != models.JobStatusSucceeded {
+		t.Fatalf("retry of a failed slot: status %q, err %v", run.Status, err)
+	}
+}
+
+func TestJobRunnerSkipsWhileLocked(t *testing.T) {
+	ctx := context.Background()
+	locker, runs := &fakeJobLocker{}, &fakeJobRunRepo{}
+	a := NewJobRunner(locker, runs, "app-a")
+	b := NewJobRunner(locker, runs, "app-b")
+	slot := CronSlot(time.Now())
+
+	_, err := a.RunScheduled(ctx, models.JobSyncFull, slot, func(ctx context.Context) (any, error) {
+		run, err := b.RunScheduled(ctx, models.JobSyncFull, slot, func(ctx context.Context) (any, error) {
+			t.Error("overlapping run executed")
+			return nil, nil
+		})
+		if !errors.Is(err, ErrJobSkipped) || run.Status != models.JobStatusSkipped {
+			t.Errorf("overlapping run: status %q, err %v", run.Status, err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
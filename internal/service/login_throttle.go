@@ -0,0 +1,186 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+)
+
+// ThrottlePolicy правила ограничения попыток входа для одного вида ключа
+type ThrottlePolicy struct {
+	DelayAfter  int           // с какой неудачи включаются задержки между попытками
+	BaseDelay   time.Duration // задержка удваивается с каждой следующей неудачей
+	MaxDelay    time.Duration
+	LockAfter   int           // с какой неудачи ключ блокируется
+	LockFor     time.Duration // удваивается с каждой неудачей сверх LockAfter
+	MaxLock     time.Duration
+	ResetWindow time.Duration // счетчик сбрасывается после такого перерыва в неудачах
+}
+
+var (
+	accountThrottlePolicy = ThrottlePolicy{
+		DelayAfter:  3,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    time.Minute,
+		LockAfter:   5,
+		LockFor:     15 * time.Minute,
+		MaxLock:     24 * time.Hour,
+		ResetWindow: time.Hour,
+	}
+	ipThrottlePolicy = ThrottlePolicy{
+		DelayAfter:  10,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		LockAfter:   30,
+		LockFor:     15 * time.Minute,
+		MaxLock:     6 * time.Hour,
+		ResetWindow: time.Hour,
+	}
+)
+
+// LoginThrottledError вход временно запрещен для аккаунта или IP
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	if e.Locked {
+		return fmt.Sprintf("слишком много неудачных попыток входа, вход заблокирован на %d с", seconds)
+	}
+	return fmt.Sprintf("слишком много неудачных попыток входа, повторите через %d с", seconds)
+}
+
+func backoff(base time.Duration, steps int, max time.Duration) time.Duration {
+	if steps > 30 {
+		return max
+	}
+	d := base << steps
+	if d <= 0 || d > max {
+		return max
+	}
+	return d
+}
+
+// wait сколько осталось ждать до следующей попытки; locked — ключ заблокирован
+func (p ThrottlePolicy) wait(t *models.AuthThrottle, now time.Time) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return t.LockedUntil.Sub(now), true
+	}
+	if now.Sub(t.LastFailureAt) > p.ResetWindow || t.Failures < p.DelayAfter {
+		return 0, false
+	}
+	next := t.LastFailureAt.Add(backoff(p.BaseDelay, t.Failures-p.DelayAfter, p.MaxDelay))
+	if now.Before(next) {
+		return next.Sub(now), false
+	}
+	return 0, false
+}
+
+// lockUntil время окончания блокировки после очередной неудачи (nil — не блокировать)
+func (p ThrottlePolicy) lockUntil(failures int, now time.Time) *time.Time {
+	if failures < p.LockAfter {
+		return nil
+	}
+	until := now.Add(backoff(p.LockFor, failures-p.LockAfter, p.MaxLock))
+	return &until
+}
+
+func accountThrottleKey(email string) string {
+	return models.ThrottleKeyAccount + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return models.ThrottleKeyIP + ip
+}
+
+type throttleTarget struct {
+	key    string
+	policy ThrottlePolicy
+}
+
+func loginThrottleTargets(actor models.AuditActor, email string) []throttleTarget {
+	targets := []throttleTarget{{accountThrottleKey(email), accountThrottlePolicy}}
+	if actor.IP != "" {
+		targets = append(targets, throttleTarget{ipThrottleKey(actor.IP), ipThrottlePolicy})
+	}
+	return targets
+}
+
+// checkLoginThrottle проверяется до сверки пароля, чтобы заблокированный аккаунт нельзя было перебирать
+func (s *AuthService) checkLoginThrottle(actor models.AuditActor, email string) error {
+	now := time.Now()
+	var worst *LoginThrottledError
+	for _, t := range loginThrottleTargets(actor, email) {
+		row, err := s.throttleRepo.Get(t.key)
+		if err != nil {
+			return err
+		}
+		if wait, locked := t.policy.wait(row, now); wait > 0 && (worst == nil || wait > worst.RetryAfter) {
+			worst = &LoginThrottledError{RetryAfter: wait, Locked: locked}
+		}
+	}
+	if worst != nil {
+		return worst
+	}
+	return nil
+}
+
+// registerLoginFailure учитывает неудачу по аккаунту и IP и блокирует ключи при превышении порога
+func (s *AuthService) registerLoginFailure(actor models.AuditActor, email string) {
+	now := time.Now().UTC()
+	for _, t := range loginThrottleTargets(actor, email) {
+		row, err := s.throttleRepo.RegisterFailure(t.key, t.policy.ResetWindow)
+		if err != nil {
+			log.Printf("auth: failed to register login failure for %s: %v", t.key, err)
+			continue
+		}
+		until := t.policy.lockUntil(row.Failures, now)
+		if until == nil {
+			continue
+		}
+		if err := s.throttleRepo.Lock(t.key, *until); err != nil {
+			log.Printf("auth: failed to lock %s: %v", t.key, err)
+			continue
+		}
+		s.audit.RecordAfter(actor, AuditEntry{
+			Action:   models.AuditActionLoginLocked,
+			Metadata: map[string]any{"key": t.key, "failures": row.Failures, "locked_until": until},
+		})
+	}
+}
+
+// UnlockUser снимает блокировку входа для аккаунта пользователя и, если задан, для IP
+func (s *AuthService) UnlockUser(actor models.AuditActor, id uint, ip string) (int64, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return 0, err
+	}
+	cleared, err := s.throttleRepo.Clear(accountThrottleKey(user.Email))
+	if err != nil {
+		return 0, err
+	}
+	if ip != "" {
+		n, err := s.throttleRepo.Clear(ipThrottleKey(ip))
+		if err != nil {
+			return cleared, err
+		}
+		cleared += n
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionLoginUnlocked,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(id),
+		Metadata:   map[string]any{"ip": ip, "cleared": cleared},
+	})
+	return cleared, nil
+}
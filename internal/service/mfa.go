@@ -0,0 +1,394 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/secretbox"
+	"vector/internal/pkg/totp"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrMFAInvalidCode    = errors.New("неверный код подтверждения")
+	ErrMFAInvalidToken   = errors.New("недействительный или просроченный токен второго шага входа")
+	ErrMFAAlreadyEnabled = errors.New("двухфакторная аутентификация уже подключена")
+	ErrMFANotEnabled     = errors.New("двухфакторная аутентификация не подключена")
+	ErrMFARequired       = errors.New("двухфакторная аутентификация обязательна для роли пользователя")
+)
+
+const (
+	// допуск рассинхронизации часов: предыдущий и следующий шаг
+	mfaSkew           = 1
+	recoveryCodeCount = 10
+	recoveryCodeLen   = 10
+)
+
+// MFARequiredForRole обязательна ли 2FA для роли (MFA_REQUIRED_ROLES)
+func (s *AuthService) MFARequiredForRole(role string) bool {
+	for _, r := range s.mfa.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// MFAEnrollmentRequired пользователь обязан подключить 2FA, прежде чем работать с данными.
+// Для учетных записей SSO второй фактор проверяет провайдер.
+func (s *AuthService) MFAEnrollmentRequired(user models.AppUser) bool {
+	return !user.MFAEnabled && !user.IsExternal() && s.MFARequiredForRole(user.Role)
+}
+
+func (s *AuthService) mfaBox() (*secretbox.Box, error) {
+	return secretbox.New(s.mfa.EncryptionKey)
+}
+
+// секрет привязан к пользователю: перенос шифротекста в другую строку не сработает
+func mfaSecretAAD(userID uint) []byte {
+	return []byte("core.app_users:" + strconv.FormatUint(uint64(userID), 10))
+}
+
+func (s *AuthService) openMFASecret(user models.AppUser) ([]byte, error) {
+	if user.MFASecret == "" {
+		return nil, ErrMFANotEnabled
+	}
+	box, err := s.mfaBox()
+	if err != nil {
+		return nil, err
+	}
+	return box.Open(user.MFASecret, mfaSecretAAD(user.ID))
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCodes коды вида xxxxx-xxxxx; возвращает сами коды и их хеши
+func generateRecoveryCodes() ([]string, []string, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:recoveryCodeLen]
+		codes[i] = raw[:recoveryCodeLen/2] + "-" + raw[recoveryCodeLen/2:]
+		hashes[i] = hashRecoveryCode(raw)
+	}
+	return codes, hashes, nil
+}
+
+// verifyTOTP проверяет код приложения и фиксирует его шаг, чтобы код нельзя было использовать повторно
+func (s *AuthService) verifyTOTP(user models.AppUser, code string) error {
+	secret, err := s.openMFASecret(user)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), mfaSkew)
+	if !ok {
+		return ErrMFAInvalidCode
+	}
+	accepted, err := s.mfaRepo.AcceptStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+// verifyMFACode принимает код приложения или код восстановления; возвращает способ подтверждения
+func (s *AuthService) verifyMFACode(actor models.AuditActor, user models.AppUser, code string) (string, error) {
+	err := s.verifyTOTP(user, code)
+	if err == nil {
+		return "totp", nil
+	}
+	if !errors.Is(err, ErrMFAInvalidCode) || len(normalizeRecoveryCode(code)) != recoveryCodeLen {
+		return "", err
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return "", err
+	}
+	if !used {
+		return "", ErrMFAInvalidCode
+	}
+
+	left, _ := s.mfaRepo.CountRecoveryCodes(user.ID)
+	actor.UserID, actor.Email, actor.Role = &user.ID, user.Email, user.Role
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionMFARecoveryUsed,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+		Metadata:   map[string]any{"recovery_codes_left": left},
+	})
+	return "recovery_code", nil
+}
+
+// issueMFAToken токен второго шага входа. Аудитории токенов различаются:
+// ValidateToken его не примет, а parseMFAToken не примет access-токен
+func (s *AuthService) issueMFAToken(user models.AppUser) (string, int64, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", 0, err
+	}
+	expiresAt := time.Now().Add(s.mfa.TokenDuration)
+	claims := &models.MFAClaims{
+		UserID: user.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Audience:  jwt.ClaimStrings{models.MFATokenAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    tokenIssuer,
+		},
+	}
+	token, err := s.jwtConfig.Keys.Sign(claims)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, expiresAt.Unix(), nil
+}
+
+func (s *AuthService) parseMFAToken(tokenString string) (*models.MFAClaims, error) {
+	claims := &models.MFAClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, s.jwtConfig.Keys.Keyfunc,
+		jwt.WithAudience(models.MFATokenAudience), jwt.WithValidMethods(s.jwtConfig.Keys.Algorithms()),
+		jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil || claims.UserID == 0 || claims.ID == "" {
+		return nil, ErrMFAInvalidToken
+	}
+	return claims, nil
+}
+
+// LoginMFA второй шаг входа: код приложения или код восстановления в обмен на токены.
+// Неверные коды учитываются тем же ограничением попыток, что и пароли.
+// Токен одноразовый: после успешной проверки кода его jti фиксируется.
+func (s *AuthService) LoginMFA(actor models.AuditActor, mfaToken, code string) (*models.LoginResponse, error) {
+	claims, err := s.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil || !user.IsActive || !user.MFAEnabled {
+		return nil, ErrMFAInvalidToken
+	}
+
+	if err := s.checkLoginThrottle(actor, user.Email); err != nil {
+		return nil, err
+	}
+
+	method, err := s.verifyMFACode(actor, user, code)
+	if errors.Is(err, ErrMFAInvalidCode) {
+		s.loginFailed(actor, user.Email, &user.ID, "bad_mfa_code")
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := s.mfaRepo.UseToken(claims.ID, user.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, ErrMFAInvalidToken
+	}
+
+	return s.completeLogin(actor, user, map[string]any{"mfa": method})
+}
+
+// GetMFAStatus состояние 2FA текущего пользователя
+func (s *AuthService) GetMFAStatus(user models.AppUser) (models.MFAStatusResponse, error) {
+	status := models.MFAStatusResponse{
+		Enabled:   user.MFAEnabled,
+		Required:  s.MFARequiredForRole(user.Role),
+		EnabledAt: user.MFAEnabledAt,
+	}
+	if user.MFAEnabled {
+		n, err := s.mfaRepo.CountRecoveryCodes(user.ID)
+		if err != nil {
+			return status, err
+		}
+		status.RecoveryCodesLeft = n
+	}
+	return status, nil
+}
+
+// BeginMFAEnrollment создает новый секрет TOTP. 2FA включается только после
+// подтверждения кодом (ConfirmMFAEnrollment); повторный вызов заменяет секрет.
+func (s *AuthService) BeginMFAEnrollment(userID uint) (*models.MFAEnrollResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	box, err := s.mfaBox()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := box.Seal(secret, mfaSecretAAD(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.SetPendingSecret(user.ID, sealed); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollResponse{
+		Secret:          totp.EncodeSecret(secret),
+		ProvisioningURI: totp.ProvisioningURI(s.mfa.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFAEnrollment включает 2FA по первому коду из приложения и выдает коды восстановления.
+// Остальные сессии пользователя завершаются.
+func (s *AuthService) ConfirmMFAEnrollment(actor models.AuditActor, userID uint, sessionID, code string) (*models.MFARecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.openMFASecret(user)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), mfaSkew)
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.Enable(user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	if err := s.revokeAfterUserChange(user.ID, sessionID, models.SessionRevokeMFAChanged); err != nil {
+		return nil, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionMFAEnabled,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+	})
+	return &models.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes выдает новый набор кодов восстановления; старые перестают действовать
+func (s *AuthService) RegenerateRecoveryCodes(actor models.AuditActor, userID uint, code string) (*models.MFARecoveryCodesResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.verifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionMFARecoveryCodes,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+	})
+	return &models.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableOwnMFA отключает 2FA по коду из приложения; недоступно, если 2FA обязательна для роли
+func (s *AuthService) DisableOwnMFA(actor models.AuditActor, userID uint, sessionID, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if s.MFARequiredForRole(user.Role) {
+		return ErrMFARequired
+	}
+	if _, err := s.verifyMFACode(actor, user, code); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.Disable(user.ID); err != nil {
+		return err
+	}
+	if err := s.revokeAfterUserChange(user.ID, sessionID, models.SessionRevokeMFAChanged); err != nil {
+		return err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionMFADisabled,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+	})
+	return nil
+}
+
+// ResetUserMFA сбрасывает 2FA пользователя (потерянное устройство и коды).
+// Все сессии пользователя завершаются; при следующем входе 2FA подключается заново.
+func (s *AuthService) ResetUserMFA(actor models.AuditActor, id uint) error {
+	if actor.UserID != nil && *actor.UserID == id {
+		return ErrSelfAction
+	}
+
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.mfaRepo.Disable(user.ID); err != nil {
+		return err
+	}
+	if err := s.revokeAfterUserChange(user.ID, "", models.SessionRevokeMFAChanged); err != nil {
+		return err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserMFAReset,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+		Metadata:   map[string]any{"was_enabled": user.MFAEnabled},
+	})
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/totp"
+)
+
+// mfaUser пользователь с включенной 2FA и секретом, зашифрованным как при подключении
+func mfaUser(t *testing.T, ta *testAuth) (models.AppUser, []byte) {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := ta.users.add(models.AppUser{Email: "mfa@example.com", Role: models.RolePodft, IsActive: true, MFAEnabled: true})
+	box, err := ta.svc.mfaBox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.MFASecret, err = box.Seal(secret, mfaSecretAAD(user.ID)); err != nil {
+		t.Fatal(err)
+	}
+	return user, secret
+}
+
+func TestVerifyTOTPRejectsReplay(t *testing.T) {
+	ta := newTestAuth(t)
+	user, secret := mfaUser(t, ta)
+
+	// не начинать у границы шага: коды ниже считаются от текущего шага
+	if left := totp.Period - time.Duration(time.Now().Unix()%30)*time.Second; left < 2*time.Second {
+		time.Sleep(left)
+	}
+	step := totp.Step(time.Now())
+
+	// код предыдущего шага в пределах допуска
+	if err := ta.svc.verifyTOTP(user, totp.Code(secret, step-1)); err != nil {
+		t.Fatalf("code within skew rejected: %v", err)
+	}
+	if err := ta.svc.verifyTOTP(user, totp.Code(secret, step-1)); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("replayed code: got %v, want ErrMFAInvalidCode", err)
+	}
+	if err := ta.svc.verifyTOTP(user, totp.Code(secret, step)); err != nil {
+		t.Fatalf("current code rejected: %v", err)
+	}
+	// после принятого шага более ранние коды не принимаются, даже не использованные
+	if err := ta.svc.verifyTOTP(user, totp.Code(secret, step-1)); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("earlier step after a later one: got %v, want ErrMFAInvalidCode", err)
+	}
+	if err := ta.svc.verifyTOTP(user, totp.Code(secret, step+1)); err != nil {
+		t.Fatalf("next step code rejected: %v", err)
+	}
+
+	for name, code := range map[string]string{
+		"outside skew": totp.Code(secret, step+3),
+		"wrong code":   "000000",
+		"too short":    "123",
+	} {
+		if err := ta.svc.verifyTOTP(user, code); !errors.Is(err, ErrMFAInvalidCode) {
+			t.Errorf("%s: got %v, want ErrMFAInvalidCode", name, err)
+		}
+	}
+}
+
+func TestVerifyTOTPSecretBoundToUser(t *testing.T) {
+	ta := newTestAuth(t)
+	user, secret := mfaUser(t, ta)
+
+	// шифротекст, перенесенный в строку другого пользователя, не расшифровывается
+	other := ta.users.add(models.AppUser{Email: "other@example.com", Role: models.RolePodft, IsActive: true, MFAEnabled: true, MFASecret: user.MFASecret})
+	if err := ta.svc.verifyTOTP(other, totp.Code(secret, totp.Step(time.Now()))); err == nil || errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("moved secret: got %v, want a decryption error", err)
+	}
+
+	if err := ta.svc.verifyTOTP(models.AppUser{ID: 99}, "123456"); !errors.Is(err, ErrMFANotEnabled) {
+		t.Fatalf("user without secret: got %v, want ErrMFANotEnabled", err)
+	}
+}
+
+func TestLoginMFARejectsReusedToken(t *testing.T) {
+	ta := newTestAuth(t)
+	user, secret := mfaUser(t, ta)
+	// LoginMFA читает пользователя из репозитория: сохранить секрет и там
+	ta.users.users[user.ID] = &user
+
+	if left := totp.Period - time.Duration(time.Now().Unix()%30)*time.Second; left < 2*time.Second {
+		time.Sleep(left)
+	}
+	step := totp.Step(time.Now())
+
+	token, _, err := ta.svc.issueMFAToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ta.svc.LoginMFA(models.AuditActor{}, token, totp.Code(secret, step)); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	// повтор токена со свежим кодом не дает второй сессии
+	if _, err := ta.svc.LoginMFA(models.AuditActor{}, token, totp.Code(secret, step+1)); !errors.Is(err, ErrMFAInvalidToken) {
+		t.Fatalf("reused token: got %v, want ErrMFAInvalidToken", err)
+	}
+
+	// неверный код не расходует токен. Шаг step+1 уже принят выше, поэтому
+	// проверка на другом пользователе
+	user, secret = mfaUser(t, ta)
+	ta.users.users[user.ID] = &user
+	token, _, err = ta.svc.issueMFAToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ta.svc.LoginMFA(models.AuditActor{}, token, "000000"); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("wrong code: got %v, want ErrMFAInvalidCode", err)
+	}
+	if _, err := ta.svc.LoginMFA(models.AuditActor{}, token, totp.Code(secret, step)); err != nil {
+		t.Fatalf("token after a wrong code: %v", err)
+	}
+}
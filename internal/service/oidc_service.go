@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/oidc"
+	"vector/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCState     = errors.New("недействительный или просроченный запрос входа через SSO")
+	ErrOIDCNoRole    = errors.New("пользователю не назначена роль в корпоративном каталоге")
+	ErrOIDCEmail     = errors.New("провайдер не передал подтвержденный email")
+	ErrOIDCLoginCode = errors.New("недействительный или просроченный код входа")
+)
+
+// время жизни одноразового кода входа, с которым frontend получает токены
+const oidcLoginCodeTTL = time.Minute
+
+// порядок проверки ролей: при нескольких подходящих группах выбирается старшая роль
+type OIDCService struct {
+	auth     *AuthService
+	userRepo repository.UserRepository
+	states   repository.OIDCStateRepository
+	provider *oidc.Provider
+	cfg      models.OIDCConfig
+	audit    *AuditService
+}
+
+func NewOIDCService(
+	auth *AuthService,
+	userRepo repository.UserRepository,
+	states repository.OIDCStateRepository,
+	cfg models.OIDCConfig,
+	audit *AuditService,
+) *OIDCService {
+	return &OIDCService{
+		auth:     auth,
+		userRepo: userRepo,
+		states:   states,
+		provider: oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.IssuerURL,
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		}, nil),
+		cfg:   cfg,
+		audit: audit,
+	}
+}
+
+func hashOIDCValue(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+func externalTokenAAD(userID uint) []byte {
+	return []byte("core.app_users.external_refresh_token:" + strconv.FormatUint(uint64(userID), 10))
+}
+
+// BeginLogin создает попытку входа (state, nonce, PKCE) и возвращает адрес страницы входа провайдера
+func (s *OIDCService) BeginLogin(ctx context.Context) (string, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.PKCE()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.states.Create(models.OIDCLoginState{
+		StateHash:    hashOIDCValue(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().UTC().Add(s.cfg.StateTTL),
+	}); err != nil {
+		return "", err
+	}
+	return s.provider.AuthCodeURL(ctx, state, nonce, challenge)
+}
+
+// Callback завершает вход у провайдера: проверяет state, обменивает код (с code_verifier),
+// проверяет ID-токен и создает или обновляет пользователя.
+// Если задан адрес frontend, возвращает одноразовый код входа, иначе сразу токены.
+func (s *OIDCService) Callback(ctx context.Context, actor models.AuditActor, code, state string) (string, *models.LoginResponse, error) {
+	st, err := s.states.Consume(hashOIDCValue(state))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, ErrOIDCState
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	tokens, err := s.provider.Exchange(ctx, code, st.CodeVerifier)
+	if errors.Is(err, oidc.ErrInvalidGrant) {
+		return "", nil, ErrOIDCState
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	idToken, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, st.Nonce)
+	if err != nil {
+		return "", nil, err
+	}
+
+	user, err := s.provision(actor, idToken, tokens.RefreshToken)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if s.cfg.FrontendRedirectURL == "" {
+		resp, err := s.auth.finishLogin(actor, user, map[string]any{"method": "oidc"})
+		return "", resp, err
+	}
+
+	loginCode, err := oidc.RandomString(32)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.states.SetLoginCode(st.ID, user.ID, hashOIDCValue(loginCode), time.Now().UTC().Add(oidcLoginCodeTTL)); err != nil {
+		return "", nil, err
+	}
+	return loginCode, nil, nil
+}
+
+// FrontendRedirect адрес frontend с одноразовым кодом входа
+func (s *OIDCService) FrontendRedirect(loginCode string) string {
+	sep := "?"
+	if strings.Contains(s.cfg.FrontendRedirectURL, "?") {
+		sep = "&"
+	}
+	return s.cfg.FrontendRedirectURL + sep + "login_code=" + url.QueryEscape(loginCode)
+}
+
+// ExchangeLoginCode обменивает одноразовый код входа на токены (или на mfa_token, если у пользователя 2FA)
+func (s *OIDCService) ExchangeLoginCode(actor models.AuditActor, loginCode string) (*models.LoginResponse, error) {
+	userID, err := s.states.RedeemLoginCode(hashOIDCValue(loginCode))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOIDCLoginCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || !user.IsActive {
+		return nil, ErrOIDCLoginCode
+	}
+	return s.auth.finishLogin(actor, user, map[string]any{"method": "oidc"})
+}
+
+// CheckRoleMapping проверяет, что роли из сопоставления групп есть в справочнике ролей
+func (s *OIDCService) CheckRoleMapping() error {
+	roles, err := s.auth.roles.snapshot()
+	if err != nil {
+		return err
+	}
+	for _, rg := range s.cfg.RoleGroups {
+		if _, ok := roles[rg.Role]; !ok {
+			return fmt.Errorf("OIDC role mapping: unknown role %q", rg.Role)
+		}
+	}
+	return nil
+}
+
+// mapRole роль по группам из ID-токена в порядке приоритета сопоставления; пустая строка — ни одна
+// группа не дает доступа. Роли, удаленные из справочника после запуска, пропускаются. Ошибка чтения
+// справочника возвращается, а не считается отсутствием роли: иначе сбой БД деактивировал бы пользователей.
+func (s *OIDCService) mapRole(token *oidc.IDToken) (string, error) {
+	roles, err := s.auth.roles.snapshot()
+	if err != nil {
+		return "", err
+	}
+	groups := make(map[string]bool)
+	for _, g := range token.Strings(s.cfg.GroupsClaim) {
+		groups[strings.ToLower(g)] = true
+	}
+	for _, rg := range s.cfg.RoleGroups {
+		if _, ok := roles[rg.Role]; !ok {
+			log.Printf("oidc: role %q from the group mapping no longer exists", rg.Role)
+			continue
+		}
+		for _, g := range rg.Groups {
+			if groups[strings.ToLower(g)] {
+				return rg.Role, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func oidcNames(token *oidc.IDToken, email string) (string, string) {
+	first, last := token.String("given_name"), token.String("family_name")
+	if first == "" && last == "" {
+		if parts := strings.Fields(token.String("name")); len(parts) > 1 {
+			first, last = parts[0], strings.Join(parts[1:], " ")
+		} else if len(parts) == 1 {
+			first = parts[0]
+		}
+	}
+	if first == "" {
+		first = strings.SplitN(email, "@", 2)[0]
+	}
+	if last == "" {
+		last = "-"
+	}
+	return first, last
+}
+
+// provision находит пользователя по sub (или по email для существующей локальной учетной записи)
+// либо создает его, синхронизирует роль с группами провайдера и сохраняет refresh-токен провайдера.
+// Поиск по email и создание учетной записи — только при email_verified = true: иначе любой, кто
+// заведет у провайдера учетную запись с чужим email, получил бы локального пользователя с этим email.
+// Уже связанный пользователь находится по sub независимо от email_verified.
+func (s *OIDCService) provision(actor models.AuditActor, token *oidc.IDToken, refreshToken string) (models.AppUser, error) {
+	email := strings.ToLower(strings.TrimSpace(token.String("email")))
+	verified, _ := token.Claims["email_verified"].(bool)
+	role, err := s.mapRole(token)
+	if err != nil {
+		return models.AppUser{}, err
+	}
+	first, last := oidcNames(token, email)
+
+	user, err := s.userRepo.GetByExternalSubject(token.Subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if email == "" || !verified {
+			s.loginRejected(actor, email, nil, "email_not_verified")
+			return user, ErrOIDCEmail
+		}
+		user, err = s.userRepo.GetByEmail(email)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if role == "" {
+			s.loginRejected(actor, email, nil, "no_role")
+			return user, ErrOIDCNoRole
+		}
+		if user, err = s.createUser(actor, token.Subject, email, first, last, role); err != nil {
+			return user, err
+		}
+	case err != nil:
+		return user, err
+	default:
+		if user.ExternalSubject != nil && *user.ExternalSubject != token.Subject {
+			// email занят учетной записью другого субъекта провайдера
+			s.loginRejected(actor, email, &user.ID, "subject_mismatch")
+			return user, ErrOIDCState
+		}
+		if !user.IsActive {
+			// деактивированных (администратором или сверкой с провайдером) SSO не возвращает
+			s.loginRejected(actor, email, &user.ID, "inactive")
+			return user, errors.New("пользователь деактивирован")
+		}
+		if role == "" {
+			s.deactivate(models.SystemActor("oidc"), user, "no_role")
+			s.loginRejected(actor, email, &user.ID, "no_role")
+			return user, ErrOIDCNoRole
+		}
+		if first == "" {
+			// без имени и email в токене имя не меняется
+			first, last = user.FirstName, user.LastName
+		}
+		if err := s.userRepo.LinkExternal(user.ID, token.Subject, first, last); err != nil {
+			return user, err
+		}
+		subject := token.Subject
+		user.AuthProvider, user.ExternalSubject, user.FirstName, user.LastName = models.AuthProviderOIDC, &subject, first, last
+		if role != user.Role {
+			if user, err = s.syncRole(models.SystemActor("oidc"), user, role); err != nil {
+				return user, err
+			}
+		}
+	}
+
+	if err := s.storeRefreshToken(user.ID, refreshToken); err != nil {
+		log.Printf("oidc: failed to store provider refresh token for user %d: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+func (s *OIDCService) createUser(actor models.AuditActor, subject, email, first, last, role string) (models.AppUser, error) {
+	user, err := s.userRepo.Create(models.AppUser{
+		Email:           email,
+		FirstName:       first,
+		LastName:        last,
+		Role:            role,
+		IsActive:        true,
+		AuthProvider:    models.AuthProviderOIDC,
+		ExternalSubject: &subject,
+		// не bcrypt-хеш: локальный вход с любым паролем невозможен
+		PasswordHash: "!oidc",
+	})
+	if err != nil {
+		return user, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserCreated,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+		After:      userSummary(user),
+		Metadata:   map[string]any{"source": "oidc"},
+	})
+	return user, nil
+}
+
+// syncRole приводит роль к группам провайдера; последнего администратора не понижает
+func (s *OIDCService) syncRole(actor models.AuditActor, user models.AppUser, role string) (models.AppUser, error) {
+	updated, err := s.auth.UpdateUserRole(actor, user.ID, role)
+	if errors.Is(err, models.ErrLastAdministrator) {
+		log.Printf("oidc: keeping role of last administrator %s despite provider groups", user.Email)
+		return user, nil
+	}
+	return updated, err
+}
+
+func (s *OIDCService) deactivate(actor models.AuditActor, user models.AppUser, reason string) error {
+	if _, err := s.auth.SetUserActive(actor, user.ID, false); err != nil {
+		log.Printf("oidc: failed to deactivate %s (%s): %v", user.Email, reason, err)
+		return err
+	}
+	log.Printf("oidc: deactivated %s (%s)", user.Email, reason)
+	return nil
+}
+
+func (s *OIDCService) loginRejected(actor models.AuditActor, email string, userID *uint, reason string) {
+	actor.Email = email
+	actor.UserID = userID
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionLoginFailed,
+		EntityType: models.AuditEntityUser,
+		Metadata:   map[string]any{"reason": reason, "method": "oidc"},
+	})
+}
+
+// storeRefreshToken отмечает проверку у провайдера и сохраняет новый refresh-токен.
+// Пустой токен (провайдер не ротирует refresh-токены) прежний не затирает: без него
+// сверка пропускала бы пользователя и не смогла бы его деактивировать.
+func (s *OIDCService) storeRefreshToken(userID uint, refreshToken string) error {
+	if refreshToken == "" {
+		return s.userRepo.TouchExternal(userID, "")
+	}
+	box, err := s.auth.mfaBox()
+	if err != nil {
+		return err
+	}
+	sealed, err := box.Seal([]byte(refreshToken), externalTokenAAD(userID))
+	if err != nil {
+		return err
+	}
+	return s.userRepo.TouchExternal(userID, sealed)
+}
+
+// Reconcile сверяет активных пользователей SSO с провайдером по сохраненным refresh-токенам:
+// отозванный доступ (invalid_grant) или отсутствие групп деактивирует пользователя,
+// изменение групп меняет роль. Пользователи без refresh-токена пропускаются.
+func (s *OIDCService) Reconcile(ctx context.Context, actor models.AuditActor) (models.OIDCReconcileResult, error) {
+	var result models.OIDCReconcileResult
+
+	active := true
+	provider := models.AuthProviderOIDC
+	users, err := s.userRepo.List(models.UserListFilter{IsActive: &active, AuthProvider: &provider})
+	if err != nil {
+		return result, err
+	}
+
+	box, err := s.auth.mfaBox()
+	if err != nil {
+		return result, err
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Checked++
+
+		if user.ExternalRefreshToken == "" {
+			result.Skipped++
+			continue
+		}
+		refreshToken, err := box.Open(user.ExternalRefreshToken, externalTokenAAD(user.ID))
+		if err != nil {
+			result.Failed++
+			log.Printf("oidc: cannot decrypt provider refresh token of %s: %v", user.Email, err)
+			continue
+		}
+
+		tokens, err := s.provider.Refresh(ctx, string(refreshToken))
+		if errors.Is(err, oidc.ErrInvalidGrant) {
+			if s.deactivate(actor, user, "removed_from_idp") != nil {
+				result.Failed++
+			} else {
+				result.Deactivated++
+			}
+			continue
+		}
+		if err != nil {
+			result.Skipped++
+			log.Printf("oidc: provider refresh failed for %s: %v", user.Email, err)
+			continue
+		}
+
+		if tokens.IDToken != "" {
+			idToken, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, "")
+			if err != nil || idToken.Subject != derefString(user.ExternalSubject) {
+				result.Failed++
+				log.Printf("oidc: bad id token on refresh for %s: %v", user.Email, err)
+				continue
+			}
+			role, err := s.mapRole(idToken)
+			switch {
+			case err != nil:
+				result.Skipped++
+				log.Printf("oidc: failed to map role of %s: %v", user.Email, err)
+				continue
+			case role == "":
+				if s.deactivate(actor, user, "no_role") != nil {
+					result.Failed++
+				} else {
+					result.Deactivated++
+				}
+				continue
+			case role != user.Role:
+				if _, err := s.syncRole(actor, user, role); err != nil {
+					result.Failed++
+					log.Printf("oidc: failed to update role of %s: %v", user.Email, err)
+				} else {
+					result.RoleChanged++
+				}
+			}
+		}
+
+		// провайдер без ротации refresh-токенов не возвращает новый: продолжаем использовать прежний
+		if tokens.RefreshToken == "" {
+			if err := s.userRepo.TouchExternal(user.ID, ""); err != nil {
+				log.Printf("oidc: failed to mark user %d as checked: %v", user.ID, err)
+			}
+			continue
+		}
+		if err := s.storeRefreshToken(user.ID, tokens.RefreshToken); err != nil {
+			log.Printf("oidc: failed to store provider refresh token for user %d: %v", user.ID, err)
+		}
+	}
+
+	if result.Deactivated > 0 || result.RoleChanged > 0 {
+		log.Printf("oidc: reconcile %s", fmt.Sprintf("checked=%d deactivated=%d role_changed=%d skipped=%d failed=%d",
+			result.Checked, result.Deactivated, result.RoleChanged, result.Skipped, result.Failed))
+	}
+	return result, nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,755 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/pkg/oidc"
+	"vector/internal/pkg/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const testOIDCClientID = "vector-app"
+
+// mockIdP провайдер OpenID Connect на httptest: discovery, JWKS и token endpoint
+// (authorization_code с PKCE и refresh_token)
+type mockIdP struct {
+	t    *testing.T
+	srv  *httptest.Server
+	keys *signing.KeySet
+
+	mu      sync.Mutex
+	seq     int
+	codes   map[string]*idpGrant
+	refresh map[string]*idpGrant
+	// без ротации refresh-токенов token endpoint при обновлении не возвращает новый
+	noRotation bool
+}
+
+// idpGrant учетная запись пользователя у провайдера, на которую выдан код или refresh-токен
+type idpGrant struct {
+	claims    jwt.MapClaims
+	challenge string
+	nonce     string
+	revoked   bool
+	// sign подменяет подпись ID-токена (проверка отказа по алгоритму и ключу)
+	sign func(claims jwt.MapClaims) string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	keys, err := signing.Ephemeral()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{t: t, keys: keys, codes: map[string]*idpGrant{}, refresh: map[string]*idpGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, oidc.Discovery{
+			Issuer:                idp.srv.URL,
+			AuthorizationEndpoint: idp.srv.URL + "/authorize",
+			TokenEndpoint:         idp.srv.URL + "/token",
+			JWKSURI:               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, idp.keys.JWKS())
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (idp *mockIdP) next(prefix string) string {
+	idp.seq++
+	return prefix + strconv.Itoa(idp.seq)
+}
+
+// claims стандартные claims ID-токена для пользователя sub
+func (idp *mockIdP) claims(sub, email string, groups ...string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            idp.srv.URL,
+		"aud":            testOIDCClientID,
+		"sub":            sub,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"email":          email,
+		"email_verified": true,
+		"given_name":     "Иван",
+		"family_name":    "Петров",
+		"groups":         groups,
+	}
+}
+
+// authorize имитирует вход пользователя на странице провайдера по адресу из BeginLogin и возвращает код
+func (idp *mockIdP) authorize(authURL string, grant *idpGrant) (code, state string) {
+	idp.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		idp.t.Fatalf("authorize request without PKCE: %s", authURL)
+	}
+	grant.challenge = q.Get("code_challenge")
+	grant.nonce = q.Get("nonce")
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code = idp.next("code-")
+	idp.codes[code] = grant
+	return code, q.Get("state")
+}
+
+func (idp *mockIdP) idToken(grant *idpGrant, nonce string) string {
+	claims := jwt.MapClaims{}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	if _, set := claims["nonce"]; !set && nonce != "" {
+		claims["nonce"] = nonce
+	}
+	if grant.sign != nil {
+		return grant.sign(claims)
+	}
+	raw, err := idp.keys.Sign(claims)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return raw
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if r.PostForm.Get("client_id") != testOIDCClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	invalidGrant := func() { writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"}) }
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	var grant *idpGrant
+	nonce := ""
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		g, ok := idp.codes[r.PostForm.Get("code")]
+		if !ok {
+			invalidGrant()
+			return
+		}
+		delete(idp.codes, r.PostForm.Get("code"))
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			invalidGrant()
+			return
+		}
+		grant, nonce = g, g.nonce
+	case "refresh_token":
+		g, ok := idp.refresh[r.PostForm.Get("refresh_token")]
+		if !ok || g.revoked {
+			invalidGrant()
+			return
+		}
+		grant = g
+		if idp.noRotation {
+			writeJSON(w, http.StatusOK, oidc.Tokens{AccessToken: "at", IDToken: idp.idToken(grant, ""), ExpiresIn: 300})
+			return
+		}
+		delete(idp.refresh, r.PostForm.Get("refresh_token"))
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	refreshToken := idp.next("rt-")
+	idp.refresh[refreshToken] = grant
+	writeJSON(w, http.StatusOK, oidc.Tokens{
+		AccessToken:  "at",
+		RefreshToken: refreshToken,
+		IDToken:      idp.idToken(grant, nonce),
+		ExpiresIn:    300,
+	})
+}
+
+type fakeOIDCStateRepo struct {
+	mu     sync.Mutex
+	states []models.OIDCLoginState
+}
+
+func (r *fakeOIDCStateRepo) Create(state models.OIDCLoginState) (models.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.ID = uint64(len(r.states) + 1)
+	r.states = append(r.states, state)
+	return state, nil
+}
+
+func (r *fakeOIDCStateRepo) Consume(stateHash string) (models.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		st := &r.states[i]
+		if st.StateHash == stateHash && st.ConsumedAt == nil && time.Now().Before(st.ExpiresAt) {
+			now := time.Now()
+			st.ConsumedAt = &now
+			return *st, nil
+		}
+	}
+	return models.OIDCLoginState{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOIDCStateRepo) SetLoginCode(id uint64, userID uint, codeHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[id-1].UserID, r.states[id-1].LoginCodeHash = &userID, &codeHash
+	return nil
+}
+
+func (r *fakeOIDCStateRepo) RedeemLoginCode(codeHash string) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		st := &r.states[i]
+		if st.LoginCodeHash != nil && *st.LoginCodeHash == codeHash && st.LoginCodeUsed == nil {
+			now := time.Now()
+			st.LoginCodeUsed = &now
+			return *st.UserID, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+type oidcFixture struct {
+	*testAuth
+	idp    *mockIdP
+	states *fakeOIDCStateRepo
+	svc    *OIDCService
+}
+
+func newOIDCFixture(t *testing.T) *oidcFixture {
+	t.Helper()
+	f := &oidcFixture{testAuth: newTestAuth(t), idp: newMockIdP(t), states: &fakeOIDCStateRepo{}}
+	f.svc = NewOIDCService(f.testAuth.svc, f.users, f.states, models.OIDCConfig{
+		IssuerURL:   f.idp.srv.URL,
+		ClientID:    testOIDCClientID,
+		RedirectURL: "http://app.local/auth/oidc/callback",
+		Scopes:      []string{"openid", "email", "profile", "offline_access"},
+		GroupsClaim: "groups",
+		RoleGroups: []models.OIDCRoleGroups{
+			{Role: models.RoleAdministrator, Groups: []string{"vector-admins"}},
+			{Role: models.RolePodft, Groups: []string{"vector-podft"}},
+			{Role: models.RoleClientManagement, Groups: []string{"vector-clients"}},
+		},
+		StateTTL: 5 * time.Minute,
+	}, f.auditSvc)
+	return f
+}
+
+// login проходит весь вход: BeginLogin, страница провайдера, Callback
+func (f *oidcFixture) login(t *testing.T, grant *idpGrant) (*models.LoginResponse, error) {
+	t.Helper()
+	authURL, err := f.svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := f.idp.authorize(authURL, grant)
+	_, resp, err := f.svc.Callback(context.Background(), models.AuditActor{IP: "10.0.0.1"}, code, state)
+	return resp, err
+}
+
+func TestOIDCBeginLogin(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	authURL, err := f.svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != f.idp.srv.URL+"/authorize" {
+		t.Fatalf("authorize endpoint = %s", got)
+	}
+
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testOIDCClientID,
+		"redirect_uri":          "http://app.local/auth/oidc/callback",
+		"scope":                 "openid email profile offline_access",
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+		}
+	}
+
+	if len(f.states.states) != 1 {
+		t.Fatalf("stored %d login states, want 1", len(f.states.states))
+	}
+	st := f.states.states[0]
+	if q.Get("state") == "" || st.StateHash != hashOIDCValue(q.Get("state")) {
+		t.Error("state must be stored hashed")
+	}
+	if q.Get("nonce") == "" || st.Nonce != q.Get("nonce") {
+		t.Error("nonce must be stored for the callback")
+	}
+	sum := sha256.Sum256([]byte(st.CodeVerifier))
+	if q.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Error("code_challenge is not S256 of the stored code_verifier")
+	}
+	if strings.Contains(authURL, st.CodeVerifier) {
+		t.Error("code_verifier must not leave the server")
+	}
+
+	// каждый вход получает свои state и nonce
+	second, err := f.svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u2, _ := url.Parse(second)
+	if u2.Query().Get("state") == q.Get("state") || u2.Query().Get("nonce") == q.Get("nonce") {
+		t.Error("state and nonce must be random per login")
+	}
+}
+
+func TestOIDCCallbackProvisionsUser(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	resp, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-1", "Ivan.Petrov@Example.com", "vector-podft")})
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if resp == nil || resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("login response without tokens: %+v", resp)
+	}
+
+	user, err := f.users.GetByExternalSubject("sub-1")
+	if err != nil {
+		t.Fatalf("user not provisioned: %v", err)
+	}
+	if user.Email != "ivan.petrov@example.com" || user.Role != models.RolePodft || !user.IsActive ||
+		user.AuthProvider != models.AuthProviderOIDC || user.FirstName != "Иван" || user.LastName != "Петров" {
+		t.Fatalf("provisioned user = %+v", user)
+	}
+	if user.CheckPassword("") || user.CheckPassword("!oidc") {
+		t.Fatal("SSO user must not have a usable local password")
+	}
+	if user.ExternalRefreshToken == "" || strings.HasPrefix(user.ExternalRefreshToken, "rt-") {
+		t.Fatalf("provider refresh token must be stored encrypted, got %q", user.ExternalRefreshToken)
+	}
+
+	// повторный вход находит пользователя по sub, а не создает нового
+	if _, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-1", "ivan.petrov@example.com", "vector-podft")}); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if n := len(f.users.users); n != 1 {
+		t.Fatalf("%d users after second login, want 1", n)
+	}
+}
+
+func TestOIDCCallbackStateAndPKCE(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+
+	authURL, err := f.svc.BeginLogin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state := f.idp.authorize(authURL, &idpGrant{claims: f.idp.claims("sub-1", "a@example.com", "vector-podft")})
+
+	if _, _, err := f.svc.Callback(ctx, models.AuditActor{}, code, "forged-state"); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("unknown state: got %v, want ErrOIDCState", err)
+	}
+	if _, _, err := f.svc.Callback(ctx, models.AuditActor{}, code, state); err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	// state одноразовый
+	if _, _, err := f.svc.Callback(ctx, models.AuditActor{}, code, state); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("replayed state: got %v, want ErrOIDCState", err)
+	}
+
+	// провайдер отклоняет код, если code_verifier не соответствует code_challenge
+	authURL, _ = f.svc.BeginLogin(ctx)
+	code, state = f.idp.authorize(authURL, &idpGrant{claims: f.idp.claims("sub-2", "b@example.com", "vector-podft")})
+	f.states.states[len(f.states.states)-1].CodeVerifier = "tampered"
+	if _, _, err := f.svc.Callback(ctx, models.AuditActor{}, code, state); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("wrong code_verifier: got %v, want ErrOIDCState", err)
+	}
+
+	// просроченная попытка входа
+	authURL, _ = f.svc.BeginLogin(ctx)
+	code, state = f.idp.authorize(authURL, &idpGrant{claims: f.idp.claims("sub-3", "c@example.com", "vector-podft")})
+	f.states.states[len(f.states.states)-1].ExpiresAt = time.Now().Add(-time.Second)
+	if _, _, err := f.svc.Callback(ctx, models.AuditActor{}, code, state); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("expired state: got %v, want ErrOIDCState", err)
+	}
+}
+
+func TestOIDCCallbackLoginCode(t *testing.T) {
+	f := newOIDCFixture(t)
+	f.svc.cfg.FrontendRedirectURL = "https://ui.local/sso?from=idp"
+	ctx := context.Background()
+
+	authURL, _ := f.svc.BeginLogin(ctx)
+	code, state := f.idp.authorize(authURL, &idpGrant{claims: f.idp.claims("sub-1", "a@example.com", "vector-clients")})
+	loginCode, resp, err := f.svc.Callback(ctx, models.AuditActor{}, code, state)
+	if err != nil || resp != nil || loginCode == "" {
+		t.Fatalf("callback with frontend redirect: code=%q resp=%v err=%v", loginCode, resp, err)
+	}
+	if got := f.svc.FrontendRedirect(loginCode); got != "https://ui.local/sso?from=idp&login_code="+url.QueryEscape(loginCode) {
+		t.Fatalf("frontend redirect = %s", got)
+	}
+
+	tokens, err := f.svc.ExchangeLoginCode(models.AuditActor{}, loginCode)
+	if err != nil || tokens.Token == "" {
+		t.Fatalf("exchange login code: %v", err)
+	}
+	if _, err := f.svc.ExchangeLoginCode(models.AuditActor{}, loginCode); !errors.Is(err, ErrOIDCLoginCode) {
+		t.Fatalf("reused login code: got %v, want ErrOIDCLoginCode", err)
+	}
+}
+
+func TestOIDCCallbackRejectsInvalidIDToken(t *testing.T) {
+	f := newOIDCFixture(t)
+	otherKeys, err := signing.Ephemeral()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		edit  func(c jwt.MapClaims)
+		sign  func(c jwt.MapClaims) string
+		nonce string
+	}{
+		{name: "bad iss", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }},
+		{name: "bad aud", edit: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "multiple aud without azp", edit: func(c jwt.MapClaims) { c["aud"] = []string{testOIDCClientID, "another-client"} }},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Minute).Unix() }},
+		{name: "no exp", edit: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "no sub", edit: func(c jwt.MapClaims) { delete(c, "sub") }},
+		{name: "nonce mismatch", edit: func(c jwt.MapClaims) { c["nonce"] = "replayed-nonce" }},
+		{name: "alg HS256", sign: func(c jwt.MapClaims) string {
+			tok := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
+			tok.Header["kid"] = f.idp.keys.ActiveKID()
+			raw, _ := tok.SignedString([]byte("shared-secret"))
+			return raw
+		}},
+		{name: "alg none", sign: func(c jwt.MapClaims) string {
+			raw, _ := jwt.NewWithClaims(jwt.SigningMethodNone, c).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return raw
+		}},
+		{name: "foreign key", sign: func(c jwt.MapClaims) string {
+			raw, _ := otherKeys.Sign(c)
+			return raw
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := f.idp.claims("sub-"+tc.name, "user@example.com", "vector-admins")
+			if tc.edit != nil {
+				tc.edit(claims)
+			}
+			_, err := f.login(t, &idpGrant{claims: claims, sign: tc.sign})
+			if !errors.Is(err, oidc.ErrInvalidToken) {
+				t.Fatalf("got %v, want oidc.ErrInvalidToken", err)
+			}
+			if n := len(f.users.users); n != 0 {
+				t.Fatalf("user provisioned from a rejected token")
+			}
+		})
+	}
+}
+
+func TestOIDCGroupRoleMapping(t *testing.T) {
+	cases := []struct {
+		groups []string
+		role   string
+	}{
+		{[]string{"vector-clients"}, models.RoleClientManagement},
+		{[]string{"vector-podft"}, models.RolePodft},
+		{[]string{"VECTOR-ADMINS"}, models.RoleAdministrator},
+		// при нескольких группах — старшая роль
+		{[]string{"vector-clients", "vector-admins", "vector-podft"}, models.RoleAdministrator},
+		{[]string{"vector-clients", "vector-podft"}, models.RolePodft},
+		{[]string{"staff"}, ""},
+		{nil, ""},
+	}
+	for _, tc := range cases {
+		f := newOIDCFixture(t)
+		_, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-1", "u@example.com", tc.groups...)})
+		if tc.role == "" {
+			if !errors.Is(err, ErrOIDCNoRole) {
+				t.Errorf("groups %v: got %v, want ErrOIDCNoRole", tc.groups, err)
+			}
+			if len(f.users.users) != 0 {
+				t.Errorf("groups %v: user without role was provisioned", tc.groups)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("groups %v: %v", tc.groups, err)
+			continue
+		}
+		if u, _ := f.users.GetByExternalSubject("sub-1"); u.Role != tc.role {
+			t.Errorf("groups %v: role %q, want %q", tc.groups, u.Role, tc.role)
+		}
+	}
+
+	// группы в виде строки через запятую или пробел
+	f := newOIDCFixture(t)
+	claims := f.idp.claims("sub-1", "u@example.com")
+	claims["groups"] = "staff, vector-podft"
+	if _, err := f.login(t, &idpGrant{claims: claims}); err != nil {
+		t.Fatalf("string groups claim: %v", err)
+	}
+
+	// при входе роль приводится к группам, без групп пользователь деактивируется
+	if _, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-1", "u@example.com", "vector-clients")}); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := f.users.GetByExternalSubject("sub-1")
+	if u.Role != models.RoleClientManagement {
+		t.Fatalf("role after group change = %q", u.Role)
+	}
+	if _, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-1", "u@example.com", "staff")}); !errors.Is(err, ErrOIDCNoRole) {
+		t.Fatalf("login without groups: got %v, want ErrOIDCNoRole", err)
+	}
+	if u, _ := f.users.GetByExternalSubject("sub-1"); u.IsActive {
+		t.Fatal("user who lost all groups must be deactivated")
+	}
+}
+
+func TestOIDCEmailLinking(t *testing.T) {
+	f := newOIDCFixture(t)
+	admin := f.users.add(models.AppUser{
+		Email: "admin@example.com", Role: models.RoleAdministrator, IsActive: true,
+		AuthProvider: models.AuthProviderLocal, PasswordHash: "local-hash",
+	})
+
+	for name, edit := range map[string]func(c jwt.MapClaims){
+		"email_verified false":  func(c jwt.MapClaims) { c["email_verified"] = false },
+		"email_verified absent": func(c jwt.MapClaims) { delete(c, "email_verified") },
+		"email_verified string": func(c jwt.MapClaims) { c["email_verified"] = "true" },
+		"no email":              func(c jwt.MapClaims) { delete(c, "email") },
+	} {
+		claims := f.idp.claims("attacker-"+name, "admin@example.com", "vector-admins")
+		edit(claims)
+		if _, err := f.login(t, &idpGrant{claims: claims}); !errors.Is(err, ErrOIDCEmail) {
+			t.Errorf("%s: got %v, want ErrOIDCEmail", name, err)
+		}
+		if u := f.users.get(admin.ID); u.ExternalSubject != nil || u.AuthProvider != models.AuthProviderLocal {
+			t.Fatalf("%s: local account was linked to an unverified SSO identity", name)
+		}
+	}
+	if n := len(f.users.users); n != 1 {
+		t.Fatalf("%d users, unverified logins must not create accounts", n)
+	}
+
+	// подтвержденный email связывает существующую локальную учетную запись
+	if _, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-admin", "admin@example.com", "vector-admins")}); err != nil {
+		t.Fatalf("verified login: %v", err)
+	}
+	u := f.users.get(admin.ID)
+	if u.ExternalSubject == nil || *u.ExternalSubject != "sub-admin" || u.AuthProvider != models.AuthProviderOIDC {
+		t.Fatalf("verified email did not link the account: %+v", u)
+	}
+
+	// связанный пользователь находится по sub и без email_verified
+	claims := f.idp.claims("sub-admin", "admin@example.com", "vector-admins")
+	delete(claims, "email_verified")
+	if _, err := f.login(t, &idpGrant{claims: claims}); err != nil {
+		t.Fatalf("login of linked user without email_verified: %v", err)
+	}
+
+	// email занят другим субъектом провайдера
+	if _, err := f.login(t, &idpGrant{claims: f.idp.claims("sub-other", "admin@example.com", "vector-admins")}); !errors.Is(err, ErrOIDCState) {
+		t.Fatalf("email of another subject: got %v, want ErrOIDCState", err)
+	}
+}
+
+func TestOIDCReconcile(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+
+	grants := map[string]*idpGrant{}
+	for sub, group := range map[string]string{
+		"removed":   "vector-podft",
+		"no-groups": "vector-podft",
+		"promoted":  "vector-clients",
+		"unchanged": "vector-podft",
+	} {
+		grants[sub] = &idpGrant{claims: f.idp.claims(sub, sub+"@example.com", group)}
+		if _, err := f.login(t, grants[sub]); err != nil {
+			t.Fatalf("login %s: %v", sub, err)
+		}
+	}
+	// пользователь без сохраненного refresh-токена пропускается
+	noToken := "no-token"
+	f.users.add(models.AppUser{Email: "no-token@example.com", Role: models.RolePodft, IsActive: true,
+		AuthProvider: models.AuthProviderOIDC, ExternalSubject: &noToken})
+	// локальные пользователи не сверяются
+	f.users.add(models.AppUser{Email: "local@example.com", Role: models.RolePodft, IsActive: true, AuthProvider: models.AuthProviderLocal})
+
+	grants["removed"].revoked = true
+	grants["no-groups"].claims["groups"] = []string{"staff"}
+	grants["promoted"].claims["groups"] = []string{"vector-podft"}
+
+	result, err := f.svc.Reconcile(ctx, models.SystemActor("oidc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.OIDCReconcileResult{Checked: 5, Deactivated: 2, RoleChanged: 1, Skipped: 1}
+	if result != want {
+		t.Fatalf("result = %+v, want %+v", result, want)
+	}
+
+	for sub, active := range map[string]bool{"removed": false, "no-groups": false, "promoted": true, "unchanged": true} {
+		u, _ := f.users.GetByExternalSubject(sub)
+		if u.IsActive != active {
+			t.Errorf("%s: active = %v, want %v", sub, u.IsActive, active)
+		}
+	}
+	if u, _ := f.users.GetByExternalSubject("promoted"); u.Role != models.RolePodft {
+		t.Errorf("promoted: role = %q", u.Role)
+	}
+}
+
+func TestOIDCReconcileWithoutRefreshTokenRotation(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+
+	grant := &idpGrant{claims: f.idp.claims("sub-1", "u@example.com", "vector-podft")}
+	if _, err := f.login(t, grant); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := f.users.GetByExternalSubject("sub-1")
+	stored := u.ExternalRefreshToken
+
+	// провайдер без ротации: ответ на refresh без refresh_token не затирает сохраненный токен
+	f.idp.noRotation = true
+	for i := 0; i < 2; i++ {
+		result, err := f.svc.Reconcile(ctx, models.SystemActor("oidc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Checked != 1 || result.Skipped != 0 || result.Failed != 0 {
+			t.Fatalf("run %d: result = %+v", i+1, result)
+		}
+	}
+	u, _ = f.users.GetByExternalSubject("sub-1")
+	if u.ExternalRefreshToken != stored {
+		t.Fatal("stored refresh token was overwritten by an empty one")
+	}
+	if u.ExternalCheckedAt == nil {
+		t.Fatal("successful check must be recorded")
+	}
+
+	// удаление у провайдера по-прежнему обнаруживается
+	grant.revoked = true
+	result, err := f.svc.Reconcile(ctx, models.SystemActor("oidc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deactivated != 1 {
+		t.Fatalf("result = %+v, want the user deactivated", result)
+	}
+	if u, _ := f.users.GetByExternalSubject("sub-1"); u.IsActive {
+		t.Fatal("user removed from the IdP is still active")
+	}
+}
+
+func TestOIDCCustomRoleMapping(t *testing.T) {
+	f := newOIDCFixture(t)
+	ctx := context.Background()
+	f.roles.perms["Auditor"] = []string{models.PermAuditRead}
+	f.testAuth.svc.roles.invalidate()
+	// пользовательская роль ниже администратора, но выше остальных встроенных
+	f.svc.cfg.RoleGroups = []models.OIDCRoleGroups{
+		{Role: models.RoleAdministrator, Groups: []string{"vector-admins"}},
+		{Role: "Auditor", Groups: []string{"vector-audit", "vector-audit-ext"}},
+		{Role: models.RolePodft, Groups: []string{"vector-podft"}},
+	}
+	if err := f.svc.CheckRoleMapping(); err != nil {
+		t.Fatalf("CheckRoleMapping: %v", err)
+	}
+
+	grant := &idpGrant{claims: f.idp.claims("sub-audit", "audit@example.com", "vector-podft", "vector-audit-ext")}
+	if _, err := f.login(t, grant); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if u, _ := f.users.GetByExternalSubject("sub-audit"); u.Role != "Auditor" {
+		t.Fatalf("role after login = %q, want Auditor", u.Role)
+	}
+
+	// сверка сохраняет пользовательскую роль и не деактивирует пользователя
+	result, err := f.svc.Reconcile(ctx, models.SystemActor("oidc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deactivated != 0 || result.RoleChanged != 0 || result.Failed != 0 {
+		t.Fatalf("reconcile = %+v, want the custom role kept", result)
+	}
+	if u, _ := f.users.GetByExternalSubject("sub-audit"); u.Role != "Auditor" || !u.IsActive {
+		t.Fatalf("after reconcile: role %q active %v", u.Role, u.IsActive)
+	}
+
+	// группы пользовательской роли сняты — роль понижается до встроенной
+	grant.claims["groups"] = []string{"vector-podft"}
+	if result, _ := f.svc.Reconcile(ctx, models.SystemActor("oidc")); result.RoleChanged != 1 {
+		t.Fatalf("reconcile = %+v, want the role changed", result)
+	}
+	if u, _ := f.users.GetByExternalSubject("sub-audit"); u.Role != models.RolePodft {
+		t.Fatalf("role = %q, want %s", u.Role, models.RolePodft)
+	}
+
+	// удаленная роль пропускается, пользователь получает следующую по порядку
+	grant.claims["groups"] = []string{"vector-podft", "vector-audit"}
+	delete(f.roles.perms, "Auditor")
+	f.testAuth.svc.roles.invalidate()
+	if result, _ := f.svc.Reconcile(ctx, models.SystemActor("oidc")); result.Deactivated != 0 || result.Failed != 0 {
+		t.Fatalf("reconcile after role deletion = %+v", result)
+	}
+	if u, _ := f.users.GetByExternalSubject("sub-audit"); u.Role != models.RolePodft || !u.IsActive {
+		t.Fatalf("after role deletion: role %q active %v", u.Role, u.IsActive)
+	}
+
+	// роль, которой нет в справочнике, останавливает запуск
+	f.svc.cfg.RoleGroups = append(f.svc.cfg.RoleGroups, models.OIDCRoleGroups{Role: "Ghost", Groups: []string{"g"}})
+	if err := f.svc.CheckRoleMapping(); err == nil {
+		t.Fatal("unknown role accepted in the mapping")
+	}
+}
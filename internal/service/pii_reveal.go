@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"vector/internal/models"
+	"vector/internal/pkg/masking"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrRevealForbidden = errors.New("field is hidden for your role and cannot be revealed")
+	ErrRevealInvalid   = errors.New("invalid reveal request")
+)
+
+func optIntString(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+// revealValues значения полей политики из версии клиента; адрес раскрывается по частям
+func revealValues(cv models.ClientVersion, field string) map[string]string {
+	switch field {
+	case masking.FieldPassSeries:
+		return map[string]string{field: cv.PassSeries}
+	case masking.FieldPassNumber:
+		return map[string]string{field: cv.PassNumber}
+	case masking.FieldPassIssuer:
+		return map[string]string{field: cv.PassIssuer}
+	case masking.FieldPassIssuerCode:
+		return map[string]string{field: cv.PassIssuerCode}
+	case masking.FieldPassIssueDate:
+		return map[string]string{field: cv.PassIssueDate}
+	case masking.FieldInn:
+		return map[string]string{field: cv.Inn}
+	case masking.FieldSnils:
+		return map[string]string{field: cv.Snils}
+	case masking.FieldMainPhone:
+		return map[string]string{field: cv.MainPhone}
+	case masking.FieldContactEmail:
+		return map[string]string{field: cv.ContactEmail}
+	case masking.FieldBirthday:
+		return map[string]string{field: cv.Birthday}
+	case masking.FieldBirthPlace:
+		return map[string]string{field: cv.BirthPlace}
+	case masking.FieldAddress:
+		return map[string]string{
+			"index":  optIntString(cv.Index),
+			"street": cv.Street,
+			"house":  cv.House,
+			"corps":  optIntString(cv.Corps),
+			"flat":   optIntString(cv.Flat),
+		}
+	}
+	return nil
+}
+
+// RevealClientFields возвращает полные значения замаскированных полей текущей версии клиента.
+// Каждое раскрытие записывается в журнал; скрытые политикой пользователя поля раскрыть нельзя.
+func (s *AppService) RevealClientFields(actor models.AuditActor, policy masking.Policy, clientID int, fields []string, reason string) (models.ClientVersion, map[string]string, error) {
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) < 5 {
+		return models.ClientVersion{}, nil, fmt.Errorf("%w: reason must contain at least 5 characters", ErrRevealInvalid)
+	}
+	if len(fields) == 0 {
+		return models.ClientVersion{}, nil, fmt.Errorf("%w: fields are required", ErrRevealInvalid)
+	}
+
+	for _, f := range fields {
+		if revealValues(models.ClientVersion{}, f) == nil {
+			return models.ClientVersion{}, nil, fmt.Errorf("%w: unsupported field %q (allowed: %s)", ErrRevealInvalid, f, strings.Join(masking.Fields, ", "))
+		}
+		if policy.Mode(f) == masking.ModeHidden {
+			return models.ClientVersion{}, nil, fmt.Errorf("%w: %s", ErrRevealForbidden, f)
+		}
+	}
+
+	cv, err := s.clientRepo.GetCurrent(clientID)
+	if err != nil {
+		return cv, nil, err
+	}
+
+	var userID uint
+	if actor.UserID != nil {
+		userID = *actor.UserID
+	}
+	fieldsJSON, _ := json.Marshal(fields)
+	if _, err := s.piiRevealRepo.Create(models.PiiRevealLog{
+		UserID:        userID,
+		UserEmail:     actor.Email,
+		UserRole:      actor.Role,
+		ClientID:      clientID,
+		ClientVersion: cv.Version,
+		Fields:        datatypes.JSON(fieldsJSON),
+		Reason:        reason,
+		IP:            actor.IP,
+	}); err != nil {
+		// без записи в журнал данные не раскрываем
+		return cv, nil, fmt.Errorf("failed to write reveal log: %w", err)
+	}
+	if err := s.audit.Record(actor, AuditEntry{
+		Action:     models.AuditActionClientPiiRevealed,
+		EntityType: models.AuditEntityClient,
+		EntityID:   strconv.Itoa(clientID),
+		Metadata: map[string]any{
+			"client_version": cv.Version,
+			"fields":         fields,
+			"reason":         reason,
+		},
+	}); err != nil {
+		return cv, nil, fmt.Errorf("failed to write audit event: %w", err)
+	}
+
+	out := make(map[string]string)
+	for _, f := range fields {
+		for k, v := range revealValues(cv, f) {
+			out[k] = v
+		}
+	}
+	return cv, out, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"vector/internal/models"
+	"vector/internal/pkg/jsonschema"
+	"vector/internal/repository"
+)
+
+// QuestionnaireInvalidError анкета второй части не соответствует схеме
+type QuestionnaireInvalidError struct {
+	SchemaVersion int
+	Errors        []jsonschema.ValidationError
+}
+
+func (e *QuestionnaireInvalidError) Error() string {
+	return fmt.Sprintf("second part data does not match questionnaire schema v%d (%d errors)", e.SchemaVersion, len(e.Errors))
+}
+
+// QuestionnaireService версии схемы анкеты второй части. Версии не изменяются,
+// поэтому скомпилированные схемы кэшируются по номеру.
+type QuestionnaireService struct {
+	repo  repository.QuestionnaireSchemaRepository
+	audit *AuditService
+
+	mu       sync.Mutex
+	compiled map[int]*jsonschema.Schema
+}
+
+func NewQuestionnaireService(repo repository.QuestionnaireSchemaRepository, audit *AuditService) *QuestionnaireService {
+	return &QuestionnaireService{repo: repo, audit: audit, compiled: map[int]*jsonschema.Schema{}}
+}
+
+// Current текущая (последняя) версия схемы
+func (s *QuestionnaireService) Current() (models.QuestionnaireSchema, error) {
+	return s.repo.Current()
+}
+
+func (s *QuestionnaireService) Get(version int) (models.QuestionnaireSchema, error) {
+	return s.repo.Get(version)
+}
+
+// List версии схемы без содержимого, новые первыми
+func (s *QuestionnaireService) List() ([]models.QuestionnaireSchemaInfo, error) {
+	all, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.QuestionnaireSchemaInfo, 0, len(all))
+	for i, v := range all {
+		out = append(out, models.QuestionnaireSchemaInfo{
+			Version:         v.Version,
+			Title:           v.Title,
+			Comment:         v.Comment,
+			CreatedByUserID: v.CreatedByUserID,
+			CreatedAt:       v.CreatedAt,
+			IsCurrent:       i == 0,
+		})
+	}
+	return out, nil
+}
+
+// Create публикует новую версию схемы; она сразу становится текущей. Схема должна
+// компилироваться (поддерживаемое подмножество JSON Schema, см. internal/pkg/jsonschema).
+func (s *QuestionnaireService) Create(actor models.AuditActor, req models.CreateQuestionnaireSchemaRequest) (models.QuestionnaireSchema, error) {
+	if len(req.Schema) == 0 {
+		return models.QuestionnaireSchema{}, fmt.Errorf("%w: schema is required", models.ErrInvalidQuestionnaireSchema)
+	}
+	raw, err := json.Marshal(req.Schema)
+	if err != nil {
+		return models.QuestionnaireSchema{}, fmt.Errorf("%w: %v", models.ErrInvalidQuestionnaireSchema, err)
+	}
+	compiled, err := jsonschema.Compile(raw)
+	if err != nil {
+		return models.QuestionnaireSchema{}, fmt.Errorf("%w: %v", models.ErrInvalidQuestionnaireSchema, err)
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		if t, ok := req.Schema["title"].(string); ok {
+			title = strings.TrimSpace(t)
+		}
+	}
+
+	v, err := s.repo.Create(models.QuestionnaireSchema{
+		Title:           title,
+		Schema:          raw,
+		Comment:         strings.TrimSpace(req.Comment),
+		CreatedByUserID: actorUserID(actor),
+	})
+	if err != nil {
+		return v, err
+	}
+
+	s.mu.Lock()
+	s.compiled[v.Version] = compiled
+	s.mu.Unlock()
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionQuestionnaire,
+		EntityType: models.AuditEntitySchema,
+		EntityID:   strconv.Itoa(v.Version),
+		After: map[string]any{
+			"version": v.Version,
+			"title":   v.Title,
+			"comment": v.Comment,
+		},
+	})
+	return v, nil
+}
+
+// Validate проверяет анкету по текущей схеме и возвращает номер версии, по которой проверено
+func (s *QuestionnaireService) Validate(data []byte) (int, []jsonschema.ValidationError, error) {
+	cur, err := s.repo.Current()
+	if err != nil {
+		return 0, nil, err
+	}
+	compiled, err := s.compile(cur)
+	if err != nil {
+		return cur.Version, nil, err
+	}
+	if len(data) == 0 {
+		data = []byte(`{}`)
+	}
+	return cur.Version, compiled.Validate(data), nil
+}
+
+func (s *QuestionnaireService) compile(v models.QuestionnaireSchema) (*jsonschema.Schema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.compiled[v.Version]; ok {
+		return c, nil
+	}
+	c, err := jsonschema.Compile(v.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("%w: version %d: %v", models.ErrInvalidQuestionnaireSchema, v.Version, err)
+	}
+	s.compiled[v.Version] = c
+	return c, nil
+}
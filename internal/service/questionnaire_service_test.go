@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"vector/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// seedQuestionnaire публикует схему из миграции, как MigrateCoreQuestionnaireSchemas
+func seedQuestionnaire(t *testing.T, repo *fakeQuestionnaireRepo) {
+	t.Helper()
+	raw, err := os.ReadFile("../migrations/questionnaire_v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Create(models.QuestionnaireSchema{Title: "Анкета второй части", Schema: raw}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuestionnaireValidate(t *testing.T) {
+	repo := &fakeQuestionnaireRepo{}
+	audit := &fakeAuditRepo{}
+	svc := NewQuestionnaireService(repo, NewAuditService(audit))
+
+	if _, _, err := svc.Validate([]byte(`{}`)); !errors.Is(err, models.ErrNoQuestionnaireSchema) {
+		t.Fatalf("no schema: got %v, want ErrNoQuestionnaireSchema", err)
+	}
+
+	seedQuestionnaire(t, repo)
+	version, violations, err := svc.Validate(nil)
+	if err != nil || version != 1 {
+		t.Fatalf("Validate(nil) = v%d, %v", version, err)
+	}
+	want := []string{"/business_relationship_purpose", "/financial_position", "/source_of_funds"}
+	if len(violations) != len(want) {
+		t.Fatalf("violations = %v, want required %v", violations, want)
+	}
+	for i, v := range violations {
+		if v.Path != want[i] || v.Message != "is required" {
+			t.Errorf("violation %d = %v, want %s is required", i, v, want[i])
+		}
+	}
+
+	_, violations, _ = svc.Validate([]byte(`{"business_relationship_purpose":"x","source_of_funds":"x",
+		"financial_position":"rich","beneficial_owner":{"share_percent":"10"}}`))
+	if len(violations) != 2 || violations[0].Path != "/beneficial_owner/share_percent" || violations[1].Path != "/financial_position" {
+		t.Fatalf("type and enum violations = %v", violations)
+	}
+
+	// новая версия сразу становится текущей
+	v2, err := svc.Create(models.SystemActor("test"), models.CreateQuestionnaireSchemaRequest{
+		Schema:  map[string]any{"title": "Анкета v2", "type": "object", "required": []any{"purpose"}},
+		Comment: "упрощенная",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if v2.Version != 2 || v2.Title != "Анкета v2" {
+		t.Fatalf("published %+v", v2)
+	}
+	version, violations, _ = svc.Validate([]byte(`{"purpose":"x"}`))
+	if version != 2 || len(violations) != 0 {
+		t.Fatalf("Validate against v2 = v%d %v", version, violations)
+	}
+	if a := audit.actions(); len(a) != 1 || a[0] != models.AuditActionQuestionnaire {
+		t.Fatalf("audit = %v", a)
+	}
+
+	list, _ := svc.List()
+	if len(list) != 2 || list[0].Version != 2 || !list[0].IsCurrent || list[1].IsCurrent {
+		t.Fatalf("List() = %+v", list)
+	}
+}
+
+func TestQuestionnaireCreateRejectsInvalidSchema(t *testing.T) {
+	repo := &fakeQuestionnaireRepo{}
+	svc := NewQuestionnaireService(repo, NewAuditService(&fakeAuditRepo{}))
+
+	cases := map[string]map[string]any{
+		"empty":               nil,
+		"unsupported keyword": {"type": "object", "oneOf": []any{}},
+		"unknown type":        {"type": "date"},
+		"bad property":        {"properties": map[string]any{"a": map[string]any{"minLength": -1}}},
+	}
+	for name, schema := range cases {
+		_, err := svc.Create(models.SystemActor("test"), models.CreateQuestionnaireSchemaRequest{Schema: schema})
+		if !errors.Is(err, models.ErrInvalidQuestionnaireSchema) {
+			t.Errorf("%s: got %v, want ErrInvalidQuestionnaireSchema", name, err)
+		}
+	}
+	if len(repo.versions) != 0 {
+		t.Fatal("an invalid schema was published")
+	}
+}
+
+func TestQuestionnaireUnknownVersion(t *testing.T) {
+	repo := &fakeQuestionnaireRepo{}
+	svc := NewQuestionnaireService(repo, NewAuditService(&fakeAuditRepo{}))
+	seedQuestionnaire(t, repo)
+
+	for _, v := range []int{0, 2, 99} {
+		if _, err := svc.Get(v); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("Get(%d): got %v, want gorm.ErrRecordNotFound", v, err)
+		}
+	}
+
+	// текущая версия, записанная в обход Create и не компилирующаяся, не пропускает анкеты
+	repo.Create(models.QuestionnaireSchema{Schema: datatypes.JSON(`{"$ref":"#/defs/a"}`)})
+	if _, _, err := svc.Validate([]byte(`{}`)); !errors.Is(err, models.ErrInvalidQuestionnaireSchema) {
+		t.Fatalf("broken current schema: got %v, want ErrInvalidQuestionnaireSchema", err)
+	}
+}
+
+func TestSecondPartDraftLenientSubmitStrict(t *testing.T) {
+	schemas := &fakeQuestionnaireRepo{}
+	seedQuestionnaire(t, schemas)
+	auditSvc := NewAuditService(&fakeAuditRepo{})
+	clients := &fakeClientRepo{sp: models.SecondPartVersion{ClientID: 7, Version: 3, Status: "draft", Data: datatypes.JSON(`{}`)}}
+	svc := NewAppService(clients, nil, nil, nil, nil, auditSvc, nil, NewQuestionnaireService(schemas, auditSvc))
+	actor := models.SystemActor("test")
+
+	// неполный черновик сохраняется, нарушения возвращаются
+	sp, violations, err := svc.PatchSecondPartDraft(actor, 7, 3, 0, []byte(`{"business_relationship_purpose":"инвестиции","financial_position":"rich"}`))
+	if err != nil {
+		t.Fatalf("patch incomplete draft: %v", err)
+	}
+	if sp.Revision != 1 || sp.SchemaVersion == nil || *sp.SchemaVersion != 1 {
+		t.Fatalf("draft not saved: %+v", sp)
+	}
+	if len(violations) != 2 || violations[0].Path != "/financial_position" || violations[1].Path != "/source_of_funds" {
+		t.Fatalf("draft violations = %v", violations)
+	}
+
+	// отправка требует соответствия схеме
+	_, err = svc.SubmitSecondPart(actor, 7)
+	var invalid *QuestionnaireInvalidError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("submit invalid draft: got %v, want QuestionnaireInvalidError", err)
+	}
+	if invalid.SchemaVersion != 1 || len(invalid.Errors) != 2 {
+		t.Fatalf("submit errors = %+v", invalid)
+	}
+	if clients.submitted != nil || clients.sp.Status != "draft" {
+		t.Fatal("invalid draft was submitted")
+	}
+
+	if _, _, err := svc.PatchSecondPartDraft(actor, 7, 3, 1, []byte(`{"financial_position":"stable","source_of_funds":"зарплата"}`)); err != nil {
+		t.Fatal(err)
+	}
+	sp, err = svc.SubmitSecondPart(actor, 7)
+	if err != nil {
+		t.Fatalf("submit valid draft: %v", err)
+	}
+	if sp.Status != "submitted" || clients.submitted == nil || *clients.submitted != 1 {
+		t.Fatalf("submitted %+v with schema %v", sp, clients.submitted)
+	}
+
+	// отправленная версия не редактируется и повторно не отправляется
+	if _, err := svc.SubmitSecondPart(actor, 7); !errors.Is(err, models.ErrSecondPartNotEditable) {
+		t.Fatalf("resubmit: got %v, want ErrSecondPartNotEditable", err)
+	}
+}
+
+func TestSubmitSecondPartRejectsEditAfterValidation(t *testing.T) {
+	schemas := &fakeQuestionnaireRepo{}
+	seedQuestionnaire(t, schemas)
+	auditSvc := NewAuditService(&fakeAuditRepo{})
+	clients := &fakeClientRepo{sp: models.SecondPartVersion{ClientID: 7, Version: 3, Revision: 1, Status: "draft",
+		Data: datatypes.JSON(`{"business_relationship_purpose":"инвестиции","source_of_funds":"зарплата","financial_position":"stable"}`)}}
+	svc := NewAppService(clients, nil, nil, nil, nil, auditSvc, nil, NewQuestionnaireService(schemas, auditSvc))
+	actor := models.SystemActor("test")
+
+	// PATCH между проверкой анкеты и записью отправки удаляет обязательное поле
+	clients.beforeSubmit = func() {
+		clients.beforeSubmit = nil
+		if _, _, err := svc.PatchSecondPartDraft(actor, 7, 3, 1, []byte(`{"source_of_funds":null}`)); err != nil {
+			t.Fatalf("concurrent patch: %v", err)
+		}
+	}
+	if _, err := svc.SubmitSecondPart(actor, 7); !errors.Is(err, models.ErrSecondPartVersionConflict) {
+		t.Fatalf("submit after concurrent edit: got %v, want ErrSecondPartVersionConflict", err)
+	}
+	if clients.submitted != nil || clients.sp.Status != "draft" || clients.sp.Revision != 2 {
+		t.Fatalf("unvalidated revision was submitted: %+v", clients.sp)
+	}
+
+	// повторная отправка проверяет новую правку и отклоняет ее по схеме
+	var invalid *QuestionnaireInvalidError
+	if _, err := svc.SubmitSecondPart(actor, 7); !errors.As(err, &invalid) {
+		t.Fatalf("resubmit: got %v, want QuestionnaireInvalidError", err)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/repository"
+)
+
+// RecalcService пересчет причин second_part_requirements (и признака needs_second_part) по правилам (cron и ручной запуск)
+type RecalcService struct {
+	recalcRepo repository.RecalcRepository
+	jobs       *JobRunner
+	timeout    time.Duration
+}
+
+func NewRecalcService(recalcRepo repository.RecalcRepository, jobs *JobRunner, timeout time.Duration) *RecalcService {
+	return &RecalcService{recalcRepo: recalcRepo, jobs: jobs, timeout: timeout}
+}
+
+type recalcRule struct {
+	reason string
+	run    func(ctx context.Context) (models.RecalcRuleResult, error)
+}
+
+func (s *RecalcService) rules() []recalcRule {
+	return []recalcRule{
+		{models.RequirementQuestionnaireExpired, s.recalcRepo.RecalcQuestionnaireExpired},
+		{models.RequirementDataChanged, s.recalcRepo.RecalcDataChanged},
+		{models.RequirementPassportExpired, s.recalcRepo.RecalcPassportExpiry},
+	}
+}
+
+// Run выполняет все правила как задачу app.recalc с общим таймаутом. Ошибка правила не останавливает
+// остальные: результат содержит отметки успешных правил, ошибка — все неудачные.
+// ErrJobSkipped — пересчет уже выполняется.
+func (s *RecalcService) Run(ctx context.Context, triggeredBy string) (models.RecalcResult, error) {
+	return s.recalc(ctx, func(ctx context.Context, fn func(ctx context.Context) (any, error)) (models.JobRun, error) {
+		return s.jobs.Run(ctx, models.JobAppRecalc, triggeredBy, fn)
+	})
+}
+
+// RunScheduled пересчет по расписанию за слот scheduledAt; ErrJobSlotDone — слот уже выполнен другим экземпляром
+func (s *RecalcService) RunScheduled(ctx context.Context, scheduledAt time.Time) (models.RecalcResult, error) {
+	return s.recalc(ctx, func(ctx context.Context, fn func(ctx context.Context) (any, error)) (models.JobRun, error) {
+		return s.jobs.RunScheduled(ctx, models.JobAppRecalc, scheduledAt, fn)
+	})
+}
+
+func (s *RecalcService) recalc(ctx context.Context, start func(ctx context.Context, fn func(ctx context.Context) (any, error)) (models.JobRun, error)) (models.RecalcResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result := models.RecalcResult{Flagged: map[string]int64{}, Resolved: map[string]int64{}}
+	run, err := start(ctx, func(ctx context.Context) (any, error) {
+		var errs []error
+		for _, rule := range s.rules() {
+			res, err := rule.run(ctx)
+			if err != nil {
+				log.Printf("[recalc] %s error: %v", rule.reason, err)
+				errs = append(errs, fmt.Errorf("%s: %w", rule.reason, err))
+				continue
+			}
+			result.Flagged[rule.reason] = res.Flagged
+			result.Resolved[rule.reason] = res.Resolved
+			result.TotalFlagged += res.Flagged
+		}
+		log.Printf("[recalc] done: flagged=%v resolved=%v total=%d", result.Flagged, result.Resolved, result.TotalFlagged)
+		return map[string]any{"flagged": result.Flagged, "resolved": result.Resolved}, errors.Join(errs...)
+	})
+
+	result.RunID = run.ID
+	result.Instance = run.Instance
+	result.StartedAt = run.StartedAt
+	if run.FinishedAt != nil {
+		result.FinishedAt = *run.FinishedAt
+	}
+	return result, err
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/repository"
+)
+
+// maxReviewIntervalMonths верхняя граница срока пересмотра (10 лет)
+const maxReviewIntervalMonths = 120
+
+var reviewLevelPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// ReviewPolicyService сроки пересмотра второй части по уровням риска
+type ReviewPolicyService struct {
+	repo  repository.ReviewPolicyRepository
+	audit *AuditService
+}
+
+func NewReviewPolicyService(repo repository.ReviewPolicyRepository, audit *AuditService) *ReviewPolicyService {
+	return &ReviewPolicyService{repo: repo, audit: audit}
+}
+
+// List все записи и действующие сегодня сроки по уровням
+func (s *ReviewPolicyService) List() ([]models.ReviewPolicy, map[string]models.ReviewPolicy, error) {
+	all, err := s.repo.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := s.repo.Effective(time.Now().UTC())
+	if err != nil {
+		return nil, nil, err
+	}
+	effective := make(map[string]models.ReviewPolicy, len(current))
+	for _, p := range current {
+		effective[p.RiskLevel] = p
+	}
+	return all, effective, nil
+}
+
+// Create добавляет срок для уровня с даты effective_from (не раньше сегодняшней: прошлые сроки — история).
+// Новый уровень становится допустимым для решения по риску с этой даты. Уже утвержденные вторые части
+// не пересчитываются: для этого есть команда migrate -action=recompute-due-at.
+func (s *ReviewPolicyService) Create(actor models.AuditActor, req models.CreateReviewPolicyRequest) (models.ReviewPolicy, error) {
+	level := strings.ToLower(strings.TrimSpace(req.RiskLevel))
+	if !reviewLevelPattern.MatchString(level) {
+		return models.ReviewPolicy{}, fmt.Errorf("%w: risk_level must be a lowercase code (a-z, 0-9, _)", models.ErrInvalidReviewPolicy)
+	}
+	if req.IntervalMonths < 1 || req.IntervalMonths > maxReviewIntervalMonths {
+		return models.ReviewPolicy{}, fmt.Errorf("%w: interval_months must be between 1 and %d", models.ErrInvalidReviewPolicy, maxReviewIntervalMonths)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today
+	if req.EffectiveFrom != "" {
+		t, err := time.Parse("2006-01-02", req.EffectiveFrom)
+		if err != nil {
+			return models.ReviewPolicy{}, fmt.Errorf("%w: effective_from must be YYYY-MM-DD", models.ErrInvalidReviewPolicy)
+		}
+		if t.Before(today) {
+			return models.ReviewPolicy{}, fmt.Errorf("%w: effective_from cannot be in the past", models.ErrInvalidReviewPolicy)
+		}
+		from = t
+	}
+
+	existing, err := s.repo.List()
+	if err != nil {
+		return models.ReviewPolicy{}, err
+	}
+	for _, p := range existing {
+		if p.RiskLevel == level && p.EffectiveFrom.Format("2006-01-02") == from.Format("2006-01-02") {
+			return models.ReviewPolicy{}, models.ErrReviewPolicyExists
+		}
+	}
+
+	p, err := s.repo.Create(models.ReviewPolicy{
+		RiskLevel:       level,
+		IntervalMonths:  req.IntervalMonths,
+		EffectiveFrom:   from,
+		Comment:         strings.TrimSpace(req.Comment),
+		CreatedByUserID: actorUserID(actor),
+	})
+	if err != nil {
+		return p, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionReviewPolicy,
+		EntityType: models.AuditEntityPolicy,
+		EntityID:   strconv.FormatUint(uint64(p.ID), 10),
+		After: map[string]any{
+			"risk_level":      p.RiskLevel,
+			"interval_months": p.IntervalMonths,
+			"effective_from":  p.EffectiveFrom.Format("2006-01-02"),
+			"comment":         p.Comment,
+		},
+	})
+	return p, nil
+}
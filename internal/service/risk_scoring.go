@@ -0,0 +1,100 @@
+package service
+
+import (
+	"vector/internal/models"
+	"vector/internal/pkg/riskscore"
+	"vector/internal/repository"
+)
+
+// RiskScoringService предлагает уровень риска второй части по правилам riskscore
+type RiskScoringService struct {
+	rules        riskscore.Rules
+	clientRepo   repository.AppClientRepository
+	checkRepo    repository.CheckRepository
+	contractRepo repository.SyncContractRepository
+}
+
+func NewRiskScoringService(
+	rules riskscore.Rules,
+	clientRepo repository.AppClientRepository,
+	checkRepo repository.CheckRepository,
+	contractRepo repository.SyncContractRepository,
+) *RiskScoringService {
+	return &RiskScoringService{rules: rules, clientRepo: clientRepo, checkRepo: checkRepo, contractRepo: contractRepo}
+}
+
+// maxScoredContracts сколько действующих договоров клиента учитывается в фактах
+const maxScoredContracts = 500
+
+// Assess считает предложение по текущей версии клиента, его действующим договорам
+// и последним результатам проверок каждого вида
+func (s *RiskScoringService) Assess(clientID int) (models.RiskAssessment, error) {
+	cv, err := s.clientRepo.GetCurrent(clientID)
+	if err != nil {
+		return models.RiskAssessment{}, err
+	}
+
+	contracts, _, err := s.contractRepo.ListContracts(
+		models.PageRequest{Page: 1, PerPage: maxScoredContracts, TotalMode: models.TotalModeNone},
+		models.ContractListFilter{UserID: &clientID, Status: []string{"active"}},
+	)
+	if err != nil {
+		return models.RiskAssessment{}, err
+	}
+
+	checks, err := s.checkRepo.ListByClient(clientID, nil)
+	if err != nil {
+		return models.RiskAssessment{}, err
+	}
+
+	return s.rules.Score(riskscore.FactsFor(cv, contracts, latestChecks(checks))), nil
+}
+
+// latestChecks последняя проверка каждого вида: повторная проверка заменяет прежний результат
+func latestChecks(checks []models.SecondPartCheck) []models.SecondPartCheck {
+	latest := map[string]models.SecondPartCheck{}
+	for _, ch := range checks {
+		if prev, ok := latest[ch.Kind]; !ok || ch.ID > prev.ID {
+			latest[ch.Kind] = ch
+		}
+	}
+	out := make([]models.SecondPartCheck, 0, len(latest))
+	for _, ch := range latest {
+		out = append(out, ch)
+	}
+	return out
+}
+
+// RiskDecisionInput решение сотрудника по предложенному уровню
+type RiskDecisionInput struct {
+	Level          string // пусто — принять предложение (при создании черновика — отложить решение)
+	OverrideReason string
+	CanLowerHigh   bool // право second_part.approve_high_risk: понизить предложенный high
+}
+
+// decideRisk сопоставляет решение с предложением: совпадение — accepted, иначе — overridden с причиной.
+// Допустимость уровня проверяется при записи: для него должен действовать срок пересмотра.
+func decideRisk(assessment models.RiskAssessment, in RiskDecisionInput, decidedBy *int) (models.SecondPartRisk, error) {
+	risk := models.SecondPartRisk{Assessment: assessment}
+	if in.Level == "" {
+		return risk, nil
+	}
+
+	risk.Level = in.Level
+	risk.DecidedBy = decidedBy
+	if in.Level == assessment.Level {
+		risk.Decision = models.RiskDecisionAccepted
+		return risk, nil
+	}
+
+	if in.OverrideReason == "" {
+		return risk, models.ErrRiskOverrideReason
+	}
+	// уровни вне low/medium/high (заданные только сроками пересмотра) тоже считаются понижением
+	if assessment.Level == models.RiskLevelHigh && models.RiskLevelRank(in.Level) < models.RiskLevelRank(models.RiskLevelHigh) && !in.CanLowerHigh {
+		return risk, models.ErrRiskDowngradeForbidden
+	}
+	risk.Decision = models.RiskDecisionOverridden
+	risk.OverrideReason = in.OverrideReason
+	return risk, nil
+}
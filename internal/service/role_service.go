@@ -0,0 +1,319 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"vector/internal/models"
+	"vector/internal/repository"
+)
+
+var (
+	ErrRoleInvalid = errors.New("некорректные данные роли")
+	ErrRoleSystem  = errors.New("системную роль нельзя удалить, а права администратора изменить")
+)
+
+// права ролей кешируются; изменения на других экземплярах видны не позже чем через rolesCacheTTL
+const rolesCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{1,63}$`)
+
+type RoleService struct {
+	roleRepo repository.RoleRepository
+	audit    *AuditService
+
+	mu       sync.RWMutex
+	perms    map[string]models.PermissionSet
+	loadedAt time.Time
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, audit *AuditService) *RoleService {
+	return &RoleService{roleRepo: roleRepo, audit: audit}
+}
+
+// allPermissions набор прав администратора: все права справочника, включая добавленные позже
+func allPermissions() models.PermissionSet {
+	set := make(models.PermissionSet, len(models.Permissions))
+	for _, p := range models.Permissions {
+		set[p.Code] = true
+	}
+	return set
+}
+
+func (s *RoleService) load() (map[string]models.PermissionSet, error) {
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	byRole, err := s.roleRepo.ListPermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make(map[string]models.PermissionSet, len(roles))
+	for _, r := range roles {
+		if r.Name == models.RoleAdministrator {
+			perms[r.Name] = allPermissions()
+			continue
+		}
+		perms[r.Name] = models.NewPermissionSet(byRole[r.Name]...)
+	}
+	return perms, nil
+}
+
+func (s *RoleService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *RoleService) cached() map[string]models.PermissionSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.perms != nil && time.Since(s.loadedAt) < rolesCacheTTL {
+		return s.perms
+	}
+	return nil
+}
+
+// snapshot права всех ролей; при ошибке чтения используется прежний кеш
+func (s *RoleService) snapshot() (map[string]models.PermissionSet, error) {
+	if perms := s.cached(); perms != nil {
+		return perms, nil
+	}
+
+	perms, err := s.load()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if s.perms != nil {
+			log.Printf("roles: failed to reload permissions, using cached: %v", err)
+			return s.perms, nil
+		}
+		return nil, err
+	}
+	s.perms, s.loadedAt = perms, time.Now()
+	return perms, nil
+}
+
+// Permissions права роли; у неизвестной роли прав нет
+func (s *RoleService) Permissions(role string) (models.PermissionSet, error) {
+	perms, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	if set, ok := perms[role]; ok {
+		return set, nil
+	}
+	return models.PermissionSet{}, nil
+}
+
+func (s *RoleService) HasPermission(role, permission string) bool {
+	set, err := s.Permissions(role)
+	if err != nil {
+		log.Printf("roles: failed to load permissions: %v", err)
+		return false
+	}
+	return set.Has(permission)
+}
+
+// RoleExists роль есть в справочнике
+func (s *RoleService) RoleExists(role string) bool {
+	perms, err := s.snapshot()
+	if err != nil {
+		log.Printf("roles: failed to load roles: %v", err)
+		return false
+	}
+	_, ok := perms[role]
+	return ok
+}
+
+func roleResponse(r models.Role, perms []string, users int64) models.RoleResponse {
+	if r.Name == models.RoleAdministrator {
+		perms = allPermissions().List()
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	return models.RoleResponse{
+		Name:        r.Name,
+		Label:       r.Label,
+		Description: r.Description,
+		IsSystem:    r.IsSystem,
+		Permissions: perms,
+		UsersCount:  users,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+func (s *RoleService) ListRoles() ([]models.RoleResponse, error) {
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.roleRepo.ListPermissions()
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.roleRepo.CountUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.RoleResponse, 0, len(roles))
+	for _, r := range roles {
+		out = append(out, roleResponse(r, perms[r.Name], users[r.Name]))
+	}
+	return out, nil
+}
+
+func (s *RoleService) GetRole(name string) (models.RoleResponse, error) {
+	role, err := s.roleRepo.Get(name)
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+	perms, err := s.roleRepo.ListPermissions()
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+	users, err := s.roleRepo.CountUsers()
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+	return roleResponse(role, perms[name], users[name]), nil
+}
+
+// normalizePermissions проверяет коды прав и убирает повторы
+func normalizePermissions(codes []string) ([]string, error) {
+	set := models.PermissionSet{}
+	for _, c := range codes {
+		c = strings.TrimSpace(c)
+		if !models.IsPermission(c) {
+			return nil, fmt.Errorf("%w: неизвестное право %q", ErrRoleInvalid, c)
+		}
+		set[c] = true
+	}
+	return set.List(), nil
+}
+
+func (s *RoleService) CreateRole(actor models.AuditActor, req models.RoleCreateRequest) (models.RoleResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Label = strings.TrimSpace(req.Label)
+	if !roleNamePattern.MatchString(req.Name) {
+		return models.RoleResponse{}, fmt.Errorf("%w: имя роли — латиница, цифры и _, от 2 до 64 символов", ErrRoleInvalid)
+	}
+	if req.Label == "" {
+		return models.RoleResponse{}, fmt.Errorf("%w: название роли обязательно", ErrRoleInvalid)
+	}
+	perms, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+	if _, err := s.roleRepo.Get(req.Name); err == nil {
+		return models.RoleResponse{}, fmt.Errorf("%w: роль %s уже существует", ErrRoleInvalid, req.Name)
+	}
+
+	role, err := s.roleRepo.Create(models.Role{
+		Name:        req.Name,
+		Label:       req.Label,
+		Description: strings.TrimSpace(req.Description),
+	}, perms)
+	if err != nil {
+		return models.RoleResponse{}, err
+	}
+	s.invalidate()
+
+	resp := roleResponse(role, perms, 0)
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionRoleCreated,
+		EntityType: models.AuditEntityRole,
+		EntityID:   role.Name,
+		After:      roleSummary(resp),
+	})
+	return resp, nil
+}
+
+func (s *RoleService) UpdateRole(actor models.AuditActor, name string, req models.RoleUpdateRequest) (models.RoleResponse, error) {
+	before, err := s.GetRole(name)
+	if err != nil {
+		return before, err
+	}
+	role, err := s.roleRepo.Get(name)
+	if err != nil {
+		return before, err
+	}
+
+	if req.Label != nil {
+		role.Label = strings.TrimSpace(*req.Label)
+		if role.Label == "" {
+			return before, fmt.Errorf("%w: название роли обязательно", ErrRoleInvalid)
+		}
+	}
+	if req.Description != nil {
+		role.Description = strings.TrimSpace(*req.Description)
+	}
+
+	var perms []string
+	if req.Permissions != nil {
+		if name == models.RoleAdministrator {
+			return before, ErrRoleSystem
+		}
+		if perms, err = normalizePermissions(*req.Permissions); err != nil {
+			return before, err
+		}
+	}
+
+	role.UpdatedAt = time.Now()
+	if _, err := s.roleRepo.Update(role, perms); err != nil {
+		return before, err
+	}
+	s.invalidate()
+
+	after, err := s.GetRole(name)
+	if err != nil {
+		return after, err
+	}
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionRoleUpdated,
+		EntityType: models.AuditEntityRole,
+		EntityID:   name,
+		Before:     roleSummary(before),
+		After:      roleSummary(after),
+	})
+	return after, nil
+}
+
+func (s *RoleService) DeleteRole(actor models.AuditActor, name string) error {
+	before, err := s.GetRole(name)
+	if err != nil {
+		return err
+	}
+	if before.IsSystem {
+		return ErrRoleSystem
+	}
+	if err := s.roleRepo.Delete(name); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionRoleDeleted,
+		EntityType: models.AuditEntityRole,
+		EntityID:   name,
+		Before:     roleSummary(before),
+	})
+	return nil
+}
+
+func roleSummary(r models.RoleResponse) map[string]any {
+	return map[string]any{
+		"label":       r.Label,
+		"description": r.Description,
+		"permissions": r.Permissions,
+	}
+}
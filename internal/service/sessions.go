@@ -0,0 +1,177 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"vector/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidRefreshToken = errors.New("недействительный refresh-токен")
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRefreshSession готовит запись сессии; сам токен возвращается клиенту и не хранится
+func (s *AuthService) newRefreshSession(actor models.AuditActor, userID uint, familyID string) (models.AuthSession, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return models.AuthSession{}, "", err
+	}
+	return models.AuthSession{
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		IP:        actor.IP,
+		UserAgent: actor.UserAgent,
+		ExpiresAt: time.Now().UTC().Add(s.jwtConfig.RefreshTokenDuration),
+	}, token, nil
+}
+
+func (s *AuthService) tokenResponse(user models.AppUser, session models.AuthSession, refreshToken string) (*models.LoginResponse, error) {
+	token, expiresAt, err := s.generateJWT(user, session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	public := user.PublicUser()
+	return &models.LoginResponse{
+		Token:                 token,
+		ExpiresAt:             expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshExpiresAt:      session.ExpiresAt.Unix(),
+		User:                  &public,
+		MFAEnrollmentRequired: s.MFAEnrollmentRequired(user),
+	}, nil
+}
+
+// startSession создает новое семейство refresh-токенов после входа
+func (s *AuthService) startSession(actor models.AuditActor, user models.AppUser) (*models.LoginResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	session, refreshToken, err := s.newRefreshSession(actor, user.ID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if session, err = s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return s.tokenResponse(user, session, refreshToken)
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов (ротация).
+// Повторное предъявление уже обмененного токена отзывает все семейство.
+func (s *AuthService) Refresh(actor models.AuditActor, refreshToken string) (*models.LoginResponse, error) {
+	current, err := s.sessionRepo.GetByTokenHash(hashRefreshToken(refreshToken))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if current.RotatedAt != nil {
+		s.revokeReusedFamily(actor, current)
+		return nil, ErrInvalidRefreshToken
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(current.UserID)
+	if err != nil || !user.IsActive {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	next, token, err := s.newRefreshSession(actor, user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	next, err = s.sessionRepo.Rotate(current, next)
+	if errors.Is(err, models.ErrSessionReused) {
+		s.revokeReusedFamily(actor, current)
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokenResponse(user, next, token)
+}
+
+func (s *AuthService) revokeReusedFamily(actor models.AuditActor, session models.AuthSession) {
+	n, err := s.sessionRepo.RevokeFamily(session.FamilyID, models.SessionRevokeReuse)
+	if err != nil {
+		log.Printf("auth: failed to revoke session family %s: %v", session.FamilyID, err)
+	}
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionRefreshReuse,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(session.UserID),
+		Metadata:   map[string]any{"session_id": session.FamilyID, "revoked": n},
+	})
+}
+
+// Logout завершает текущую сессию: access-токен с этим sid перестает приниматься
+func (s *AuthService) Logout(actor models.AuditActor, sessionID string) error {
+	if _, err := s.sessionRepo.RevokeFamily(sessionID, models.SessionRevokeLogout); err != nil {
+		return err
+	}
+	var userID uint
+	if actor.UserID != nil {
+		userID = *actor.UserID
+	}
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionLogout,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(userID),
+		Metadata:   map[string]any{"session_id": sessionID},
+	})
+	return nil
+}
+
+// LogoutAllUserSessions завершает все сессии пользователя (администратор)
+func (s *AuthService) LogoutAllUserSessions(actor models.AuditActor, userID uint) (int64, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return 0, err
+	}
+	n, err := s.sessionRepo.RevokeUser(userID, "", models.SessionRevokeLogoutAll)
+	if err != nil {
+		return 0, err
+	}
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionSessionsRevoked,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(userID),
+		Metadata:   map[string]any{"revoked": n},
+	})
+	return n, nil
+}
+
+// revokeAfterUserChange отзывает сессии пользователя после изменения роли, статуса или пароля,
+// чтобы старые токены не продолжали действовать с прежними правами
+func (s *AuthService) revokeAfterUserChange(userID uint, exceptSessionID, reason string) error {
+	_, err := s.sessionRepo.RevokeUser(userID, exceptSessionID, reason)
+	return err
+}
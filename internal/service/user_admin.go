@@ -0,0 +1,257 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"vector/internal/models"
+)
+
+var (
+	ErrUserInvalid   = errors.New("некорректные данные пользователя")
+	ErrSelfAction    = errors.New("действие недоступно для собственной учетной записи")
+	ErrWrongPassword = errors.New("неверный текущий пароль")
+	// ErrExternalAccount пароль учетной записи SSO управляется провайдером
+	ErrExternalAccount = errors.New("учетная запись входит через корпоративный SSO, локальный пароль не используется")
+)
+
+// validateNewPassword проверяет пароль по политике и, для существующего пользователя,
+// по истории последних паролей
+func (s *AuthService) validateNewPassword(user *models.AppUser, email, password string) error {
+	if err := s.passwords.Validate(password, email); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserInvalid, err)
+	}
+	if user == nil || s.passwords.HistorySize <= 0 {
+		return nil
+	}
+
+	hashes, err := s.userRepo.RecentPasswordHashes(user.ID, s.passwords.HistorySize)
+	if err != nil {
+		return err
+	}
+	// у пользователей, созданных до появления истории, текущего пароля в ней нет
+	hashes = append(hashes, user.PasswordHash)
+	if err := s.passwords.CheckHistory(password, hashes); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserInvalid, err)
+	}
+	return nil
+}
+
+func (s *AuthService) validateUserRequest(req models.CreateUserRequest) error {
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return fmt.Errorf("%w: некорректный email", ErrUserInvalid)
+	}
+	if strings.TrimSpace(req.FirstName) == "" || strings.TrimSpace(req.LastName) == "" {
+		return fmt.Errorf("%w: имя и фамилия обязательны", ErrUserInvalid)
+	}
+	if !s.isValidRole(req.Role) {
+		return fmt.Errorf("%w: недопустимая роль", ErrUserInvalid)
+	}
+	return s.validateNewPassword(nil, req.Email, req.Password)
+}
+
+// generatePassword временный пароль для сброса администратором
+func generatePassword() (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+	b := make([]byte, 16)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+func userEntityID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func (s *AuthService) ListUsers(filter models.UserListFilter) ([]models.AppUser, error) {
+	return s.userRepo.List(filter)
+}
+
+func (s *AuthService) GetUser(id uint) (models.AppUser, error) {
+	return s.userRepo.GetByID(id)
+}
+
+func (s *AuthService) UpdateUserRole(actor models.AuditActor, id uint, role string) (models.AppUser, error) {
+	if !s.isValidRole(role) {
+		return models.AppUser{}, fmt.Errorf("%w: недопустимая роль", ErrUserInvalid)
+	}
+	if actor.UserID != nil && *actor.UserID == id {
+		return models.AppUser{}, ErrSelfAction
+	}
+
+	before, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return before, err
+	}
+	user, err := s.userRepo.UpdateRole(id, role)
+	if err != nil {
+		return user, err
+	}
+	if err := s.revokeAfterUserChange(id, "", models.SessionRevokeUserChanged); err != nil {
+		return user, err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserRoleChanged,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(id),
+		Before:     userSummary(before),
+		After:      userSummary(user),
+	})
+	return user, nil
+}
+
+// SetUserActive деактивирует или повторно активирует пользователя
+func (s *AuthService) SetUserActive(actor models.AuditActor, id uint, active bool) (models.AppUser, error) {
+	if !active && actor.UserID != nil && *actor.UserID == id {
+		return models.AppUser{}, ErrSelfAction
+	}
+
+	before, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return before, err
+	}
+	if err := s.userRepo.SetActive(id, active); err != nil {
+		return before, err
+	}
+	if !active {
+		if err := s.revokeAfterUserChange(id, "", models.SessionRevokeUserChanged); err != nil {
+			return before, err
+		}
+	}
+	user := before
+	user.IsActive = active
+
+	action := models.AuditActionUserDeactivated
+	if active {
+		action = models.AuditActionUserActivated
+	}
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     action,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(id),
+		Before:     userSummary(before),
+		After:      userSummary(user),
+	})
+	return user, nil
+}
+
+// ResetUserPassword задает пароль пользователю. Если пароль не передан, генерируется
+// временный и возвращается вызывающему (единственный раз). При следующем входе
+// пользователь должен будет сменить пароль.
+func (s *AuthService) ResetUserPassword(actor models.AuditActor, id uint, password string) (string, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	if user.IsExternal() {
+		return "", ErrExternalAccount
+	}
+
+	generated := password == ""
+	if generated {
+		if password, err = generatePassword(); err != nil {
+			return "", err
+		}
+	} else if err := s.validateNewPassword(&user, user.Email, password); err != nil {
+		return "", err
+	}
+
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return "", errors.New("ошибка хеширования пароля")
+	}
+	if err := s.userRepo.UpdatePassword(id, hash, true); err != nil {
+		return "", err
+	}
+	if err := s.revokeAfterUserChange(id, "", models.SessionRevokePasswordChange); err != nil {
+		return "", err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserPasswordReset,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(id),
+		Metadata:   map[string]any{"generated": generated},
+	})
+
+	if generated {
+		return password, nil
+	}
+	return "", nil
+}
+
+// DeleteUser мягко удаляет пользователя
+func (s *AuthService) DeleteUser(actor models.AuditActor, id uint) error {
+	if actor.UserID != nil && *actor.UserID == id {
+		return ErrSelfAction
+	}
+
+	before, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.Delete(id); err != nil {
+		return err
+	}
+	if err := s.revokeAfterUserChange(id, "", models.SessionRevokeUserChanged); err != nil {
+		return err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserDeleted,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(id),
+		Before:     userSummary(before),
+	})
+	return nil
+}
+
+// ChangeOwnPassword смена пароля пользователем; требуется текущий пароль.
+// Остальные сессии пользователя завершаются, текущая (sessionID) сохраняется.
+func (s *AuthService) ChangeOwnPassword(actor models.AuditActor, sessionID, currentPassword, newPassword string) error {
+	if actor.UserID == nil {
+		return errors.New("пользователь не аутентифицирован")
+	}
+	user, err := s.userRepo.GetByID(*actor.UserID)
+	if err != nil {
+		return err
+	}
+	if user.IsExternal() {
+		return ErrExternalAccount
+	}
+	if !user.CheckPassword(currentPassword) {
+		return ErrWrongPassword
+	}
+	if err := s.validateNewPassword(&user, user.Email, newPassword); err != nil {
+		return err
+	}
+
+	hash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return errors.New("ошибка хеширования пароля")
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hash, false); err != nil {
+		return err
+	}
+	if err := s.revokeAfterUserChange(user.ID, sessionID, models.SessionRevokePasswordChange); err != nil {
+		return err
+	}
+
+	s.audit.RecordAfter(actor, AuditEntry{
+		Action:     models.AuditActionUserPasswordChange,
+		EntityType: models.AuditEntityUser,
+		EntityID:   userEntityID(user.ID),
+	})
+	return nil
+}